@@ -0,0 +1,353 @@
+// Package ai plays bots on the server itself, so it never feels empty: a
+// Filler adds bots while few people are playing and takes them away as
+// people join. Bots are ordinary players to the rest of the game; a Brain
+// plays each one by holding actions and firing through its session, like a
+// person's keys would. Fighter is the standard brain, at a Skill level.
+package ai
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/input"
+	"github.com/imjasonh/terminus/server"
+)
+
+// Brain plays a bot: each simulation step it looks at the world and decides
+// what the bot's player does
+type Brain interface {
+	Step(gs *server.GameServer, session *server.PlayerSession, snapshot *server.Snapshot, dt float64)
+}
+
+// Skill is how well a Fighter plays
+type Skill struct {
+	Name     string
+	Reaction float64 // Seconds a target must be in sight before the bot fires at it
+	Aim      float64 // Radians off target the bot still fires at
+	Sight    float64 // Cells away the bot can see a target
+	Vision   float64 // Radians either side of where the bot faces that it notices new targets
+	Strafe   bool    // Whether the bot dodges side to side while fighting
+	Hunt     bool    // Whether the bot seeks out players it can't see, rather than wandering
+}
+
+// Skills are the skill levels bots can play at, worst first
+var Skills = []Skill{
+	{Name: "easy", Reaction: 0.8, Aim: 0.3, Sight: 8, Vision: 0.6},
+	{Name: "normal", Reaction: 0.4, Aim: 0.15, Sight: 12, Vision: 0.9, Strafe: true, Hunt: true},
+	{Name: "hard", Reaction: 0.15, Aim: 0.08, Sight: 20, Vision: 1.3, Strafe: true, Hunt: true},
+}
+
+// FindSkill returns the skill level with a name, like "normal"
+func FindSkill(name string) (Skill, error) {
+	var names []string
+	for _, skill := range Skills {
+		if skill.Name == name {
+			return skill, nil
+		}
+		names = append(names, skill.Name)
+	}
+	return Skill{}, fmt.Errorf("unknown bot skill %q: want %s", name, strings.Join(names, ", "))
+}
+
+// Fighter tuning
+const (
+	turnDeadZone    = 0.05 // Radians off a heading the bot stops turning at, so it doesn't overshoot
+	walkAngle       = 0.6  // Radians off a waypoint the bot still walks toward it
+	waypointReach   = 0.3  // Cells from a waypoint that count as reaching it
+	closeRange      = 2.0  // Cells from a target the bot backs away at
+	farRange        = 4.0  // Cells from a target the bot closes in from
+	repathInterval  = 1.5  // Seconds between planning paths to a moving goal
+	stuckTime       = 1.0  // Seconds without moving before the bot gives up on its path
+	stuckDistance   = 0.1  // Cells the bot must move in stuckTime to not be stuck
+	lowHealth       = 40   // Health the bot goes looking for a health pack below
+	npcPreference   = 1.5  // How much farther away a player can be than an NPC and still be chosen first
+	minStrafeSwitch = 0.5  // Seconds between a fighting bot changing which way it strafes
+	maxStrafeSwitch = 1.5
+)
+
+// Fighter is a brain that fights whoever it can see, players before NPCs,
+// and otherwise finds its way around the map: to health when it's hurt, to
+// where it last saw a target, or to other players or a random spot
+type Fighter struct {
+	Skill Skill
+
+	target   string // The player or NPC being fought, if any
+	inSight  float64
+	lastSeen game.Vector // Where the target was last seen, to chase after it
+	chasing  bool
+
+	goal   game.Vector
+	path   []game.Vector
+	repath float64
+
+	anchor game.Vector // Where the bot was when it last made progress
+	stuck  float64
+
+	strafe     input.Action
+	strafeTime float64
+}
+
+// NewFighter returns a fighter that plays at a skill level
+func NewFighter(skill Skill) *Fighter {
+	return &Fighter{Skill: skill, strafe: input.ActionStrafeLeft}
+}
+
+// sighting is a target the bot can see
+type sighting struct {
+	id       string
+	position game.Vector
+	distance float64
+}
+
+// Step decides what the bot does this step
+func (f *Fighter) Step(gs *server.GameServer, session *server.PlayerSession, snapshot *server.Snapshot, dt float64) {
+	self, ok := snapshot.Player(session.ID)
+	if !ok {
+		return
+	}
+	me := &self.Player
+	holds := session.Holds
+	worldMap := snapshot.Map.Floor(me.Floor)
+
+	if target, ok := f.pickTarget(snapshot, self, worldMap); ok {
+		if target.id != f.target {
+			f.target, f.inSight = target.id, 0
+		}
+		f.inSight += dt
+		f.lastSeen, f.chasing = target.position, true
+		f.path = nil
+		f.fight(gs, session, me, target, dt)
+		return
+	}
+
+	f.target, f.inSight = "", 0
+	holds.Release(input.ActionStrafeLeft)
+	holds.Release(input.ActionStrafeRight)
+	holds.Release(input.ActionFire)
+	f.roam(session, snapshot, self, worldMap, dt)
+}
+
+// pickTarget returns the target the bot should fight, if it can see any:
+// the one it's already fighting while it stays in sight, or else the
+// nearest it notices, preferring players to NPCs
+func (f *Fighter) pickTarget(snapshot *server.Snapshot, self *server.PlayerState, worldMap *game.Map) (sighting, bool) {
+	me := &self.Player
+	var best sighting
+	bestScore := math.Inf(1)
+	consider := func(id string, pos game.Vector, weight float64) {
+		to := pos.Sub(me.Position)
+		dist := to.Length()
+		if dist > f.Skill.Sight || !clearPath(worldMap, me.Position, pos) {
+			return
+		}
+		if id != f.target && math.Abs(angleTo(me.Direction, to)) > f.Skill.Vision {
+			return // Hasn't noticed it yet
+		}
+		score := dist * weight
+		if id == f.target {
+			score = 0 // Stick with a fight
+		}
+		if score < bestScore {
+			best, bestScore = sighting{id: id, position: pos, distance: dist}, score
+		}
+	}
+
+	for i := range snapshot.Players {
+		ps := &snapshot.Players[i]
+		if ps.ID == self.ID || ps.Player.IsInvisible() || ps.Player.Health <= 0 || ps.Player.Floor != me.Floor {
+			continue
+		}
+		consider(ps.ID, ps.Player.Position, 1)
+	}
+	for i := range snapshot.Entities {
+		e := &snapshot.Entities[i]
+		if e.Kind == game.KindNPC && e.Floor == me.Floor {
+			consider(fmt.Sprintf("npc:%d", e.ID), e.Position, npcPreference)
+		}
+	}
+	return best, !math.IsInf(bestScore, 1)
+}
+
+// fight turns toward a target, keeps a fighting distance, dodges if the
+// bot's skilled enough, and fires once it's had time to react and aim
+func (f *Fighter) fight(gs *server.GameServer, session *server.PlayerSession, me *game.Player, target sighting, dt float64) {
+	holds := session.Holds
+	to := target.position.Sub(me.Position)
+	off := f.turn(holds, me.Direction, to)
+
+	switch {
+	case target.distance > farRange:
+		holds.Press(input.ActionMoveForward)
+	case target.distance < closeRange:
+		holds.Press(input.ActionMoveBackward)
+	default:
+		holds.Release(input.ActionMoveForward)
+		holds.Release(input.ActionMoveBackward)
+	}
+
+	if f.Skill.Strafe {
+		f.strafeTime -= dt
+		if f.strafeTime <= 0 {
+			if f.strafe == input.ActionStrafeLeft {
+				f.strafe = input.ActionStrafeRight
+			} else {
+				f.strafe = input.ActionStrafeLeft
+			}
+			f.strafeTime = minStrafeSwitch + rand.Float64()*(maxStrafeSwitch-minStrafeSwitch)
+		}
+		holds.Press(f.strafe)
+	}
+
+	if f.inSight < f.Skill.Reaction || math.Abs(off) > f.Skill.Aim {
+		holds.Release(input.ActionFire)
+		return
+	}
+	if game.GetWeapon(me.Weapon).Continuous {
+		holds.Press(input.ActionFire)
+	} else {
+		gs.FireProjectile(session)
+	}
+}
+
+// roam walks the bot toward somewhere worth going when there's nothing to
+// fight, planning a path there
+func (f *Fighter) roam(session *server.PlayerSession, snapshot *server.Snapshot, self *server.PlayerState, worldMap *game.Map, dt float64) {
+	me := &self.Player
+	holds := session.Holds
+
+	// Give up on a path the bot isn't getting anywhere along, like one
+	// blocked by other players
+	if me.Position.Sub(f.anchor).Length() > stuckDistance {
+		f.anchor, f.stuck = me.Position, 0
+	} else if f.stuck += dt; f.stuck > stuckTime {
+		f.stuck = 0
+		f.chasing = false
+		f.path = nil
+		f.goal = randomOpenCell(worldMap)
+	}
+
+	f.repath -= dt
+	if len(f.path) == 0 || f.repath <= 0 {
+		f.goal = f.chooseGoal(snapshot, self, worldMap)
+		f.path = FindPath(worldMap, me.Position, f.goal)
+		f.repath = repathInterval
+	}
+
+	// Head for the farthest waypoint in a straight line, so the bot cuts
+	// across open space rather than following the grid
+	for len(f.path) > 1 && clearPath(worldMap, me.Position, f.path[1]) {
+		f.path = f.path[1:]
+	}
+	if len(f.path) > 0 && me.Position.Sub(f.path[0]).Length() < waypointReach {
+		f.path = f.path[1:]
+		if len(f.path) == 0 {
+			f.chasing = false // Got where it was going
+		}
+	}
+	if len(f.path) == 0 {
+		holds.Release(input.ActionMoveForward)
+		holds.Release(input.ActionTurnLeft)
+		holds.Release(input.ActionTurnRight)
+		return
+	}
+
+	if off := f.turn(holds, me.Direction, f.path[0].Sub(me.Position)); math.Abs(off) < walkAngle {
+		holds.Press(input.ActionMoveForward)
+	} else {
+		holds.Release(input.ActionMoveForward)
+	}
+}
+
+// chooseGoal decides where a bot with nothing to fight goes: to a health
+// pack if it's hurt, after the target it last saw, to the nearest player if
+// it hunts, or on to the spot it was wandering to
+func (f *Fighter) chooseGoal(snapshot *server.Snapshot, self *server.PlayerState, worldMap *game.Map) game.Vector {
+	me := &self.Player
+	if me.Health < lowHealth {
+		if pos, ok := nearest(me.Position, healthPacks(snapshot)); ok {
+			return pos
+		}
+	}
+	if f.chasing {
+		return f.lastSeen
+	}
+	if f.Skill.Hunt {
+		var players []game.Vector
+		for _, ps := range snapshot.Players {
+			if ps.ID != self.ID && !ps.Player.IsInvisible() {
+				players = append(players, ps.Player.Position)
+			}
+		}
+		if pos, ok := nearest(me.Position, players); ok {
+			return pos
+		}
+	}
+	if len(f.path) > 0 && !worldMap.IsWall(int(f.goal.X), int(f.goal.Y)) {
+		return f.goal
+	}
+	return randomOpenCell(worldMap)
+}
+
+// turn turns the bot toward a direction, returning how far off it is, in
+// radians, positive to the left
+func (f *Fighter) turn(holds *input.HoldTracker, facing, to game.Vector) float64 {
+	off := angleTo(facing, to)
+	switch {
+	case off > turnDeadZone:
+		holds.Press(input.ActionTurnLeft)
+	case off < -turnDeadZone:
+		holds.Press(input.ActionTurnRight)
+	default:
+		holds.Release(input.ActionTurnLeft)
+		holds.Release(input.ActionTurnRight)
+	}
+	return off
+}
+
+// angleTo returns the angle from a facing to a direction, in radians from
+// -π to π. Turning left increases it, as ActionTurnLeft turns players.
+func angleTo(facing, to game.Vector) float64 {
+	return math.Atan2(facing.X*to.Y-facing.Y*to.X, facing.Dot(to))
+}
+
+// healthPacks returns where the ready health packs are
+func healthPacks(snapshot *server.Snapshot) []game.Vector {
+	var packs []game.Vector
+	for i := range snapshot.Entities {
+		e := &snapshot.Entities[i]
+		if e.Pickup != nil && e.Pickup.Kind == game.PickupHealth && e.Pickup.Ready() {
+			packs = append(packs, e.Position)
+		}
+	}
+	return packs
+}
+
+// nearest returns the closest of some positions, if there are any
+func nearest(from game.Vector, positions []game.Vector) (game.Vector, bool) {
+	best, bestDist := game.Vector{}, math.Inf(1)
+	for _, pos := range positions {
+		if d := pos.Sub(from).Length(); d < bestDist {
+			best, bestDist = pos, d
+		}
+	}
+	return best, !math.IsInf(bestDist, 1)
+}
+
+// randomOpenCell returns the center of a random open cell on the map
+func randomOpenCell(m *game.Map) game.Vector {
+	var open []cell
+	for y, row := range m.Grid {
+		for x, v := range row {
+			if v == 0 {
+				open = append(open, cell{x, y})
+			}
+		}
+	}
+	if len(open) == 0 {
+		return game.Vector{X: 1.5, Y: 1.5}
+	}
+	return open[rand.Intn(len(open))].center()
+}
@@ -0,0 +1,112 @@
+package ai
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/google/uuid"
+
+	"github.com/imjasonh/terminus/server"
+)
+
+// balanceInterval is how often the filler checks whether bots should join or leave
+const balanceInterval = time.Second
+
+// names are what bots are called, in the order they join
+var names = []string{"Ada", "Boole", "Curie", "Dijkstra", "Euler", "Fermat", "Gauss", "Hopper", "Ising", "Jacobi", "Knuth", "Lovelace", "Markov", "Noether", "Ohm", "Pascal"}
+
+// Filler keeps a server from feeling empty by playing bots while fewer than
+// Fill people are on. Bots leave as people join, and always leave a slot free
+// so nobody has to wait in line behind one.
+type Filler struct {
+	Server   *server.GameServer
+	Fill     int          // How many players, people and bots, the server should have
+	NewBrain func() Brain // Makes the brain for each bot that joins
+}
+
+// bot is a bot the filler is playing
+type bot struct {
+	session *server.PlayerSession
+	brain   Brain
+}
+
+// Run plays bots until ctx is done, then removes them
+func (f *Filler) Run(ctx context.Context) {
+	var bots []*bot
+	defer func() {
+		for _, b := range bots {
+			f.Server.RemovePlayer(b.session.ID)
+		}
+	}()
+
+	var balanced, last time.Time
+	for {
+		snapshot, next := f.Server.LatestSnapshot()
+		if time.Since(balanced) >= balanceInterval {
+			bots = f.balance(bots)
+			balanced = time.Now()
+		}
+
+		// Kicked bots leave like disconnected players
+		bots = slices.DeleteFunc(bots, func(b *bot) bool {
+			select {
+			case <-b.session.Kicked():
+				b.session.Log.Infof("Bot kicked: %s", b.session.KickReason())
+				f.Server.RemovePlayer(b.session.ID)
+				return true
+			default:
+				return false
+			}
+		})
+
+		if !last.IsZero() && snapshot.Time.After(last) {
+			dt := snapshot.Time.Sub(last).Seconds()
+			for _, b := range bots {
+				b.brain.Step(f.Server, b.session, snapshot, dt)
+			}
+		}
+		last = snapshot.Time
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-next:
+		}
+	}
+}
+
+// balance adds or removes bots so there are as many as the filler wants,
+// returning the bots left playing
+func (f *Filler) balance(bots []*bot) []*bot {
+	people, _ := f.Server.CountPlayers()
+	want := max(0, min(f.Fill, f.Server.MaxPlayers-1)-people)
+
+	for len(bots) > want {
+		b := bots[len(bots)-1]
+		bots = bots[:len(bots)-1]
+		b.session.Log.Info("Bot leaving for a person")
+		f.Server.RemovePlayer(b.session.ID)
+	}
+	for len(bots) < want {
+		session, err := f.Server.AddBot(uuid.New().String(), f.freeName(bots))
+		if err != nil {
+			clog.Debugf("Couldn't add a bot: %v", err)
+			break // Like when people are waiting in line
+		}
+		session.Log.Info("Bot joined")
+		bots = append(bots, &bot{session: session, brain: f.NewBrain()})
+	}
+	return bots
+}
+
+// freeName returns the first bot name no playing bot has
+func (f *Filler) freeName(bots []*bot) string {
+	for _, name := range names {
+		if !slices.ContainsFunc(bots, func(b *bot) bool { return b.session.Name == name }) {
+			return name
+		}
+	}
+	return "bot"
+}
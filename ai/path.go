@@ -0,0 +1,118 @@
+package ai
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/imjasonh/terminus/game"
+)
+
+// cell is a map grid cell
+type cell struct {
+	x, y int
+}
+
+// center returns the middle of the cell, where paths pass through
+func (c cell) center() game.Vector {
+	return game.Vector{X: float64(c.x) + 0.5, Y: float64(c.y) + 0.5}
+}
+
+// cellAt returns the cell a position is in
+func cellAt(pos game.Vector) cell {
+	return cell{int(pos.X), int(pos.Y)}
+}
+
+// neighbors are the steps a path can take from a cell, straight or diagonal
+var neighbors = []cell{{1, 0}, {-1, 0}, {0, 1}, {0, -1}, {1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+
+// FindPath returns the centers of the cells on a shortest path through open
+// space from one position to another, not including the cell it starts in,
+// or nil if there's no way there. Diagonal steps don't cut past wall corners,
+// where players would snag.
+func FindPath(m *game.Map, from, to game.Vector) []game.Vector {
+	start, goal := cellAt(from), cellAt(to)
+	if m.IsWall(goal.x, goal.y) {
+		return nil
+	}
+	if start == goal {
+		return []game.Vector{to}
+	}
+
+	// A* with the octile distance, which never overestimates on this grid
+	estimate := func(c cell) float64 {
+		dx, dy := math.Abs(float64(c.x-goal.x)), math.Abs(float64(c.y-goal.y))
+		return max(dx, dy) + (math.Sqrt2-1)*min(dx, dy)
+	}
+	cost := map[cell]float64{start: 0}
+	came := make(map[cell]cell)
+	open := &frontier{{cell: start, priority: estimate(start)}}
+	for open.Len() > 0 {
+		current := heap.Pop(open).(node).cell
+		if current == goal {
+			break
+		}
+		for _, step := range neighbors {
+			next := cell{current.x + step.x, current.y + step.y}
+			if m.IsWall(next.x, next.y) {
+				continue
+			}
+			length := 1.0
+			if step.x != 0 && step.y != 0 {
+				if m.IsWall(current.x+step.x, current.y) || m.IsWall(current.x, current.y+step.y) {
+					continue
+				}
+				length = math.Sqrt2
+			}
+			c := cost[current] + length
+			if known, ok := cost[next]; ok && known <= c {
+				continue
+			}
+			cost[next] = c
+			came[next] = current
+			heap.Push(open, node{cell: next, priority: c + estimate(next)})
+		}
+	}
+	if _, ok := came[goal]; !ok {
+		return nil
+	}
+
+	var path []game.Vector
+	for c := goal; c != start; c = came[c] {
+		path = append(path, c.center())
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// clearPath reports whether a straight line between two positions crosses
+// no walls
+func clearPath(m *game.Map, from, to game.Vector) bool {
+	d := to.Sub(from)
+	dist := d.Length()
+	if dist == 0 {
+		return true
+	}
+	return game.CastRay(from, d.Scale(1/dist), m).Distance >= dist
+}
+
+// node is a cell waiting to be explored, and its estimated path length
+type node struct {
+	cell     cell
+	priority float64
+}
+
+// frontier is a priority queue of cells to explore, shortest estimate first
+type frontier []node
+
+func (f frontier) Len() int           { return len(f) }
+func (f frontier) Less(i, j int) bool { return f[i].priority < f[j].priority }
+func (f frontier) Swap(i, j int)      { f[i], f[j] = f[j], f[i] }
+func (f *frontier) Push(x any)        { *f = append(*f, x.(node)) }
+func (f *frontier) Pop() any {
+	old := *f
+	n := old[len(old)-1]
+	*f = old[:len(old)-1]
+	return n
+}
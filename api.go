@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/chainguard-dev/clog"
+
+	"github.com/imjasonh/terminus/auth"
+	"github.com/imjasonh/terminus/command"
+	"github.com/imjasonh/terminus/server"
+)
+
+// maxAPIBody is the largest request body the admin API reads
+const maxAPIBody = 64 << 10
+
+// apiHandler handles an admin API request from an authenticated operator
+type apiHandler func(w http.ResponseWriter, r *http.Request, operator *server.PlayerSession)
+
+// apiReply is the body of a response to an admin API request that changes
+// something: the command's message, or why it failed
+type apiReply struct {
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// apiStats is the server's status along with every player's stats
+type apiStats struct {
+	server.Status
+	PlayerStats []server.PlayerInfo `json:"player_stats"`
+}
+
+// serveAPI serves the admin HTTP API at /api/ until the listener fails.
+// Requests carry a bearer token from the -api-tokens file, and act as an
+// admin named after it: changes run the same commands admins type in the
+// console, so they're checked and audited the same way.
+//
+//	GET  /api/players                  connected players
+//	POST /api/players/{name}/kick      {"reason": "..."}
+//	POST /api/players/{name}/ban       {"duration": "7d", "reason": "..."}, permanent without a duration
+//	POST /api/map                      {"map": "cave.map"}
+//	POST /api/broadcast                {"message": "..."}
+//	GET  /api/stats                    server status and every player's stats
+//	GET  /api/settings                 runtime settings
+//	PUT  /api/settings/{name}          {"value": "..."}
+//	POST /api/command                  {"command": "/npc spawn 3"}
+func serveAPI(addr string, tokens *auth.Tokens, mode string) {
+	mux := http.NewServeMux()
+	handle := func(pattern string, h apiHandler) {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			name, known := tokens.Lookup(token)
+			if !ok || !known {
+				clog.Infof("Admin API request from %s with a bad token: %s %s", r.RemoteAddr, r.Method, r.URL.Path)
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeAPI(w, http.StatusUnauthorized, apiReply{Error: "missing or unknown API token"})
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxAPIBody)
+			h(w, r, server.NewOperator(name))
+		})
+	}
+
+	handle("GET /api/players", func(w http.ResponseWriter, r *http.Request, _ *server.PlayerSession) {
+		writeAPI(w, http.StatusOK, gameServer.PlayerList())
+	})
+	handle("POST /api/players/{name}/kick", func(w http.ResponseWriter, r *http.Request, operator *server.PlayerSession) {
+		var req struct {
+			Reason string `json:"reason"`
+		}
+		if name, ok := apiPlayerName(w, r); ok && readAPI(w, r, &req) {
+			runAPICommand(w, operator, "kick", name, req.Reason)
+		}
+	})
+	handle("POST /api/players/{name}/ban", func(w http.ResponseWriter, r *http.Request, operator *server.PlayerSession) {
+		var req struct {
+			Duration string `json:"duration"`
+			Reason   string `json:"reason"`
+		}
+		name, ok := apiPlayerName(w, r)
+		if !ok || !readAPI(w, r, &req) {
+			return
+		}
+		// Always pass a duration, so /ban can't take one from the reason
+		if req.Duration == "" {
+			req.Duration = "permanent"
+		} else if _, ok := command.ParseBanDuration(req.Duration); !ok {
+			writeAPI(w, http.StatusBadRequest, apiReply{Error: "invalid duration " + req.Duration + "; want one like 30m, 2h, or 7d"})
+			return
+		}
+		runAPICommand(w, operator, "ban", name, req.Duration, req.Reason)
+	})
+	handle("POST /api/map", func(w http.ResponseWriter, r *http.Request, operator *server.PlayerSession) {
+		var req struct {
+			Map string `json:"map"`
+		}
+		if !readAPI(w, r, &req) {
+			return
+		}
+		if req.Map == "" {
+			writeAPI(w, http.StatusBadRequest, apiReply{Error: "map is required"})
+			return
+		}
+		runAPICommand(w, operator, "map", req.Map)
+	})
+	handle("POST /api/broadcast", func(w http.ResponseWriter, r *http.Request, operator *server.PlayerSession) {
+		var req struct {
+			Message string `json:"message"`
+		}
+		if readAPI(w, r, &req) {
+			runAPICommand(w, operator, "say", req.Message)
+		}
+	})
+	handle("GET /api/stats", func(w http.ResponseWriter, r *http.Request, _ *server.PlayerSession) {
+		status := gameServer.Status()
+		status.Mode = mode
+		writeAPI(w, http.StatusOK, apiStats{Status: status, PlayerStats: gameServer.PlayerList()})
+	})
+	handle("GET /api/settings", func(w http.ResponseWriter, r *http.Request, _ *server.PlayerSession) {
+		writeAPI(w, http.StatusOK, gameServer.SettingValues())
+	})
+	handle("PUT /api/settings/{name}", func(w http.ResponseWriter, r *http.Request, operator *server.PlayerSession) {
+		var req struct {
+			Value string `json:"value"`
+		}
+		if !readAPI(w, r, &req) {
+			return
+		}
+		if req.Value == "" {
+			writeAPI(w, http.StatusBadRequest, apiReply{Error: "value is required"})
+			return
+		}
+		runAPICommand(w, operator, "set", r.PathValue("name"), req.Value)
+	})
+	handle("POST /api/command", func(w http.ResponseWriter, r *http.Request, operator *server.PlayerSession) {
+		var req struct {
+			Command string `json:"command"`
+		}
+		if readAPI(w, r, &req) {
+			runAPICommand(w, operator, req.Command)
+		}
+	})
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      10 * time.Second,
+	}
+	clog.Infof("Admin API on http://%s/api/ with %d tokens", addr, tokens.Len())
+	clog.Errorf("Admin API stopped: %v", srv.ListenAndServe())
+}
+
+// runAPICommand runs a console command as the operator, joining its
+// non-empty parts into a command line, and replies with its message
+func runAPICommand(w http.ResponseWriter, operator *server.PlayerSession, parts ...string) {
+	var fields []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			fields = append(fields, part)
+		}
+	}
+	msg, err := commands.Execute(&command.Context{Server: gameServer, Session: operator}, strings.Join(fields, " "))
+	if err != nil {
+		writeAPI(w, http.StatusBadRequest, apiReply{Error: err.Error()})
+		return
+	}
+	writeAPI(w, http.StatusOK, apiReply{Message: msg})
+}
+
+// apiPlayerName returns the player named in the request's path, replying
+// with an error and returning false if it couldn't be a player's name
+func apiPlayerName(w http.ResponseWriter, r *http.Request) (string, bool) {
+	name := r.PathValue("name")
+	if strings.ContainsFunc(name, unicode.IsSpace) {
+		writeAPI(w, http.StatusNotFound, apiReply{Error: "no player named " + name})
+		return "", false
+	}
+	return name, true
+}
+
+// readAPI decodes a JSON request body, replying with an error and returning
+// false if it can't
+func readAPI(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeAPI(w, http.StatusBadRequest, apiReply{Error: "invalid JSON body: " + err.Error()})
+		return false
+	}
+	return true
+}
+
+// writeAPI replies with a JSON body
+func writeAPI(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		clog.Debugf("Failed to write admin API response: %v", err)
+	}
+}
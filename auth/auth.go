@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// Fingerprint returns the SHA256 fingerprint of a public key, as shown by ssh-keygen -l
+func Fingerprint(key gossh.PublicKey) string {
+	if key == nil {
+		return ""
+	}
+	return gossh.FingerprintSHA256(key)
+}
+
+// Allowlist holds the public keys permitted to join, loaded from an
+// authorized_keys-style file
+type Allowlist struct {
+	path string
+
+	mu   sync.RWMutex
+	keys map[string]string // Fingerprint to the key's comment
+}
+
+// LoadAllowlist reads an authorized_keys-style file
+func LoadAllowlist(path string) (*Allowlist, error) {
+	a := &Allowlist{path: path}
+	if err := a.Reload(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Reload re-reads the allowlist file, keeping the old keys if it can't be read
+func (a *Allowlist) Reload() error {
+	data, err := os.ReadFile(a.path)
+	if err != nil {
+		return fmt.Errorf("failed to read authorized keys file %s: %w", a.path, err)
+	}
+
+	keys := make(map[string]string)
+	for len(bytes.TrimSpace(data)) > 0 {
+		key, comment, _, rest, err := gossh.ParseAuthorizedKey(data)
+		if err != nil {
+			// ParseAuthorizedKey skips comments, blank lines, and malformed lines, so this means no more keys
+			break
+		}
+		keys[Fingerprint(key)] = comment
+		data = rest
+	}
+
+	a.mu.Lock()
+	a.keys = keys
+	a.mu.Unlock()
+	return nil
+}
+
+// Allowed reports whether the key is on the allowlist
+func (a *Allowlist) Allowed(key gossh.PublicKey) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	_, ok := a.keys[Fingerprint(key)]
+	return ok
+}
+
+// AllowedFingerprint reports whether a key with the given fingerprint is on the allowlist
+func (a *Allowlist) AllowedFingerprint(fingerprint string) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	_, ok := a.keys[fingerprint]
+	return ok
+}
+
+// Len returns the number of keys on the allowlist
+func (a *Allowlist) Len() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return len(a.keys)
+}
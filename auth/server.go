@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"net"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/gliderlabs/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// Options controls who may connect to the SSH server
+type Options struct {
+	// Allowlist, if set, lets listed public keys join
+	Allowlist *Allowlist
+
+	// InviteCode, if set, lets clients that enter it (by password or
+	// keyboard-interactive prompt) join
+	InviteCode string
+
+	// Handoff, if set, lets in clients whose password is a token another
+	// server gave a player it sent through a portal, on any server
+	Handoff func(ctx ssh.Context, password string) bool
+}
+
+// open reports whether anyone may join
+func (o Options) open() bool {
+	return o.Allowlist == nil && o.InviteCode == ""
+}
+
+// Mode describes who may join: "open", "allowlist", "invite", or "allowlist+invite"
+func (o Options) Mode() string {
+	switch {
+	case o.open():
+		return "open"
+	case o.InviteCode == "":
+		return "allowlist"
+	case o.Allowlist == nil:
+		return "invite"
+	default:
+		return "allowlist+invite"
+	}
+}
+
+// Configure installs authentication handlers on the SSH server.
+//
+// On an open server any public key is accepted, so it can identify returning
+// players, and keyless clients get in through keyboard-interactive auth
+// without any prompts. Otherwise clients must present an allowlisted key or
+// the invite code. Invited players connect without a key fingerprint, since
+// SSH accepts the first method that succeeds. Handoff tokens are accepted as
+// passwords whoever may join.
+func Configure(srv *ssh.Server, opts Options) {
+	srv.PublicKeyHandler = func(ctx ssh.Context, key ssh.PublicKey) bool {
+		if opts.open() {
+			return true
+		}
+		if opts.Allowlist != nil && opts.Allowlist.Allowed(key) {
+			return true
+		}
+		clog.Infof("Key %s from %s is not on the allowlist", Fingerprint(key), ctx.RemoteAddr())
+		return false
+	}
+
+	switch {
+	case opts.open():
+		srv.KeyboardInteractiveHandler = func(ctx ssh.Context, challenger gossh.KeyboardInteractiveChallenge) bool {
+			return true
+		}
+	case opts.InviteCode != "":
+		srv.KeyboardInteractiveHandler = func(ctx ssh.Context, challenger gossh.KeyboardInteractiveChallenge) bool {
+			answers, err := challenger("", "This is a private Terminus server.", []string{"Invite code: "}, []bool{false})
+			if err != nil || len(answers) != 1 {
+				return false
+			}
+			return checkInviteCode(ctx.RemoteAddr(), opts.InviteCode, answers[0])
+		}
+	}
+
+	if opts.InviteCode != "" || opts.Handoff != nil {
+		srv.PasswordHandler = func(ctx ssh.Context, password string) bool {
+			if opts.Handoff != nil && opts.Handoff(ctx, password) {
+				return true
+			}
+			if opts.InviteCode == "" {
+				return false
+			}
+			return checkInviteCode(ctx.RemoteAddr(), opts.InviteCode, password)
+		}
+	}
+}
+
+// AdmitKeyless reports whether a client connecting without an SSH key, such as
+// over WebSocket, may join with the invite code it gave
+func (o Options) AdmitKeyless(remote net.Addr, inviteCode string) bool {
+	switch {
+	case o.open():
+		return true
+	case o.InviteCode != "":
+		return checkInviteCode(remote, o.InviteCode, inviteCode)
+	default:
+		return false
+	}
+}
+
+// checkInviteCode compares an entered code in constant time, logging failures
+func checkInviteCode(remote net.Addr, want, got string) bool {
+	if subtle.ConstantTimeCompare([]byte(want), []byte(got)) == 1 {
+		return true
+	}
+	clog.Infof("Wrong invite code from %s", remote)
+	return false
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/subtle"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// minTokenLength is the shortest token accepted, so tokens can't be guessed
+const minTokenLength = 16
+
+// Tokens holds the bearer tokens operators present to the admin API, loaded
+// from a file of "<token> <name>" lines. Blank lines and lines starting with
+// # are ignored.
+type Tokens struct {
+	path string
+
+	mu     sync.RWMutex
+	tokens map[string]string // Token to the operator's name
+}
+
+// LoadTokens reads an API tokens file
+func LoadTokens(path string) (*Tokens, error) {
+	t := &Tokens{path: path}
+	if err := t.Reload(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Reload re-reads the tokens file, keeping the old tokens if it can't be read
+func (t *Tokens) Reload() error {
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		return fmt.Errorf("failed to read API tokens file %s: %w", t.path, err)
+	}
+
+	tokens := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		token, name, _ := strings.Cut(line, " ")
+		name = strings.TrimSpace(name)
+		if len(token) < minTokenLength || name == "" {
+			return fmt.Errorf("%s:%d: want a token of at least %d characters and a name", t.path, n, minTokenLength)
+		}
+		tokens[token] = name
+	}
+
+	t.mu.Lock()
+	t.tokens = tokens
+	t.mu.Unlock()
+	return nil
+}
+
+// Lookup returns the name of the operator a token belongs to, comparing it
+// with every token in constant time
+func (t *Tokens) Lookup(token string) (string, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	found := ""
+	for candidate, name := range t.tokens {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(token)) == 1 {
+			found = name
+		}
+	}
+	return found, found != ""
+}
+
+// Len returns the number of tokens
+func (t *Tokens) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.tokens)
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	aliceToken = "0123456789abcdef"
+	bobToken   = "fedcba9876543210fedcba"
+)
+
+// writeTokens writes a tokens file and returns its path
+func writeTokens(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tokens")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLookup(t *testing.T) {
+	tokens, err := LoadTokens(writeTokens(t, "# Operators\n\n"+aliceToken+" alice\n  "+bobToken+"   bob smith  \n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tokens.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", tokens.Len())
+	}
+
+	for _, tt := range []struct {
+		token    string
+		wantName string
+	}{
+		{aliceToken, "alice"},
+		{bobToken, "bob smith"},
+		{"", ""},
+		{"unknown-token-0000", ""},
+		{aliceToken[:10], ""},
+		{aliceToken + "0", ""},
+		{"# Operators", ""},
+	} {
+		name, ok := tokens.Lookup(tt.token)
+		if name != tt.wantName || ok != (tt.wantName != "") {
+			t.Errorf("Lookup(%q) = %q, %t, want %q", tt.token, name, ok, tt.wantName)
+		}
+	}
+}
+
+func TestLoadTokensErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		data string
+	}{
+		{"short token", "short alice\n"},
+		{"no name", aliceToken + "\n"},
+		{"blank name", aliceToken + "    \n"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadTokens(writeTokens(t, tt.data)); err == nil {
+				t.Error("LoadTokens succeeded, want an error")
+			}
+		})
+	}
+
+	if _, err := LoadTokens(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("LoadTokens of a missing file succeeded, want an error")
+	}
+}
+
+func TestReloadKeepsTokensOnError(t *testing.T) {
+	path := writeTokens(t, aliceToken+" alice\n")
+	tokens, err := LoadTokens(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(bobToken+" bob\nshort carol\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := tokens.Reload(); err == nil {
+		t.Error("Reload of a bad file succeeded, want an error")
+	}
+	if name, _ := tokens.Lookup(aliceToken); name != "alice" {
+		t.Errorf("after a failed reload, Lookup(alice) = %q, want alice", name)
+	}
+	if _, ok := tokens.Lookup(bobToken); ok {
+		t.Error("after a failed reload, bob's token was accepted")
+	}
+
+	if err := os.WriteFile(path, []byte(bobToken+" bob\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := tokens.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tokens.Lookup(aliceToken); ok {
+		t.Error("after reloading, alice's removed token was accepted")
+	}
+	if name, _ := tokens.Lookup(bobToken); name != "bob" {
+		t.Errorf("after reloading, Lookup(bob) = %q, want bob", name)
+	}
+}
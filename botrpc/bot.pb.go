@@ -0,0 +1,885 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: botrpc/bot.proto
+
+package botrpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Action is a control a bot holds down, like a key on the keyboard
+type Action int32
+
+const (
+	Action_ACTION_UNSPECIFIED   Action = 0
+	Action_ACTION_MOVE_FORWARD  Action = 1
+	Action_ACTION_MOVE_BACKWARD Action = 2
+	Action_ACTION_STRAFE_LEFT   Action = 3
+	Action_ACTION_STRAFE_RIGHT  Action = 4
+	Action_ACTION_TURN_LEFT     Action = 5
+	Action_ACTION_TURN_RIGHT    Action = 6
+	Action_ACTION_SPRINT        Action = 7
+	Action_ACTION_FIRE          Action = 8
+)
+
+// Enum value maps for Action.
+var (
+	Action_name = map[int32]string{
+		0: "ACTION_UNSPECIFIED",
+		1: "ACTION_MOVE_FORWARD",
+		2: "ACTION_MOVE_BACKWARD",
+		3: "ACTION_STRAFE_LEFT",
+		4: "ACTION_STRAFE_RIGHT",
+		5: "ACTION_TURN_LEFT",
+		6: "ACTION_TURN_RIGHT",
+		7: "ACTION_SPRINT",
+		8: "ACTION_FIRE",
+	}
+	Action_value = map[string]int32{
+		"ACTION_UNSPECIFIED":   0,
+		"ACTION_MOVE_FORWARD":  1,
+		"ACTION_MOVE_BACKWARD": 2,
+		"ACTION_STRAFE_LEFT":   3,
+		"ACTION_STRAFE_RIGHT":  4,
+		"ACTION_TURN_LEFT":     5,
+		"ACTION_TURN_RIGHT":    6,
+		"ACTION_SPRINT":        7,
+		"ACTION_FIRE":          8,
+	}
+)
+
+func (x Action) Enum() *Action {
+	p := new(Action)
+	*p = x
+	return p
+}
+
+func (x Action) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Action) Descriptor() protoreflect.EnumDescriptor {
+	return file_botrpc_bot_proto_enumTypes[0].Descriptor()
+}
+
+func (Action) Type() protoreflect.EnumType {
+	return &file_botrpc_bot_proto_enumTypes[0]
+}
+
+func (x Action) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Action.Descriptor instead.
+func (Action) EnumDescriptor() ([]byte, []int) {
+	return file_botrpc_bot_proto_rawDescGZIP(), []int{0}
+}
+
+// Command is what a bot does next
+type Command struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The player's name; only read from the first command
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Actions to hold. Each is held for 0.3 seconds after the command that
+	// holds it, as if a key were pressed, so bots resend them to keep moving.
+	Hold []Action `protobuf:"varint,2,rep,packed,name=hold,proto3,enum=terminus.bot.v1.Action" json:"hold,omitempty"`
+	// Weapon slot to switch to, from 1 to 9, or 0 to keep the current weapon
+	Weapon int32 `protobuf:"varint,3,opt,name=weapon,proto3" json:"weapon,omitempty"`
+	// Reload the current weapon
+	Reload bool `protobuf:"varint,4,opt,name=reload,proto3" json:"reload,omitempty"`
+	// Light or put out the torch
+	ToggleTorch   bool `protobuf:"varint,5,opt,name=toggle_torch,json=toggleTorch,proto3" json:"toggle_torch,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Command) Reset() {
+	*x = Command{}
+	mi := &file_botrpc_bot_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Command) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Command) ProtoMessage() {}
+
+func (x *Command) ProtoReflect() protoreflect.Message {
+	mi := &file_botrpc_bot_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Command.ProtoReflect.Descriptor instead.
+func (*Command) Descriptor() ([]byte, []int) {
+	return file_botrpc_bot_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Command) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Command) GetHold() []Action {
+	if x != nil {
+		return x.Hold
+	}
+	return nil
+}
+
+func (x *Command) GetWeapon() int32 {
+	if x != nil {
+		return x.Weapon
+	}
+	return 0
+}
+
+func (x *Command) GetReload() bool {
+	if x != nil {
+		return x.Reload
+	}
+	return false
+}
+
+func (x *Command) GetToggleTorch() bool {
+	if x != nil {
+		return x.ToggleTorch
+	}
+	return false
+}
+
+// Vector is a position or direction on the map, in map cells
+type Vector struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	X             float64                `protobuf:"fixed64,1,opt,name=x,proto3" json:"x,omitempty"`
+	Y             float64                `protobuf:"fixed64,2,opt,name=y,proto3" json:"y,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Vector) Reset() {
+	*x = Vector{}
+	mi := &file_botrpc_bot_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Vector) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Vector) ProtoMessage() {}
+
+func (x *Vector) ProtoReflect() protoreflect.Message {
+	mi := &file_botrpc_bot_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Vector.ProtoReflect.Descriptor instead.
+func (*Vector) Descriptor() ([]byte, []int) {
+	return file_botrpc_bot_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Vector) GetX() float64 {
+	if x != nil {
+		return x.X
+	}
+	return 0
+}
+
+func (x *Vector) GetY() float64 {
+	if x != nil {
+		return x.Y
+	}
+	return 0
+}
+
+// Map is the layout of the world
+type Map struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Name   string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Width  int32                  `protobuf:"varint,2,opt,name=width,proto3" json:"width,omitempty"`
+	Height int32                  `protobuf:"varint,3,opt,name=height,proto3" json:"height,omitempty"`
+	// Wall type of each cell, row by row, 0 for open floor
+	Cells         []int32 `protobuf:"varint,4,rep,packed,name=cells,proto3" json:"cells,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Map) Reset() {
+	*x = Map{}
+	mi := &file_botrpc_bot_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Map) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Map) ProtoMessage() {}
+
+func (x *Map) ProtoReflect() protoreflect.Message {
+	mi := &file_botrpc_bot_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Map.ProtoReflect.Descriptor instead.
+func (*Map) Descriptor() ([]byte, []int) {
+	return file_botrpc_bot_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Map) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Map) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *Map) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *Map) GetCells() []int32 {
+	if x != nil {
+		return x.Cells
+	}
+	return nil
+}
+
+// Self is the bot's own player
+type Self struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Position      *Vector                `protobuf:"bytes,3,opt,name=position,proto3" json:"position,omitempty"`
+	Direction     *Vector                `protobuf:"bytes,4,opt,name=direction,proto3" json:"direction,omitempty"`
+	Health        float64                `protobuf:"fixed64,5,opt,name=health,proto3" json:"health,omitempty"`
+	Stamina       float64                `protobuf:"fixed64,6,opt,name=stamina,proto3" json:"stamina,omitempty"`
+	Weapon        string                 `protobuf:"bytes,7,opt,name=weapon,proto3" json:"weapon,omitempty"`
+	Ammo          int32                  `protobuf:"varint,8,opt,name=ammo,proto3" json:"ammo,omitempty"` // Shots left in the current weapon's magazine
+	Reloading     bool                   `protobuf:"varint,9,opt,name=reloading,proto3" json:"reloading,omitempty"`
+	TorchLit      bool                   `protobuf:"varint,10,opt,name=torch_lit,json=torchLit,proto3" json:"torch_lit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Self) Reset() {
+	*x = Self{}
+	mi := &file_botrpc_bot_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Self) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Self) ProtoMessage() {}
+
+func (x *Self) ProtoReflect() protoreflect.Message {
+	mi := &file_botrpc_bot_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Self.ProtoReflect.Descriptor instead.
+func (*Self) Descriptor() ([]byte, []int) {
+	return file_botrpc_bot_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Self) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Self) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Self) GetPosition() *Vector {
+	if x != nil {
+		return x.Position
+	}
+	return nil
+}
+
+func (x *Self) GetDirection() *Vector {
+	if x != nil {
+		return x.Direction
+	}
+	return nil
+}
+
+func (x *Self) GetHealth() float64 {
+	if x != nil {
+		return x.Health
+	}
+	return 0
+}
+
+func (x *Self) GetStamina() float64 {
+	if x != nil {
+		return x.Stamina
+	}
+	return 0
+}
+
+func (x *Self) GetWeapon() string {
+	if x != nil {
+		return x.Weapon
+	}
+	return ""
+}
+
+func (x *Self) GetAmmo() int32 {
+	if x != nil {
+		return x.Ammo
+	}
+	return 0
+}
+
+func (x *Self) GetReloading() bool {
+	if x != nil {
+		return x.Reloading
+	}
+	return false
+}
+
+func (x *Self) GetTorchLit() bool {
+	if x != nil {
+		return x.TorchLit
+	}
+	return false
+}
+
+// Player is another player in the match
+type Player struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Position      *Vector                `protobuf:"bytes,2,opt,name=position,proto3" json:"position,omitempty"`
+	Direction     *Vector                `protobuf:"bytes,3,opt,name=direction,proto3" json:"direction,omitempty"`
+	Health        float64                `protobuf:"fixed64,4,opt,name=health,proto3" json:"health,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Player) Reset() {
+	*x = Player{}
+	mi := &file_botrpc_bot_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Player) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Player) ProtoMessage() {}
+
+func (x *Player) ProtoReflect() protoreflect.Message {
+	mi := &file_botrpc_bot_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Player.ProtoReflect.Descriptor instead.
+func (*Player) Descriptor() ([]byte, []int) {
+	return file_botrpc_bot_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Player) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Player) GetPosition() *Vector {
+	if x != nil {
+		return x.Position
+	}
+	return nil
+}
+
+func (x *Player) GetDirection() *Vector {
+	if x != nil {
+		return x.Direction
+	}
+	return nil
+}
+
+func (x *Player) GetHealth() float64 {
+	if x != nil {
+		return x.Health
+	}
+	return 0
+}
+
+// Entity is an NPC, or a pickup that's there to take
+type Entity struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Kind          string                 `protobuf:"bytes,2,opt,name=kind,proto3" json:"kind,omitempty"` // "enemy", or what the pickup is, like "health pack"
+	Position      *Vector                `protobuf:"bytes,3,opt,name=position,proto3" json:"position,omitempty"`
+	Health        float64                `protobuf:"fixed64,4,opt,name=health,proto3" json:"health,omitempty"` // For NPCs; 0 for things without health
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Entity) Reset() {
+	*x = Entity{}
+	mi := &file_botrpc_bot_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Entity) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Entity) ProtoMessage() {}
+
+func (x *Entity) ProtoReflect() protoreflect.Message {
+	mi := &file_botrpc_bot_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Entity.ProtoReflect.Descriptor instead.
+func (*Entity) Descriptor() ([]byte, []int) {
+	return file_botrpc_bot_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Entity) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Entity) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Entity) GetPosition() *Vector {
+	if x != nil {
+		return x.Position
+	}
+	return nil
+}
+
+func (x *Entity) GetHealth() float64 {
+	if x != nil {
+		return x.Health
+	}
+	return 0
+}
+
+// Projectile is a shot in flight
+type Projectile struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"` // e.g. "fireball" or "rocket"
+	Position      *Vector                `protobuf:"bytes,3,opt,name=position,proto3" json:"position,omitempty"`
+	Direction     *Vector                `protobuf:"bytes,4,opt,name=direction,proto3" json:"direction,omitempty"`
+	Speed         float64                `protobuf:"fixed64,5,opt,name=speed,proto3" json:"speed,omitempty"`
+	Own           bool                   `protobuf:"varint,6,opt,name=own,proto3" json:"own,omitempty"` // Whether the bot fired it
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Projectile) Reset() {
+	*x = Projectile{}
+	mi := &file_botrpc_bot_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Projectile) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Projectile) ProtoMessage() {}
+
+func (x *Projectile) ProtoReflect() protoreflect.Message {
+	mi := &file_botrpc_bot_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Projectile.ProtoReflect.Descriptor instead.
+func (*Projectile) Descriptor() ([]byte, []int) {
+	return file_botrpc_bot_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Projectile) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Projectile) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Projectile) GetPosition() *Vector {
+	if x != nil {
+		return x.Position
+	}
+	return nil
+}
+
+func (x *Projectile) GetDirection() *Vector {
+	if x != nil {
+		return x.Direction
+	}
+	return nil
+}
+
+func (x *Projectile) GetSpeed() float64 {
+	if x != nil {
+		return x.Speed
+	}
+	return 0
+}
+
+func (x *Projectile) GetOwn() bool {
+	if x != nil {
+		return x.Own
+	}
+	return false
+}
+
+// Observation is the world as of a simulation step
+type Observation struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Step      uint64                 `protobuf:"varint,1,opt,name=step,proto3" json:"step,omitempty"`
+	WorldTime float64                `protobuf:"fixed64,2,opt,name=world_time,json=worldTime,proto3" json:"world_time,omitempty"` // Seconds of simulation run
+	// The map, sent in the first observation and whenever it changes, like
+	// when a door opens or the server switches maps
+	Map         *Map          `protobuf:"bytes,3,opt,name=map,proto3" json:"map,omitempty"`
+	Self        *Self         `protobuf:"bytes,4,opt,name=self,proto3" json:"self,omitempty"`
+	Players     []*Player     `protobuf:"bytes,5,rep,name=players,proto3" json:"players,omitempty"` // Other players, except invisible ones
+	Entities    []*Entity     `protobuf:"bytes,6,rep,name=entities,proto3" json:"entities,omitempty"`
+	Projectiles []*Projectile `protobuf:"bytes,7,rep,name=projectiles,proto3" json:"projectiles,omitempty"`
+	// Messages for the player since the last observation, like who killed them
+	Notices       []string `protobuf:"bytes,8,rep,name=notices,proto3" json:"notices,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Observation) Reset() {
+	*x = Observation{}
+	mi := &file_botrpc_bot_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Observation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Observation) ProtoMessage() {}
+
+func (x *Observation) ProtoReflect() protoreflect.Message {
+	mi := &file_botrpc_bot_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Observation.ProtoReflect.Descriptor instead.
+func (*Observation) Descriptor() ([]byte, []int) {
+	return file_botrpc_bot_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Observation) GetStep() uint64 {
+	if x != nil {
+		return x.Step
+	}
+	return 0
+}
+
+func (x *Observation) GetWorldTime() float64 {
+	if x != nil {
+		return x.WorldTime
+	}
+	return 0
+}
+
+func (x *Observation) GetMap() *Map {
+	if x != nil {
+		return x.Map
+	}
+	return nil
+}
+
+func (x *Observation) GetSelf() *Self {
+	if x != nil {
+		return x.Self
+	}
+	return nil
+}
+
+func (x *Observation) GetPlayers() []*Player {
+	if x != nil {
+		return x.Players
+	}
+	return nil
+}
+
+func (x *Observation) GetEntities() []*Entity {
+	if x != nil {
+		return x.Entities
+	}
+	return nil
+}
+
+func (x *Observation) GetProjectiles() []*Projectile {
+	if x != nil {
+		return x.Projectiles
+	}
+	return nil
+}
+
+func (x *Observation) GetNotices() []string {
+	if x != nil {
+		return x.Notices
+	}
+	return nil
+}
+
+var File_botrpc_bot_proto protoreflect.FileDescriptor
+
+const file_botrpc_bot_proto_rawDesc = "" +
+	"\n" +
+	"\x10botrpc/bot.proto\x12\x0fterminus.bot.v1\"\x9d\x01\n" +
+	"\aCommand\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12+\n" +
+	"\x04hold\x18\x02 \x03(\x0e2\x17.terminus.bot.v1.ActionR\x04hold\x12\x16\n" +
+	"\x06weapon\x18\x03 \x01(\x05R\x06weapon\x12\x16\n" +
+	"\x06reload\x18\x04 \x01(\bR\x06reload\x12!\n" +
+	"\ftoggle_torch\x18\x05 \x01(\bR\vtoggleTorch\"$\n" +
+	"\x06Vector\x12\f\n" +
+	"\x01x\x18\x01 \x01(\x01R\x01x\x12\f\n" +
+	"\x01y\x18\x02 \x01(\x01R\x01y\"]\n" +
+	"\x03Map\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05width\x18\x02 \x01(\x05R\x05width\x12\x16\n" +
+	"\x06height\x18\x03 \x01(\x05R\x06height\x12\x14\n" +
+	"\x05cells\x18\x04 \x03(\x05R\x05cells\"\xaf\x02\n" +
+	"\x04Self\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x123\n" +
+	"\bposition\x18\x03 \x01(\v2\x17.terminus.bot.v1.VectorR\bposition\x125\n" +
+	"\tdirection\x18\x04 \x01(\v2\x17.terminus.bot.v1.VectorR\tdirection\x12\x16\n" +
+	"\x06health\x18\x05 \x01(\x01R\x06health\x12\x18\n" +
+	"\astamina\x18\x06 \x01(\x01R\astamina\x12\x16\n" +
+	"\x06weapon\x18\a \x01(\tR\x06weapon\x12\x12\n" +
+	"\x04ammo\x18\b \x01(\x05R\x04ammo\x12\x1c\n" +
+	"\treloading\x18\t \x01(\bR\treloading\x12\x1b\n" +
+	"\ttorch_lit\x18\n" +
+	" \x01(\bR\btorchLit\"\xa0\x01\n" +
+	"\x06Player\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x123\n" +
+	"\bposition\x18\x02 \x01(\v2\x17.terminus.bot.v1.VectorR\bposition\x125\n" +
+	"\tdirection\x18\x03 \x01(\v2\x17.terminus.bot.v1.VectorR\tdirection\x12\x16\n" +
+	"\x06health\x18\x04 \x01(\x01R\x06health\"y\n" +
+	"\x06Entity\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04kind\x18\x02 \x01(\tR\x04kind\x123\n" +
+	"\bposition\x18\x03 \x01(\v2\x17.terminus.bot.v1.VectorR\bposition\x12\x16\n" +
+	"\x06health\x18\x04 \x01(\x01R\x06health\"\xc4\x01\n" +
+	"\n" +
+	"Projectile\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x123\n" +
+	"\bposition\x18\x03 \x01(\v2\x17.terminus.bot.v1.VectorR\bposition\x125\n" +
+	"\tdirection\x18\x04 \x01(\v2\x17.terminus.bot.v1.VectorR\tdirection\x12\x14\n" +
+	"\x05speed\x18\x05 \x01(\x01R\x05speed\x12\x10\n" +
+	"\x03own\x18\x06 \x01(\bR\x03own\"\xd4\x02\n" +
+	"\vObservation\x12\x12\n" +
+	"\x04step\x18\x01 \x01(\x04R\x04step\x12\x1d\n" +
+	"\n" +
+	"world_time\x18\x02 \x01(\x01R\tworldTime\x12&\n" +
+	"\x03map\x18\x03 \x01(\v2\x14.terminus.bot.v1.MapR\x03map\x12)\n" +
+	"\x04self\x18\x04 \x01(\v2\x15.terminus.bot.v1.SelfR\x04self\x121\n" +
+	"\aplayers\x18\x05 \x03(\v2\x17.terminus.bot.v1.PlayerR\aplayers\x123\n" +
+	"\bentities\x18\x06 \x03(\v2\x17.terminus.bot.v1.EntityR\bentities\x12=\n" +
+	"\vprojectiles\x18\a \x03(\v2\x1b.terminus.bot.v1.ProjectileR\vprojectiles\x12\x18\n" +
+	"\anotices\x18\b \x03(\tR\anotices*\xd5\x01\n" +
+	"\x06Action\x12\x16\n" +
+	"\x12ACTION_UNSPECIFIED\x10\x00\x12\x17\n" +
+	"\x13ACTION_MOVE_FORWARD\x10\x01\x12\x18\n" +
+	"\x14ACTION_MOVE_BACKWARD\x10\x02\x12\x16\n" +
+	"\x12ACTION_STRAFE_LEFT\x10\x03\x12\x17\n" +
+	"\x13ACTION_STRAFE_RIGHT\x10\x04\x12\x14\n" +
+	"\x10ACTION_TURN_LEFT\x10\x05\x12\x15\n" +
+	"\x11ACTION_TURN_RIGHT\x10\x06\x12\x11\n" +
+	"\rACTION_SPRINT\x10\a\x12\x0f\n" +
+	"\vACTION_FIRE\x10\b2S\n" +
+	"\rBotController\x12B\n" +
+	"\x04Play\x12\x18.terminus.bot.v1.Command\x1a\x1c.terminus.bot.v1.Observation(\x010\x01B%Z#github.com/imjasonh/terminus/botrpcb\x06proto3"
+
+var (
+	file_botrpc_bot_proto_rawDescOnce sync.Once
+	file_botrpc_bot_proto_rawDescData []byte
+)
+
+func file_botrpc_bot_proto_rawDescGZIP() []byte {
+	file_botrpc_bot_proto_rawDescOnce.Do(func() {
+		file_botrpc_bot_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_botrpc_bot_proto_rawDesc), len(file_botrpc_bot_proto_rawDesc)))
+	})
+	return file_botrpc_bot_proto_rawDescData
+}
+
+var file_botrpc_bot_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_botrpc_bot_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_botrpc_bot_proto_goTypes = []any{
+	(Action)(0),         // 0: terminus.bot.v1.Action
+	(*Command)(nil),     // 1: terminus.bot.v1.Command
+	(*Vector)(nil),      // 2: terminus.bot.v1.Vector
+	(*Map)(nil),         // 3: terminus.bot.v1.Map
+	(*Self)(nil),        // 4: terminus.bot.v1.Self
+	(*Player)(nil),      // 5: terminus.bot.v1.Player
+	(*Entity)(nil),      // 6: terminus.bot.v1.Entity
+	(*Projectile)(nil),  // 7: terminus.bot.v1.Projectile
+	(*Observation)(nil), // 8: terminus.bot.v1.Observation
+}
+var file_botrpc_bot_proto_depIdxs = []int32{
+	0,  // 0: terminus.bot.v1.Command.hold:type_name -> terminus.bot.v1.Action
+	2,  // 1: terminus.bot.v1.Self.position:type_name -> terminus.bot.v1.Vector
+	2,  // 2: terminus.bot.v1.Self.direction:type_name -> terminus.bot.v1.Vector
+	2,  // 3: terminus.bot.v1.Player.position:type_name -> terminus.bot.v1.Vector
+	2,  // 4: terminus.bot.v1.Player.direction:type_name -> terminus.bot.v1.Vector
+	2,  // 5: terminus.bot.v1.Entity.position:type_name -> terminus.bot.v1.Vector
+	2,  // 6: terminus.bot.v1.Projectile.position:type_name -> terminus.bot.v1.Vector
+	2,  // 7: terminus.bot.v1.Projectile.direction:type_name -> terminus.bot.v1.Vector
+	3,  // 8: terminus.bot.v1.Observation.map:type_name -> terminus.bot.v1.Map
+	4,  // 9: terminus.bot.v1.Observation.self:type_name -> terminus.bot.v1.Self
+	5,  // 10: terminus.bot.v1.Observation.players:type_name -> terminus.bot.v1.Player
+	6,  // 11: terminus.bot.v1.Observation.entities:type_name -> terminus.bot.v1.Entity
+	7,  // 12: terminus.bot.v1.Observation.projectiles:type_name -> terminus.bot.v1.Projectile
+	1,  // 13: terminus.bot.v1.BotController.Play:input_type -> terminus.bot.v1.Command
+	8,  // 14: terminus.bot.v1.BotController.Play:output_type -> terminus.bot.v1.Observation
+	14, // [14:15] is the sub-list for method output_type
+	13, // [13:14] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_botrpc_bot_proto_init() }
+func file_botrpc_bot_proto_init() {
+	if File_botrpc_bot_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_botrpc_bot_proto_rawDesc), len(file_botrpc_bot_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_botrpc_bot_proto_goTypes,
+		DependencyIndexes: file_botrpc_bot_proto_depIdxs,
+		EnumInfos:         file_botrpc_bot_proto_enumTypes,
+		MessageInfos:      file_botrpc_bot_proto_msgTypes,
+	}.Build()
+	File_botrpc_bot_proto = out.File
+	file_botrpc_bot_proto_goTypes = nil
+	file_botrpc_bot_proto_depIdxs = nil
+}
@@ -0,0 +1,129 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: botrpc/bot.proto
+
+package botrpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	BotController_Play_FullMethodName = "/terminus.bot.v1.BotController/Play"
+)
+
+// BotControllerClient is the client API for BotController service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// BotController drives players from outside the game, for bot tournaments
+// and machine learning experiments.
+type BotControllerClient interface {
+	// Play joins the match as a player for as long as the stream stays open,
+	// named by the first command. The server sends an observation after every
+	// simulation step, skipping steps the client is too slow to receive. On
+	// invite-only servers, send the invite code as "invite-code" metadata.
+	Play(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[Command, Observation], error)
+}
+
+type botControllerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBotControllerClient(cc grpc.ClientConnInterface) BotControllerClient {
+	return &botControllerClient{cc}
+}
+
+func (c *botControllerClient) Play(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[Command, Observation], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &BotController_ServiceDesc.Streams[0], BotController_Play_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[Command, Observation]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BotController_PlayClient = grpc.BidiStreamingClient[Command, Observation]
+
+// BotControllerServer is the server API for BotController service.
+// All implementations must embed UnimplementedBotControllerServer
+// for forward compatibility.
+//
+// BotController drives players from outside the game, for bot tournaments
+// and machine learning experiments.
+type BotControllerServer interface {
+	// Play joins the match as a player for as long as the stream stays open,
+	// named by the first command. The server sends an observation after every
+	// simulation step, skipping steps the client is too slow to receive. On
+	// invite-only servers, send the invite code as "invite-code" metadata.
+	Play(grpc.BidiStreamingServer[Command, Observation]) error
+	mustEmbedUnimplementedBotControllerServer()
+}
+
+// UnimplementedBotControllerServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedBotControllerServer struct{}
+
+func (UnimplementedBotControllerServer) Play(grpc.BidiStreamingServer[Command, Observation]) error {
+	return status.Error(codes.Unimplemented, "method Play not implemented")
+}
+func (UnimplementedBotControllerServer) mustEmbedUnimplementedBotControllerServer() {}
+func (UnimplementedBotControllerServer) testEmbeddedByValue()                       {}
+
+// UnsafeBotControllerServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BotControllerServer will
+// result in compilation errors.
+type UnsafeBotControllerServer interface {
+	mustEmbedUnimplementedBotControllerServer()
+}
+
+func RegisterBotControllerServer(s grpc.ServiceRegistrar, srv BotControllerServer) {
+	// If the following call panics, it indicates UnimplementedBotControllerServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&BotController_ServiceDesc, srv)
+}
+
+func _BotController_Play_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BotControllerServer).Play(&grpc.GenericServerStream[Command, Observation]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type BotController_PlayServer = grpc.BidiStreamingServer[Command, Observation]
+
+// BotController_ServiceDesc is the grpc.ServiceDesc for BotController service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BotController_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "terminus.bot.v1.BotController",
+	HandlerType: (*BotControllerServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Play",
+			Handler:       _BotController_Play_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "botrpc/bot.proto",
+}
@@ -0,0 +1,230 @@
+// Package botrpc serves the BotController gRPC service, which lets external
+// programs play: a bot joins as a player, receives what's in the world each
+// simulation step, and sends the actions its player takes, without a
+// terminal in between. bot.proto defines the service; regenerate bot.pb.go
+// and bot_grpc.pb.go with protoc-gen-go and protoc-gen-go-grpc after
+// changing it.
+package botrpc
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/imjasonh/terminus/auth"
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/input"
+	"github.com/imjasonh/terminus/server"
+)
+
+// heldActions are the player actions bots hold like keys, by their API name
+var heldActions = map[Action]input.Action{
+	Action_ACTION_MOVE_FORWARD:  input.ActionMoveForward,
+	Action_ACTION_MOVE_BACKWARD: input.ActionMoveBackward,
+	Action_ACTION_STRAFE_LEFT:   input.ActionStrafeLeft,
+	Action_ACTION_STRAFE_RIGHT:  input.ActionStrafeRight,
+	Action_ACTION_TURN_LEFT:     input.ActionTurnLeft,
+	Action_ACTION_TURN_RIGHT:    input.ActionTurnRight,
+	Action_ACTION_SPRINT:        input.ActionSprint,
+}
+
+// Service plays bots' players on a game server. Bots join like keyless
+// players: anyone on an open server, or with the invite code on a private
+// one, unless their address is banned.
+type Service struct {
+	UnimplementedBotControllerServer
+	Server *server.GameServer
+	Auth   auth.Options
+}
+
+// Play runs a bot's player until the bot disconnects or is kicked
+func (s *Service) Play(stream grpc.BidiStreamingServer[Command, Observation]) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return status.Error(codes.Internal, "no peer address")
+	}
+	code := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("invite-code")) > 0 {
+		code = md.Get("invite-code")[0]
+	}
+	if !s.Auth.AdmitKeyless(p.Addr, code) {
+		return status.Error(codes.PermissionDenied, "this server requires an invite code")
+	}
+	remoteIP := p.Addr.String()
+	if host, _, err := net.SplitHostPort(remoteIP); err == nil {
+		remoteIP = host
+	}
+	if ban, banned := s.Server.Bans.Check("", remoteIP); banned {
+		return status.Error(codes.PermissionDenied, ban.Message())
+	}
+
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	sessionID := uuid.New().String()
+	session, err := s.Server.AddPlayer(sessionID, "", first.GetName(), server.Connection{RemoteIP: remoteIP})
+	if errors.Is(err, server.ErrServerFull) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	} else if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	defer s.Server.RemovePlayer(sessionID)
+	session.Log.Info("Bot connected")
+
+	s.apply(session, first)
+	go func() {
+		defer cancel()
+		for {
+			cmd, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			s.apply(session, cmd)
+		}
+	}()
+
+	var sentMap *game.Map
+	for {
+		// Wait for a snapshot with the bot's player in it
+		snapshot, next := s.Server.LatestSnapshot()
+		if _, ok := snapshot.Player(session.ID); ok {
+			obs := s.observe(session, snapshot)
+			if worldMap := snapshot.Map; worldMap != sentMap {
+				obs.Map = newMap(snapshot.MapName, worldMap)
+				sentMap = worldMap
+			}
+			if err := stream.Send(obs); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			session.Log.Info("Bot disconnected")
+			return nil
+		case <-session.Kicked():
+			return status.Error(codes.Aborted, session.KickReason())
+		case <-next:
+		}
+	}
+}
+
+// apply makes the bot's player do what a command says
+func (s *Service) apply(session *server.PlayerSession, cmd *Command) {
+	for _, a := range cmd.GetHold() {
+		if a == Action_ACTION_FIRE {
+			// Continuous weapons fire each step while it's held, like in the game
+			if game.GetWeapon(session.Player.Weapon).Continuous {
+				session.Holds.Press(input.ActionFire)
+			} else {
+				s.Server.FireProjectile(session)
+			}
+		} else if action, ok := heldActions[a]; ok {
+			session.Holds.Press(action)
+		}
+	}
+	if slot := cmd.GetWeapon(); slot != 0 {
+		if weapon, ok := game.WeaponInSlot(int(slot)); ok {
+			s.Server.SwitchWeapon(session, weapon.Type)
+		}
+	}
+	if cmd.GetReload() {
+		s.Server.Reload(session)
+	}
+	if cmd.GetToggleTorch() {
+		session.Notify(s.Server.ToggleTorch(session))
+	}
+}
+
+// observe describes a snapshot from the bot's point of view, with the
+// messages queued for its player
+func (s *Service) observe(session *server.PlayerSession, snapshot *server.Snapshot) *Observation {
+	obs := &Observation{Step: snapshot.Step, WorldTime: snapshot.WorldTime}
+	for _, ps := range snapshot.Players {
+		p := &ps.Player
+		if ps.ID == session.ID {
+			weapon := game.GetWeapon(p.Weapon)
+			obs.Self = &Self{
+				Id:        ps.ID,
+				Name:      ps.Name,
+				Position:  vector(p.Position),
+				Direction: vector(p.Direction),
+				Health:    p.Health,
+				Stamina:   p.Stamina,
+				Weapon:    weapon.Name,
+				Ammo:      int32(p.Ammo[p.Weapon]),
+				Reloading: p.ReloadTimer > 0,
+				TorchLit:  p.TorchLit,
+			}
+			continue
+		}
+		if p.IsInvisible() {
+			continue
+		}
+		obs.Players = append(obs.Players, &Player{
+			Name:      ps.Name,
+			Position:  vector(p.Position),
+			Direction: vector(p.Direction),
+			Health:    p.Health,
+		})
+	}
+
+	for _, e := range snapshot.Entities {
+		if e.Kind != game.KindNPC && (e.Pickup == nil || !e.Pickup.Ready()) {
+			continue
+		}
+		entity := &Entity{Id: e.ID, Kind: string(e.Kind), Position: vector(e.Position)}
+		if e.Health != nil {
+			entity.Health = e.Health.Current
+		}
+		obs.Entities = append(obs.Entities, entity)
+	}
+
+	for i := range snapshot.Projectiles {
+		p := &snapshot.Projectiles[i]
+		obs.Projectiles = append(obs.Projectiles, &Projectile{
+			Id:        p.ID,
+			Type:      string(p.Entity().Kind),
+			Position:  vector(p.Position),
+			Direction: vector(p.Direction),
+			Speed:     p.Speed,
+			Own:       p.Owner == session.ID,
+		})
+	}
+
+	for {
+		select {
+		case msg := <-session.Notices():
+			obs.Notices = append(obs.Notices, msg)
+		default:
+			return obs
+		}
+	}
+}
+
+// newMap describes a map's layout
+func newMap(name string, m *game.Map) *Map {
+	cells := make([]int32, 0, m.Width*m.Height)
+	for _, row := range m.Grid {
+		for _, cell := range row {
+			cells = append(cells, int32(cell))
+		}
+	}
+	return &Map{Name: name, Width: int32(m.Width), Height: int32(m.Height), Cells: cells}
+}
+
+// vector converts a game vector for the API
+func vector(v game.Vector) *Vector {
+	return &Vector{X: v.X, Y: v.Y}
+}
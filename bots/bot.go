@@ -0,0 +1,268 @@
+// Package bots plays the game over real SSH connections, for load testing a
+// server. Bots read just enough of their output to get through the welcome
+// screens and count frames, and press random movement and fire keys.
+package bots
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Options configures a load test
+type Options struct {
+	Addr     string        // The server's SSH address, e.g. localhost:2222
+	Count    int           // Bots to connect
+	Duration time.Duration // How long each bot stays connected
+	Ramp     time.Duration // Delay between starting bots, so they don't all connect at once
+	KeyRate  int           // Keys each bot presses per second
+	Code     string        // Invite code for private servers, sent as the password
+	Term     string        // TERM to request, which decides the color depth the server sends
+	Width    int           // Terminal size in character cells
+	Height   int
+}
+
+// DefaultOptions returns options for n bots playing for 30 seconds on a
+// local server
+func DefaultOptions(n int) Options {
+	return Options{
+		Addr:     "localhost:2222",
+		Count:    n,
+		Duration: 30 * time.Second,
+		Ramp:     50 * time.Millisecond,
+		KeyRate:  10,
+		Term:     "xterm-256color",
+		Width:    80,
+		Height:   24,
+	}
+}
+
+// Stats is what one bot measured
+type Stats struct {
+	Joined    bool          // Reached the game
+	Queued    bool          // Waited in line for a slot at some point
+	Err       error         // Why the bot couldn't connect or was disconnected early
+	JoinTime  time.Duration // From dialing to the first game frame
+	Played    time.Duration // From the first game frame to disconnecting
+	Bytes     int64         // Output received, including the welcome screens
+	Frames    int
+	Intervals []time.Duration // Between consecutive frames
+	KeysSent  int
+}
+
+// Output the bot watches for. Frames are found by frameMarker instead, since
+// it depends on the terminal's height.
+var (
+	markerPrompt   = [][]byte{[]byte("Press ENTER"), []byte("Press any key")}
+	markerQueued   = []byte("Server full")
+	markerRejected = []byte("Connection rejected")
+)
+
+// keys are what bots press in the game: mostly movement, with turns and shots
+var keys = []string{"w", "w", "w", "a", "s", "d", "q", "e", "q", "e", " ", " ", "1", "2"}
+
+// promptRetry is how often a bot presses ENTER while waiting for the game to
+// start, in case a custom welcome screen doesn't say to
+const promptRetry = 2 * time.Second
+
+// frameMarker returns the output that appears once in every game frame: the
+// cursor moving to the start of the bottom row, for the HUD's compass strip.
+// Nothing else the server draws, like the welcome screens, moves it there.
+func frameMarker(height int) []byte {
+	return fmt.Appendf(nil, "\x1b[%d;1H", height)
+}
+
+// bot is one connection's state, shared between its reader and key loop
+type bot struct {
+	id          int
+	start       time.Time
+	markerFrame []byte
+
+	mu        sync.Mutex
+	stats     Stats
+	lastFrame time.Time
+	tail      []byte // The end of the last read, for markers split across reads
+	prompted  bool   // Whether a prompt to press a key was seen since the last key
+	rejected  bool
+}
+
+// play connects a bot and plays until ctx is done or the server disconnects it
+func play(ctx context.Context, id int, opts Options) Stats {
+	b := &bot{id: id, start: time.Now(), markerFrame: frameMarker(opts.Height)}
+	if err := b.play(ctx, opts); err != nil {
+		b.mu.Lock()
+		b.stats.Err = err
+		b.mu.Unlock()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stats.Joined {
+		b.stats.Played = time.Since(b.start) - b.stats.JoinTime
+	}
+	return b.stats
+}
+
+func (b *bot) play(ctx context.Context, opts Options) error {
+	config := &ssh.ClientConfig{
+		User: fmt.Sprintf("bot%d", b.id),
+		Auth: []ssh.AuthMethod{
+			ssh.Password(opts.Code),
+			ssh.KeyboardInteractive(func(_, _ string, questions []string, _ []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range answers {
+					answers[i] = opts.Code
+				}
+				return answers, nil
+			}),
+		},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // Bots only test servers, not trust them
+		Timeout:         10 * time.Second,
+	}
+	client, err := ssh.Dial("tcp", opts.Addr, config)
+	if err != nil {
+		return fmt.Errorf("dial: %w", err)
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return fmt.Errorf("session: %w", err)
+	}
+	defer session.Close()
+	if err := session.RequestPty(opts.Term, opts.Height, opts.Width, ssh.TerminalModes{}); err != nil {
+		return fmt.Errorf("pty: %w", err)
+	}
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := session.Shell(); err != nil {
+		return fmt.Errorf("shell: %w", err)
+	}
+
+	readErr := make(chan error, 1)
+	go func() { readErr <- b.read(stdout) }()
+
+	interval := time.Second / time.Duration(max(opts.KeyRate, 1))
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	lastEnter := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			stdin.Write([]byte{3}) // Ctrl+C to leave the way a player would
+			return nil
+		case err := <-readErr:
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			switch {
+			case b.rejected:
+				return fmt.Errorf("rejected by the server")
+			case err != nil && err != io.EOF:
+				return err
+			default:
+				return fmt.Errorf("disconnected by the server")
+			}
+		case <-ticker.C:
+			b.mu.Lock()
+			joined, prompted := b.stats.Joined, b.prompted
+			b.prompted = false
+			b.mu.Unlock()
+
+			key := keys[rand.Intn(len(keys))]
+			if !joined {
+				// Get through the terminal settings and welcome screens
+				if !prompted && time.Since(lastEnter) < promptRetry {
+					continue
+				}
+				key, lastEnter = "\r", time.Now()
+			}
+			if _, err := io.WriteString(stdin, key); err != nil {
+				return fmt.Errorf("write: %w", err)
+			}
+			b.mu.Lock()
+			b.stats.KeysSent++
+			b.mu.Unlock()
+		}
+	}
+}
+
+// read consumes output until the connection closes, counting frames and
+// noting prompts
+func (b *bot) read(r io.Reader) error {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			b.scan(buf[:n], time.Now())
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// maxMarker is the length of the longest marker, which bounds how much of each
+// read is kept to find markers split across reads
+const maxMarker = 20
+
+// scan records a chunk of output received at the given time
+func (b *bot) scan(chunk []byte, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stats.Bytes += int64(len(chunk))
+
+	data := append(b.tail, chunk...)
+	seen := len(b.tail) // Markers entirely within the tail were already counted
+	for _, marker := range markerPrompt {
+		if count(data, marker, seen) > 0 {
+			b.prompted = true
+		}
+	}
+	if count(data, markerQueued, seen) > 0 {
+		b.stats.Queued = true
+	}
+	if count(data, markerRejected, seen) > 0 {
+		b.rejected = true
+	}
+	for range count(data, b.markerFrame, seen) {
+		if !b.stats.Joined {
+			b.stats.Joined = true
+			b.stats.JoinTime = now.Sub(b.start)
+		} else {
+			b.stats.Intervals = append(b.stats.Intervals, now.Sub(b.lastFrame))
+		}
+		b.lastFrame = now
+		b.stats.Frames++
+	}
+
+	keep := min(len(data), maxMarker-1)
+	b.tail = append(b.tail[:0], data[len(data)-keep:]...)
+}
+
+// count returns how many times marker appears in data ending after the first
+// skip bytes
+func count(data, marker []byte, skip int) int {
+	n := 0
+	for i := 0; ; {
+		j := bytes.Index(data[i:], marker)
+		if j < 0 {
+			return n
+		}
+		i += j + len(marker)
+		if i > skip {
+			n++
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package bots
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Report summarizes a load test
+type Report struct {
+	Bots     int
+	Joined   int // Bots that reached the game
+	Queued   int // Bots that waited in line for a slot
+	Failed   int // Bots that couldn't connect or were disconnected early
+	Errors   map[string]int
+	Duration time.Duration
+
+	Frames        int
+	Bytes         int64
+	KeysSent      int
+	FrameInterval Distribution // Time between frames, which rises when the server falls behind
+	JoinTime      Distribution // From dialing to the first game frame
+	Played        time.Duration
+}
+
+// Distribution summarizes a set of durations
+type Distribution struct {
+	P50, P95, P99, Max time.Duration
+}
+
+// distribution summarizes durations, sorting them in place
+func distribution(d []time.Duration) Distribution {
+	if len(d) == 0 {
+		return Distribution{}
+	}
+	sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+	at := func(p float64) time.Duration {
+		return d[min(len(d)-1, int(p*float64(len(d))))]
+	}
+	return Distribution{P50: at(0.50), P95: at(0.95), P99: at(0.99), Max: d[len(d)-1]}
+}
+
+func (d Distribution) String() string {
+	round := func(d time.Duration) time.Duration { return d.Round(100 * time.Microsecond) }
+	return fmt.Sprintf("p50 %s, p95 %s, p99 %s, max %s", round(d.P50), round(d.P95), round(d.P99), round(d.Max))
+}
+
+// Run connects opts.Count bots, starting one every opts.Ramp, and plays each
+// for opts.Duration or until ctx is done. progress, if not nil, is called as
+// each bot finishes.
+func Run(ctx context.Context, opts Options, progress func(id int, stats Stats)) Report {
+	start := time.Now()
+	results := make([]Stats, opts.Count)
+
+	var wg sync.WaitGroup
+	for id := range opts.Count {
+		if id > 0 && opts.Ramp > 0 {
+			select {
+			case <-time.After(opts.Ramp):
+			case <-ctx.Done():
+			}
+		}
+		if ctx.Err() != nil {
+			results[id].Err = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			botCtx, cancel := context.WithTimeout(ctx, opts.Duration)
+			defer cancel()
+			results[id] = play(botCtx, id+1, opts)
+			if progress != nil {
+				progress(id+1, results[id])
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Bots: opts.Count, Errors: make(map[string]int), Duration: time.Since(start)}
+	var intervals, joinTimes []time.Duration
+	for _, stats := range results {
+		if stats.Joined {
+			report.Joined++
+			joinTimes = append(joinTimes, stats.JoinTime)
+		}
+		if stats.Queued {
+			report.Queued++
+		}
+		if stats.Err != nil {
+			report.Failed++
+			report.Errors[stats.Err.Error()]++
+		}
+		report.Frames += stats.Frames
+		report.Bytes += stats.Bytes
+		report.KeysSent += stats.KeysSent
+		report.Played += stats.Played
+		intervals = append(intervals, stats.Intervals...)
+	}
+	report.FrameInterval = distribution(intervals)
+	report.JoinTime = distribution(joinTimes)
+	return report
+}
+
+// Write prints the report for people
+func (r Report) Write(w io.Writer) {
+	fmt.Fprintf(w, "Bots:           %d joined, %d queued, %d failed of %d in %s\n",
+		r.Joined, r.Queued, r.Failed, r.Bots, r.Duration.Round(time.Millisecond))
+	for msg, n := range r.Errors {
+		fmt.Fprintf(w, "  %dx %s\n", n, msg)
+	}
+	if r.Joined == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Join time:      %s\n", r.JoinTime)
+	fmt.Fprintf(w, "Frame interval: %s\n", r.FrameInterval)
+	if played := r.Played.Seconds(); played > 0 {
+		fmt.Fprintf(w, "Frame rate:     %.1f frames/s per bot\n", float64(r.Frames)/played)
+		fmt.Fprintf(w, "Bandwidth:      %.1f KB/s per bot, %.1f KB/s total\n",
+			float64(r.Bytes)/1024/played, float64(r.Bytes)/1024/r.Duration.Seconds())
+	}
+	fmt.Fprintf(w, "Received:       %d frames, %.1f MB; sent %d keys\n", r.Frames, float64(r.Bytes)/(1<<20), r.KeysSent)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"net"
+
+	"github.com/chainguard-dev/clog"
+	"google.golang.org/grpc"
+
+	"github.com/imjasonh/terminus/auth"
+	"github.com/imjasonh/terminus/botrpc"
+)
+
+// serveBots accepts bot controllers over gRPC until the listener fails or the
+// server shuts down. Each
+// stream plays a player, counted as a live session so shutdown waits for it
+// to leave, and ends when the server shuts down.
+func serveBots(addr string, authOpts auth.Options) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		clog.Errorf("Bot controller endpoint failed: %v", err)
+		return
+	}
+
+	srv := grpc.NewServer(grpc.StreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		liveSessions.Add(1)
+		defer liveSessions.Add(-1)
+		return handler(srv, ss)
+	}))
+	botrpc.RegisterBotControllerServer(srv, &botrpc.Service{Server: gameServer, Auth: authOpts})
+	context.AfterFunc(serverCtx, srv.Stop)
+
+	clog.Infof("Bot controller gRPC endpoint on %s", addr)
+	if err := srv.Serve(l); err != nil {
+		clog.Errorf("Bot controller endpoint stopped: %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+// Package cast records terminal output as asciinema asciicast v2 files
+// (https://docs.asciinema.org/manual/asciicast/v2/), which `asciinema play`
+// replays in a terminal and asciinema-player embeds in web pages.
+package cast
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Header is the first line of a cast file, describing the terminal
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"` // When recording started, in Unix seconds
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"` // Like TERM, which players use to pick how to draw
+}
+
+// Recorder writes output to a cast file as it's sent, timed from when the
+// recording started. It's safe to use from multiple goroutines.
+type Recorder struct {
+	mu      sync.Mutex
+	w       *bufio.Writer
+	closer  io.Closer
+	start   time.Time
+	pending []byte // The start of a character split across writes
+	err     error  // The first write error; output is discarded after one
+}
+
+// NewRecorder starts a recording to w of a terminal described by header,
+// writing the header now. Close the recorder to flush it and close w.
+func NewRecorder(w io.WriteCloser, header Header) (*Recorder, error) {
+	r := &Recorder{w: bufio.NewWriter(w), closer: w, start: time.Now()}
+	header.Version = 2
+	if header.Timestamp == 0 {
+		header.Timestamp = r.start.Unix()
+	}
+	data, err := json.Marshal(header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.w.Write(append(data, '\n')); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Output records bytes written to the terminal. Events in a cast file are
+// text, so a character split between writes is held back until it's whole.
+func (r *Recorder) Output(data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data = append(r.pending, data...)
+	cut := len(data)
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	r.pending = append([]byte(nil), data[cut:]...)
+	if cut > 0 {
+		r.event("o", string(data[:cut]))
+	}
+}
+
+// Resize records the terminal changing size
+func (r *Recorder) Resize(width, height int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.event("r", fmt.Sprintf("%dx%d", width, height))
+}
+
+// event writes an event of a kind, like "o" for output, at the time since
+// the recording started
+func (r *Recorder) event(kind, data string) {
+	if r.err != nil {
+		return
+	}
+	elapsed := math.Round(time.Since(r.start).Seconds()*1e6) / 1e6
+	line, err := json.Marshal([]any{elapsed, kind, data})
+	if err == nil {
+		_, err = r.w.Write(append(line, '\n'))
+	}
+	r.err = err
+}
+
+// Close flushes the recording and closes its file, returning the first error
+// writing it, if any
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.pending) > 0 {
+		r.event("o", string(r.pending)) // Never completed, but shouldn't be lost
+		r.pending = nil
+	}
+	err := r.err
+	if ferr := r.w.Flush(); err == nil {
+		err = ferr
+	}
+	if cerr := r.closer.Close(); err == nil {
+		err = cerr
+	}
+	r.err = io.ErrClosedPipe // Anything sent after closing is dropped
+	return err
+}
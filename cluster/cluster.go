@@ -0,0 +1,347 @@
+// Package cluster lets several servers host shards of one world: each
+// server plays its own map, and portals send players from one to another,
+// even across machines. The servers coordinate through a NATS JetStream
+// key-value store, where each shard advertises where it can be reached and
+// each player sent through a portal leaves a one-time handoff token that
+// signs them in at the other end.
+package cluster
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/gliderlabs/ssh"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/server"
+)
+
+const (
+	shardsBucket   = "terminus-shards"
+	handoffsBucket = "terminus-handoffs"
+
+	heartbeatInterval = 10 * time.Second // How often a shard tells the others it's still up
+	shardTTL          = 3 * heartbeatInterval
+	handoffTTL        = time.Minute // How long a player has to arrive with a handoff token
+	storeTimeout      = 5 * time.Second
+)
+
+// HandoffPrefix starts the password a server signs a player in with at the
+// other end of a portal, before the handoff token
+const HandoffPrefix = "handoff:"
+
+// shardNames are the names shards may have, which are also their keys in
+// the store
+var shardNames = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Shard is a server hosting one map of the world, as advertised in the store
+type Shard struct {
+	Name       string    `json:"name"`
+	Address    string    `json:"address"`  // Where it accepts SSH connections, like host:2222
+	HostKey    string    `json:"host_key"` // Its SSH host key, in authorized_keys format
+	Players    int       `json:"players"`
+	MaxPlayers int       `json:"max_players"`
+	Updated    time.Time `json:"updated"`
+}
+
+// Handoff is what a server leaves in the store for a player it's sending
+// through a portal, for the destination to claim when they arrive
+type Handoff struct {
+	Shard       string          `json:"shard"` // Where the player is going
+	From        string          `json:"from"`  // The shard they left
+	Arrival     *game.Vector    `json:"arrival,omitempty"`
+	Fingerprint string          `json:"fingerprint,omitempty"` // The player's SSH key, which the destination never sees
+	RemoteIP    string          `json:"remote_ip,omitempty"`
+	Traveler    server.Traveler `json:"traveler"`
+	Issued      time.Time       `json:"issued"`
+}
+
+// Cluster is this server's membership in a world of shards
+type Cluster struct {
+	self     Shard
+	name     string // Names the store in logs, without any password
+	conn     *nats.Conn
+	shards   jetstream.KeyValue
+	handoffs jetstream.KeyValue
+
+	mu      sync.Mutex
+	leaving map[string]bool // Sessions being sent through a portal
+}
+
+// Join connects to the store at a NATS URL, like nats://localhost:4222, to
+// host a shard. The store must be reachable, with JetStream enabled; if the
+// connection is lost later, it's retried for as long as the server runs.
+func Join(ctx context.Context, rawURL string, self Shard) (*Cluster, error) {
+	if !shardNames.MatchString(self.Name) {
+		return nil, fmt.Errorf("shard name %q may only have letters, digits, dashes, and underscores", self.Name)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("%q isn't a NATS URL, like nats://localhost:4222", rawURL)
+	}
+	c := &Cluster{self: self, name: u.Redacted(), leaving: make(map[string]bool)}
+
+	c.conn, err = nats.Connect(rawURL,
+		nats.Name("terminus "+self.Name),
+		nats.MaxReconnects(-1),
+		nats.ReconnectHandler(func(*nats.Conn) { clog.Infof("Reconnected to %s", c.name) }),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				clog.Warnf("Lost connection to %s: %v", c.name, err)
+			}
+		}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", c.name, err)
+	}
+	js, err := jetstream.New(c.conn)
+	if err != nil {
+		c.conn.Close()
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, storeTimeout)
+	defer cancel()
+	if c.shards, err = js.CreateOrUpdateKeyValue(ctx, jetstream.KeyValueConfig{Bucket: shardsBucket, TTL: shardTTL}); err != nil {
+		c.conn.Close()
+		return nil, fmt.Errorf("failed to open %s on %s (is JetStream enabled?): %w", shardsBucket, c.name, err)
+	}
+	if c.handoffs, err = js.CreateOrUpdateKeyValue(ctx, jetstream.KeyValueConfig{Bucket: handoffsBucket, TTL: handoffTTL}); err != nil {
+		c.conn.Close()
+		return nil, fmt.Errorf("failed to open %s on %s: %w", handoffsBucket, c.name, err)
+	}
+	if other, err := c.Lookup(ctx, self.Name); err == nil && other.Address != self.Address {
+		clog.Warnf("Shard %s was last hosted at %s; taking it over", self.Name, other.Address)
+	}
+	return c, nil
+}
+
+// Name returns the name of this server's shard
+func (c *Cluster) Name() string {
+	return c.self.Name
+}
+
+// Run advertises the shard and sends players through the portals they step
+// into until ctx is done, then withdraws the shard so no more players are
+// sent here
+func (c *Cluster) Run(ctx context.Context, gs *server.GameServer) {
+	sub := gs.Events.Subscribe(server.EventPortal)
+	defer sub.Close()
+	defer c.conn.Drain()
+	defer c.withdraw()
+
+	c.heartbeat(ctx, gs)
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.heartbeat(ctx, gs)
+		case e, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			go c.travel(ctx, gs, e)
+		}
+	}
+}
+
+// Lookup returns the shard with a name, if a server is hosting it
+func (c *Cluster) Lookup(ctx context.Context, name string) (Shard, error) {
+	if !shardNames.MatchString(name) {
+		return Shard{}, fmt.Errorf("no shard %q", name)
+	}
+	entry, err := c.shards.Get(ctx, name)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return Shard{}, fmt.Errorf("no server is hosting %s", name)
+	}
+	if err != nil {
+		return Shard{}, err
+	}
+	var shard Shard
+	if err := json.Unmarshal(entry.Value(), &shard); err != nil {
+		return Shard{}, fmt.Errorf("bad record for shard %s: %w", name, err)
+	}
+	return shard, nil
+}
+
+// heartbeat writes the shard's record, which expires unless it's rewritten
+func (c *Cluster) heartbeat(ctx context.Context, gs *server.GameServer) {
+	status := gs.Status()
+	shard := c.self
+	shard.Players = status.Players
+	shard.MaxPlayers = status.MaxPlayers
+	shard.Updated = time.Now()
+	data, err := json.Marshal(shard)
+	if err != nil {
+		clog.Warnf("Failed to encode shard %s: %v", shard.Name, err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, storeTimeout)
+	defer cancel()
+	if _, err := c.shards.Put(ctx, shard.Name, data); err != nil {
+		clog.Warnf("Failed to advertise shard %s on %s: %v", shard.Name, c.name, err)
+	}
+}
+
+// withdraw removes the shard's record, unless another server has taken
+// the shard over since
+func (c *Cluster) withdraw() {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	entry, err := c.shards.Get(ctx, c.self.Name)
+	if err != nil {
+		return
+	}
+	var shard Shard
+	if json.Unmarshal(entry.Value(), &shard) != nil || shard.Address != c.self.Address {
+		return
+	}
+	if err := c.shards.Delete(ctx, c.self.Name, jetstream.LastRevision(entry.Revision())); err != nil {
+		clog.Warnf("Failed to withdraw shard %s from %s: %v", c.self.Name, c.name, err)
+	}
+}
+
+// travel sends a player who stepped into a portal to the shard it leads to:
+// it leaves a handoff for them in the store, then transfers their session
+// there. Players who can't travel, or whose destination is down, stay here.
+func (c *Cluster) travel(ctx context.Context, gs *server.GameServer, e server.Event) {
+	session, ok := gs.GetPlayerSession(e.SessionID)
+	if !ok || session.Bot {
+		return
+	}
+	if !session.CanTravel {
+		session.Notify("The portal to " + e.Detail + " is closed to you")
+		return
+	}
+
+	c.mu.Lock()
+	if c.leaving[session.ID] {
+		c.mu.Unlock()
+		return
+	}
+	c.leaving[session.ID] = true
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.leaving, session.ID)
+		c.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, storeTimeout)
+	defer cancel()
+	shard, err := c.Lookup(ctx, e.Detail)
+	if err != nil {
+		session.Log.Infof("Portal to %s is closed: %v", e.Detail, err)
+		session.Notify("The portal to " + e.Detail + " is closed")
+		return
+	}
+
+	var arrival *game.Vector
+	gs.PlayersMutex.RLock()
+	portal, ok := gs.Map.PortalAt(int(e.Position.X), int(e.Position.Y))
+	gs.PlayersMutex.RUnlock()
+	if ok {
+		arrival = portal.Arrival
+	}
+	token, err := newToken()
+	if err != nil {
+		session.Log.Warnf("Failed to make a handoff token: %v", err)
+		return
+	}
+	data, err := json.Marshal(Handoff{
+		Shard:       shard.Name,
+		From:        c.self.Name,
+		Arrival:     arrival,
+		Fingerprint: session.KeyFingerprint,
+		RemoteIP:    session.RemoteIP,
+		Traveler:    gs.Traveler(session),
+		Issued:      time.Now(),
+	})
+	if err != nil {
+		session.Log.Warnf("Failed to encode handoff: %v", err)
+		return
+	}
+	if _, err := c.handoffs.Create(ctx, token, data); err != nil {
+		session.Log.Warnf("Failed to store handoff to %s: %v", shard.Name, err)
+		session.Notify("The portal to " + shard.Name + " flickers, but nothing happens")
+		return
+	}
+	session.Transfer(server.Transfer{Shard: shard.Name, Address: shard.Address, HostKey: shard.HostKey, Token: token})
+}
+
+// newToken returns a random handoff token
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// arrivalKey keys the handoff a connection signed in with in its SSH context
+type arrivalKey struct{}
+
+// Admit reports whether an SSH password is a handoff token for a player
+// coming to this shard, claiming it so it can't be used again. It's meant
+// for auth.Options.Handoff. The handoff is kept in the connection's context,
+// for Arrival.
+func (c *Cluster) Admit(ctx ssh.Context, password string) bool {
+	token, ok := strings.CutPrefix(password, HandoffPrefix)
+	if !ok || !shardNames.MatchString(token) {
+		return false
+	}
+	h, err := c.claim(ctx, token)
+	if err != nil {
+		clog.Infof("Rejected handoff from %s: %v", ctx.RemoteAddr(), err)
+		return false
+	}
+	if h.Shard != c.self.Name {
+		clog.Infof("Rejected handoff from %s: it's for %s", ctx.RemoteAddr(), h.Shard)
+		return false
+	}
+	ctx.SetValue(arrivalKey{}, h)
+	return true
+}
+
+// claim takes a handoff out of the store. Only one claim of a token can
+// succeed.
+func (c *Cluster) claim(ctx context.Context, token string) (Handoff, error) {
+	ctx, cancel := context.WithTimeout(ctx, storeTimeout)
+	defer cancel()
+	entry, err := c.handoffs.Get(ctx, token)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return Handoff{}, errors.New("unknown or expired token")
+	}
+	if err != nil {
+		return Handoff{}, err
+	}
+	if err := c.handoffs.Delete(ctx, token, jetstream.LastRevision(entry.Revision())); err != nil {
+		return Handoff{}, fmt.Errorf("token already claimed: %w", err)
+	}
+	var h Handoff
+	if err := json.Unmarshal(entry.Value(), &h); err != nil {
+		return Handoff{}, fmt.Errorf("bad handoff: %w", err)
+	}
+	return h, nil
+}
+
+// Arrival returns the handoff a connection signed in with, if it's a player
+// arriving through a portal
+func Arrival(ctx context.Context) (Handoff, bool) {
+	h, ok := ctx.Value(arrivalKey{}).(Handoff)
+	return h, ok
+}
@@ -0,0 +1,112 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/imjasonh/terminus/game"
+)
+
+// fakeEntry is a stored handoff
+type fakeEntry struct {
+	jetstream.KeyValueEntry
+	value    []byte
+	revision uint64
+}
+
+func (e fakeEntry) Value() []byte    { return e.value }
+func (e fakeEntry) Revision() uint64 { return e.revision }
+
+// fakeHandoffs is an in-memory handoffs bucket. With staleReads, Get still
+// returns deleted entries, like a claim that read a token just before another
+// claim deleted it.
+type fakeHandoffs struct {
+	jetstream.KeyValue
+	entries    map[string]fakeEntry
+	deleted    map[string]bool
+	staleReads bool
+}
+
+func (kv *fakeHandoffs) Get(_ context.Context, key string) (jetstream.KeyValueEntry, error) {
+	entry, ok := kv.entries[key]
+	if !ok || (kv.deleted[key] && !kv.staleReads) {
+		return nil, jetstream.ErrKeyNotFound
+	}
+	return entry, nil
+}
+
+func (kv *fakeHandoffs) Delete(_ context.Context, key string, _ ...jetstream.KVDeleteOpt) error {
+	if kv.deleted[key] {
+		return errors.New("wrong last sequence")
+	}
+	kv.deleted[key] = true
+	return nil
+}
+
+func TestClaim(t *testing.T) {
+	arrival := game.Vector{X: 3, Y: 4}
+	valid, err := json.Marshal(Handoff{Shard: "east", From: "west", Arrival: &arrival, Issued: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name       string
+		staleReads bool
+		claims     []string // Tokens claimed in turn
+		wantErrs   []string // What each claim fails with, or "" if it succeeds
+	}{{
+		name:     "valid handoff",
+		claims:   []string{"valid"},
+		wantErrs: []string{""},
+	}, {
+		name:     "unknown token",
+		claims:   []string{"nope"},
+		wantErrs: []string{"unknown or expired token"},
+	}, {
+		name:     "claimed twice",
+		claims:   []string{"valid", "valid"},
+		wantErrs: []string{"", "unknown or expired token"},
+	}, {
+		name:       "claimed twice at once",
+		staleReads: true,
+		claims:     []string{"valid", "valid"},
+		wantErrs:   []string{"", "token already claimed"},
+	}, {
+		name:     "bad handoff",
+		claims:   []string{"garbled"},
+		wantErrs: []string{"bad handoff"},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Cluster{handoffs: &fakeHandoffs{
+				entries: map[string]fakeEntry{
+					"valid":   {value: valid, revision: 1},
+					"garbled": {value: []byte("{"), revision: 2},
+				},
+				deleted:    map[string]bool{},
+				staleReads: tt.staleReads,
+			}}
+			for i, token := range tt.claims {
+				h, err := c.claim(context.Background(), token)
+				if tt.wantErrs[i] != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.wantErrs[i]) {
+						t.Errorf("claim %d of %q: err = %v, want %q", i, token, err, tt.wantErrs[i])
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("claim %d of %q: %v", i, token, err)
+				}
+				if h.Shard != "east" || h.From != "west" || h.Arrival == nil || *h.Arrival != arrival {
+					t.Errorf("claim %d of %q = %+v, want the handoff from west to east at %v", i, token, h, arrival)
+				}
+			}
+		})
+	}
+}
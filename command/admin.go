@@ -0,0 +1,382 @@
+package command
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/server"
+)
+
+// Limits for admin commands
+const (
+	maxNPCSpawn   = 20 // Most NPCs /npc spawn adds at once
+	maxBansListed = 3  // Most bans /bans shows, to fit the console
+)
+
+// permanentBan is the /ban duration for a ban that doesn't expire
+const permanentBan = "permanent"
+
+// givableItems are the things /give can hand out besides inventory items and status effects
+var givableItems = map[string]func(gs *server.GameServer, session *server.PlayerSession){
+	"stamina": (*server.GameServer).RestoreStamina,
+	"health":  (*server.GameServer).HealPlayer,
+}
+
+// givableEffects are the status effects /give can apply
+var givableEffects = map[string]game.StatusEffect{
+	"speed":        game.SpeedBoost,
+	"invisibility": game.Invisibility,
+	"quad":         game.QuadDamage,
+	"burning":      game.Burning,
+}
+
+// RegisterAdmin adds the admin-only moderation and world commands to the registry
+func RegisterAdmin(r *Registry) {
+	r.Register(&Command{
+		Name:      "kick",
+		Usage:     "<player> [reason]",
+		Help:      "Disconnect a player",
+		AdminOnly: true,
+		Run: func(ctx *Context, args []string) (string, error) {
+			if len(args) == 0 {
+				return "", fmt.Errorf("usage: /kick <player> [reason]")
+			}
+			if err := ctx.Server.KickPlayer(args[0], strings.Join(args[1:], " ")); err != nil {
+				return "", err
+			}
+			return "Kicked " + args[0], nil
+		},
+		Complete: completePlayer,
+	})
+
+	r.Register(&Command{
+		Name:      "ban",
+		Usage:     "<player> [duration|permanent] [reason]",
+		Help:      "Disconnect a player and ban their key and IP, permanently or for a duration like 30m, 2h, or 7d",
+		AdminOnly: true,
+		Run: func(ctx *Context, args []string) (string, error) {
+			if len(args) == 0 {
+				return "", fmt.Errorf("usage: /ban <player> [duration|permanent] [reason]")
+			}
+			target, rest := args[0], args[1:]
+			var duration time.Duration
+			if len(rest) > 0 {
+				if d, ok := ParseBanDuration(rest[0]); ok {
+					duration, rest = d, rest[1:]
+				}
+			}
+			if err := ctx.Server.BanPlayer(target, strings.Join(rest, " "), ctx.Session.Name, duration); err != nil {
+				return "", err
+			}
+			if duration == 0 {
+				return "Banned " + target, nil
+			}
+			return fmt.Sprintf("Banned %s for %s", target, args[1]), nil
+		},
+		Complete: completePlayer,
+	})
+
+	r.Register(&Command{
+		Name:      "unban",
+		Usage:     "<fingerprint|ip>",
+		Help:      "Lift the bans on an SSH key fingerprint or IP address",
+		AdminOnly: true,
+		Run: func(ctx *Context, args []string) (string, error) {
+			if len(args) != 1 {
+				return "", fmt.Errorf("usage: /unban <fingerprint|ip>")
+			}
+			removed, err := ctx.Server.Bans.Remove(args[0])
+			if err != nil {
+				return "", err
+			}
+			if removed == 0 {
+				return "", fmt.Errorf("no bans on %s", args[0])
+			}
+			return fmt.Sprintf("Removed %d bans on %s", removed, args[0]), nil
+		},
+		Complete: func(ctx *Context, prefix string) []string {
+			var targets []string
+			for _, ban := range ctx.Server.Bans.List() {
+				for _, t := range []string{ban.Fingerprint, ban.IP} {
+					if t != "" && strings.HasPrefix(t, prefix) {
+						targets = append(targets, t)
+					}
+				}
+			}
+			return targets
+		},
+	})
+
+	r.Register(&Command{
+		Name:      "bans",
+		Help:      "List active bans",
+		AdminOnly: true,
+		Run: func(ctx *Context, args []string) (string, error) {
+			bans := ctx.Server.Bans.List()
+			if len(bans) == 0 {
+				return "No active bans", nil
+			}
+			lines := []string{fmt.Sprintf("%d active bans, newest first:", len(bans))}
+			slices.Reverse(bans)
+			for _, ban := range bans[:min(len(bans), maxBansListed)] {
+				until := "permanent"
+				if !ban.Expires.IsZero() {
+					until = "for " + time.Until(ban.Expires).Round(time.Minute).String()
+				}
+				lines = append(lines, fmt.Sprintf("%s %s %s (%s): %s",
+					orDash(ban.Name), orDash(ban.Fingerprint), orDash(ban.IP), until, ban.Reason))
+			}
+			return strings.Join(lines, "\n"), nil
+		},
+	})
+
+	r.Register(&Command{
+		Name:      "say",
+		Usage:     "<message>",
+		Help:      "Send a message to every player",
+		AdminOnly: true,
+		Run: func(ctx *Context, args []string) (string, error) {
+			if len(args) == 0 {
+				return "", fmt.Errorf("usage: /say <message>")
+			}
+			ctx.Server.Broadcast("[" + ctx.Session.Name + "] " + strings.Join(args, " "))
+			return "", nil
+		},
+	})
+
+	r.Register(&Command{
+		Name:      "teleport",
+		Usage:     "[player] <x> <y> | [player] <target>",
+		Help:      "Move yourself or a player to a position or to another player",
+		AdminOnly: true,
+		Run: func(ctx *Context, args []string) (string, error) {
+			who := ctx.Session
+			// Coordinates are always a pair, so an odd count or a leading
+			// non-number names the player to move
+			if len(args) == 3 || (len(args) == 2 && !isNumber(args[0])) {
+				session, ok := ctx.Server.FindPlayer(args[0])
+				if !ok {
+					return "", fmt.Errorf("no player named %s", args[0])
+				}
+				who, args = session, args[1:]
+			}
+
+			var dest game.Vector
+			switch len(args) {
+			case 1:
+				target, ok := ctx.Server.FindPlayer(args[0])
+				if !ok {
+					return "", fmt.Errorf("no player named %s", args[0])
+				}
+				dest = ctx.Server.PlayerPosition(target)
+			case 2:
+				x, errX := strconv.ParseFloat(args[0], 64)
+				y, errY := strconv.ParseFloat(args[1], 64)
+				if errX != nil || errY != nil {
+					return "", fmt.Errorf("coordinates must be numbers")
+				}
+				dest = game.Vector{X: x, Y: y}
+			default:
+				return "", fmt.Errorf("usage: /teleport [player] <x> <y> or /teleport [player] <target>")
+			}
+
+			if err := ctx.Server.TeleportPlayer(who, dest); err != nil {
+				return "", err
+			}
+			if who != ctx.Session {
+				who.Notify(fmt.Sprintf("%s teleported you", ctx.Session.Name))
+			}
+			return fmt.Sprintf("Teleported %s to (%.1f, %.1f)", who.Name, dest.X, dest.Y), nil
+		},
+		Complete: completePlayer,
+	})
+
+	r.Register(&Command{
+		Name:      "give",
+		Usage:     "<player> <item>",
+		Help:      "Give a player an item (" + strings.Join(itemNames(), ", ") + ")",
+		AdminOnly: true,
+		Run: func(ctx *Context, args []string) (string, error) {
+			if len(args) != 2 {
+				return "", fmt.Errorf("usage: /give <player> <item>")
+			}
+			session, ok := ctx.Server.FindPlayer(args[0])
+			if !ok {
+				return "", fmt.Errorf("no player named %s", args[0])
+			}
+			name := strings.ToLower(args[1])
+			if item, ok := inventoryItem(name); ok {
+				if ctx.Server.GiveItem(session, item.Type, 1) == 0 {
+					return "", fmt.Errorf("%s can't carry any more %ss", session.Name, name)
+				}
+			} else if effect, ok := givableEffects[name]; ok {
+				ctx.Server.ApplyEffect(session, effect)
+			} else if give, ok := givableItems[name]; ok {
+				give(ctx.Server, session)
+			} else {
+				return "", fmt.Errorf("unknown item %q; items: %s", args[1], strings.Join(itemNames(), ", "))
+			}
+			if session != ctx.Session {
+				session.Notify(fmt.Sprintf("%s gave you %s", ctx.Session.Name, name))
+			}
+			return fmt.Sprintf("Gave %s %s", session.Name, name), nil
+		},
+		Complete: completePlayer,
+	})
+
+	r.Register(&Command{
+		Name:      "npc",
+		Usage:     "spawn [count] | clear",
+		Help:      "Spawn or remove NPCs",
+		AdminOnly: true,
+		Run: func(ctx *Context, args []string) (string, error) {
+			if len(args) == 0 {
+				return fmt.Sprintf("%d NPCs in the world", ctx.Server.CountEntities(game.KindNPC)), nil
+			}
+			switch strings.ToLower(args[0]) {
+			case "spawn":
+				count := 1
+				if len(args) > 1 {
+					n, err := strconv.Atoi(args[1])
+					if err != nil || n < 1 || n > maxNPCSpawn {
+						return "", fmt.Errorf("count must be a number from 1 to %d", maxNPCSpawn)
+					}
+					count = n
+				}
+				ctx.Server.SpawnNPCs(count)
+				return fmt.Sprintf("Spawned %d NPCs", count), nil
+			case "clear":
+				ctx.Server.ClearNPCs()
+				return "Removed all NPCs", nil
+			default:
+				return "", fmt.Errorf("usage: /npc spawn [count] or /npc clear")
+			}
+		},
+		Complete: func(ctx *Context, prefix string) []string {
+			var subs []string
+			for _, sub := range []string{"clear", "spawn"} {
+				if strings.HasPrefix(sub, prefix) {
+					subs = append(subs, sub)
+				}
+			}
+			return subs
+		},
+	})
+
+	r.Register(&Command{
+		Name:      "set",
+		Usage:     "[setting] [value]",
+		Help:      "Show or change server settings (" + strings.Join(server.SettingNames(), ", ") + ")",
+		AdminOnly: true,
+		Run: func(ctx *Context, args []string) (string, error) {
+			values := ctx.Server.SettingValues()
+			switch len(args) {
+			case 0:
+				var lines []string
+				for _, name := range server.SettingNames() {
+					lines = append(lines, name+" = "+values[name])
+				}
+				return strings.Join(lines, "\n"), nil
+			case 1:
+				value, ok := values[args[0]]
+				if !ok {
+					return "", fmt.Errorf("unknown setting %q; settings: %s", args[0], strings.Join(server.SettingNames(), ", "))
+				}
+				return args[0] + " = " + value, nil
+			case 2:
+				if err := ctx.Server.ChangeSetting(args[0], args[1]); err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("Set %s to %s", args[0], args[1]), nil
+			default:
+				return "", fmt.Errorf("usage: /set [setting] [value]")
+			}
+		},
+		Complete: func(ctx *Context, prefix string) []string {
+			var names []string
+			for _, name := range server.SettingNames() {
+				if strings.HasPrefix(name, prefix) {
+					names = append(names, name)
+				}
+			}
+			return names
+		},
+	})
+}
+
+// completePlayer completes the name of a connected player
+func completePlayer(ctx *Context, prefix string) []string {
+	var names []string
+	for _, name := range ctx.Server.PlayerNames() {
+		if strings.HasPrefix(strings.ToLower(name), strings.ToLower(prefix)) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// itemNames returns the names of the items /give accepts
+func itemNames() []string {
+	var names []string
+	for name := range givableItems {
+		names = append(names, name)
+	}
+	for name := range givableEffects {
+		names = append(names, name)
+	}
+	for _, item := range game.Items {
+		names = append(names, strings.ToLower(item.Name))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// inventoryItem returns the inventory item with a lowercase name, if there is one
+func inventoryItem(name string) (*game.Item, bool) {
+	for i := range game.Items {
+		if strings.ToLower(game.Items[i].Name) == name {
+			return &game.Items[i], true
+		}
+	}
+	return nil, false
+}
+
+// isNumber reports whether s parses as a coordinate
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// ParseBanDuration parses durations like 30m or 2h, plus whole days like 7d.
+// "permanent" is a zero duration, so a reason can start with something that
+// looks like a duration.
+func ParseBanDuration(s string) (time.Duration, bool) {
+	if s == permanentBan {
+		return 0, true
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, false
+		}
+		return time.Duration(n) * 24 * time.Hour, true
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, false
+	}
+	return d, true
+}
+
+// orDash returns s, or a dash if it's empty
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,33 @@
+package command
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBanDuration(t *testing.T) {
+	for _, tt := range []struct {
+		s      string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"30m", 30 * time.Minute, true},
+		{"2h", 2 * time.Hour, true},
+		{"1h30m", 90 * time.Minute, true},
+		{"7d", 7 * 24 * time.Hour, true},
+		{"permanent", 0, true},
+		{"", 0, false},
+		{"0", 0, false},
+		{"-1h", 0, false},
+		{"0d", 0, false},
+		{"1.5d", 0, false},
+		{"spam", 0, false},
+		{"7 d", 0, false},
+		{"Permanent", 0, false},
+	} {
+		got, ok := ParseBanDuration(tt.s)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ParseBanDuration(%q) = %s, %t, want %s, %t", tt.s, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
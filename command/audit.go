@@ -0,0 +1,40 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// auditLog records admin commands and denied attempts to run them
+type auditLog struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// SetAuditLog sets where admin command activity is recorded. A nil writer disables the audit log.
+func (r *Registry) SetAuditLog(w io.Writer) {
+	r.audit.mu.Lock()
+	defer r.audit.mu.Unlock()
+	r.audit.w = w
+}
+
+// record writes one audit entry for a command run by the context's session
+func (a *auditLog) record(ctx *Context, line, outcome string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.w == nil {
+		return
+	}
+
+	name, fingerprint := "-", "-"
+	if ctx.Session != nil {
+		name = ctx.Session.Name
+		if ctx.Session.KeyFingerprint != "" {
+			fingerprint = ctx.Session.KeyFingerprint
+		}
+	}
+	fmt.Fprintf(a.w, "%s %s %s %q %s\n", time.Now().UTC().Format(time.RFC3339), name, fingerprint, strings.TrimSpace(line), outcome)
+}
@@ -0,0 +1,870 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/input"
+	"github.com/imjasonh/terminus/locale"
+	"github.com/imjasonh/terminus/renderer"
+	"github.com/imjasonh/terminus/screen"
+	"github.com/imjasonh/terminus/server"
+)
+
+// Limits for the /fov command, in degrees
+const (
+	minFOV = 40
+	maxFOV = 120
+)
+
+// topCount is how many players /top shows, to fit on one console line
+const topCount = 5
+
+// RegisterBuiltins adds the standard player and admin commands to the registry
+func RegisterBuiltins(r *Registry) {
+	r.Register(&Command{
+		Name:  "help",
+		Usage: "[command]",
+		Help:  "List available commands or show how to use one",
+		Run: func(ctx *Context, args []string) (string, error) {
+			if len(args) > 0 {
+				cmd, ok := r.commands[strings.TrimPrefix(strings.ToLower(args[0]), "/")]
+				if !ok || !allowed(ctx, cmd) {
+					return "", fmt.Errorf("unknown command /%s", args[0])
+				}
+				return strings.TrimSpace(fmt.Sprintf("/%s %s - %s", cmd.Name, cmd.Usage, cmd.Help)), nil
+			}
+
+			var names []string
+			for _, cmd := range r.Available(ctx) {
+				names = append(names, "/"+cmd.Name)
+			}
+			return "Commands: " + strings.Join(names, " ") + " (/help <command> for details)", nil
+		},
+		Complete: func(ctx *Context, prefix string) []string {
+			var names []string
+			for _, cmd := range r.Available(ctx) {
+				if strings.HasPrefix(cmd.Name, prefix) {
+					names = append(names, cmd.Name)
+				}
+			}
+			return names
+		},
+	})
+
+	r.Register(&Command{
+		Name:  "name",
+		Usage: "<name>",
+		Help:  "Change your display name",
+		Run: func(ctx *Context, args []string) (string, error) {
+			if len(args) != 1 {
+				return "", fmt.Errorf("usage: /name <name>")
+			}
+			if err := ctx.Server.RenamePlayer(ctx.Session, args[0]); err != nil {
+				return "", err
+			}
+			return "You are now known as " + ctx.Session.Name, nil
+		},
+	})
+
+	r.Register(&Command{
+		Name:  "chat",
+		Usage: "<message>",
+		Help:  "Send a chat message to everyone (or press Enter)",
+		Run: func(ctx *Context, args []string) (string, error) {
+			return "", ctx.Server.Say(ctx.Session, strings.Join(args, " "))
+		},
+	})
+
+	r.Register(&Command{
+		Name:  "team",
+		Usage: "<message>",
+		Help:  "Send a chat message to your team",
+		Run: func(ctx *Context, args []string) (string, error) {
+			return "", ctx.Server.SayToTeam(ctx.Session, strings.Join(args, " "))
+		},
+	})
+
+	r.Register(&Command{
+		Name:  "jointeam",
+		Usage: "[team]",
+		Help:  "Join a team by name, or leave yours",
+		Run: func(ctx *Context, args []string) (string, error) {
+			if len(args) > 1 {
+				return "", fmt.Errorf("usage: /jointeam [team]")
+			}
+			if len(args) == 0 {
+				if ctx.Session.Team == "" {
+					return "You're not on a team", nil
+				}
+				if err := ctx.Server.SetTeam(ctx.Session, ""); err != nil {
+					return "", err
+				}
+				return "You left your team", nil
+			}
+			if err := ctx.Server.SetTeam(ctx.Session, args[0]); err != nil {
+				return "", err
+			}
+			return "You joined team " + ctx.Session.Team, nil
+		},
+	})
+
+	r.Register(&Command{
+		Name:  "msg",
+		Usage: "<player> <message>",
+		Help:  "Whisper a chat message to one player",
+		Run: func(ctx *Context, args []string) (string, error) {
+			if len(args) < 2 {
+				return "", fmt.Errorf("usage: /msg <player> <message>")
+			}
+			return "", ctx.Server.Whisper(ctx.Session, args[0], strings.Join(args[1:], " "))
+		},
+		Complete: completePlayer,
+	})
+
+	r.Register(&Command{
+		Name:  "invite",
+		Usage: "<player>",
+		Help:  "Invite a player to your party, who spawns near you and shares your team",
+		Run: func(ctx *Context, args []string) (string, error) {
+			if len(args) != 1 {
+				return "", fmt.Errorf("usage: /invite <player>")
+			}
+			if err := ctx.Server.InviteToParty(ctx.Session, args[0]); err != nil {
+				return "", err
+			}
+			return "Invited " + args[0] + " to your party", nil
+		},
+		Complete: completePlayer,
+	})
+
+	r.Register(&Command{
+		Name: "accept",
+		Help: "Join the party you were last invited to",
+		Run: func(ctx *Context, args []string) (string, error) {
+			leader, err := ctx.Server.AcceptPartyInvite(ctx.Session)
+			if err != nil {
+				return "", err
+			}
+			return "You joined " + leader + "'s party", nil
+		},
+	})
+
+	r.Register(&Command{
+		Name:  "party",
+		Usage: "[leave]",
+		Help:  "List your party's members, or leave your party",
+		Run: func(ctx *Context, args []string) (string, error) {
+			if len(args) == 1 && strings.EqualFold(args[0], "leave") {
+				if err := ctx.Server.LeaveParty(ctx.Session); err != nil {
+					return "", err
+				}
+				return "You left your party", nil
+			}
+			if len(args) > 0 {
+				return "", fmt.Errorf("usage: /party [leave]")
+			}
+			members := ctx.Server.PartyMembers(ctx.Session)
+			if members == nil {
+				return "You're not in a party; /invite <player> to start one", nil
+			}
+			var names []string
+			for _, member := range members {
+				names = append(names, member.Name)
+			}
+			return "Your party: " + strings.Join(names, ", "), nil
+		},
+		Complete: func(ctx *Context, prefix string) []string {
+			if strings.HasPrefix("leave", prefix) {
+				return []string{"leave"}
+			}
+			return nil
+		},
+	})
+
+	r.Register(&Command{
+		Name:  "color",
+		Usage: "<color>",
+		Help:  "Change how other players see you (" + strings.Join(colorNames(), ", ") + ")",
+		Run: func(ctx *Context, args []string) (string, error) {
+			if len(args) != 1 {
+				return "", fmt.Errorf("usage: /color <color>; colors: %s", strings.Join(colorNames(), ", "))
+			}
+			name := strings.ToLower(args[0])
+			c, ok := game.PlayerColors[name]
+			if !ok {
+				return "", fmt.Errorf("unknown color %q; colors: %s", args[0], strings.Join(colorNames(), ", "))
+			}
+			ctx.Session.Player.Color = c
+			ctx.Session.Color = name
+			return "You are now " + name, nil
+		},
+		Complete: func(ctx *Context, prefix string) []string {
+			var names []string
+			for _, name := range colorNames() {
+				if strings.HasPrefix(name, prefix) {
+					names = append(names, name)
+				}
+			}
+			return names
+		},
+	})
+
+	r.Register(&Command{
+		Name:  "glyph",
+		Usage: "<glyph>",
+		Help:  "Change the character other players see you as (" + strings.Join(glyphNames(), ", ") + ")",
+		Run: func(ctx *Context, args []string) (string, error) {
+			if len(args) != 1 {
+				return "", fmt.Errorf("usage: /glyph <glyph>; glyphs: %s", strings.Join(glyphChoices(), ", "))
+			}
+			name, g, ok := findGlyph(args[0])
+			if !ok {
+				return "", fmt.Errorf("unknown glyph %q; glyphs: %s", args[0], strings.Join(glyphChoices(), ", "))
+			}
+			ctx.Session.Player.Glyph = g
+			ctx.Session.Glyph = name
+			return "You now appear as " + string(g), nil
+		},
+		Complete: func(ctx *Context, prefix string) []string {
+			var names []string
+			for _, name := range glyphNames() {
+				if strings.HasPrefix(name, prefix) {
+					names = append(names, name)
+				}
+			}
+			return names
+		},
+	})
+
+	r.Register(&Command{
+		Name: "stats",
+		Help: "Show your stats for this session and your lifetime",
+		Run: func(ctx *Context, args []string) (string, error) {
+			stats := ctx.Session.Stats()
+			msg := "This session: " + ctx.Session.SessionStats().Summary()
+			if ctx.Session.KeyFingerprint == "" {
+				return msg + " (connect with an SSH key to keep stats)", nil
+			}
+			return fmt.Sprintf("%s\nLifetime: %d sessions since %s, %s", msg,
+				stats.Sessions, stats.FirstSeen.Format("2006-01-02"), stats.Summary()), nil
+		},
+	})
+
+	r.Register(&Command{
+		Name:  "top",
+		Usage: "[" + strings.Join(leaderboardStatNames(), "|") + "]",
+		Help:  "Show the leaderboard",
+		Run: func(ctx *Context, args []string) (string, error) {
+			if ctx.Server.Leaderboard == nil {
+				return "", fmt.Errorf("this server doesn't keep a leaderboard")
+			}
+			stat := server.DefaultLeaderboardStat
+			if len(args) > 0 {
+				stat = strings.ToLower(args[0])
+			}
+			if _, ok := server.LeaderboardStats[stat]; !ok {
+				return "", fmt.Errorf("unknown stat %q; stats: %s", args[0], strings.Join(leaderboardStatNames(), ", "))
+			}
+			entries, err := ctx.Server.Leaderboard.Top(stat, topCount)
+			if err != nil {
+				return "", err
+			}
+			if len(entries) == 0 {
+				return "No results yet", nil
+			}
+			var ranks []string
+			for i, e := range entries {
+				ranks = append(ranks, fmt.Sprintf("%d. %s %s", i+1, e.Name, formatStat(stat, e)))
+			}
+			return "Top by " + stat + ": " + strings.Join(ranks, "  "), nil
+		},
+		Complete: func(ctx *Context, prefix string) []string {
+			var names []string
+			for _, name := range leaderboardStatNames() {
+				if strings.HasPrefix(name, prefix) {
+					names = append(names, name)
+				}
+			}
+			return names
+		},
+	})
+
+	r.Register(&Command{
+		Name:  "fov",
+		Usage: "[degrees]",
+		Help:  fmt.Sprintf("Show or set your field of view (%d-%d)", minFOV, maxFOV),
+		Run: func(ctx *Context, args []string) (string, error) {
+			player := ctx.Session.Player
+			if len(args) == 0 {
+				return fmt.Sprintf("Field of view is %.0f degrees", player.FOV()), nil
+			}
+			degrees, err := strconv.ParseFloat(args[0], 64)
+			if err != nil || degrees < minFOV || degrees > maxFOV {
+				return "", fmt.Errorf("field of view must be a number from %d to %d", minFOV, maxFOV)
+			}
+			player.SetFOV(degrees)
+			return fmt.Sprintf("Field of view set to %.0f degrees", degrees), nil
+		},
+	})
+
+	r.Register(&Command{
+		Name:  "colors",
+		Usage: "[" + strings.Join(screen.ColorDepthNames, "|") + "]",
+		Help:  "Show or limit the colors you're sent; fewer colors use less bandwidth",
+		Run: func(ctx *Context, args []string) (string, error) {
+			if len(args) == 0 {
+				return describeColorLimit(ctx.Session.ColorLimit), nil
+			}
+			depth, ok := screen.ParseColorDepth(args[0])
+			if !ok {
+				return "", fmt.Errorf("unknown color limit %q; choose from %s", args[0], strings.Join(screen.ColorDepthNames, ", "))
+			}
+			ctx.Session.ColorLimit = depth
+			return describeColorLimit(depth), nil
+		},
+		Complete: func(ctx *Context, prefix string) []string {
+			var names []string
+			for _, name := range screen.ColorDepthNames {
+				if strings.HasPrefix(name, prefix) {
+					names = append(names, name)
+				}
+			}
+			return names
+		},
+	})
+
+	r.Register(&Command{
+		Name:  "theme",
+		Usage: "[" + strings.Join(renderer.ThemeNames(), "|") + "]",
+		Help:  "Show or change how the world is colored; contrast uses bright, outlined colors that are easier to tell apart",
+		Run: func(ctx *Context, args []string) (string, error) {
+			if len(args) > 1 {
+				return "", fmt.Errorf("usage: /theme [%s]", strings.Join(renderer.ThemeNames(), "|"))
+			}
+			if len(args) == 1 {
+				theme, ok := renderer.ParseTheme(args[0])
+				if !ok {
+					return "", fmt.Errorf("unknown theme %q; choose from %s", args[0], strings.Join(renderer.ThemeNames(), ", "))
+				}
+				ctx.Session.Theme = theme.String()
+			}
+			theme, _ := renderer.ParseTheme(ctx.Session.Theme)
+			return "Using the " + theme.String() + " theme", nil
+		},
+		Complete: func(ctx *Context, prefix string) []string {
+			var names []string
+			for _, name := range renderer.ThemeNames() {
+				if strings.HasPrefix(name, prefix) {
+					names = append(names, name)
+				}
+			}
+			return names
+		},
+	})
+
+	r.Register(&Command{
+		Name:  "language",
+		Usage: "[" + strings.Join(locale.Tags(), "|") + "]",
+		Help:  "Choose the language of the HUD, menus, and messages",
+		Run: func(ctx *Context, args []string) (string, error) {
+			if len(args) > 1 {
+				return "", fmt.Errorf("usage: /language [%s]", strings.Join(locale.Tags(), "|"))
+			}
+			if len(args) == 1 {
+				tag, ok := findLanguage(args[0])
+				if !ok {
+					return "", fmt.Errorf("unknown language %q; languages: %s", args[0], languageChoices())
+				}
+				ctx.Server.SetLanguage(ctx.Session, tag)
+			}
+			return ctx.Session.T("Language: %s", locale.Name(ctx.Session.Language)) + " (" + languageChoices() + ")", nil
+		},
+		Complete: func(ctx *Context, prefix string) []string {
+			var matches []string
+			for _, tag := range locale.Tags() {
+				if strings.HasPrefix(tag, prefix) {
+					matches = append(matches, tag)
+				}
+			}
+			return matches
+		},
+	})
+
+	r.Register(&Command{
+		Name:  "hud",
+		Usage: "[large|normal]",
+		Help:  "Show health and ammo in big block digits above the status line, for small or distant screens",
+		Run: func(ctx *Context, args []string) (string, error) {
+			if len(args) == 1 {
+				switch strings.ToLower(args[0]) {
+				case "large":
+					ctx.Session.LargeHUD = true
+				case "normal":
+					ctx.Session.LargeHUD = false
+				default:
+					return "", fmt.Errorf("usage: /hud [large|normal]")
+				}
+			} else if len(args) > 1 {
+				return "", fmt.Errorf("usage: /hud [large|normal]")
+			}
+			if ctx.Session.LargeHUD {
+				return "Large HUD: health and ammo in big digits", nil
+			}
+			return "Normal HUD", nil
+		},
+		Complete: func(ctx *Context, prefix string) []string {
+			var matches []string
+			for _, option := range []string{"large", "normal"} {
+				if strings.HasPrefix(option, prefix) {
+					matches = append(matches, option)
+				}
+			}
+			return matches
+		},
+	})
+
+	r.Register(&Command{
+		Name:  "fps",
+		Usage: "[" + strings.Join(frameRateChoices(), "|") + "|server]",
+		Help:  "Draw your view at fewer frames per second, for slow links and tmux; the game itself runs at the server's rate",
+		Run: func(ctx *Context, args []string) (string, error) {
+			if len(args) > 1 {
+				return "", fmt.Errorf("usage: /fps [%s|server]", strings.Join(frameRateChoices(), "|"))
+			}
+			if len(args) == 1 {
+				rate, ok := parseFrameRate(args[0])
+				if !ok {
+					return "", fmt.Errorf("unknown frame rate %q; choose from %s, or server", args[0], strings.Join(frameRateChoices(), ", "))
+				}
+				ctx.Server.SetFrameRate(ctx.Session, rate)
+			}
+			if ctx.Session.FrameRate == 0 {
+				return "Drawing at the server's frame rate", nil
+			}
+			return fmt.Sprintf("Drawing at %d frames per second", ctx.Session.FrameRate), nil
+		},
+		Complete: func(ctx *Context, prefix string) []string {
+			var matches []string
+			for _, option := range append(frameRateChoices(), "server") {
+				if strings.HasPrefix(option, prefix) {
+					matches = append(matches, option)
+				}
+			}
+			return matches
+		},
+	})
+
+	r.Register(&Command{
+		Name:  "bell",
+		Usage: "[on|off]",
+		Help:  "Ring the terminal bell when you take damage, kill someone, or pick something up",
+		Run: func(ctx *Context, args []string) (string, error) {
+			if len(args) == 1 {
+				switch strings.ToLower(args[0]) {
+				case "on":
+					ctx.Session.Bell = true
+				case "off":
+					ctx.Session.Bell = false
+				default:
+					return "", fmt.Errorf("usage: /bell [on|off]")
+				}
+			} else if len(args) > 1 {
+				return "", fmt.Errorf("usage: /bell [on|off]")
+			}
+			if ctx.Session.Bell {
+				return "Bell on: it rings when you take damage, kill someone, or pick something up", nil
+			}
+			return "Bell off", nil
+		},
+		Complete: func(ctx *Context, prefix string) []string {
+			var matches []string
+			for _, option := range []string{"on", "off"} {
+				if strings.HasPrefix(option, prefix) {
+					matches = append(matches, option)
+				}
+			}
+			return matches
+		},
+	})
+
+	r.Register(&Command{
+		Name:  "motion",
+		Usage: "[reduced|full]",
+		Help:  "Calm flickering, flashing, and pulsing lights if you're sensitive to motion",
+		Run: func(ctx *Context, args []string) (string, error) {
+			if len(args) == 1 {
+				switch strings.ToLower(args[0]) {
+				case "reduced":
+					ctx.Session.ReducedMotion = true
+				case "full":
+					ctx.Session.ReducedMotion = false
+				default:
+					return "", fmt.Errorf("usage: /motion [reduced|full]")
+				}
+			} else if len(args) > 1 {
+				return "", fmt.Errorf("usage: /motion [reduced|full]")
+			}
+			if ctx.Session.ReducedMotion {
+				return "Reduced motion: lights hold steady and flashes are dimmed", nil
+			}
+			return "Full motion: lights flicker, pulse, and flash", nil
+		},
+		Complete: func(ctx *Context, prefix string) []string {
+			var matches []string
+			for _, option := range []string{"reduced", "full"} {
+				if strings.HasPrefix(option, prefix) {
+					matches = append(matches, option)
+				}
+			}
+			return matches
+		},
+	})
+
+	r.Register(&Command{
+		Name:      "map",
+		Usage:     "<file.map|url>",
+		Help:      "Switch the server to another map, from a file or remote storage",
+		AdminOnly: true,
+		Run: func(ctx *Context, args []string) (string, error) {
+			if len(args) != 1 {
+				return fmt.Sprintf("Current map is %s", ctx.Server.MapName), nil
+			}
+			worldMap, err := ctx.Server.LoadMap(context.Background(), args[0])
+			if err != nil {
+				return "", err
+			}
+			ctx.Server.ChangeMap(args[0], worldMap)
+			return "Switched map to " + args[0], nil
+		},
+		Complete: func(ctx *Context, prefix string) []string {
+			matches, _ := filepath.Glob(prefix + "*.map")
+			return matches
+		},
+	})
+
+	r.Register(&Command{
+		Name:  "keys",
+		Usage: "[preset]",
+		Help:  "Show or change your key bindings (" + strings.Join(input.PresetNames(), ", ") + ")",
+		Run: func(ctx *Context, args []string) (string, error) {
+			if len(args) == 0 {
+				return fmt.Sprintf("Using %s keys; presets: %s", ctx.Session.Keymap.Name, strings.Join(input.PresetNames(), ", ")), nil
+			}
+			keymap, ok := input.Preset(strings.ToLower(args[0]))
+			if !ok {
+				return "", fmt.Errorf("unknown preset %q; presets: %s", args[0], strings.Join(input.PresetNames(), ", "))
+			}
+			// Keep the turn/strafe preference across presets
+			if ctx.Session.Keymap.TurnStrafeSwapped {
+				keymap.SwapTurnStrafe()
+			}
+			ctx.Session.Keymap = keymap
+			return "Switched to " + keymap.Name + " keys", nil
+		},
+		Complete: func(ctx *Context, prefix string) []string {
+			var names []string
+			for _, name := range input.PresetNames() {
+				if strings.HasPrefix(name, prefix) {
+					names = append(names, name)
+				}
+			}
+			return names
+		},
+	})
+
+	r.Register(&Command{
+		Name:  "ghost",
+		Usage: "[record|stop [name]|play [name] [loop]|clear]",
+		Help:  "Record your run through the map, and replay runs as ghosts everyone sees",
+		Run: func(ctx *Context, args []string) (string, error) {
+			if len(args) == 0 {
+				return listReplays(ctx)
+			}
+			switch strings.ToLower(args[0]) {
+			case "record":
+				if err := ctx.Server.StartRecording(ctx.Session); err != nil {
+					return "", err
+				}
+				return "Recording your run; /ghost stop when you're done", nil
+			case "stop":
+				if len(args) > 2 {
+					return "", fmt.Errorf("usage: /ghost stop [name]")
+				}
+				replay, err := ctx.Server.StopRecording(ctx.Session)
+				if err != nil {
+					return "", err
+				}
+				if ctx.Server.Replays == nil {
+					return fmt.Sprintf("Recorded %.1fs; /ghost play to watch it", replay.Duration()), nil
+				}
+				saved := *replay
+				if len(args) == 2 {
+					saved.Name = args[1]
+				}
+				if err := ctx.Server.Replays.Save(&saved); err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("Saved %.1fs as %s; /ghost play %s to watch it", saved.Duration(), saved.Name, saved.Name), nil
+			case "play":
+				if len(args) > 3 || (len(args) == 3 && !strings.EqualFold(args[2], "loop")) {
+					return "", fmt.Errorf("usage: /ghost play [name] [loop]")
+				}
+				replay, ok := ctx.Server.LastReplay(ctx.Session)
+				if len(args) >= 2 {
+					if ctx.Server.Replays == nil {
+						return "", fmt.Errorf("this server doesn't save replays; /ghost play replays your latest run")
+					}
+					var err error
+					if replay, err = ctx.Server.Replays.Load(args[1]); err != nil {
+						return "", err
+					}
+				} else if !ok {
+					return "", fmt.Errorf("you haven't recorded a run; /ghost record to start")
+				}
+				if err := ctx.Server.PlayGhost(replay, len(args) == 3); err != nil {
+					return "", err
+				}
+				return fmt.Sprintf("%s's ghost is retracing %s", replay.Player, replay.Name), nil
+			case "clear":
+				if !ctx.Session.IsAdmin() {
+					return "", fmt.Errorf("only admins can clear ghosts")
+				}
+				return fmt.Sprintf("Cleared %d ghosts", ctx.Server.ClearGhosts()), nil
+			}
+			return "", fmt.Errorf("usage: /ghost [record|stop [name]|play [name] [loop]|clear]")
+		},
+		Complete: func(ctx *Context, prefix string) []string {
+			var matches []string
+			for _, sub := range []string{"record", "stop", "play", "clear"} {
+				if strings.HasPrefix(sub, prefix) {
+					matches = append(matches, sub)
+				}
+			}
+			return matches
+		},
+	})
+
+	r.Register(&Command{
+		Name: "spectate",
+		Help: "Watch the match without playing",
+		Run: func(ctx *Context, args []string) (string, error) {
+			return "", fmt.Errorf("to watch without playing, reconnect as the spectate user, e.g. ssh spectate@host")
+		},
+	})
+
+	r.Register(&Command{
+		Name: "practice",
+		Help: "Practice alone on this map, at any speed",
+		Run: func(ctx *Context, args []string) (string, error) {
+			if ctx.Server.Practicing() {
+				return "You're practicing: /speed to slow down or speed up, /freeze to stop NPCs", nil
+			}
+			return "", fmt.Errorf("to practice alone, reconnect as the practice user, e.g. ssh practice@host")
+		},
+	})
+
+	r.Register(&Command{
+		Name:  "speed",
+		Usage: "[0.25-2]",
+		Help:  "Slow down or speed up the game while practicing",
+		Run: func(ctx *Context, args []string) (string, error) {
+			if len(args) > 1 {
+				return "", fmt.Errorf("usage: /speed [0.25-2]")
+			}
+			if len(args) == 1 {
+				scale, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(args[0]), "x"), 64)
+				if err != nil {
+					return "", fmt.Errorf("usage: /speed [0.25-2]")
+				}
+				if err := ctx.Server.SetTimeScale(scale); err != nil {
+					return "", err
+				}
+			}
+			return fmt.Sprintf("Speed is %gx", ctx.Server.TimeScale()), nil
+		},
+		Complete: func(ctx *Context, prefix string) []string {
+			var matches []string
+			for _, option := range []string{"0.25", "0.5", "1", "1.5", "2"} {
+				if strings.HasPrefix(option, prefix) {
+					matches = append(matches, option)
+				}
+			}
+			return matches
+		},
+	})
+
+	r.Register(&Command{
+		Name:  "freeze",
+		Usage: "[on|off]",
+		Help:  "Stop NPCs moving while practicing",
+		Run: func(ctx *Context, args []string) (string, error) {
+			frozen := !ctx.Server.NPCsFrozen()
+			if len(args) == 1 {
+				switch strings.ToLower(args[0]) {
+				case "on":
+					frozen = true
+				case "off":
+					frozen = false
+				default:
+					return "", fmt.Errorf("usage: /freeze [on|off]")
+				}
+			} else if len(args) > 1 {
+				return "", fmt.Errorf("usage: /freeze [on|off]")
+			}
+			if err := ctx.Server.FreezeNPCs(frozen); err != nil {
+				return "", err
+			}
+			if frozen {
+				return "NPCs are frozen", nil
+			}
+			return "NPCs are moving", nil
+		},
+		Complete: func(ctx *Context, prefix string) []string {
+			var matches []string
+			for _, option := range []string{"on", "off"} {
+				if strings.HasPrefix(option, prefix) {
+					matches = append(matches, option)
+				}
+			}
+			return matches
+		},
+	})
+}
+
+// findLanguage returns the language /language names, by its tag, its name,
+// or a locale like es_MX
+func findLanguage(name string) (string, bool) {
+	for _, l := range locale.Languages {
+		if strings.EqualFold(name, l.Name) {
+			return l.Tag, true
+		}
+	}
+	return locale.Parse(name)
+}
+
+// languageChoices lists the languages /language accepts, like "es Español"
+func languageChoices() string {
+	var choices []string
+	for _, l := range locale.Languages {
+		choices = append(choices, l.Tag+" "+l.Name)
+	}
+	return strings.Join(choices, ", ")
+}
+
+// frameRateChoices lists the frame rates /fps accepts, like "15"
+func frameRateChoices() []string {
+	choices := make([]string, len(server.FrameRates))
+	for i, rate := range server.FrameRates {
+		choices[i] = strconv.Itoa(rate)
+	}
+	return choices
+}
+
+// parseFrameRate parses a frame rate for /fps, like "15" or "15fps", or
+// "server" for the server's rate, which is 0
+func parseFrameRate(name string) (int, bool) {
+	name = strings.TrimSuffix(strings.ToLower(name), "fps")
+	if name == "server" {
+		return 0, true
+	}
+	for _, rate := range server.FrameRates {
+		if name == strconv.Itoa(rate) {
+			return rate, true
+		}
+	}
+	return 0, false
+}
+
+// listReplays lists the saved replays for /ghost
+func listReplays(ctx *Context) (string, error) {
+	if ctx.Server.Replays == nil {
+		return "/ghost record, then /ghost stop and /ghost play to race your ghost", nil
+	}
+	names, err := ctx.Server.Replays.List()
+	if err != nil {
+		return "", err
+	}
+	if len(names) == 0 {
+		return "No saved replays; /ghost record to make one", nil
+	}
+	return "Replays: " + strings.Join(names, ", "), nil
+}
+
+// colorNames returns the player colors /color accepts, sorted
+func colorNames() []string {
+	var names []string
+	for name := range game.PlayerColors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// describeColorLimit describes a player's color limit for /colors
+func describeColorLimit(depth screen.ColorDepth) string {
+	switch depth {
+	case screen.TrueColor:
+		return "Sending as many colors as your terminal supports"
+	case screen.Monochrome:
+		return "Sending no colors, shading the world with characters"
+	}
+	return fmt.Sprintf("Sending at most %s", depth)
+}
+
+// glyphNames returns the names of the player glyphs /glyph accepts, sorted
+func glyphNames() []string {
+	var names []string
+	for name := range game.PlayerGlyphs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// glyphChoices lists the player glyphs with their names, like "spade ♠"
+func glyphChoices() []string {
+	var choices []string
+	for _, name := range glyphNames() {
+		choices = append(choices, name+" "+string(game.PlayerGlyphs[name]))
+	}
+	return choices
+}
+
+// findGlyph looks up a player glyph by name or by the character itself
+func findGlyph(arg string) (string, rune, bool) {
+	name := strings.ToLower(arg)
+	if g, ok := game.PlayerGlyphs[name]; ok {
+		return name, g, true
+	}
+	for name, g := range game.PlayerGlyphs {
+		if arg == string(g) {
+			return name, g, true
+		}
+	}
+	return "", 0, false
+}
+
+// leaderboardStatNames returns the stats /top can rank by, sorted
+func leaderboardStatNames() []string {
+	var names []string
+	for name := range server.LeaderboardStats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatStat formats an entry's value of a leaderboard stat
+func formatStat(stat string, e server.LeaderboardEntry) string {
+	if stat == "time" {
+		return (time.Duration(e.PlaySeconds) * time.Second).String()
+	}
+	return strconv.FormatInt(server.LeaderboardStats[stat](e), 10)
+}
@@ -0,0 +1,136 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/imjasonh/terminus/server"
+)
+
+// Context is passed to commands when they run
+type Context struct {
+	Server  *server.GameServer
+	Session *server.PlayerSession // The session running the command
+}
+
+// Command is a console command like /name or /help
+type Command struct {
+	Name      string
+	Usage     string // Argument synopsis, e.g. "<name>"
+	Help      string // One-line description
+	AdminOnly bool
+
+	// Run executes the command and returns a message for the caller
+	Run func(ctx *Context, args []string) (string, error)
+
+	// Complete optionally returns completions for the argument being typed
+	Complete func(ctx *Context, prefix string) []string
+}
+
+// Registry holds the available commands
+type Registry struct {
+	commands map[string]*Command
+	audit    auditLog
+}
+
+// NewRegistry creates an empty command registry
+func NewRegistry() *Registry {
+	return &Registry{commands: make(map[string]*Command)}
+}
+
+// Register adds a command to the registry, replacing any with the same name
+func (r *Registry) Register(cmd *Command) {
+	r.commands[cmd.Name] = cmd
+}
+
+// allowed reports whether the caller has permission to run the command
+func allowed(ctx *Context, cmd *Command) bool {
+	return !cmd.AdminOnly || (ctx.Session != nil && ctx.Session.IsAdmin())
+}
+
+// Available returns the commands the caller may run, sorted by name
+func (r *Registry) Available(ctx *Context) []*Command {
+	var cmds []*Command
+	for _, cmd := range r.commands {
+		if allowed(ctx, cmd) {
+			cmds = append(cmds, cmd)
+		}
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return cmds
+}
+
+// Execute parses and runs a command line. The leading slash is optional.
+func (r *Registry) Execute(ctx *Context, line string) (string, error) {
+	fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(line), "/"))
+	if len(fields) == 0 {
+		return "", nil
+	}
+
+	cmd, ok := r.commands[strings.ToLower(fields[0])]
+	if !ok {
+		return "", fmt.Errorf("unknown command /%s, try /help", fields[0])
+	}
+	if !allowed(ctx, cmd) {
+		r.audit.record(ctx, line, "denied")
+		return "", fmt.Errorf("/%s requires admin permission", cmd.Name)
+	}
+
+	msg, err := cmd.Run(ctx, fields[1:])
+	if cmd.AdminOnly {
+		if err != nil {
+			r.audit.record(ctx, line, "failed: "+err.Error())
+		} else {
+			r.audit.record(ctx, line, "ok")
+		}
+	}
+	return msg, err
+}
+
+// Complete returns the possible completions of a partially typed command
+// line, as full replacement lines
+func (r *Registry) Complete(ctx *Context, line string) []string {
+	hasSlash := strings.HasPrefix(line, "/")
+	trimmed := strings.TrimPrefix(line, "/")
+	prefix := ""
+	if hasSlash {
+		prefix = "/"
+	}
+
+	name, arg, hasArg := strings.Cut(trimmed, " ")
+	var completions []string
+	if !hasArg {
+		// Complete the command name
+		for _, cmd := range r.Available(ctx) {
+			if strings.HasPrefix(cmd.Name, strings.ToLower(name)) {
+				completions = append(completions, prefix+cmd.Name+" ")
+			}
+		}
+		return completions
+	}
+
+	// Complete the argument
+	cmd, ok := r.commands[strings.ToLower(name)]
+	if !ok || cmd.Complete == nil || !allowed(ctx, cmd) {
+		return nil
+	}
+	for _, c := range cmd.Complete(ctx, arg) {
+		completions = append(completions, prefix+cmd.Name+" "+c)
+	}
+	return completions
+}
+
+// CommonPrefix returns the longest prefix shared by all the strings
+func CommonPrefix(strs []string) string {
+	if len(strs) == 0 {
+		return ""
+	}
+	prefix := strs[0]
+	for _, s := range strs[1:] {
+		for !strings.HasPrefix(s, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
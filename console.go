@@ -0,0 +1,28 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+
+	"github.com/imjasonh/terminus/command"
+)
+
+// commands is the command registry shared by every session's console
+var commands = newCommandRegistry()
+
+// newCommandRegistry creates the registry of console commands
+func newCommandRegistry() *command.Registry {
+	r := command.NewRegistry()
+	command.RegisterBuiltins(r)
+	command.RegisterAdmin(r)
+	return r
+}
+
+// auditWriter sends audit log entries to the server log
+type auditWriter struct{}
+
+func (auditWriter) Write(p []byte) (int, error) {
+	clog.Infof("audit: %s", strings.TrimSpace(string(p)))
+	return len(p), nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+
+	"github.com/imjasonh/terminus/server"
+)
+
+// runtimeStats is a snapshot of the process and game for diagnosing performance
+type runtimeStats struct {
+	Goroutines     int                   `json:"goroutines"`
+	HeapAllocBytes uint64                `json:"heap_alloc_bytes"`
+	HeapObjects    uint64                `json:"heap_objects"`
+	SysBytes       uint64                `json:"sys_bytes"`
+	NumGC          uint32                `json:"num_gc"`
+	GCPauseTotalMs float64               `json:"gc_pause_total_ms"`
+	Players        int                   `json:"players"`
+	Spectators     int                   `json:"spectators"`
+	Projectiles    int                   `json:"projectiles"`
+	Sessions       []server.FrameSummary `json:"sessions"` // Slowest first
+}
+
+// checkLoopback returns an error unless addr only listens on this machine,
+// since profiles expose the server's internals
+func checkLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("%q isn't a localhost address, e.g. localhost:6060", addr)
+}
+
+// serveDebug serves pprof profiles at /debug/pprof/ and runtime stats as JSON
+// at /debug/runtime until the listener fails
+func serveDebug(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	mux.HandleFunc("GET /debug/runtime", func(w http.ResponseWriter, r *http.Request) {
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		stats := runtimeStats{
+			Goroutines:     runtime.NumGoroutine(),
+			HeapAllocBytes: mem.HeapAlloc,
+			HeapObjects:    mem.HeapObjects,
+			SysBytes:       mem.Sys,
+			NumGC:          mem.NumGC,
+			GCPauseTotalMs: float64(mem.PauseTotalNs) / float64(time.Millisecond),
+			Players:        gameServer.GetPlayerCount(),
+			Spectators:     gameServer.SpectatorCount(),
+			Projectiles:    len(gameServer.ProjectileManager.Snapshot()),
+			Sessions:       gameServer.FrameTimes(),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache")
+		if err := json.NewEncoder(w).Encode(stats); err != nil {
+			clog.Debugf("Failed to write runtime stats: %v", err)
+		}
+	})
+
+	// No write timeout, since CPU profiles and traces stream for as long as asked
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	clog.Infof("Debug endpoint on http://%s/debug/pprof/ and /debug/runtime", addr)
+	clog.Errorf("Debug endpoint stopped: %v", srv.ListenAndServe())
+}
@@ -0,0 +1,138 @@
+// Package discord bridges the game's chat with a Discord channel: players'
+// chat messages are posted to the channel by a bot, and the channel's
+// messages appear in the game's chat overlay. The bot needs the Message
+// Content intent enabled in Discord's developer portal to read messages.
+package discord
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/chainguard-dev/clog"
+
+	"github.com/imjasonh/terminus/server"
+)
+
+// nameSuffix marks names in the game's chat as coming from Discord
+const nameSuffix = "@discord"
+
+// markdown are the characters Discord formats text with, escaped in players'
+// messages so they show as typed
+const markdown = "\\*_~`|>#-[]()"
+
+// Bridge relays chat between a game server and a Discord channel
+type Bridge struct {
+	gs        *server.GameServer
+	channelID string
+	session   *discordgo.Session
+	chat      *server.Subscription
+	done      chan struct{}
+}
+
+// LoadToken reads a bot token from a file, ignoring surrounding whitespace
+func LoadToken(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Discord token file %s: %w", path, err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("Discord token file %s is empty", path)
+	}
+	return token, nil
+}
+
+// Open connects to Discord as the bot with the token and starts relaying chat
+// with the channel, until Close
+func Open(gs *server.GameServer, token, channelID string) (*Bridge, error) {
+	session, err := discordgo.New("Bot " + token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Discord session: %w", err)
+	}
+	session.Identify.Intents = discordgo.IntentsGuildMessages | discordgo.IntentMessageContent
+
+	b := &Bridge{gs: gs, channelID: channelID, session: session, done: make(chan struct{})}
+	session.AddHandler(b.relayToGame)
+	if err := session.Open(); err != nil {
+		return nil, fmt.Errorf("failed to connect to Discord: %w", err)
+	}
+	if _, err := session.Channel(channelID); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("failed to find Discord channel %s: %w", channelID, err)
+	}
+
+	b.chat = gs.Events.Subscribe(server.EventChat)
+	go b.relayToDiscord()
+	return b, nil
+}
+
+// Close stops relaying and disconnects from Discord
+func (b *Bridge) Close() {
+	close(b.done)
+	b.chat.Close()
+	b.session.Close()
+}
+
+// relayToGame shows a message posted in the channel in the game's chat
+func (b *Bridge) relayToGame(_ *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.ChannelID != b.channelID || m.Author == nil || m.Author.Bot {
+		return // Including the bridge's own messages
+	}
+	text := m.ContentWithMentionsReplaced()
+	if text == "" {
+		return // Like a message that's only an attachment
+	}
+	if err := b.gs.RelayChat(displayName(m)+nameSuffix, text); err != nil {
+		clog.Debugf("Didn't relay a Discord message from %s: %v", m.Author.Username, err)
+	}
+}
+
+// relayToDiscord posts players' chat messages to the channel, in order,
+// until the bridge is closed
+func (b *Bridge) relayToDiscord() {
+	for {
+		var e server.Event
+		select {
+		case <-b.done:
+			return
+		case e = <-b.chat.Events():
+		}
+		if e.SessionID == "" || len(e.Recipients) > 0 {
+			continue // Relayed from Discord, or not meant for everyone
+		}
+		_, err := b.session.ChannelMessageSendComplex(b.channelID, &discordgo.MessageSend{
+			Content:         "**" + escape(e.Name) + "**: " + escape(e.Detail),
+			AllowedMentions: &discordgo.MessageAllowedMentions{}, // No pinging @everyone from the game
+		})
+		if err != nil {
+			clog.Warnf("Failed to relay chat to Discord: %v", err)
+		}
+	}
+}
+
+// displayName returns the name a message's author shows in the server, as
+// a game name
+func displayName(m *discordgo.MessageCreate) string {
+	name := m.Author.GlobalName
+	if m.Member != nil && m.Member.Nick != "" {
+		name = m.Member.Nick
+	}
+	if name == "" {
+		name = m.Author.Username
+	}
+	return strings.Join(strings.Fields(name), "_")
+}
+
+// escape keeps Discord from formatting text as markdown
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		if strings.ContainsRune(markdown, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
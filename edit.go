@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/term"
+
+	"github.com/imjasonh/terminus/editor"
+	"github.com/imjasonh/terminus/engine"
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/input"
+	"github.com/imjasonh/terminus/screen"
+	"github.com/imjasonh/terminus/server"
+)
+
+// editUsage is the first line of `terminus edit -h`
+const editUsage = "Usage: %s edit [flags] <map file>\n\nEdits a map from above in the terminal, creating it if it doesn't exist. Press Enter to test-play it, and Esc to come back.\n\n"
+
+// sizePollInterval is how often the editor checks whether the terminal was resized
+const sizePollInterval = 250 * time.Millisecond
+
+// runEditor runs the edit subcommand with its arguments, returning the exit code
+func runEditor(args []string) int {
+	fs := flag.NewFlagSet("edit", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), editUsage, os.Args[0])
+		fs.PrintDefaults()
+	}
+	width := fs.Int("width", 20, "width of a new map, in cells")
+	height := fs.Int("height", 20, "height of a new map, in cells")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	if *width < 3 || *height < 3 {
+		fmt.Fprintln(os.Stderr, "-width and -height must be at least 3")
+		return 2
+	}
+	path := fs.Arg(0)
+	worldMap, err := game.LoadMapFromFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		worldMap = editor.NewMap(*width, *height)
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		fmt.Fprintln(os.Stderr, "The editor needs a terminal")
+		return 1
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to set up the terminal: %v\n", err)
+		return 1
+	}
+	defer term.Restore(fd, state)
+
+	// The test-play server's logs would draw over the editor
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	// Draw on the alternate screen, so the shell comes back as it was
+	fmt.Print("\x1b[?1049h\x1b[?25l\x1b[2J")
+	defer fmt.Print("\x1b[0m\x1b[?25h\x1b[?1049l")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	keys := make(chan input.Key, 64)
+	go input.ReadKeys(ctx, os.Stdin, keys)
+	resizes := make(chan engine.Size, 1)
+	go watchTerminalSize(ctx, fd, resizes)
+
+	caps := screen.DetectCapabilities(os.Getenv("TERM"), os.Environ())
+	size := terminalSize(fd)
+	s := screen.NewScreen(size.Width, size.Height)
+	s.SetCapabilities(caps)
+	ed := editor.New(path, worldMap)
+	for {
+		ed.Draw(s)
+		os.Stdout.Write(s.Frame())
+
+		select {
+		case <-resizes:
+			size := terminalSize(fd) // The latest, in case the terminal was resized again
+			s.Resize(size.Width, size.Height)
+			fmt.Print("\x1b[2J")
+		case key := <-keys:
+			switch ed.HandleKey(key) {
+			case editor.ActionQuit:
+				return 0
+			case editor.ActionPlay:
+				err := testPlay(ctx, filepath.Base(path), ed.Playable(), caps, connInput{ctx, keys, resizes}, terminalSize(fd))
+				if err != nil {
+					ed.Message(err.Error())
+				}
+				size := terminalSize(fd)
+				s.Resize(size.Width, size.Height)
+				s.Invalidate()
+				fmt.Print("\x1b[0m\x1b[?25l\x1b[2J")
+			}
+		}
+	}
+}
+
+// testPlay plays a map on a server of its own, in the terminal, until the
+// player quits. They play as an admin, so console commands like /spawn and
+// /tp work for trying things out.
+func testPlay(ctx context.Context, name string, worldMap *game.Map, caps screen.Capabilities, keys engine.InputSource, size engine.Size) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	gs := server.NewGameServer(worldMap, 1)
+	gs.MapName = name
+	go gs.Run(ctx, tickInterval())
+
+	playerSession, err := gs.AddPlayer(uuid.New().String(), "", os.Getenv("USER"), server.Connection{Role: server.RoleAdmin})
+	if err != nil {
+		return err
+	}
+	defer gs.RemovePlayer(playerSession.ID)
+
+	session := &engine.Session{
+		Server:        gs,
+		Player:        playerSession,
+		Commands:      commands,
+		Input:         keys,
+		Output:        terminalSink{os.Stdout},
+		Clock:         engine.SystemClock{},
+		FrameInterval: frameInterval(),
+	}
+	return session.Run(caps, size)
+}
+
+// terminalSize returns the size of the local terminal, or the usual 80x24 if
+// it can't be told
+func terminalSize(fd int) engine.Size {
+	width, height, err := term.GetSize(fd)
+	if err != nil || width <= 0 || height <= 0 {
+		return engine.Size{Width: 80, Height: 24}
+	}
+	return engine.Size{Width: width, Height: height}
+}
+
+// watchTerminalSize sends the local terminal's size whenever it changes,
+// until ctx is done. It polls, since not every platform signals resizes.
+func watchTerminalSize(ctx context.Context, fd int, resizes chan<- engine.Size) {
+	ticker := time.NewTicker(sizePollInterval)
+	defer ticker.Stop()
+	last := terminalSize(fd)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if size := terminalSize(fd); size != last {
+			last = size
+			select {
+			case resizes <- size:
+			default:
+			}
+		}
+	}
+}
+
+// terminalSink adapts the local terminal to an engine.FrameSink
+type terminalSink struct {
+	w io.Writer
+}
+
+func (s terminalSink) WriteFrame(frame []byte) error {
+	_, err := s.w.Write(frame)
+	return err
+}
@@ -0,0 +1,497 @@
+// Package editor edits maps in the terminal from above: painting walls,
+// placing player spawns, NPCs, pickups, and lights, and saving the result in
+// the map file format. Test-playing is left to the caller, which knows how to
+// run a game.
+package editor
+
+import (
+	"fmt"
+	"image/color"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/input"
+	"github.com/imjasonh/terminus/renderer"
+	"github.com/imjasonh/terminus/screen"
+)
+
+// Action is what the editor's caller should do after a key
+type Action int
+
+const (
+	ActionNone Action = iota // Keep editing
+	ActionPlay               // Test-play the map as it is, from Playable
+	ActionQuit               // Stop editing
+)
+
+// Placement defaults
+const (
+	pageStep       = 5 // Cells the cursor moves with shifted movement keys
+	lightRadius    = 3.0
+	lightIntensity = 0.6
+	lightColor     = "orange"
+)
+
+// help lists the editor's keys, shown on the bottom row
+const help = "arrows/hjkl move  0-9/b brush  space paint  d draw  s spawn  n npc  p pickup  g light  a animate  x clear  enter play  w save  q quit"
+
+// pages are the directions shifted movement keys move the cursor pageStep
+// cells in
+var pages = map[rune][2]int{'H': {-1, 0}, 'J': {0, 1}, 'K': {0, -1}, 'L': {1, 0}}
+
+// animations are the light animations, in the order 'a' cycles through them
+var animations = []game.LightAnimation{game.LightSteady, game.LightFlicker, game.LightPulse, game.LightStrobe}
+
+// Editor colors
+var (
+	floorFg  = color.RGBA{70, 70, 70, 255}
+	floorBg  = color.RGBA{0, 0, 0, 255}
+	cursorBg = color.RGBA{220, 220, 220, 255}
+	cursorFg = color.RGBA{0, 0, 0, 255}
+)
+
+// Editor edits a map loaded from, and saved to, a file
+type Editor struct {
+	Path string
+	Map  *game.Map
+
+	x, y      int  // The cell the cursor is on
+	left, top int  // The first cell shown, when the map doesn't fit on screen
+	brush     int  // Wall type painted, or 0 for open space
+	drawing   bool // Whether moving the cursor paints
+	pickup    game.PickupKind
+	color     string // Color of the next light placed
+	dirty     bool   // Whether there are unsaved changes
+	quitting  bool   // Whether quit was pressed once with unsaved changes
+	message   string // Shown on the HUD until the next key
+}
+
+// New starts editing a map that's saved to path
+func New(path string, m *game.Map) *Editor {
+	e := &Editor{Path: path, Map: m, x: m.Width / 2, y: m.Height / 2, brush: 1, color: lightColor}
+	if len(m.Spawns) > 0 {
+		e.x, e.y = int(m.Spawns[0].X), int(m.Spawns[0].Y)
+	}
+	return e
+}
+
+// NewMap creates an empty map of the given size, walled in
+func NewMap(width, height int) *game.Map {
+	grid := make([][]int, height)
+	for y := range grid {
+		grid[y] = make([]int, width)
+		for x := range grid[y] {
+			if x == 0 || y == 0 || x == width-1 || y == height-1 {
+				grid[y][x] = 1
+			}
+		}
+	}
+	return &game.Map{Width: width, Height: height, Grid: grid}
+}
+
+// Playable returns a copy of the map as edited so far, ready to play
+func (e *Editor) Playable() *game.Map {
+	m := *e.Map
+	m.Grid = make([][]int, len(e.Map.Grid))
+	for y, row := range e.Map.Grid {
+		m.Grid[y] = slices.Clone(row)
+	}
+	m.Spawns = slices.Clone(m.Spawns)
+	m.NPCs = slices.Clone(m.NPCs)
+	m.Pickups = slices.Clone(m.Pickups)
+	m.Lights = slices.Clone(m.Lights)
+	m.Lightmap = game.NewLightmap(m.Width, m.Height, m.Lights)
+	return &m
+}
+
+// HandleKey applies a key press, returning what the caller should do next
+func (e *Editor) HandleKey(k input.Key) Action {
+	e.message = ""
+	quitting := e.quitting
+	e.quitting = false
+
+	switch k.Code {
+	case input.KeyUp:
+		e.move(0, -1)
+	case input.KeyDown:
+		e.move(0, 1)
+	case input.KeyLeft:
+		e.move(-1, 0)
+	case input.KeyRight:
+		e.move(1, 0)
+	case input.KeyEscape:
+		return e.quit(quitting)
+	case input.KeyRune:
+		switch r := k.Rune; r {
+		case 'k':
+			e.move(0, -1)
+		case 'j':
+			e.move(0, 1)
+		case 'h':
+			e.move(-1, 0)
+		case 'l':
+			e.move(1, 0)
+		case 'H', 'J', 'K', 'L':
+			for range pageStep {
+				e.move(pages[r][0], pages[r][1])
+			}
+		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			e.brush = int(r - '0')
+			e.paint()
+		case 'b':
+			e.brush = game.BreakableWall
+			e.paint()
+		case ' ':
+			e.paint()
+		case 'd':
+			e.drawing = !e.drawing
+			if e.drawing {
+				e.paint()
+			}
+		case 's':
+			e.toggle(&e.Map.Spawns, "spawn")
+		case 'n':
+			e.toggle(&e.Map.NPCs, "NPC")
+		case 'p':
+			e.placePickup()
+		case 'g':
+			e.placeLight()
+		case 'a':
+			e.animateLight()
+		case 'x', 127:
+			if e.clear() {
+				e.message = "Cleared"
+			}
+		case '\r', '\n', 't':
+			return ActionPlay
+		case 'w', 19: // Ctrl+S
+			e.save()
+		case 'q', 3: // Ctrl+C
+			return e.quit(quitting)
+		}
+	}
+	return ActionNone
+}
+
+// Message shows a message on the HUD until the next key, like why a
+// test-play failed
+func (e *Editor) Message(msg string) {
+	e.message = msg
+}
+
+// move moves the cursor by a number of cells, painting the cell it reaches
+// if drawing
+func (e *Editor) move(dx, dy int) {
+	e.x = min(max(e.x+dx, 0), e.Map.Width-1)
+	e.y = min(max(e.y+dy, 0), e.Map.Height-1)
+	if e.drawing {
+		e.paint()
+	}
+}
+
+// paint sets the cursor's cell to the brush, removing anything placed there
+// if it becomes a wall
+func (e *Editor) paint() {
+	if e.Map.Grid[e.y][e.x] == e.brush {
+		return
+	}
+	if e.brush != 0 && e.clear() {
+		e.message = "Removed what was placed under the wall"
+	}
+	e.Map.Grid[e.y][e.x] = e.brush
+	e.dirty = true
+}
+
+// placeable reports whether things can be placed in the cursor's cell,
+// telling the user why not if they can't
+func (e *Editor) placeable() bool {
+	if e.Map.IsWall(e.x, e.y) {
+		e.message = "Things can only be placed in open space"
+		return false
+	}
+	return true
+}
+
+// center returns the middle of the cursor's cell, where things are placed
+func (e *Editor) center() game.Vector {
+	return game.Vector{X: float64(e.x) + 0.5, Y: float64(e.y) + 0.5}
+}
+
+// inCell reports whether a position is in the cursor's cell
+func (e *Editor) inCell(pos game.Vector) bool {
+	return int(pos.X) == e.x && int(pos.Y) == e.y
+}
+
+// toggle adds a position to the cursor's cell, or removes the ones there
+func (e *Editor) toggle(positions *[]game.Vector, what string) {
+	before := len(*positions)
+	*positions = slices.DeleteFunc(*positions, e.inCell)
+	if len(*positions) < before {
+		e.message = "Removed " + what
+	} else if e.placeable() {
+		*positions = append(*positions, e.center())
+		e.message = "Placed " + what
+	} else {
+		return
+	}
+	e.dirty = true
+}
+
+// placePickup places a pickup in the cursor's cell, or changes the kind of
+// the one there to the next kind
+func (e *Editor) placePickup() {
+	for i, spawn := range e.Map.Pickups {
+		if e.inCell(spawn.Position) {
+			e.pickup = (spawn.Kind + 1) % game.PickupKind(len(game.PickupTypes))
+			e.Map.Pickups[i].Kind = e.pickup
+			e.message = "Pickup is now " + game.GetPickupType(e.pickup).Noun
+			e.dirty = true
+			return
+		}
+	}
+	if e.placeable() {
+		e.Map.Pickups = append(e.Map.Pickups, game.PickupSpawn{Kind: e.pickup, Position: e.center()})
+		e.message = "Placed " + game.GetPickupType(e.pickup).Noun + "; press p again for another kind"
+		e.dirty = true
+	}
+}
+
+// placeLight places a light in the cursor's cell, or changes the color of
+// the one there to the next color
+func (e *Editor) placeLight() {
+	if i := e.lightIndex(); i >= 0 {
+		colors := colorNames()
+		e.color = colors[(slices.Index(colors, e.Map.Lights[i].ColorName)+1)%len(colors)]
+		e.Map.Lights[i].Color, _ = game.LightColor(e.color)
+		e.Map.Lights[i].ColorName = e.color
+		e.message = "Light is now " + e.color
+		e.dirty = true
+		return
+	}
+	if e.placeable() {
+		c, _ := game.LightColor(e.color)
+		e.Map.Lights = append(e.Map.Lights, game.LightSpawn{Position: e.center(), Radius: lightRadius, Intensity: lightIntensity, Color: c, ColorName: e.color})
+		e.message = "Placed " + e.color + " light; press g again for another color"
+		e.dirty = true
+	}
+}
+
+// animateLight changes the animation of the light in the cursor's cell to
+// the next one
+func (e *Editor) animateLight() {
+	i := e.lightIndex()
+	if i < 0 {
+		e.message = "There's no light here to animate"
+		return
+	}
+	light := &e.Map.Lights[i]
+	light.Animation = animations[(slices.Index(animations, light.Animation)+1)%len(animations)]
+	e.message = "Light is now " + light.Animation.String()
+	e.dirty = true
+}
+
+// lightIndex returns the index of the light in the cursor's cell, or -1
+func (e *Editor) lightIndex() int {
+	return slices.IndexFunc(e.Map.Lights, func(spawn game.LightSpawn) bool { return e.inCell(spawn.Position) })
+}
+
+// clear removes everything placed in the cursor's cell, reporting whether
+// there was anything
+func (e *Editor) clear() bool {
+	m := e.Map
+	before := len(m.Spawns) + len(m.NPCs) + len(m.Pickups) + len(m.Lights)
+	m.Spawns = slices.DeleteFunc(m.Spawns, e.inCell)
+	m.NPCs = slices.DeleteFunc(m.NPCs, e.inCell)
+	m.Pickups = slices.DeleteFunc(m.Pickups, func(spawn game.PickupSpawn) bool { return e.inCell(spawn.Position) })
+	m.Lights = slices.DeleteFunc(m.Lights, func(spawn game.LightSpawn) bool { return e.inCell(spawn.Position) })
+	if len(m.Spawns)+len(m.NPCs)+len(m.Pickups)+len(m.Lights) == before {
+		return false
+	}
+	e.dirty = true
+	return true
+}
+
+// save writes the map to its file
+func (e *Editor) save() {
+	if err := game.SaveMapToFile(e.Map, e.Path); err != nil {
+		e.message = err.Error()
+		return
+	}
+	e.dirty = false
+	e.message = "Saved " + e.Path
+}
+
+// quit stops editing, unless there are unsaved changes and quit wasn't
+// pressed twice in a row
+func (e *Editor) quit(again bool) Action {
+	if e.dirty && !again {
+		e.quitting = true
+		e.message = "Unsaved changes: press q again to quit without saving, or w to save"
+		return ActionNone
+	}
+	return ActionQuit
+}
+
+// colorNames returns the names of the colors lights can be, in order
+func colorNames() []string {
+	names := make([]string, 0, len(game.PlayerColors))
+	for name := range game.PlayerColors {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Draw draws the map from above onto the screen, two columns to a cell so
+// cells look square, with the cursor's cell highlighted
+func (e *Editor) Draw(s *screen.Screen) {
+	s.Clear()
+	cols, rows := max(s.Width/2, 1), max(s.GameHeight, 1)
+
+	// Scroll to keep the cursor on screen
+	e.left = min(max(e.left, e.x-cols+1), e.x)
+	e.top = min(max(e.top, e.y-rows+1), e.y)
+
+	for row := range rows {
+		y := e.top + row
+		if y >= e.Map.Height {
+			break
+		}
+		for col := range cols {
+			x := e.left + col
+			if x >= e.Map.Width {
+				break
+			}
+			glyphs, fg, bg := e.cell(x, y)
+			if x == e.x && y == e.y {
+				bg = cursorBg
+				if e.Map.IsWall(x, y) {
+					glyphs = [2]glyph{{glyph: '░'}, {glyph: '░'}} // Shows the wall's color through the cursor
+				} else {
+					fg = cursorFg
+				}
+			}
+			s.SetCell(col*2, row, glyphs[0].glyph, glyphs[0].color(fg), bg)
+			s.SetCell(col*2+1, row, glyphs[1].glyph, glyphs[1].color(fg), bg)
+		}
+	}
+
+	name := filepath.Base(e.Path)
+	if e.dirty {
+		name += "*"
+	}
+	status := e.message
+	if status == "" {
+		status = fmt.Sprintf("(%d, %d) %s", e.x, e.y, e.describe())
+	}
+	brush := "brush " + wallName(e.brush)
+	if e.drawing {
+		brush += " (drawing)"
+	}
+	s.SetDebugMessage(" " + name + "  " + status)
+	s.SetStatus(brush)
+	s.SetCompass(help)
+}
+
+// glyph is one of the two characters drawn for a cell, in its own color if
+// it has one
+type glyph struct {
+	glyph rune
+	fg    color.RGBA
+}
+
+func (g glyph) color(fallback color.RGBA) color.RGBA {
+	if g.fg.A == 0 {
+		return fallback
+	}
+	return g.fg
+}
+
+// cell returns how a cell looks: what's placed there, or the floor or wall
+func (e *Editor) cell(x, y int) ([2]glyph, color.RGBA, color.RGBA) {
+	if wall := e.Map.GetWallType(x, y); wall != 0 {
+		r := '█'
+		switch wall {
+		case game.LockedDoor:
+			r = '▒'
+		case game.BreakableWall:
+			r = '▓'
+		}
+		return [2]glyph{{glyph: r}, {glyph: r}}, renderer.WallColor(wall), floorBg
+	}
+
+	var placed []glyph
+	for _, pos := range e.Map.Spawns {
+		if int(pos.X) == x && int(pos.Y) == y {
+			placed = append(placed, glyph{game.PlayerSprite.Glyph, game.DefaultPlayerColor})
+		}
+	}
+	for _, pos := range e.Map.NPCs {
+		if int(pos.X) == x && int(pos.Y) == y {
+			placed = append(placed, glyph{game.NPCSprite.Glyph, game.NPCSprite.Color})
+		}
+	}
+	for _, spawn := range e.Map.Pickups {
+		if int(spawn.Position.X) == x && int(spawn.Position.Y) == y {
+			t := game.GetPickupType(spawn.Kind)
+			placed = append(placed, glyph{t.Glyph, t.Color})
+		}
+	}
+	for _, spawn := range e.Map.Lights {
+		if int(spawn.Position.X) == x && int(spawn.Position.Y) == y {
+			placed = append(placed, glyph{'☼', game.PlayerColors[spawn.ColorName]})
+		}
+	}
+
+	glyphs := [2]glyph{{glyph: '·'}, {glyph: ' '}}
+	copy(glyphs[:], placed)
+	return glyphs, floorFg, floorBg
+}
+
+// describe describes the cursor's cell for the HUD
+func (e *Editor) describe() string {
+	if wall := e.Map.GetWallType(e.x, e.y); wall != 0 {
+		return wallName(wall)
+	}
+	var things []string
+	for _, pos := range e.Map.Spawns {
+		if e.inCell(pos) {
+			things = append(things, "player spawn")
+		}
+	}
+	for _, pos := range e.Map.NPCs {
+		if e.inCell(pos) {
+			things = append(things, "NPC")
+		}
+	}
+	for _, spawn := range e.Map.Pickups {
+		if e.inCell(spawn.Position) {
+			things = append(things, game.GetPickupType(spawn.Kind).Noun)
+		}
+	}
+	for _, spawn := range e.Map.Lights {
+		if e.inCell(spawn.Position) {
+			things = append(things, fmt.Sprintf("%s %s light", spawn.ColorName, spawn.Animation))
+		}
+	}
+	if len(things) == 0 {
+		return "open space"
+	}
+	return strings.Join(things, ", ")
+}
+
+// wallName names a grid value, like "wall 2"
+func wallName(wall int) string {
+	switch wall {
+	case 0:
+		return "open space"
+	case game.LockedDoor:
+		return "locked door"
+	case game.BreakableWall:
+		return "breakable wall"
+	default:
+		return fmt.Sprintf("wall %d", wall)
+	}
+}
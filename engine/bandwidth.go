@@ -0,0 +1,98 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/imjasonh/terminus/screen"
+)
+
+// quality is how far a session's output is degraded to fit its bandwidth
+// budget. Each level keeps the savings of the ones before it.
+type quality int
+
+const (
+	qualityFull         quality = iota // Whole frames at the terminal's full color depth
+	qualityDiff                        // Only the cells that changed since the last frame
+	qualityReducedColor                // At most 256 colors
+	qualityLowFrameRate                // 16 colors at half the frame rate
+)
+
+// String describes the quality level for the player
+func (q quality) String() string {
+	switch q {
+	case qualityDiff:
+		return "sending only changes"
+	case qualityReducedColor:
+		return "sending changes in 256 colors"
+	case qualityLowFrameRate:
+		return "sending changes in 16 colors at half the frame rate"
+	default:
+		return "full quality"
+	}
+}
+
+// apply returns the terminal capabilities to draw with at this quality
+func (q quality) apply(caps screen.Capabilities) screen.Capabilities {
+	switch {
+	case q >= qualityLowFrameRate:
+		caps.ColorDepth = max(caps.ColorDepth, screen.Color16)
+	case q >= qualityReducedColor:
+		caps.ColorDepth = max(caps.ColorDepth, screen.Color256)
+	}
+	return caps
+}
+
+// Bandwidth budget tuning
+const (
+	bandwidthWindow = time.Second // How often usage is measured and quality adjusted
+	recoverWindows  = 5           // Windows well under budget before quality improves
+)
+
+// bandwidthGovernor measures how many bytes a session sends each second, and
+// lowers its quality while it's over budget, raising it again once usage has
+// stayed well under budget for a while
+type bandwidthGovernor struct {
+	budget      int // Bytes per second; 0 for no limit
+	level       quality
+	windowStart time.Time
+	bytes       int // Sent in the current window
+	underBudget int // Consecutive windows under half the budget
+}
+
+// record counts bytes sent at the given time, and returns whether the quality
+// changed at the end of a measurement window
+func (g *bandwidthGovernor) record(now time.Time, n int) bool {
+	if g.budget <= 0 {
+		return false
+	}
+	if g.windowStart.IsZero() {
+		g.windowStart = now
+	}
+	g.bytes += n
+
+	elapsed := now.Sub(g.windowStart)
+	if elapsed < bandwidthWindow {
+		return false
+	}
+	rate := float64(g.bytes) / elapsed.Seconds()
+	g.windowStart, g.bytes = now, 0
+
+	switch {
+	case rate > float64(g.budget):
+		g.underBudget = 0
+		if g.level < qualityLowFrameRate {
+			g.level++
+			return true
+		}
+	case rate < float64(g.budget)/2:
+		g.underBudget++
+		if g.underBudget >= recoverWindows && g.level > qualityFull {
+			g.underBudget = 0
+			g.level--
+			return true
+		}
+	default:
+		g.underBudget = 0
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/imjasonh/terminus/screen"
+)
+
+func TestBandwidthGovernor(t *testing.T) {
+	const budget = 1000
+	for _, tt := range []struct {
+		name      string
+		budget    int
+		start     quality
+		rates     []int // Bytes sent in each successive window
+		wantLevel quality
+	}{
+		{"no budget", 0, qualityFull, []int{1e9, 1e9}, qualityFull},
+		{"under budget", budget, qualityFull, []int{900, 900, 900}, qualityFull},
+		{"over budget steps down", budget, qualityFull, []int{2000}, qualityDiff},
+		{"steps down once a window", budget, qualityFull, []int{2000, 2000, 2000}, qualityLowFrameRate},
+		{"no lower than the lowest", budget, qualityFull, []int{2000, 2000, 2000, 2000, 2000}, qualityLowFrameRate},
+		{"recovers after windows well under budget", budget, qualityReducedColor, []int{100, 100, 100, 100, 100}, qualityDiff},
+		{"not before enough of them", budget, qualityReducedColor, []int{100, 100, 100, 100}, qualityReducedColor},
+		{"a window near the budget restarts the count", budget, qualityReducedColor, []int{100, 100, 100, 700, 100, 100, 100, 100}, qualityReducedColor},
+		{"no higher than full", budget, qualityFull, []int{100, 100, 100, 100, 100, 100, 100, 100, 100, 100}, qualityFull},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &bandwidthGovernor{budget: tt.budget, level: tt.start}
+			now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			g.record(now, 0)
+			for _, n := range tt.rates {
+				// Half the window's bytes partway through, and the rest as it ends
+				if g.record(now.Add(bandwidthWindow/2), n/2) {
+					t.Fatal("quality changed partway through a window")
+				}
+				now = now.Add(bandwidthWindow)
+				g.record(now, n-n/2)
+			}
+			if g.level != tt.wantLevel {
+				t.Errorf("level = %s, want %s", g.level, tt.wantLevel)
+			}
+		})
+	}
+}
+
+func TestQualityApply(t *testing.T) {
+	truecolor := screen.Capabilities{ColorDepth: screen.TrueColor, Unicode: true}
+	for _, tt := range []struct {
+		level quality
+		caps  screen.Capabilities
+		want  screen.ColorDepth
+	}{
+		{qualityFull, truecolor, screen.TrueColor},
+		{qualityDiff, truecolor, screen.TrueColor},
+		{qualityReducedColor, truecolor, screen.Color256},
+		{qualityLowFrameRate, truecolor, screen.Color16},
+		// Terminals with fewer colors keep them
+		{qualityReducedColor, screen.Capabilities{ColorDepth: screen.Color16}, screen.Color16},
+		{qualityLowFrameRate, screen.Capabilities{ColorDepth: screen.Monochrome}, screen.Monochrome},
+	} {
+		if got := tt.level.apply(tt.caps).ColorDepth; got != tt.want {
+			t.Errorf("%s applied to %s = %s, want %s", tt.level, tt.caps.ColorDepth, got, tt.want)
+		}
+	}
+}
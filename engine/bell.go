@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/server"
+)
+
+// bellInterval is the least time between bells, so steady damage, like from
+// a flamethrower, doesn't ring every frame
+const bellInterval = 500 * time.Millisecond
+
+// bell rings the terminal bell on significant personal events, for players
+// who turned it on with /bell, so they notice them without looking: taking
+// damage, killing someone, and picking something up
+type bell struct {
+	health float64   // The player's health as of the last frame
+	seen   bool      // Whether health has been recorded yet
+	rung   time.Time // When the bell last rang
+}
+
+// hurt records the player's health as of a frame, and returns whether they
+// lost some since the last one. Respawning refills health, so dying counts
+// when the kill is announced, not here.
+func (b *bell) hurt(view *game.Player) bool {
+	lost := b.seen && view.Health < b.health
+	b.health, b.seen = view.Health, true
+	return lost
+}
+
+// personal reports whether an event is one the player should hear about: a
+// kill they scored, or a pickup they took
+func personal(e server.Event, playerSession *server.PlayerSession) bool {
+	switch e.Kind {
+	case server.EventKilled:
+		return e.SessionID != playerSession.ID && e.Target == playerSession.Name
+	case server.EventPickedUp:
+		return e.SessionID == playerSession.ID
+	}
+	return false
+}
+
+// ring sends the terminal bell, if the player wants it and it hasn't rung
+// too recently
+func (b *bell) ring(s *Session, now time.Time) {
+	if !s.Player.Bell || now.Sub(b.rung) < bellInterval {
+		return
+	}
+	b.rung = now
+	s.print("\a")
+}
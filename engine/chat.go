@@ -0,0 +1,92 @@
+package engine
+
+import (
+	"image/color"
+	"time"
+
+	"github.com/imjasonh/terminus/screen"
+	"github.com/imjasonh/terminus/server"
+)
+
+// Chat overlay tuning
+const (
+	chatLines    = 4                // Lines shown at the top of the game area as they arrive
+	chatShowTime = 10 * time.Second // How long a line stays visible after it arrives
+)
+
+// chatOverlay shows recent chat at the top of the game area, separate from the
+// console. While the player is typing a message it shows more of the history.
+type chatOverlay struct {
+	clock Clock
+	lines []chatLine
+}
+
+// chatLine is a line of chat and when it arrived
+type chatLine struct {
+	text    string
+	color   color.RGBA
+	arrived time.Time // Zero for history from before the player joined
+}
+
+// Chat colors, by who a line is for
+var (
+	chatColor         = color.RGBA{200, 230, 255, 255} // Everyone
+	teamChatColor     = color.RGBA{120, 255, 120, 255}
+	whisperColor      = color.RGBA{255, 140, 255, 255}
+	announcementColor = color.RGBA{160, 160, 160, 255} // Players joining and leaving
+)
+
+// newChatLine formats a chat event in its color, in a language
+func newChatLine(e server.Event, lang string, arrived time.Time) chatLine {
+	line := chatLine{text: server.ChatLine(e, lang), color: announcementColor, arrived: arrived}
+	switch e.Kind {
+	case server.EventChat:
+		line.color = chatColor
+	case server.EventTeamChat:
+		line.color = teamChatColor
+	case server.EventWhisper:
+		line.color = whisperColor
+	}
+	return line
+}
+
+// add shows a chat event as it arrives, in the player's language
+func (c *chatOverlay) add(e server.Event, lang string) {
+	c.push(newChatLine(e, lang, c.clock.Now()))
+}
+
+// catchUp adds chat from before the player joined, shown only while they're typing
+func (c *chatOverlay) catchUp(history []server.Event, lang string) {
+	for _, e := range history {
+		c.push(newChatLine(e, lang, time.Time{}))
+	}
+}
+
+// push adds a line, forgetting the oldest past the server's history length
+func (c *chatOverlay) push(line chatLine) {
+	c.lines = append(c.lines, line)
+	if len(c.lines) > server.ChatHistory {
+		c.lines = c.lines[len(c.lines)-server.ChatHistory:]
+	}
+}
+
+// draw overlays recent chat at the top of the game area, or up to half of it
+// while the player is typing
+func (c *chatOverlay) draw(s *screen.Screen, typing bool) {
+	var shown []chatLine
+	if typing {
+		shown = c.lines[max(0, len(c.lines)-s.GameHeight/2):]
+	} else {
+		now := c.clock.Now()
+		for _, line := range c.lines[max(0, len(c.lines)-chatLines):] {
+			if !line.arrived.IsZero() && now.Sub(line.arrived) < chatShowTime {
+				shown = append(shown, line)
+			}
+		}
+	}
+
+	bg := color.RGBA{10, 20, 40, 255}
+	for row, line := range shown {
+		s.DrawText(0, row, padRight(line.text, s.Width), line.color, bg)
+	}
+}
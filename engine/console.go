@@ -0,0 +1,145 @@
+package engine
+
+import (
+	"image/color"
+	"strings"
+	"time"
+
+	"github.com/imjasonh/terminus/command"
+	"github.com/imjasonh/terminus/input"
+	"github.com/imjasonh/terminus/screen"
+)
+
+// Console tuning
+const (
+	consoleHistory    = 4               // Output lines kept on screen
+	consoleOutputTime = 6 * time.Second // How long output stays visible after the console closes
+	consoleMaxLength  = 120             // Longest command line accepted
+)
+
+// console is a per-session command line opened with '/' or '~', which also
+// takes chat messages when opened with Enter
+type console struct {
+	commands    *command.Registry
+	clock       Clock
+	open        bool
+	chat        bool // Whether the line is a chat message rather than a command
+	line        []rune
+	output      []string
+	outputUntil time.Time
+}
+
+// handleKey processes a key while the console is open, running the command or
+// sending the chat message on Enter. It returns false if the console didn't
+// consume the key.
+func (c *console) handleKey(key input.Key, ctx *command.Context) bool {
+	if !c.open {
+		if key.Is('/') || key.Is('~') || key.Is('\r') {
+			c.open = true
+			c.chat = key.Is('\r')
+			c.line = c.line[:0]
+			if key.Is('/') {
+				c.line = append(c.line, '/')
+			}
+			return true
+		}
+		return false
+	}
+
+	switch {
+	case key.Code == input.KeyEscape:
+		c.open = false
+	case key.Is('\r') || key.Is('\n'):
+		c.open = false
+		line := string(c.line)
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+		if c.chat {
+			if err := ctx.Server.Say(ctx.Session, line); err != nil {
+				c.print("Error: " + err.Error())
+			}
+			break
+		}
+		reply, err := c.commands.Execute(ctx, line)
+		ctx.Session.Log.Debug("Ran command", "line", line, "error", err)
+		if err != nil {
+			c.print("Error: " + err.Error())
+		} else if reply != "" {
+			c.print(reply)
+		}
+	case key.Is('\t'):
+		if !c.chat {
+			c.complete(ctx)
+		}
+	case key.Is(127) || key.Is(8): // Backspace
+		if len(c.line) > 0 {
+			c.line = c.line[:len(c.line)-1]
+		}
+	case key.Is(21): // Ctrl+U clears the line
+		c.line = c.line[:0]
+	case key.Code == input.KeyRune && key.Rune >= ' ' && len(c.line) < consoleMaxLength:
+		c.line = append(c.line, key.Rune)
+	}
+	return true
+}
+
+// complete applies tab completion to the current line
+func (c *console) complete(ctx *command.Context) {
+	completions := c.commands.Complete(ctx, string(c.line))
+	switch len(completions) {
+	case 0:
+		return
+	case 1:
+		c.line = []rune(completions[0])
+	default:
+		if prefix := command.CommonPrefix(completions); len(prefix) > len(string(c.line)) {
+			c.line = []rune(prefix)
+		}
+		c.print(strings.Join(completions, "  "))
+	}
+}
+
+// print adds output to the console, one entry per line
+func (c *console) print(text string) {
+	c.output = append(c.output, strings.Split(text, "\n")...)
+	if len(c.output) > consoleHistory {
+		c.output = c.output[len(c.output)-consoleHistory:]
+	}
+	c.outputUntil = c.clock.Now().Add(consoleOutputTime)
+}
+
+// draw overlays the console's output and input line at the bottom of the game area
+func (c *console) draw(s *screen.Screen) {
+	showOutput := c.open || c.clock.Now().Before(c.outputUntil)
+	if !c.open && !showOutput {
+		return
+	}
+
+	fg := color.RGBA{230, 230, 230, 255}
+	bg := color.RGBA{20, 20, 20, 255}
+	row := s.GameHeight - 1
+
+	if c.open {
+		prompt := "> " + string(c.line) + "_"
+		if c.chat {
+			prompt = "Say: " + string(c.line) + "_"
+		}
+		s.DrawText(0, row, padRight(prompt, s.Width), color.RGBA{255, 255, 100, 255}, bg)
+		row--
+	}
+	if showOutput {
+		for i := len(c.output) - 1; i >= 0 && row >= 0; i-- {
+			s.DrawText(0, row, padRight(c.output[i], s.Width), fg, bg)
+			row--
+		}
+	}
+}
+
+// padRight pads text with spaces to fill width columns
+func padRight(text string, width int) string {
+	if n := len([]rune(text)); n < width {
+		return text + strings.Repeat(" ", width-n)
+	}
+	return text
+}
@@ -0,0 +1,39 @@
+package engine
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/screen"
+)
+
+// Status effect indicator colors
+var (
+	effectColor = color.RGBA{40, 20, 60, 255}  // Helpful effects, like powerups
+	harmColor   = color.RGBA{100, 20, 20, 255} // Damage over time
+)
+
+// drawEffects overlays the player's active status effects at the right of the
+// game area, starting at a row, each with its icon, how many are stacked, and
+// the seconds left on the longest
+func drawEffects(s *screen.Screen, view *game.Player, row int) {
+	fg := color.RGBA{255, 255, 255, 255}
+	for _, t := range game.EffectTypes {
+		left := view.EffectTime(t.Kind)
+		if left <= 0 {
+			continue
+		}
+		line := fmt.Sprintf(" %c %s %.0fs ", t.Icon, t.Name, math.Ceil(left))
+		if stacks := view.EffectStacks(t.Kind); stacks > 1 {
+			line = fmt.Sprintf(" %c %s x%d %.0fs ", t.Icon, t.Name, stacks, math.Ceil(left))
+		}
+		bg := effectColor
+		if t.Kind == game.EffectDamageOverTime {
+			bg = harmColor
+		}
+		s.DrawText(s.Width-len([]rune(line)), row, line, fg, bg)
+		row++
+	}
+}
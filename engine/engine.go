@@ -0,0 +1,63 @@
+// Package engine runs a player's game loop independent of how they're
+// connected. Transports like SSH, WebSocket, and telnet (or tests) supply
+// keys through an InputSource, receive terminal output through a FrameSink,
+// and drive time through a Clock.
+package engine
+
+import (
+	"time"
+
+	"github.com/imjasonh/terminus/input"
+)
+
+// Size is a terminal size in character cells
+type Size struct {
+	Width, Height int
+}
+
+// InputSource delivers what the player does: decoded keys, terminal resizes,
+// and disconnection
+type InputSource interface {
+	Keys() <-chan input.Key
+	Resizes() <-chan Size
+	Done() <-chan struct{} // Closed when the player disconnects
+}
+
+// FrameSink receives output for the player's terminal, from full frames to
+// escape sequences and lines of text. Sessions write to it from a FrameWriter's
+// goroutine, one write at a time. The frame's buffer is reused after
+// WriteFrame returns, so sinks must not keep it.
+type FrameSink interface {
+	WriteFrame(frame []byte) error
+}
+
+// Clock tells the time and paces the game loop, so tests can control both
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks on C until it's stopped
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// SystemClock is the real wall clock
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (SystemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+// systemTicker adapts a time.Ticker to a Ticker
+type systemTicker struct {
+	t *time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time { return t.t.C }
+func (t systemTicker) Stop()               { t.t.Stop() }
@@ -0,0 +1,211 @@
+package engine
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/input"
+	"github.com/imjasonh/terminus/screen"
+	"github.com/imjasonh/terminus/server"
+)
+
+// helpKey opens and closes the help overlay
+const helpKey = '?'
+
+// helpOverlay is a paged guide over the game area, opened with '?': the
+// player's key bindings, the game's rules, and what's on the map. Its text is
+// built from the player's keymap and the server each time it opens, so it's
+// never out of date.
+type helpOverlay struct {
+	open     bool
+	page     int
+	sections []helpSection
+}
+
+// helpSection is a titled part of the help, split across as many pages as it
+// takes to fit the screen
+type helpSection struct {
+	title string
+	lines []string
+}
+
+// show opens the help at its first page
+func (h *helpOverlay) show(sections []helpSection) {
+	h.open = true
+	h.page = 0
+	h.sections = sections
+}
+
+// handleKey turns the help's pages while it's open, closing it on Escape or
+// '?'. It returns false if the help isn't open to take the key.
+func (h *helpOverlay) handleKey(key input.Key) bool {
+	if !h.open {
+		return false
+	}
+	switch {
+	case key.Code == input.KeyEscape || key.Is(helpKey) || key.Is('q'):
+		h.open = false
+	case key.Code == input.KeyRight || key.Code == input.KeyDown || key.Is(' ') || key.Is('n'):
+		h.page++ // Kept in range by draw, which knows how many pages fit
+	case key.Code == input.KeyLeft || key.Code == input.KeyUp || key.Is('p'):
+		h.page--
+	}
+	return true
+}
+
+// helpPage is one screenful of a section
+type helpPage struct {
+	title string
+	lines []string
+}
+
+// paginate wraps the sections' lines to a width and splits them into pages of
+// at most rows lines each
+func paginate(sections []helpSection, width, rows int) []helpPage {
+	var pages []helpPage
+	for _, section := range sections {
+		var wrapped []string
+		for _, line := range section.lines {
+			wrapped = append(wrapped, wrap("- "+line, width, "  ")...)
+		}
+		for len(wrapped) > 0 {
+			n := min(rows, len(wrapped))
+			pages = append(pages, helpPage{title: section.title, lines: wrapped[:n]})
+			wrapped = wrapped[n:]
+		}
+	}
+	return pages
+}
+
+// wrap breaks text into lines of at most width columns at spaces, indenting
+// the lines after the first
+func wrap(text string, width int, indent string) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		switch {
+		case line == "":
+			line = word
+		case len([]rune(line))+1+len([]rune(word)) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = indent + word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// draw overlays the current page of the help on the game area, its title and
+// footer in the player's language
+func (h *helpOverlay) draw(s *screen.Screen, playerSession *server.PlayerSession) {
+	if !h.open {
+		return
+	}
+	const margin = 2
+	width, rows := s.Width-2*margin, s.GameHeight-4 // Leaving rows for the title and footer, and a gap under the title
+	if width < 10 || rows < 1 {
+		return
+	}
+	pages := paginate(h.sections, width-2, rows)
+	if len(pages) == 0 {
+		return
+	}
+	h.page = max(0, min(h.page, len(pages)-1))
+	page := pages[h.page]
+
+	fg := color.RGBA{230, 230, 230, 255}
+	bg := color.RGBA{15, 20, 40, 255}
+	titleColor := color.RGBA{255, 255, 100, 255}
+	blank := strings.Repeat(" ", width)
+	for y := 0; y < s.GameHeight; y++ {
+		s.DrawText(margin, y, blank, fg, bg)
+	}
+	s.DrawText(margin+1, 0, playerSession.T("Help: %s (%d/%d)", page.title, h.page+1, len(pages)), titleColor, bg)
+	for i, line := range page.lines {
+		s.DrawText(margin+1, 2+i, line, fg, bg)
+	}
+	s.DrawText(margin+1, s.GameHeight-1, playerSession.T("Left/Right: turn pages   ? or Esc: close"), titleColor, bg)
+}
+
+// helpSections builds the help from the player's keymap and the server's
+// rules and map as they are now
+func (s *Session) helpSections() []helpSection {
+	return []helpSection{
+		{title: s.Player.T("Keys"), lines: keyHelp(s.Player.Keymap)},
+		{title: s.Player.T("Rules"), lines: s.Server.Rulebook(s.Player)},
+		{title: s.Player.T("Map"), lines: s.Server.Objectives()},
+	}
+}
+
+// fixedKeys are the keys bound to an action in every keymap
+var fixedKeys = map[input.Action]string{
+	input.ActionMoveForward:  "Up",
+	input.ActionMoveBackward: "Down",
+	input.ActionTurnLeft:     "Left",
+	input.ActionTurnRight:    "Right",
+}
+
+// keyHelp lists what the keys do in a keymap, followed by the keys every
+// keymap shares
+func keyHelp(keymap input.Keymap) []string {
+	var lines []string
+	for action := input.ActionMoveForward; action <= input.ActionTorch; action++ {
+		var names []string
+		for _, r := range keymap.KeysFor(action) {
+			names = append(names, keyName(r))
+		}
+		if fixed, ok := fixedKeys[action]; ok {
+			names = append(names, fixed)
+		}
+		if len(names) > 0 {
+			lines = append(lines, fmt.Sprintf("%s: %s", strings.Join(names, ", "), action))
+		}
+	}
+
+	var weapons []string
+	for _, w := range game.Weapons {
+		weapons = append(weapons, fmt.Sprintf("%d %s", w.Slot, w.Name))
+	}
+	lines = append(lines, "Weapons: "+strings.Join(weapons, ", "))
+	var items []string
+	for _, item := range game.Items {
+		items = append(items, fmt.Sprintf("F%d %s", 4+item.Slot, item.Name))
+	}
+	lines = append(lines, "Use items: "+strings.Join(items, ", "))
+	var emotes []string
+	for i, emote := range game.Emotes {
+		emotes = append(emotes, fmt.Sprintf("F%d %s", 1+i, emote.Name))
+	}
+	lines = append(lines, "Emotes: "+strings.Join(emotes, ", "))
+	return append(lines,
+		"Enter: Chat",
+		"/ or ~: Commands (/help lists them)",
+		fmt.Sprintf("%c: This help", helpKey),
+		"Esc: Quit",
+		fmt.Sprintf("Keys: %s (/keys to switch presets)", keymap.Name),
+	)
+}
+
+// keyName returns how a key is written in the help
+func keyName(r rune) string {
+	if r == ' ' {
+		return "Space"
+	}
+	return strings.ToUpper(string(r))
+}
+
+// printHelp prints the whole help as text, for players in text-only mode
+func (s *Session) printHelp() {
+	for _, section := range s.helpSections() {
+		s.print(section.title + ":\r\n")
+		for _, line := range section.lines {
+			s.print("- " + line + "\r\n")
+		}
+	}
+}
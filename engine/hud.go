@@ -0,0 +1,41 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/screen"
+	"github.com/imjasonh/terminus/server"
+)
+
+// largeHUD draws the player's health and the current weapon's ammo in big
+// block digits for the HUD panel, for players who asked for a large HUD, or
+// returns nil for the usual HUD
+func largeHUD(player *game.Player, playerSession *server.PlayerSession) []string {
+	if !playerSession.LargeHUD {
+		return nil
+	}
+	weapon := game.GetWeapon(player.Weapon)
+	health := screen.BigText(fmt.Sprintf("%.0f", math.Ceil(player.Health)))
+	ammo := screen.BigText(fmt.Sprintf("%d/%d", player.Ammo[player.Weapon], weapon.Magazine))
+	healthLabel, ammoLabel := playerSession.T("HP"), playerSession.T("AMMO")
+
+	rows := make([]string, screen.BigTextRows)
+	for i := range rows {
+		// Labels beside the middle row of digits
+		left, right := "", ""
+		if i == screen.BigTextRows/2 {
+			left, right = healthLabel, ammoLabel
+		}
+		rows[i] = " " + pad(left, healthLabel) + " " + health[i] + "    " + pad(right, ammoLabel) + " " + ammo[i]
+	}
+	return rows
+}
+
+// pad pads text with spaces to be as wide as another label
+func pad(text, label string) string {
+	return text + strings.Repeat(" ", max(0, utf8.RuneCountInString(label)-utf8.RuneCountInString(text)))
+}
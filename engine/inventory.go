@@ -0,0 +1,22 @@
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/server"
+)
+
+// inventorySlots describes the player's inventory for the status line: each
+// slot they carry something in, with the function key that uses it and how
+// many, like "F6 Potion 2 F7 Grenade 1". It's empty if they carry nothing.
+func inventorySlots(player *game.Player, playerSession *server.PlayerSession) string {
+	var slots []string
+	for _, item := range game.Items {
+		if count := player.Inventory.Count(item.Type); count > 0 {
+			slots = append(slots, fmt.Sprintf("F%d %s %d", 4+item.Slot, playerSession.T(item.Name), count))
+		}
+	}
+	return strings.Join(slots, " ")
+}
@@ -0,0 +1,29 @@
+package engine
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Frame timings, which the global meter provider sends nowhere unless the
+// server exports telemetry. Durations are in milliseconds.
+var (
+	meter           = otel.Meter("github.com/imjasonh/terminus/engine")
+	durationBuckets = metric.WithExplicitBucketBoundaries(0.1, 0.25, 0.5, 1, 2.5, 5, 10, 25, 50, 100, 250)
+
+	renderDuration, _ = meter.Float64Histogram("terminus.frame.render.duration",
+		metric.WithDescription("Time to draw a frame for a player and queue it to be sent"),
+		metric.WithUnit("ms"),
+		durationBuckets)
+	writeDuration, _ = meter.Float64Histogram("terminus.output.write.duration",
+		metric.WithDescription("Time to write output to a player's connection, like SSH"),
+		metric.WithUnit("ms"),
+		durationBuckets)
+)
+
+// Attributes of writes, by what was written
+var (
+	frameWrite = metric.WithAttributeSet(attribute.NewSet(attribute.String("output", "frame")))
+	textWrite  = metric.WithAttributeSet(attribute.NewSet(attribute.String("output", "text")))
+)
@@ -0,0 +1,35 @@
+package engine
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/renderer"
+	"github.com/imjasonh/terminus/screen"
+	"github.com/imjasonh/terminus/server"
+)
+
+// drawParty overlays the player's party members at the top right of the game
+// area, each with an arrow pointing toward them and how far away they are. It
+// returns how many rows it drew.
+func drawParty(s *screen.Screen, view *game.Player, snapshot *server.Snapshot, members []*server.PlayerSession) int {
+	if len(members) == 0 {
+		return 0
+	}
+
+	fg := color.RGBA{120, 255, 120, 255}
+	bg := color.RGBA{10, 30, 10, 255}
+	row := 0
+	for _, member := range members {
+		state, ok := snapshot.Player(member.ID)
+		if !ok {
+			continue // Joined since the snapshot
+		}
+		target := state.Player.Position
+		line := fmt.Sprintf(" %s %c %.0fm ", state.Name, renderer.DirectionArrow(view, target), target.Sub(view.Position).Length())
+		s.DrawText(s.Width-len([]rune(line)), row, line, fg, bg)
+		row++
+	}
+	return row
+}
@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/renderer"
+	"github.com/imjasonh/terminus/screen"
+)
+
+// proximityRange is how close, in cells, another player must be to show at
+// the edge of the view while they're outside it
+const proximityRange = 2.0
+
+// proximityBehind is how far, in degrees from straight ahead, a player must
+// be to count as behind rather than beside
+const proximityBehind = 135.0
+
+// drawProximity marks the edges of the game area toward other players who are
+// very close but outside the view cone, so no one can sneak up unnoticed: the
+// left or right edge for those beside the player, and the bottom corner on
+// their side for those behind. Marks are dim, brightening as the other player gets closer.
+func drawProximity(s *screen.Screen, view *game.Player, others []*game.Player) {
+	bg := color.RGBA{0, 0, 0, 255}
+	for _, other := range others {
+		if other.IsInvisible() {
+			continue
+		}
+		distance := other.Position.Sub(view.Position).Length()
+		offset := renderer.RelativeBearing(view, other.Position)
+		if distance > proximityRange || math.Abs(offset) <= view.FOV()/2 {
+			continue
+		}
+
+		// Fade from white when touching to gray at the edge of the range
+		level := uint8(255 - 135*distance/proximityRange)
+		fg := color.RGBA{level, level, level, 255}
+		switch {
+		case offset < -proximityBehind:
+			s.SetCell(0, s.GameHeight-1, '◣', fg, bg)
+		case offset > proximityBehind:
+			s.SetCell(s.Width-1, s.GameHeight-1, '◢', fg, bg)
+		case offset < 0:
+			s.SetCell(0, s.GameHeight/2, '◀', fg, bg)
+		default:
+			s.SetCell(s.Width-1, s.GameHeight/2, '▶', fg, bg)
+		}
+	}
+}
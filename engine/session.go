@@ -0,0 +1,489 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/imjasonh/terminus/command"
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/input"
+	"github.com/imjasonh/terminus/renderer"
+	"github.com/imjasonh/terminus/screen"
+	"github.com/imjasonh/terminus/server"
+)
+
+// MaxKeysPerTick caps how many keys a session processes per game loop tick
+const MaxKeysPerTick = 32
+
+// describeInterval is how often scene descriptions are printed in text-only mode
+const describeInterval = 2 * time.Second
+
+// Session runs the game loop for one player: it applies their input to the
+// shared game, and renders the world from their point of view
+type Session struct {
+	Server        *server.GameServer
+	Player        *server.PlayerSession
+	Commands      *command.Registry // Commands the player's console can run
+	Input         InputSource
+	Output        FrameSink
+	Clock         Clock
+	FrameInterval time.Duration // Time between frames; the simulation steps independently
+	// BandwidthBudget is how many bytes per second the session may send before
+	// its frames are degraded to fit; 0 for no limit
+	BandwidthBudget int
+
+	out       *FrameWriter // Sends to Output without blocking the game loop
+	sprinting bool         // Whether the player toggled sprinting on, so moving sprints
+}
+
+// Run plays until the player quits, is kicked, or disconnects, rendering with
+// the given terminal capabilities and initial size. It returns an error if
+// output couldn't be written.
+func (s *Session) Run(caps screen.Capabilities, size Size) error {
+	playerSession := s.Player
+	gameServer := s.Server
+
+	width, height := size.Width, size.Height
+	if width <= 0 || height <= 0 {
+		width, height = 80, 24 // Default fallback
+	}
+	gameScreen := screen.NewScreen(width, height)
+	gameScreen.SetCapabilities(caps)
+	gameRenderer := renderer.NewRenderer(width, height)
+
+	// Degrade frames while the session sends more than its bandwidth budget,
+	// and never send more colors than the player asked for
+	bandwidth := &bandwidthGovernor{budget: s.BandwidthBudget}
+	applyQuality := func() {
+		limited := caps
+		limited.ColorDepth = max(limited.ColorDepth, playerSession.ColorLimit)
+		gameScreen.SetCapabilities(bandwidth.level.apply(limited))
+		gameScreen.SetDiff(bandwidth.level >= qualityDiff)
+	}
+	var frames int
+
+	// Write from a separate goroutine, dropping stale frames if the connection is slow
+	s.out = NewFrameWriter(s.Output)
+	defer func() {
+		s.out.Close()
+		if dropped := s.out.Dropped(); dropped > 0 {
+			playerSession.Log.Debugf("Dropped %d frames for a slow connection", dropped)
+		}
+	}()
+
+	// Clear screen
+	s.print("\x1b[2J\x1b[H")
+
+	// Frame loop
+	ticker := s.Clock.NewTicker(s.FrameInterval)
+	defer ticker.Stop()
+
+	// Keys count as held briefly after each press; simulation steps apply the movement
+	holds := playerSession.Holds
+
+	// Per-session command console
+	con := &console{commands: s.Commands, clock: s.Clock}
+	help := &helpOverlay{}
+	notice := func(msg string) {
+		if playerSession.AccessMode == server.AccessTextOnly {
+			s.print(msg + "\r\n")
+		} else {
+			con.print(msg)
+		}
+	}
+
+	// Show chat, including players coming and going, in its own overlay, and
+	// catch up on what was said before the player joined
+	chat := &chatOverlay{clock: s.Clock}
+	showChat := func(e server.Event) {
+		if playerSession.AccessMode == server.AccessTextOnly {
+			s.print(server.ChatLine(e, playerSession.Language) + "\r\n")
+		} else {
+			chat.add(e, playerSession.Language)
+		}
+	}
+
+	// Tell the player about chat, renames, map changes, deaths, the time of
+	// day, match results, and records, show others' emotes, ring the bell for
+	// their pickups, and show sounds out of view
+	kinds := append([]server.EventKind{server.EventRenamed, server.EventMapChanged, server.EventEmote, server.EventKilled, server.EventTimeOfDay, server.EventMatchEnded, server.EventRecordBroken, server.EventPickedUp, server.EventFired, server.EventExploded, server.EventUsedItem}, server.ChatKinds...)
+	feed := gameServer.Events.Subscribe(kinds...)
+	defer feed.Close()
+	chat.catchUp(gameServer.Chat.History(playerSession.ID), playerSession.Language)
+	var bubbles server.EmoteBubbles
+	var ding bell
+	var sounds soundCues
+
+	// Keep the latest two snapshots of the world to draw between
+	var snapshots server.SnapshotBuffer
+	latest, nextSnapshot := gameServer.LatestSnapshot()
+	snapshots.Push(latest)
+
+	// When the last frame was drawn, to hold players to the frame rate they chose
+	var lastFrame time.Time
+
+	// Text-mode scene description state
+	var lastDescribed time.Time
+	var lastDescription string
+
+	for {
+		select {
+		case <-ticker.C():
+			currentTime := s.Clock.Now()
+
+			// Process input
+			previousMode, previousColors := playerSession.AccessMode, playerSession.ColorLimit
+			if !s.processInput(holds, con, help) {
+				return nil // Player requested exit
+			}
+			if playerSession.ColorLimit != previousColors {
+				applyQuality()
+			}
+			if playerSession.AccessMode != previousMode {
+				// Start each mode from a clean screen
+				s.print("\x1b[2J\x1b[H")
+				gameScreen.Invalidate()
+				lastDescription = ""
+				if playerSession.AccessMode == server.AccessTextOnly {
+					toggleKeys := string(playerSession.Keymap.KeysFor(input.ActionToggleAccess))
+					s.print(playerSession.T("Text mode. Press %s to return to graphics.", strings.ToUpper(toggleKeys)) + "\r\n")
+				}
+			}
+
+			// Draw everything, including the player's own view, between the latest
+			// two snapshots so motion is smooth at any frame and tick rate
+			snapshot := snapshots.At(currentTime)
+			self, ok := snapshot.Player(playerSession.ID)
+			if !ok {
+				continue // Joined since the latest step
+			}
+			view := &self.Player
+			if ding.hurt(view) {
+				ding.ring(s, currentTime)
+			}
+			worldMap := snapshot.Map.Floor(view.Floor)
+			otherPlayers := slices.DeleteFunc(snapshot.OtherPlayers(playerSession.ID), func(other *game.Player) bool {
+				return other.Floor != view.Floor
+			})
+			entities := snapshot.VisibleEntities(playerSession.ID, bubbles.Labels(snapshot, view.Position, currentTime))
+
+			// Text-only mode prints a scene description when it changes, at most every couple of seconds
+			if playerSession.AccessMode == server.AccessTextOnly {
+				if currentTime.Sub(lastDescribed) >= describeInterval {
+					description := renderer.DescribeScene(view, worldMap, entities)
+					if description != lastDescription {
+						s.print(description + "\r\n")
+						lastDescription = description
+					}
+					lastDescribed = currentTime
+				}
+				continue
+			}
+
+			// Skip frames the player asked not to be drawn, with half a tick of
+			// slack so a rate that divides the server's lands on every few ticks
+			if rate := playerSession.FrameRate; rate > 0 && currentTime.Sub(lastFrame) < time.Second/time.Duration(rate)-s.FrameInterval/2 {
+				continue
+			}
+
+			// Skip frames while the connection is still sending the last one, or to
+			// halve the frame rate when over the bandwidth budget
+			if s.out.FramePending() {
+				continue
+			}
+			lastFrame = currentTime
+			frames++
+			if bandwidth.level >= qualityLowFrameRate && frames%2 == 0 {
+				continue
+			}
+
+			// Create debug message including server info
+			player := view
+			playerCount := len(snapshot.Players)
+			activeCount := 0
+			var nearestFireball *game.Projectile
+			for i, p := range snapshot.Projectiles {
+				if p.Type == game.Fireball {
+					activeCount++
+					if nearestFireball == nil {
+						nearestFireball = &snapshot.Projectiles[i]
+					}
+				}
+			}
+
+			debugMsg := fmt.Sprintf("Player: (%.1f,%.1f) | Players: %d/%d | FB: %d",
+				player.Position.X, player.Position.Y, playerCount, gameServer.MaxPlayers, activeCount)
+
+			if nearestFireball != nil {
+				debugMsg = fmt.Sprintf("Player: (%.1f,%.1f) | Players: %d/%d | FB: %d at (%.1f,%.1f)",
+					player.Position.X, player.Position.Y, playerCount, gameServer.MaxPlayers, activeCount,
+					nearestFireball.Position.X, nearestFireball.Position.Y)
+			}
+
+			// Scene descriptions supplement the graphics in the HUD
+			if playerSession.AccessMode == server.AccessSupplement {
+				debugMsg = renderer.DescribeScene(view, worldMap, entities)
+			}
+
+			gameScreen.SetDebugMessage(debugMsg)
+
+			// Current weapon, health, stamina meter, and inventory
+			weapon := game.GetWeapon(player.Weapon)
+			stamina := screen.Meter(playerSession.T("STA"), player.Stamina, game.MaxStamina, 5)
+			if player.IsExhausted() {
+				// As wide as the meter, so the status line fits
+				stamina = fmt.Sprintf("%s %-5.5s", playerSession.T("STA"), playerSession.T("TIRED"))
+			}
+			status := fmt.Sprintf("[%d] %s | %s %.0f | %s", weapon.Slot, playerSession.T(weapon.Name), playerSession.T("HP"), math.Ceil(player.Health), stamina)
+			if inventory := inventorySlots(player, playerSession); inventory != "" {
+				status += " | " + inventory
+			}
+			if len(snapshot.Map.Floors) > 0 {
+				// Which floor they're on, on maps with more than one
+				status = playerSession.T("F%d", view.Floor+1) + " | " + status
+			}
+			gameScreen.SetStatus(status)
+			gameScreen.SetPanel(largeHUD(player, playerSession))
+
+			// Compass with markers for other players, except those hidden by
+			// invisibility or the dark
+			lights := snapshot.Lights(playerSession.ReducedMotion)
+			var markers []renderer.CompassMarker
+			for _, other := range otherPlayers {
+				if other.IsInvisible() || !renderer.Visible(view, other.Position, worldMap, lights) {
+					continue
+				}
+				markers = append(markers, renderer.CompassMarker{Position: other.Position, Glyph: other.Sprite().Glyph})
+			}
+			gameScreen.SetCompass(renderer.BuildCompass(gameScreen.Width, view, markers))
+
+			// Render the game with other players, NPCs, and shared projectiles,
+			// lit by the time of day
+			gameRenderer.Ambient = snapshot.Ambient
+			gameRenderer.Theme, _ = renderer.ParseTheme(playerSession.Theme)
+			gameRenderer.Monochrome = gameScreen.Capabilities().ColorDepth == screen.Monochrome
+			gameRenderer.Render(view, worldMap, gameScreen, lights, entities)
+			drawProximity(gameScreen, view, otherPlayers)
+			sounds.footsteps(snapshot, playerSession, currentTime)
+			sounds.draw(gameScreen, view, worldMap, playerSession, currentTime)
+			drawWeapon(gameScreen, view)
+			drawTorch(gameScreen, view)
+			chat.draw(gameScreen, con.open && con.chat)
+			rows := drawParty(gameScreen, view, snapshot, gameServer.PartyMembers(playerSession))
+			drawEffects(gameScreen, view, rows)
+			con.draw(gameScreen)
+			help.draw(gameScreen, playerSession)
+			frame := gameScreen.Frame()
+			if err := s.out.WriteFrame(frame); err != nil {
+				return fmt.Errorf("writing frame: %w", err)
+			}
+			drawn := s.Clock.Now().Sub(currentTime)
+			playerSession.Frames.Record(drawn)
+			renderDuration.Record(context.Background(), float64(drawn)/float64(time.Millisecond))
+			if bandwidth.record(currentTime, len(frame)) {
+				applyQuality()
+				playerSession.Log.Debugf("Bandwidth budget: %s", bandwidth.level)
+				con.print("Connection: " + bandwidth.level.String())
+			}
+
+		case <-nextSnapshot:
+			latest, nextSnapshot = gameServer.LatestSnapshot()
+			snapshots.Push(latest)
+
+		case msg := <-playerSession.Notices():
+			notice(msg)
+
+		case event := <-feed.Events():
+			if personal(event, playerSession) {
+				ding.ring(s, s.Clock.Now())
+			}
+			switch {
+			case !event.For(playerSession.ID):
+			case event.Kind == server.EventPickedUp: // Only for the bell; the player is notified directly
+			case event.Kind == server.EventFired, event.Kind == server.EventExploded, event.Kind == server.EventUsedItem:
+				sounds.event(event, playerSession, s.Clock.Now())
+			case event.Kind == server.EventChat, event.Kind == server.EventTeamChat, event.Kind == server.EventWhisper:
+				showChat(event)
+			case event.SessionID == playerSession.ID:
+			case event.Kind == server.EventJoined, event.Kind == server.EventLeft:
+				showChat(event)
+			case event.Kind == server.EventEmote:
+				bubbles.Show(event)
+				if playerSession.AccessMode == server.AccessTextOnly && nearby(latest, playerSession.ID, event.SessionID) {
+					notice(event.Text(playerSession.Language))
+				}
+			default:
+				notice(event.Text(playerSession.Language))
+			}
+
+		case <-playerSession.Kicked():
+			s.print(fmt.Sprintf("\x1b[0m\x1b[2J\x1b[H%s\r\n", playerSession.KickReason()))
+			return nil
+
+		case size := <-s.Input.Resizes():
+			// Handle terminal resize
+			if size.Width > 0 && size.Height > 0 {
+				gameScreen.Resize(size.Width, size.Height)
+				gameRenderer.Resize(size.Width, size.Height)
+			}
+
+		case <-s.Input.Done():
+			return nil
+		}
+	}
+}
+
+// processInput handles the player's pending input. Movement keys mark actions
+// as held; the server's simulation steps apply the movement. It returns false
+// if the player asked to exit.
+func (s *Session) processInput(holds *input.HoldTracker, con *console, help *helpOverlay) bool {
+	ctx := &command.Context{Server: s.Server, Session: s.Player}
+
+	// Process available input, up to a cap so a flood of bytes can't starve the game loop
+	for range MaxKeysPerTick {
+		select {
+		case key := <-s.Input.Keys():
+			// The console and help take all input while they're open, except Ctrl+C
+			if !key.Is(3) && (help.handleKey(key) || con.handleKey(key, ctx)) {
+				continue
+			}
+			if !s.handleGameKey(key, holds, con, help) {
+				return false
+			}
+		default:
+			return true // No more input to process, continue game loop
+		}
+	}
+	return true // Leave the rest for the next tick
+}
+
+// handleGameKey applies a gameplay key using the player's keymap, returning
+// false if they asked to exit
+func (s *Session) handleGameKey(key input.Key, holds *input.HoldTracker, con *console, help *helpOverlay) bool {
+	playerSession := s.Player
+	player := playerSession.Player
+
+	switch key.Code {
+	case input.KeyF1, input.KeyF2, input.KeyF3:
+		s.Server.Emote(playerSession, game.Emotes[key.Code-input.KeyF1])
+	case input.KeyF5, input.KeyF6, input.KeyF7, input.KeyF8:
+		// Use the item in an inventory slot
+		if item, ok := game.ItemInSlot(int(key.Code - input.KeyF4)); ok {
+			result, err := s.Server.UseItem(playerSession, item.Type)
+			if err != nil {
+				result = err.Error()
+			}
+			con.print(result)
+		}
+	case input.KeyUp:
+		holds.Press(input.ActionMoveForward)
+	case input.KeyDown:
+		holds.Press(input.ActionMoveBackward)
+	case input.KeyLeft:
+		holds.Press(input.ActionTurnLeft)
+	case input.KeyRight:
+		holds.Press(input.ActionTurnRight)
+	case input.KeyEscape:
+		s.print("\x1b[?25h\x1b[2J\x1b[H") // Show cursor and clear screen
+		playerSession.Log.Debug("Player quit")
+		return false
+	case input.KeyRune:
+		switch key.Rune {
+		case 3: // Ctrl+C
+			s.print("\x1b[?25h\x1b[2J\x1b[H") // Show cursor and clear screen
+			playerSession.Log.Debug("Player quit")
+			return false
+		case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			// Select a weapon directly
+			if weapon, ok := game.WeaponInSlot(int(key.Rune - '0')); ok {
+				s.Server.SwitchWeapon(playerSession, weapon.Type)
+			}
+			return true
+		case helpKey:
+			if playerSession.AccessMode == server.AccessTextOnly {
+				s.printHelp()
+			} else {
+				help.show(s.helpSections())
+			}
+			return true
+		}
+
+		action, ok := playerSession.Keymap.Lookup(key.Rune)
+		if !ok {
+			return true
+		}
+
+		// The sprint key toggles sprinting, since terminals don't say when
+		// keys are let go; while it's on, movement sprints
+		if action == input.ActionSprint {
+			s.sprinting = !s.sprinting
+			if s.sprinting {
+				con.print(playerSession.T("Sprint on"))
+			} else {
+				holds.Release(input.ActionSprint)
+				con.print(playerSession.T("Sprint off"))
+			}
+			return true
+		}
+		if action.IsMovement() && s.sprinting {
+			holds.Press(input.ActionSprint)
+		}
+
+		if action.IsContinuous() {
+			holds.Press(action)
+			return true
+		}
+
+		switch action {
+		case input.ActionFire:
+			// Fire the current weapon (shared projectile system, rate limited by
+			// the server); continuous weapons fire each step while it's held
+			if game.GetWeapon(player.Weapon).Continuous {
+				holds.Press(input.ActionFire)
+			} else {
+				s.Server.FireProjectile(playerSession)
+			}
+		case input.ActionLastWeapon:
+			s.Server.SwitchWeapon(playerSession, player.LastWeapon)
+		case input.ActionReload:
+			s.Server.Reload(playerSession)
+		case input.ActionTorch:
+			con.print(s.Server.ToggleTorch(playerSession))
+		case input.ActionSwapTurnStrafe:
+			if playerSession.Allow(server.ActionToggle) {
+				playerSession.Keymap.SwapTurnStrafe()
+				if playerSession.Keymap.TurnStrafeSwapped {
+					con.print(playerSession.T("Classic controls: strafe keys turn, turn keys strafe"))
+				} else {
+					con.print(playerSession.T("Standard controls restored"))
+				}
+			}
+		case input.ActionToggleAccess:
+			// Cycle accessible text-description modes
+			if playerSession.Allow(server.ActionToggle) {
+				playerSession.AccessMode = playerSession.AccessMode.Next()
+				playerSession.Log.Debugf("Access mode: %s", playerSession.AccessMode)
+			}
+		}
+	}
+	return true
+}
+
+// nearby reports whether two players are within emote range in a snapshot
+func nearby(snap *server.Snapshot, a, b string) bool {
+	first, ok := snap.Player(a)
+	if !ok {
+		return false
+	}
+	second, ok := snap.Player(b)
+	return ok && first.Player.Position.Sub(second.Player.Position).Length() <= game.EmoteRange
+}
+
+// print writes text to the player's terminal, like a status line or escape sequence
+func (s *Session) print(text string) {
+	s.out.Print(text)
+}
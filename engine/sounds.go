@@ -0,0 +1,163 @@
+package engine
+
+import (
+	"image/color"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/renderer"
+	"github.com/imjasonh/terminus/screen"
+	"github.com/imjasonh/terminus/server"
+)
+
+// soundDuration is how long a sound cue stays on screen
+const soundDuration = time.Second
+
+// footstepInterval is the least time between footstep cues for one player
+const footstepInterval = 600 * time.Millisecond
+
+// sound is a kind of noise the game can make, and how far away it's heard
+type sound struct {
+	word  string  // Shown at the edge of the screen, like "BANG"
+	reach float64 // Cells away it can be heard from
+}
+
+// The sounds the game makes
+var (
+	soundShot      = sound{word: "BANG", reach: 16}
+	soundExplosion = sound{word: "BOOM", reach: 24}
+	soundFootstep  = sound{word: "step", reach: 5}
+	soundDoor      = sound{word: "CLUNK", reach: 10}
+)
+
+// soundCue is a sound heard out of view, shown briefly at the edge of the
+// screen toward where it came from
+type soundCue struct {
+	sound    sound
+	position game.Vector
+	heard    time.Time
+}
+
+// soundCues stands in for audio: it hears shots, explosions, footsteps, and
+// doors near the player that they can't see, and shows each briefly at the
+// edge of the screen on its side, with an arrow toward it, brighter and in
+// capitals when it's close and loud
+type soundCues struct {
+	cues  []soundCue
+	steps map[string]game.Vector // Other players' positions as of the last frame, to hear them walk
+	next  map[string]game.Vector // Scratch space for the positions as of this frame
+	heard map[string]time.Time   // When each player's footsteps were last shown
+}
+
+// event hears the sound an event makes, if it makes one and it wasn't the
+// player's own doing
+func (c *soundCues) event(e server.Event, playerSession *server.PlayerSession, now time.Time) {
+	if e.SessionID == playerSession.ID {
+		return
+	}
+	switch {
+	case e.Kind == server.EventFired:
+		c.hear(soundShot, e.Position, now)
+	case e.Kind == server.EventExploded:
+		c.hear(soundExplosion, e.Position, now)
+	case e.Kind == server.EventUsedItem && e.Detail == game.GetItem(game.ItemKey).Name:
+		c.hear(soundDoor, e.Position, now)
+	}
+}
+
+// footsteps hears other players who moved since the last frame, at most every
+// footstepInterval each. Invisible players are silent, as well as unseen.
+func (c *soundCues) footsteps(snapshot *server.Snapshot, playerSession *server.PlayerSession, now time.Time) {
+	if c.steps == nil {
+		c.steps = make(map[string]game.Vector)
+		c.next = make(map[string]game.Vector)
+		c.heard = make(map[string]time.Time)
+	}
+	defer func() {
+		// Forget players who left
+		c.steps, c.next = c.next, c.steps
+		clear(c.next)
+		for id := range c.heard {
+			if _, ok := c.steps[id]; !ok {
+				delete(c.heard, id)
+			}
+		}
+	}()
+	for _, state := range snapshot.Players {
+		if state.ID == playerSession.ID {
+			continue
+		}
+		last, ok := c.steps[state.ID]
+		c.next[state.ID] = state.Player.Position
+		if !ok || state.Player.IsInvisible() || state.Player.Position.Sub(last).Length() < 0.01 {
+			continue
+		}
+		if now.Sub(c.heard[state.ID]) >= footstepInterval {
+			c.heard[state.ID] = now
+			c.hear(soundFootstep, state.Player.Position, now)
+		}
+	}
+}
+
+// hear remembers a sound until it's been shown for soundDuration. Repeats of
+// a sound from about the same place, like a flamethrower's steady fire, keep
+// the one cue showing instead of piling up.
+func (c *soundCues) hear(s sound, position game.Vector, now time.Time) {
+	for i := range c.cues {
+		if c.cues[i].sound == s && c.cues[i].position.Sub(position).Length() < 2 {
+			c.cues[i].position, c.cues[i].heard = position, now
+			return
+		}
+	}
+	c.cues = append(c.cues, soundCue{sound: s, position: position, heard: now})
+}
+
+// draw shows the sounds the player can hear but not see, stacked down each
+// side of the game area from a third of the way down, and forgets old ones
+func (c *soundCues) draw(s *screen.Screen, view *game.Player, worldMap *game.Map, playerSession *server.PlayerSession, now time.Time) {
+	bg := color.RGBA{0, 0, 0, 255}
+	kept := c.cues[:0]
+	left, right := 0, 0
+	for _, cue := range c.cues {
+		if now.Sub(cue.heard) >= soundDuration {
+			continue
+		}
+		kept = append(kept, cue)
+
+		loudness := 1 - cue.position.Sub(view.Position).Length()/cue.sound.reach
+		if loudness <= 0 || inView(view, cue.position, worldMap) {
+			continue
+		}
+
+		// Loud sounds shout, in white; faint ones murmur, in gray
+		word := strings.ToLower(playerSession.T(cue.sound.word))
+		if loudness > 0.5 {
+			word = strings.ToUpper(word)
+		}
+		level := uint8(120 + 135*loudness)
+		fg := color.RGBA{level, level, level, 255}
+		arrow := string(renderer.DirectionArrow(view, cue.position))
+		if renderer.RelativeBearing(view, cue.position) < 0 {
+			s.DrawText(1, s.GameHeight/3+left, arrow+" "+word, fg, bg)
+			left++
+		} else {
+			text := word + " " + arrow
+			s.DrawText(s.Width-1-len([]rune(text)), s.GameHeight/3+right, text, fg, bg)
+			right++
+		}
+	}
+	c.cues = kept
+}
+
+// inView reports whether the player can see a position: it's within their
+// field of view, with no wall in between
+func inView(view *game.Player, position game.Vector, worldMap *game.Map) bool {
+	if math.Abs(renderer.RelativeBearing(view, position)) > view.FOV()/2 {
+		return false
+	}
+	toward := position.Sub(view.Position)
+	distance := toward.Length()
+	return distance == 0 || game.CastRay(view.Position, toward.Normalize(), worldMap).Distance >= distance
+}
@@ -0,0 +1,28 @@
+package engine
+
+import (
+	"image/color"
+
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/screen"
+)
+
+// Torch meter colors
+var (
+	torchColor   = color.RGBA{255, 200, 120, 255}
+	torchUnlitFg = color.RGBA{140, 120, 100, 255}
+	torchBg      = color.RGBA{40, 30, 20, 255}
+)
+
+// drawTorch overlays the fuel left in the player's torch at the bottom left
+// of the game area, while it's lit or still refueling
+func drawTorch(s *screen.Screen, view *game.Player) {
+	if !view.TorchLit && view.TorchFuel >= game.MaxTorchFuel {
+		return
+	}
+	fg := torchColor
+	if !view.TorchLit {
+		fg = torchUnlitFg
+	}
+	s.DrawText(1, s.GameHeight-1, " "+screen.Meter("TORCH", view.TorchFuel, game.MaxTorchFuel, 7)+" ", fg, torchBg)
+}
@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/screen"
+)
+
+// weaponBarWidth is how many cells wide the weapon cooldown and reload bars are
+const weaponBarWidth = 7
+
+// Weapon status colors
+var (
+	weaponStatusColor = color.RGBA{255, 150, 0, 255}
+	weaponReloadColor = color.RGBA{120, 200, 255, 255}
+	weaponStatusBg    = color.RGBA{40, 30, 20, 255}
+)
+
+// drawWeapon overlays the current weapon's state around it at the bottom
+// center of the game area: the shots left in its magazine beside it, and
+// above it a bar that fills up while it charges, reloads, or cools down
+// after firing
+func drawWeapon(s *screen.Screen, view *game.Player) {
+	weapon := game.GetWeapon(view.Weapon)
+	ammo := fmt.Sprintf(" %d/%d ", view.Ammo[view.Weapon], weapon.Magazine)
+	s.DrawText(s.Width/2+2, s.GameHeight-1, ammo, weaponStatusColor, weaponStatusBg)
+
+	if progress := view.ChargeProgress(); progress > 0 {
+		bar := screen.Meter("CHARGE", progress, 1, weaponBarWidth)
+		s.DrawText(s.Width/2-len([]rune(bar))/2, s.GameHeight-4, bar, game.RailColor, weaponStatusBg)
+		return
+	}
+	if progress := view.ReloadProgress(); progress > 0 {
+		bar := screen.Meter("RELOAD", progress, 1, weaponBarWidth)
+		s.DrawText(s.Width/2-len([]rune(bar))/2, s.GameHeight-4, bar, weaponReloadColor, weaponStatusBg)
+		return
+	}
+	if remaining := view.Cooldown(); remaining > 0 {
+		filled := int(math.Round((1 - remaining) * weaponBarWidth))
+		bar := strings.Repeat("█", filled) + strings.Repeat("░", weaponBarWidth-filled)
+		s.DrawText(s.Width/2-weaponBarWidth/2, s.GameHeight-4, bar, weaponStatusColor, weaponStatusBg)
+	}
+}
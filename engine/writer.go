@@ -0,0 +1,167 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// maxQueuedOutput caps how many writes other than frames, like notices and
+// scene descriptions, may wait for a slow connection before more are dropped
+const maxQueuedOutput = 64
+
+// flushTimeout is how long Close waits for queued output to reach a connection
+const flushTimeout = time.Second
+
+// FrameWriter sends output to a FrameSink from its own goroutine, so a stalled
+// connection can't hold up the game loop. Frames redraw the whole screen, so
+// only the newest waits to be sent: a frame queued behind a slow write is
+// replaced by the next one rather than buffered. Other output is sent in order.
+type FrameWriter struct {
+	sink FrameSink
+
+	mu      sync.Mutex
+	ready   *sync.Cond
+	queue   []queuedOutput
+	spare   [][]byte // Buffers from sent or dropped frames, for reuse
+	closed  bool
+	err     error // The first write error; output is discarded after one
+	dropped int   // Stale frames and overflowing output that were never sent
+
+	stopped chan struct{}
+}
+
+// queuedOutput is a write waiting to be sent
+type queuedOutput struct {
+	data  []byte
+	frame bool
+}
+
+// NewFrameWriter starts a writer sending to sink. Close it when done.
+func NewFrameWriter(sink FrameSink) *FrameWriter {
+	w := &FrameWriter{sink: sink, stopped: make(chan struct{})}
+	w.ready = sync.NewCond(&w.mu)
+	go w.run()
+	return w
+}
+
+// WriteFrame queues a full frame, replacing any frame that hasn't been sent
+// yet. The frame is copied, so the caller may reuse its buffer. It returns
+// the error from an earlier failed write, if any.
+func (w *FrameWriter) WriteFrame(frame []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil || w.closed {
+		return w.err
+	}
+
+	// Drop stale frames still waiting to be sent
+	kept := w.queue[:0]
+	for _, out := range w.queue {
+		if out.frame {
+			w.spare = append(w.spare, out.data[:0])
+			w.dropped++
+		} else {
+			kept = append(kept, out)
+		}
+	}
+	clear(w.queue[len(kept):])
+	w.queue = kept
+
+	var buf []byte
+	if n := len(w.spare); n > 0 {
+		buf, w.spare = w.spare[n-1], w.spare[:n-1]
+	}
+	w.queue = append(w.queue, queuedOutput{data: append(buf, frame...), frame: true})
+	w.ready.Signal()
+	return nil
+}
+
+// Print queues text like an escape sequence or a line of output, to be sent
+// after everything queued before it. If too much output is already waiting,
+// the text is dropped.
+func (w *FrameWriter) Print(text string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil || w.closed {
+		return w.err
+	}
+	if len(w.queue) >= maxQueuedOutput {
+		w.dropped++
+		return nil
+	}
+	w.queue = append(w.queue, queuedOutput{data: []byte(text)})
+	w.ready.Signal()
+	return nil
+}
+
+// FramePending reports whether a frame is still waiting to be sent, meaning
+// the connection isn't keeping up
+func (w *FrameWriter) FramePending() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, out := range w.queue {
+		if out.frame {
+			return true
+		}
+	}
+	return false
+}
+
+// Dropped returns how many frames and other writes were dropped rather than sent
+func (w *FrameWriter) Dropped() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.dropped
+}
+
+// Close stops accepting output and waits briefly for what's queued to be sent
+func (w *FrameWriter) Close() {
+	w.mu.Lock()
+	w.closed = true
+	w.ready.Signal()
+	w.mu.Unlock()
+
+	select {
+	case <-w.stopped:
+	case <-time.After(flushTimeout):
+	}
+}
+
+// run sends queued output in order until the writer is closed and drained
+func (w *FrameWriter) run() {
+	defer close(w.stopped)
+	for {
+		w.mu.Lock()
+		for len(w.queue) == 0 && !w.closed {
+			w.ready.Wait()
+		}
+		if len(w.queue) == 0 {
+			w.mu.Unlock()
+			return
+		}
+		out := w.queue[0]
+		w.queue[0] = queuedOutput{}
+		w.queue = w.queue[1:]
+		w.mu.Unlock()
+
+		start := time.Now()
+		err := w.sink.WriteFrame(out.data)
+		kind := textWrite
+		if out.frame {
+			kind = frameWrite
+		}
+		writeDuration.Record(context.Background(), float64(time.Since(start))/float64(time.Millisecond), kind)
+
+		w.mu.Lock()
+		if out.frame {
+			w.spare = append(w.spare, out.data[:0])
+		}
+		if err != nil && w.err == nil {
+			// The connection is gone; discard the rest
+			w.err = err
+			w.queue = nil
+		}
+		w.mu.Unlock()
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// envPrefix starts the environment variables that set flags: -max-players is
+// TERMINUS_MAX_PLAYERS, -hostkey is TERMINUS_HOSTKEY, and so on
+const envPrefix = "TERMINUS_"
+
+// envName returns the environment variable that sets a flag
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// applyEnv sets each flag of fs that wasn't given on the command line from
+// its environment variable, if that's set, so containers can be configured
+// without mounting files or changing their command. Flags on the command
+// line win.
+func applyEnv(fs *flag.FlagSet) error {
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || given[f.Name] {
+			return
+		}
+		value, ok := os.LookupEnv(envName(f.Name))
+		if !ok {
+			return
+		}
+		if setErr := fs.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("invalid %s %q: %w", envName(f.Name), value, setErr)
+		}
+	})
+	return err
+}
@@ -0,0 +1,30 @@
+-- Kill streaks: announces players on a roll, and drops a health pack where
+-- each player falls. Run with: ./terminus -script examples/streaks.lua
+
+-- Shown to players in the in-game help
+rules = "Kill streaks: 3 kills in a row without dying gets you announced, and every player who falls drops a health pack"
+
+local streaks = {}
+local titles = { [3] = "is on a killing spree", [5] = "is unstoppable", [8] = "is legendary" }
+
+function onPlayerJoin(name)
+  streaks[name] = 0
+  terminus.tell(name, "Kill streaks are on: 3 kills in a row without dying gets you announced")
+end
+
+function onKill(victim, killer, x, y)
+  if killer and (streaks[victim] or 0) >= 3 then
+    terminus.broadcast(killer .. " ended " .. victim .. "'s streak of " .. streaks[victim])
+  end
+  streaks[victim] = 0
+
+  if killer then
+    streaks[killer] = (streaks[killer] or 0) + 1
+    local title = titles[streaks[killer]]
+    if title then
+      terminus.broadcast(killer .. " " .. title .. "!")
+    end
+  end
+
+  terminus.spawn("health", x, y)
+end
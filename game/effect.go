@@ -0,0 +1,174 @@
+package game
+
+import "slices"
+
+// EffectKind identifies what a status effect does to a player
+type EffectKind int
+
+const (
+	EffectSpeed          EffectKind = iota // Multiplies movement speed
+	EffectDamage                           // Multiplies the damage the player's projectiles do
+	EffectDamageOverTime                   // Takes health every second
+	EffectInvisibility                     // Hides the player from others
+)
+
+// Stacking is what happens when an effect is applied to a player who already
+// has one of the same kind from the same source
+type Stacking int
+
+const (
+	StackRefresh     Stacking = iota // Restart it, keeping the stronger magnitude
+	StackExtend                      // Add to its time left, up to the kind's MaxDuration
+	StackIndependent                 // Add another, up to MaxStacks, replacing the one closest to expiring
+)
+
+// EffectType describes a kind of status effect
+type EffectType struct {
+	Kind        EffectKind
+	Name        string
+	Icon        rune // Shown on the HUD while it's active
+	Stacking    Stacking
+	MaxStacks   int     // Most at once from one source, for StackIndependent
+	MaxDuration float64 // Longest it can be extended to, for StackExtend
+}
+
+// EffectTypes lists every kind of status effect
+var EffectTypes = []EffectType{
+	{Kind: EffectSpeed, Name: "Speed", Icon: '»', Stacking: StackRefresh},
+	{Kind: EffectDamage, Name: "Damage", Icon: '✦', Stacking: StackRefresh},
+	{Kind: EffectDamageOverTime, Name: "Burning", Icon: '≈', Stacking: StackIndependent, MaxStacks: 3},
+	{Kind: EffectInvisibility, Name: "Invisible", Icon: '◌', Stacking: StackExtend, MaxDuration: 30},
+}
+
+// GetEffectType returns the description of a kind of status effect
+func GetEffectType(kind EffectKind) *EffectType {
+	for i := range EffectTypes {
+		if EffectTypes[i].Kind == kind {
+			return &EffectTypes[i]
+		}
+	}
+	return &EffectTypes[0]
+}
+
+// StatusEffect is a timed change to a player, like a powerup, a hazard, or a
+// spell. Effects of one kind combine: speed multipliers multiply, the
+// strongest damage multiplier applies, and damage over time adds up.
+type StatusEffect struct {
+	Kind      EffectKind
+	Source    string  // What applied it, like "quad damage"; effects stack by source
+	Magnitude float64 // A multiplier for speed and damage; health per second for damage over time
+	Remaining float64 // Seconds left
+	Owner     string  // Session ID of the player responsible, if any, credited with damage it does
+}
+
+// DamageTick is damage a status effect did during a tick
+type DamageTick struct {
+	Amount float64
+	Owner  string
+}
+
+// ApplyEffect gives the player a status effect, stacking it with any of the
+// same kind from the same source by the kind's rules
+func (p *Player) ApplyEffect(e StatusEffect) {
+	t := GetEffectType(e.Kind)
+	var same []int
+	for i, existing := range p.Effects {
+		if existing.Kind == e.Kind && existing.Source == e.Source {
+			same = append(same, i)
+		}
+	}
+	if len(same) == 0 {
+		p.Effects = append(p.Effects, e)
+		return
+	}
+
+	existing := &p.Effects[same[0]]
+	switch t.Stacking {
+	case StackRefresh:
+		existing.Remaining = max(existing.Remaining, e.Remaining)
+		existing.Magnitude = max(existing.Magnitude, e.Magnitude)
+		existing.Owner = e.Owner
+	case StackExtend:
+		existing.Remaining = min(existing.Remaining+e.Remaining, max(t.MaxDuration, e.Remaining))
+	case StackIndependent:
+		if len(same) < t.MaxStacks {
+			p.Effects = append(p.Effects, e)
+			return
+		}
+		for _, i := range same[1:] {
+			if p.Effects[i].Remaining < existing.Remaining {
+				existing = &p.Effects[i]
+			}
+		}
+		*existing = e
+	}
+}
+
+// UpdateEffects counts down the player's status effects, removing those that
+// expired, and returns the damage over time they did during the tick
+func (p *Player) UpdateEffects(deltaTime float64) []DamageTick {
+	var damage []DamageTick
+	for i := range p.Effects {
+		e := &p.Effects[i]
+		if e.Kind == EffectDamageOverTime {
+			damage = append(damage, DamageTick{Amount: e.Magnitude * min(deltaTime, e.Remaining), Owner: e.Owner})
+		}
+		e.Remaining -= deltaTime
+	}
+	p.Effects = slices.DeleteFunc(p.Effects, func(e StatusEffect) bool { return e.Remaining <= 0 })
+	return damage
+}
+
+// EffectTime returns the longest time left on the player's effects of a
+// kind, or zero if they have none
+func (p *Player) EffectTime(kind EffectKind) float64 {
+	remaining := 0.0
+	for _, e := range p.Effects {
+		if e.Kind == kind {
+			remaining = max(remaining, e.Remaining)
+		}
+	}
+	return remaining
+}
+
+// EffectStacks returns how many effects of a kind the player has
+func (p *Player) EffectStacks(kind EffectKind) int {
+	n := 0
+	for _, e := range p.Effects {
+		if e.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// SpeedMultiplier scales the player's movement speed by their speed effects
+func (p *Player) SpeedMultiplier() float64 {
+	multiplier := 1.0
+	for _, e := range p.Effects {
+		if e.Kind == EffectSpeed {
+			multiplier *= e.Magnitude
+		}
+	}
+	return multiplier
+}
+
+// DamageMultiplier scales the damage the player's projectiles do by their
+// strongest damage effect
+func (p *Player) DamageMultiplier() float64 {
+	multiplier := 1.0
+	for _, e := range p.Effects {
+		if e.Kind == EffectDamage {
+			multiplier = max(multiplier, e.Magnitude)
+		}
+	}
+	return multiplier
+}
+
+// IsInvisible reports whether other players can't see the player
+func (p *Player) IsInvisible() bool {
+	return p.EffectTime(EffectInvisibility) > 0
+}
+
+// Burning is damage over time from fire, like a hazard or a spell
+var Burning = StatusEffect{Kind: EffectDamageOverTime, Source: "fire", Magnitude: 5, Remaining: 4}
@@ -0,0 +1,32 @@
+package game
+
+// Emote is a quick gesture players show to those nearby, as a bubble above
+// their sprite
+type Emote struct {
+	Name   string
+	Verb   string // Describes the emote in text, e.g. "alice waves"
+	Bubble string // Drawn above the player's sprite
+}
+
+// Emotes lists the emotes in the order of the function keys that show them
+var Emotes = []Emote{
+	{Name: "wave", Verb: "waves", Bubble: "o/"},
+	{Name: "taunt", Verb: "taunts", Bubble: ">:P"},
+	{Name: "laugh", Verb: "laughs", Bubble: "XD"},
+}
+
+// Emote tuning
+const (
+	EmoteSeconds = 2.5  // How long an emote's bubble is shown
+	EmoteRange   = 12.0 // How close a viewer must be to see an emote, in map units
+)
+
+// FindEmote returns the emote with the given name
+func FindEmote(name string) (Emote, bool) {
+	for _, e := range Emotes {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return Emote{}, false
+}
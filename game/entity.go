@@ -0,0 +1,443 @@
+package game
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+)
+
+// EntityKind names what an entity is, as a noun used in scene descriptions
+type EntityKind string
+
+const (
+	KindPlayer    EntityKind = "player"
+	KindNPC       EntityKind = "enemy"
+	KindFireball  EntityKind = "fireball"
+	KindGrenade   EntityKind = "grenade"
+	KindRocket    EntityKind = "rocket"
+	KindExplosion EntityKind = "explosion"
+	KindMine      EntityKind = "mine"
+	KindBeam      EntityKind = "beam"
+	KindFlame     EntityKind = "flame"
+	KindLamp      EntityKind = "lamp"
+	KindFlash     EntityKind = "flash"
+	KindDebris    EntityKind = "debris"
+)
+
+// Entity is an object in the world. Its optional components decide how it
+// behaves: systems move entities with a Velocity, bounce or stop them at walls
+// by their Collider unless they're Frozen, move a Ghost along its replay, remove them when their
+// Health or Lifetime runs out, and
+// the renderer draws any entity with a Sprite and lights the world around any
+// with a Light. New kinds of objects are new combinations of components
+// rather than new types.
+type Entity struct {
+	ID       uint64 // Identifies the entity from one snapshot to the next; zero if it isn't tracked
+	Kind     EntityKind
+	Position Vector
+	Floor    int // Which of the map's floors it's on, from 0 for the ground floor
+	Velocity *Velocity
+	Sprite   *Sprite
+	Collider *Collider
+	Health   *Health
+	Wander   *Wander
+	Pickup   *Pickup
+	Mine     *Mine
+	Beam     *Beam
+	Light    *Light
+	Lifetime *Lifetime
+	Ghost    *Ghost
+	Frozen   bool // Held still, neither moving nor wandering, like NPCs frozen for practice
+	Removed  bool // Set to remove the entity on the next update
+}
+
+// Velocity moves an entity each tick
+type Velocity struct {
+	Direction Vector // Unit vector
+	Speed     float64
+}
+
+// WallResponse is what happens to a moving entity that runs into a wall
+type WallResponse int
+
+const (
+	BounceOffWalls WallResponse = iota // Reflect off the wall, like wandering NPCs
+	RemoveOnWall                       // Disappear, like projectiles
+)
+
+// Collider keeps an entity out of walls
+type Collider struct {
+	Radius float64 // How close the entity may get to the map's edge
+	OnWall WallResponse
+}
+
+// Health removes an entity once it's used up
+type Health struct {
+	Current, Max float64
+}
+
+// Wander changes an entity's direction at random every few seconds
+type Wander struct {
+	Timer float64 // Time until the next direction change
+}
+
+// Light makes an entity cast light, fading out over its Lifetime if it has one
+type Light struct {
+	Radius    float64
+	Intensity float64
+	Color     [3]float64 // RGB values 0-1
+	Animation LightAnimation
+	Seed      float64 // Seconds its animation is offset by, so lights don't animate in step
+}
+
+// Beam is a straight streak of light from the entity's position, like a
+// railgun shot, that the renderer draws across the view
+type Beam struct {
+	To    Vector
+	Color color.RGBA
+}
+
+// Lifetime removes an entity when it runs out
+type Lifetime struct {
+	Remaining, Max float64 // Seconds
+}
+
+// Sprite is how the renderer draws an entity: a glyph filling an ellipse
+// that's brightest at its center
+type Sprite struct {
+	Glyph      rune
+	Color      color.RGBA
+	Scale      float64 // Height relative to the view at distance 1
+	MinSize    int     // Smallest height in rows, so distant sprites stay visible
+	Width      float64 // Width as a fraction of height
+	FadeX      float64 // How quickly brightness falls off horizontally, relative to vertically
+	Threshold  float64 // Dimmest intensity that's still drawn
+	Brightness float64 // Color multiplier at full intensity
+	Label      string  // Text drawn just above the sprite, like an emote bubble
+	OnFloor    bool    // Drawn resting on the floor rather than centered at eye level
+}
+
+// Sprites for the built-in kinds of entity
+var (
+	PlayerSprite   = Sprite{Glyph: '@', Color: DefaultPlayerColor, Scale: 1.2, MinSize: 4, Width: 0.75, FadeX: 0.5, Threshold: 0.05, Brightness: 1.5}
+	NPCSprite      = Sprite{Glyph: '◐', Color: color.RGBA{0, 150, 255, 255}, Scale: 1.0, MinSize: 3, Width: 0.5, FadeX: 0.7, Threshold: 0.15, Brightness: 1.3}
+	FireballSprite = Sprite{Glyph: '●', Color: color.RGBA{255, 150, 0, 255}, Scale: 0.5, Width: 1.0 / 3, FadeX: 1, Threshold: 0.1, Brightness: 1.2}
+	PickupSprite   = Sprite{Glyph: '+', Scale: 0.4, MinSize: 2, Width: 0.6, FadeX: 0.8, Threshold: 0.1, Brightness: 1.4}
+	RocketSprite   = Sprite{Glyph: '◆', Color: color.RGBA{200, 200, 210, 255}, Scale: 0.35, Width: 1.0 / 3, FadeX: 1, Threshold: 0.1, Brightness: 1.2}
+	GrenadeSprite  = Sprite{Glyph: '•', Color: color.RGBA{90, 160, 60, 255}, Scale: 0.35, Width: 1.0 / 3, FadeX: 1, Threshold: 0.1, Brightness: 1.2}
+	FlameSprite    = Sprite{Glyph: '▲', Color: color.RGBA{255, 90, 0, 255}, Scale: 0.3, Width: 1.0 / 3, FadeX: 1, Threshold: 0.1, Brightness: 1.4}
+)
+
+// explosionFlash is how long an explosion lights up its surroundings, in seconds
+const explosionFlash = 0.4
+
+// NewExplosion creates the flash of light from an explosion with the given
+// blast radius
+func NewExplosion(pos Vector, blastRadius float64) *Entity {
+	return &Entity{
+		Kind:     KindExplosion,
+		Position: pos,
+		Light:    &Light{Radius: 2 * blastRadius, Intensity: 1.0, Color: [3]float64{1.0, 0.8, 0.4}},
+		Lifetime: &Lifetime{Remaining: explosionFlash, Max: explosionFlash},
+	}
+}
+
+// muzzleFlashTime is how long firing a weapon lights up its surroundings, in
+// seconds
+const muzzleFlashTime = 0.1
+
+// NewMuzzleFlash creates the brief flash of light from firing a weapon
+func NewMuzzleFlash(pos Vector) *Entity {
+	return &Entity{
+		Kind:     KindFlash,
+		Position: pos,
+		Light:    &Light{Radius: 3, Intensity: 1.0, Color: [3]float64{1.0, 0.9, 0.6}},
+		Lifetime: &Lifetime{Remaining: muzzleFlashTime, Max: muzzleFlashTime},
+	}
+}
+
+// railBeamTime is how long a railgun's beam stays visible, in seconds
+const railBeamTime = 0.3
+
+// RailColor is the color of a railgun's beam
+var RailColor = color.RGBA{150, 230, 255, 255}
+
+// NewRailBeam creates the visible trail of a railgun shot between two points
+func NewRailBeam(from, to Vector) *Entity {
+	return &Entity{
+		Kind:     KindBeam,
+		Position: from,
+		Beam:     &Beam{To: to, Color: RailColor},
+		Lifetime: &Lifetime{Remaining: railBeamTime, Max: railBeamTime},
+	}
+}
+
+// DebrisSprite is a chip of a crumbled wall
+var DebrisSprite = Sprite{Glyph: '▪', Color: color.RGBA{150, 140, 120, 255}, Scale: 0.15, MinSize: 1, Width: 1, FadeX: 1, Threshold: 0.05, Brightness: 1, OnFloor: true}
+
+// Debris tuning
+const (
+	debrisPieces   = 6
+	debrisSpeed    = 3.0 // Cells per second, at most
+	debrisLifetime = 0.8 // Seconds
+)
+
+// NewDebris creates the chips flying out of a wall that crumbled, centered on
+// the cell it filled
+func NewDebris(center Vector) []*Entity {
+	debris := make([]*Entity, debrisPieces)
+	for i := range debris {
+		sprite := DebrisSprite
+		life := debrisLifetime * (0.5 + rand.Float64()/2)
+		debris[i] = &Entity{
+			Kind:     KindDebris,
+			Position: center,
+			Velocity: &Velocity{Direction: randomDirection(), Speed: debrisSpeed * (0.3 + rand.Float64()*0.7)},
+			Sprite:   &sprite,
+			Collider: &Collider{Radius: 0.1, OnWall: RemoveOnWall},
+			Lifetime: &Lifetime{Remaining: life, Max: life},
+		}
+	}
+	return debris
+}
+
+// LightSource returns the light the entity casts, if it has a Light
+func (e *Entity) LightSource() (LightSource, bool) {
+	if e.Light == nil {
+		return LightSource{}, false
+	}
+	intensity := e.Light.Intensity * e.Fade()
+	return LightSource{Position: e.Position, Radius: e.Light.Radius, Intensity: intensity, Color: e.Light.Color, Animation: e.Light.Animation, Seed: e.Light.Seed, Floor: e.Floor}, true
+}
+
+// Fade returns the fraction of its Lifetime the entity has left, or 1 if it
+// doesn't have one, for effects that fade out as they expire
+func (e *Entity) Fade() float64 {
+	if e.Lifetime == nil || e.Lifetime.Max <= 0 {
+		return 1
+	}
+	return max(0, e.Lifetime.Remaining/e.Lifetime.Max)
+}
+
+// NPC tuning
+const (
+	NPCSpeed     = 1.5 // Slower than players (5.0)
+	NPCMaxHealth = 100
+)
+
+// NewNPC creates a wandering NPC at the specified position
+func NewNPC(x, y float64) *Entity {
+	sprite := NPCSprite
+	return &Entity{
+		Kind:     KindNPC,
+		Position: Vector{x, y},
+		Velocity: &Velocity{Direction: randomDirection(), Speed: NPCSpeed},
+		Sprite:   &sprite,
+		Collider: &Collider{Radius: 0.2, OnWall: BounceOffWalls},
+		Health:   &Health{Current: NPCMaxHealth, Max: NPCMaxHealth},
+		Wander:   &Wander{Timer: wanderInterval()},
+	}
+}
+
+// Sprite returns how others see the player, in their chosen color and glyph
+func (p *Player) Sprite() Sprite {
+	sprite := PlayerSprite
+	if p.Color.A != 0 {
+		sprite.Color = p.Color
+	}
+	if p.Glyph != 0 {
+		sprite.Glyph = p.Glyph
+	}
+	return sprite
+}
+
+// Entity returns an entity for drawing the player as others see them
+func (p *Player) Entity() *Entity {
+	sprite := p.Sprite()
+	return &Entity{Kind: KindPlayer, Position: p.Position, Floor: p.Floor, Sprite: &sprite}
+}
+
+// Entity returns an entity for drawing the projectile
+func (p *Projectile) Entity() *Entity {
+	switch p.Type {
+	case Grenade:
+		sprite := GrenadeSprite
+		return &Entity{ID: p.ID, Kind: KindGrenade, Position: p.Position, Floor: p.Floor, Sprite: &sprite}
+	case Rocket:
+		sprite := RocketSprite
+		return &Entity{ID: p.ID, Kind: KindRocket, Position: p.Position, Floor: p.Floor, Sprite: &sprite}
+	case Flame:
+		// Flames die down as they burn out
+		sprite := FlameSprite
+		sprite.Brightness *= p.Life / p.MaxLife
+		return &Entity{ID: p.ID, Kind: KindFlame, Position: p.Position, Floor: p.Floor, Sprite: &sprite}
+	}
+	sprite := FireballSprite
+	return &Entity{ID: p.ID, Kind: KindFireball, Position: p.Position, Floor: p.Floor, Sprite: &sprite}
+}
+
+// Clone returns a copy of the entity with copies of its components, so the
+// copy doesn't change as the simulation updates the original
+func (e *Entity) Clone() Entity {
+	c := *e
+	if e.Velocity != nil {
+		v := *e.Velocity
+		c.Velocity = &v
+	}
+	if e.Sprite != nil {
+		s := *e.Sprite
+		c.Sprite = &s
+	}
+	if e.Collider != nil {
+		col := *e.Collider
+		c.Collider = &col
+	}
+	if e.Health != nil {
+		h := *e.Health
+		c.Health = &h
+	}
+	if e.Wander != nil {
+		w := *e.Wander
+		c.Wander = &w
+	}
+	if e.Pickup != nil {
+		p := *e.Pickup
+		c.Pickup = &p
+	}
+	if e.Mine != nil {
+		m := *e.Mine
+		c.Mine = &m
+	}
+	if e.Beam != nil {
+		b := *e.Beam
+		c.Beam = &b
+	}
+	if e.Light != nil {
+		l := *e.Light
+		c.Light = &l
+	}
+	if e.Lifetime != nil {
+		l := *e.Lifetime
+		c.Lifetime = &l
+	}
+	if e.Ghost != nil {
+		g := *e.Ghost
+		c.Ghost = &g
+	}
+	return c
+}
+
+// MaxInterpolation is the farthest an object may move between two snapshots
+// and still be drawn in between; longer jumps are teleports
+const MaxInterpolation = 1.0
+
+// Interpolates reports whether an object that moved between two positions
+// should be drawn in between them, rather than having jumped
+func Interpolates(from, to Vector) bool {
+	return from.Sub(to).Length() <= MaxInterpolation
+}
+
+// UpdateEntities runs the entity systems for one tick and returns the
+// entities that remain
+func UpdateEntities(entities []*Entity, deltaTime float64, worldMap *Map) []*Entity {
+	remaining := entities[:0]
+	for _, e := range entities {
+		if e.Wander != nil && e.Velocity != nil && !e.Frozen {
+			wander(e, deltaTime)
+		}
+		if e.Velocity != nil && !e.Frozen {
+			move(e, deltaTime, worldMap.Floor(e.Floor))
+		}
+		if e.Ghost != nil {
+			haunt(e, deltaTime)
+		}
+		if e.Pickup != nil && e.Pickup.Respawn > 0 {
+			e.Pickup.Respawn -= deltaTime
+		}
+		if e.Mine != nil && e.Mine.Arming > 0 {
+			e.Mine.Arming -= deltaTime
+		}
+		if e.Health != nil && e.Health.Current <= 0 {
+			e.Removed = true
+		}
+		if e.Lifetime != nil {
+			e.Lifetime.Remaining -= deltaTime
+			if e.Lifetime.Remaining <= 0 {
+				e.Removed = true
+			}
+		}
+		if !e.Removed {
+			remaining = append(remaining, e)
+		}
+	}
+	// Clear the tail so removed entities can be garbage collected
+	clear(entities[len(remaining):])
+	return remaining
+}
+
+// wander turns the entity in a random direction when its timer runs out
+func wander(e *Entity, deltaTime float64) {
+	e.Wander.Timer -= deltaTime
+	if e.Wander.Timer <= 0 {
+		e.Velocity.Direction = randomDirection()
+		e.Wander.Timer = wanderInterval()
+	}
+}
+
+// move applies the entity's velocity, resolving wall collisions by its collider
+func move(e *Entity, deltaTime float64, worldMap *Map) {
+	newPos := e.Position.Add(e.Velocity.Direction.Scale(e.Velocity.Speed * deltaTime))
+	if e.Collider == nil {
+		e.Position = newPos
+		return
+	}
+
+	if e.Collider.OnWall == RemoveOnWall {
+		if worldMap.IsWall(int(newPos.X), int(newPos.Y)) {
+			e.Removed = true
+			return
+		}
+		e.Position = newPos
+		return
+	}
+
+	// Bounce off walls one axis at a time, and wander off soon after
+	if worldMap.IsWall(int(newPos.X), int(e.Position.Y)) {
+		e.Velocity.Direction.X = -e.Velocity.Direction.X
+		if e.Wander != nil {
+			e.Wander.Timer = 0.5
+		}
+	} else {
+		e.Position.X = newPos.X
+	}
+	if worldMap.IsWall(int(e.Position.X), int(newPos.Y)) {
+		e.Velocity.Direction.Y = -e.Velocity.Direction.Y
+		if e.Wander != nil {
+			e.Wander.Timer = 0.5
+		}
+	} else {
+		e.Position.Y = newPos.Y
+	}
+
+	// Stay within the map's bounds
+	r := e.Collider.Radius
+	if e.Position.X < r || e.Position.X > float64(worldMap.Width)-r {
+		e.Velocity.Direction.X = -e.Velocity.Direction.X
+	}
+	if e.Position.Y < r || e.Position.Y > float64(worldMap.Height)-r {
+		e.Velocity.Direction.Y = -e.Velocity.Direction.Y
+	}
+	e.Position.X = math.Max(r, math.Min(float64(worldMap.Width)-r, e.Position.X))
+	e.Position.Y = math.Max(r, math.Min(float64(worldMap.Height)-r, e.Position.Y))
+}
+
+// randomDirection returns a unit vector at a random angle
+func randomDirection() Vector {
+	angle := rand.Float64() * 2 * math.Pi
+	return Vector{math.Cos(angle), math.Sin(angle)}
+}
+
+// wanderInterval returns how long until a wandering entity next changes direction, 2-4 seconds
+func wanderInterval() float64 {
+	return 2.0 + rand.Float64()*2.0
+}
@@ -0,0 +1,102 @@
+package game
+
+import (
+	"image/color"
+	"math"
+	"time"
+)
+
+// KindGhost is the kind of entity that replays a recorded run through a map
+const KindGhost EntityKind = "ghost"
+
+// Replay is a recorded run through a map: where a player was, sampled over
+// time, for ghosts to retrace, like the best lap of a race
+type Replay struct {
+	Name     string        `json:"name"`
+	Player   string        `json:"player"`
+	Map      string        `json:"map"` // The name of the map it was recorded on
+	Color    color.RGBA    `json:"color"`
+	Glyph    rune          `json:"glyph,omitempty"`
+	Recorded time.Time     `json:"recorded"`
+	Frames   []ReplayFrame `json:"frames"` // In order of time
+}
+
+// ReplayFrame is where the player was at a moment of a replay
+type ReplayFrame struct {
+	T        float64 `json:"t"` // Seconds since the recording started
+	Position Vector  `json:"position"`
+}
+
+// Duration returns how long the replay lasts, in seconds
+func (r *Replay) Duration() float64 {
+	if len(r.Frames) == 0 {
+		return 0
+	}
+	return r.Frames[len(r.Frames)-1].T
+}
+
+// PositionAt returns where the player was some seconds into the replay,
+// between the frames around that time. Jumps too long to have been walked,
+// like respawns, aren't smoothed over.
+func (r *Replay) PositionAt(t float64) Vector {
+	if len(r.Frames) == 0 {
+		return Vector{}
+	}
+	i := 0
+	for i+1 < len(r.Frames) && r.Frames[i+1].T <= t {
+		i++
+	}
+	from := r.Frames[i]
+	if i+1 == len(r.Frames) {
+		return from.Position
+	}
+	to := r.Frames[i+1]
+	if !Interpolates(from.Position, to.Position) || to.T <= from.T {
+		return from.Position
+	}
+	f := (t - from.T) / (to.T - from.T)
+	return from.Position.Add(to.Position.Sub(from.Position).Scale(f))
+}
+
+// Ghost moves an entity along a replay, in step with the simulation
+type Ghost struct {
+	Replay  *Replay // Shared by copies of the entity, and never changed
+	Elapsed float64 // Seconds into the replay
+	Loop    bool    // Start over at the end, rather than vanishing
+}
+
+// NewGhost creates a ghost that retraces a replay from its start: a faint
+// copy of the player who recorded it, that nothing collides with
+func NewGhost(r *Replay, loop bool) *Entity {
+	sprite := PlayerSprite
+	if r.Color.A != 0 {
+		sprite.Color = r.Color
+	}
+	if r.Glyph != 0 {
+		sprite.Glyph = r.Glyph
+	}
+	sprite.Brightness = 0.7
+	sprite.Threshold = 0.3
+	sprite.Label = r.Player + "'s ghost"
+	return &Entity{
+		Kind:     KindGhost,
+		Position: r.PositionAt(0),
+		Sprite:   &sprite,
+		Ghost:    &Ghost{Replay: r, Loop: loop},
+	}
+}
+
+// haunt moves a ghost on along its replay, removing it at the end unless it
+// loops
+func haunt(e *Entity, deltaTime float64) {
+	g := e.Ghost
+	g.Elapsed += deltaTime
+	if duration := g.Replay.Duration(); g.Elapsed > duration {
+		if !g.Loop || duration <= 0 {
+			e.Removed = true
+			return
+		}
+		g.Elapsed = math.Mod(g.Elapsed, duration)
+	}
+	e.Position = g.Replay.PositionAt(g.Elapsed)
+}
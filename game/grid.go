@@ -0,0 +1,68 @@
+package game
+
+import "math"
+
+// SpatialGrid buckets entities into square cells over the map, so finding
+// what's near a point only looks at entities in the surrounding cells rather
+// than every entity in the world. It's rebuilt each simulation step; the
+// cells' storage is kept between rebuilds.
+type SpatialGrid struct {
+	cellSize   float64
+	cols, rows int
+	cells      [][]*Entity
+}
+
+// NewSpatialGrid creates a grid covering a map of the given size in map
+// cells, with grid cells cellSize map cells across
+func NewSpatialGrid(width, height int, cellSize float64) *SpatialGrid {
+	cols := max(1, int(math.Ceil(float64(width)/cellSize)))
+	rows := max(1, int(math.Ceil(float64(height)/cellSize)))
+	return &SpatialGrid{
+		cellSize: cellSize,
+		cols:     cols,
+		rows:     rows,
+		cells:    make([][]*Entity, cols*rows),
+	}
+}
+
+// Clear empties the grid, keeping its storage for the next rebuild
+func (g *SpatialGrid) Clear() {
+	for i := range g.cells {
+		clear(g.cells[i])
+		g.cells[i] = g.cells[i][:0]
+	}
+}
+
+// Insert adds an entity to the cell containing its position. Entities outside
+// the map go in the nearest edge cell.
+func (g *SpatialGrid) Insert(e *Entity) {
+	col, row := g.cell(e.Position)
+	i := row*g.cols + col
+	g.cells[i] = append(g.cells[i], e)
+}
+
+// Query appends to dst the entities within radius of pos and returns the
+// extended slice, so callers can reuse it between queries
+func (g *SpatialGrid) Query(dst []*Entity, pos Vector, radius float64) []*Entity {
+	minCol, minRow := g.cell(Vector{pos.X - radius, pos.Y - radius})
+	maxCol, maxRow := g.cell(Vector{pos.X + radius, pos.Y + radius})
+	radiusSq := radius * radius
+	for row := minRow; row <= maxRow; row++ {
+		for col := minCol; col <= maxCol; col++ {
+			for _, e := range g.cells[row*g.cols+col] {
+				d := e.Position.Sub(pos)
+				if d.X*d.X+d.Y*d.Y <= radiusSq {
+					dst = append(dst, e)
+				}
+			}
+		}
+	}
+	return dst
+}
+
+// cell returns the column and row of the cell containing pos, clamped to the grid
+func (g *SpatialGrid) cell(pos Vector) (int, int) {
+	col := int(math.Floor(pos.X / g.cellSize))
+	row := int(math.Floor(pos.Y / g.cellSize))
+	return max(0, min(g.cols-1, col)), max(0, min(g.rows-1, row))
+}
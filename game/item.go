@@ -0,0 +1,74 @@
+package game
+
+// ItemType identifies a kind of item players carry
+type ItemType int
+
+const (
+	ItemKey     ItemType = iota // Opens a locked door
+	ItemPotion                  // Restores stamina
+	ItemGrenade                 // Thrown, exploding where it lands
+	ItemMine                    // Placed, exploding when an enemy comes near
+	numItemTypes
+)
+
+// Item describes a kind of item players can carry and use
+type Item struct {
+	Type     ItemType
+	Name     string
+	Slot     int // Inventory slot on the HUD; function key F4+Slot uses the item
+	MaxCount int // Most a player can carry
+}
+
+// Items lists all items in slot order
+var Items = []Item{
+	{Type: ItemKey, Name: "Key", Slot: 1, MaxCount: 3},
+	{Type: ItemPotion, Name: "Potion", Slot: 2, MaxCount: 5},
+	{Type: ItemGrenade, Name: "Grenade", Slot: 3, MaxCount: 5},
+	{Type: ItemMine, Name: "Mine", Slot: 4, MaxCount: 3},
+}
+
+// GetItem returns the item of the given type
+func GetItem(t ItemType) *Item {
+	for i := range Items {
+		if Items[i].Type == t {
+			return &Items[i]
+		}
+	}
+	return &Items[0]
+}
+
+// ItemInSlot returns the item used from an inventory slot, if there is one
+func ItemInSlot(slot int) (*Item, bool) {
+	for i := range Items {
+		if Items[i].Slot == slot {
+			return &Items[i], true
+		}
+	}
+	return nil, false
+}
+
+// Inventory counts how many of each item a player carries. It's a value, so
+// copies of a player, like those in snapshots, don't share it.
+type Inventory [numItemTypes]int
+
+// Count returns how many of an item are carried
+func (inv *Inventory) Count(t ItemType) int {
+	return inv[t]
+}
+
+// Add adds up to n of an item, stopping at its MaxCount, and returns how many
+// were added
+func (inv *Inventory) Add(t ItemType, n int) int {
+	n = max(0, min(n, GetItem(t).MaxCount-inv[t]))
+	inv[t] += n
+	return n
+}
+
+// Take removes one of an item, returning false if none are carried
+func (inv *Inventory) Take(t ItemType) bool {
+	if inv[t] == 0 {
+		return false
+	}
+	inv[t]--
+	return true
+}
@@ -0,0 +1,185 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// LightAnimation is how a light's brightness changes over time
+type LightAnimation int
+
+const (
+	LightSteady  LightAnimation = iota // Always the same
+	LightFlicker                       // Wavers unpredictably, like a flame
+	LightPulse                         // Swells and fades smoothly
+	LightStrobe                        // Flashes on and off, like an alarm
+)
+
+// lightAnimations names each animation, as used in map files
+var lightAnimations = map[string]LightAnimation{
+	"steady":  LightSteady,
+	"flicker": LightFlicker,
+	"pulse":   LightPulse,
+	"strobe":  LightStrobe,
+}
+
+// String returns the animation's name, as used in map files
+func (a LightAnimation) String() string {
+	for name, animation := range lightAnimations {
+		if animation == a {
+			return name
+		}
+	}
+	return "steady"
+}
+
+// Animation timing, in seconds
+const (
+	flickerStep  = 0.08 // How often a flicker picks a new brightness
+	pulsePeriod  = 2.0
+	strobePeriod = 0.5
+)
+
+// How lights animate for players who asked for reduced motion
+const (
+	calmPulseSlowdown = 3    // Times longer a pulse takes
+	calmFlash         = 0.35 // Fraction of its intensity a brief flash keeps
+)
+
+// Animate returns the light as it is a number of seconds into the
+// simulation, with its intensity scaled by its animation
+func (ls LightSource) Animate(t float64) LightSource {
+	t += ls.Seed
+	switch ls.Animation {
+	case LightFlicker:
+		// Blend between random levels so the flame wavers rather than jumps
+		step := t / flickerStep
+		i := math.Floor(step)
+		level := flickerLevel(i) + (flickerLevel(i+1)-flickerLevel(i))*(step-i)
+		ls.Intensity *= 0.7 + 0.3*level
+	case LightPulse:
+		ls.Intensity *= 0.6 + 0.4*math.Sin(2*math.Pi*t/pulsePeriod)
+	case LightStrobe:
+		if math.Mod(t, strobePeriod) >= strobePeriod/2 {
+			ls.Intensity = 0
+		}
+	}
+	ls.Animation = LightSteady
+	return ls
+}
+
+// AnimateCalmly returns the light as it is a number of seconds into the
+// simulation for players sensitive to motion: flickers hold steady at their
+// average, strobes glow steadily at half strength instead of flashing, and
+// pulses swell and fade slower and less
+func (ls LightSource) AnimateCalmly(t float64) LightSource {
+	t += ls.Seed
+	switch ls.Animation {
+	case LightFlicker:
+		ls.Intensity *= 0.85
+	case LightPulse:
+		ls.Intensity *= 0.7 + 0.2*math.Sin(2*math.Pi*t/(calmPulseSlowdown*pulsePeriod))
+	case LightStrobe:
+		ls.Intensity *= 0.5
+	}
+	ls.Animation = LightSteady
+	return ls
+}
+
+// Calm returns a brief flash of light, like a muzzle flash or explosion,
+// dimmed for players sensitive to motion
+func (ls LightSource) Calm() LightSource {
+	ls.Intensity *= calmFlash
+	return ls
+}
+
+// flickerLevel returns a repeatable pseudo-random level from 0 to 1 for a
+// step of a flicker, so every view of a light sees it flicker the same way
+func flickerLevel(step float64) float64 {
+	x := math.Sin(step*12.9898) * 43758.5453
+	return x - math.Floor(x)
+}
+
+// LightSpawn is where a map places a light, and how it looks
+type LightSpawn struct {
+	Position  Vector
+	Radius    float64
+	Intensity float64
+	Color     [3]float64 // RGB values 0-1
+	ColorName string     // The name of the color, from PlayerColors
+	Animation LightAnimation
+}
+
+// LightColor returns the RGB values of a light in one of the PlayerColors
+func LightColor(name string) ([3]float64, bool) {
+	c, ok := PlayerColors[name]
+	if !ok {
+		return [3]float64{}, false
+	}
+	return [3]float64{float64(c.R) / 255, float64(c.G) / 255, float64(c.B) / 255}, true
+}
+
+// colorName returns the name of the light's color for a map file, matching
+// it to one of the PlayerColors if it wasn't named
+func (spawn LightSpawn) colorName() string {
+	if spawn.ColorName != "" {
+		return spawn.ColorName
+	}
+	for name := range PlayerColors {
+		if c, _ := LightColor(name); c == spawn.Color {
+			return name
+		}
+	}
+	return "white"
+}
+
+// Source returns the light the spawn casts, before any animation
+func (spawn LightSpawn) Source() LightSource {
+	return LightSource{Position: spawn.Position, Radius: spawn.Radius, Intensity: spawn.Intensity, Color: spawn.Color, Animation: spawn.Animation}
+}
+
+// NewLamp creates an animated light fixed in the world, like a map's
+// flickering torches and alarm lights; steady ones are baked into the map's
+// Lightmap instead. The seed offsets its animation from other lamps'.
+func NewLamp(spawn LightSpawn, seed float64) *Entity {
+	return &Entity{
+		Kind:     KindLamp,
+		Position: spawn.Position,
+		Light:    &Light{Radius: spawn.Radius, Intensity: spawn.Intensity, Color: spawn.Color, Animation: spawn.Animation, Seed: seed},
+	}
+}
+
+// parseLight parses the position, radius, intensity, color, and optional
+// animation of a light line in a map file. Colors are the names of
+// PlayerColors.
+func parseLight(fields []string) (LightSpawn, error) {
+	if len(fields) != 5 && len(fields) != 6 {
+		return LightSpawn{}, fmt.Errorf("light lines need an x, y, radius, intensity, color, and optionally an animation")
+	}
+	var values [4]float64
+	for i := range values {
+		v, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return LightSpawn{}, fmt.Errorf("invalid light value %s", fields[i])
+		}
+		values[i] = v
+	}
+	c, ok := LightColor(fields[4])
+	if !ok {
+		return LightSpawn{}, fmt.Errorf("unknown light color %q", fields[4])
+	}
+	spawn := LightSpawn{
+		Position:  Vector{values[0], values[1]},
+		Radius:    values[2],
+		Intensity: values[3],
+		Color:     c,
+		ColorName: fields[4],
+	}
+	if len(fields) == 6 {
+		if spawn.Animation, ok = lightAnimations[fields[5]]; !ok {
+			return LightSpawn{}, fmt.Errorf("unknown light animation %q", fields[5])
+		}
+	}
+	return spawn, nil
+}
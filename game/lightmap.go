@@ -0,0 +1,73 @@
+package game
+
+// Lightmap holds the light that a map's steady lights cast, baked when the
+// map loads so renderers only add up the lights that change at draw time. It
+// stores the light at each corner of the grid's cells and blends between
+// them, so walls and floors anywhere get smooth light.
+type Lightmap struct {
+	width, height int          // Corners across and down: one more than the map's cells
+	corners       [][3]float64 // RGB light, each channel from 0 to 1, by row then column
+}
+
+// NewLightmap bakes the light from the steady lights among spawns over a grid
+// of cells
+func NewLightmap(width, height int, spawns []LightSpawn) *Lightmap {
+	var static []LightSource
+	for _, spawn := range spawns {
+		if spawn.Animation == LightSteady {
+			static = append(static, spawn.Source())
+		}
+	}
+
+	lm := &Lightmap{width: width + 1, height: height + 1, corners: make([][3]float64, (width+1)*(height+1))}
+	if len(static) == 0 {
+		return lm
+	}
+	for y := range lm.height {
+		for x := range lm.width {
+			lm.corners[y*lm.width+x] = LightAt(Vector{float64(x), float64(y)}, static)
+		}
+	}
+	return lm
+}
+
+// At returns the baked light at a position, blended from the corners of the
+// cell it's in. A nil Lightmap has no light.
+func (lm *Lightmap) At(pos Vector) [3]float64 {
+	if lm == nil {
+		return [3]float64{}
+	}
+	x := min(max(pos.X, 0), float64(lm.width-1))
+	y := min(max(pos.Y, 0), float64(lm.height-1))
+	x0, y0 := min(int(x), lm.width-2), min(int(y), lm.height-2)
+	fx, fy := x-float64(x0), y-float64(y0)
+
+	var light [3]float64
+	for c := range light {
+		top := lm.corner(x0, y0)[c]*(1-fx) + lm.corner(x0+1, y0)[c]*fx
+		bottom := lm.corner(x0, y0+1)[c]*(1-fx) + lm.corner(x0+1, y0+1)[c]*fx
+		light[c] = top*(1-fy) + bottom*fy
+	}
+	return light
+}
+
+// corner returns the baked light at a corner of the grid
+func (lm *Lightmap) corner(x, y int) [3]float64 {
+	return lm.corners[y*lm.width+x]
+}
+
+// LightAt returns the color of the light falling on a position from every
+// light, each channel from 0 to 1
+func LightAt(pos Vector, lights []LightSource) [3]float64 {
+	var total [3]float64
+	for _, light := range lights {
+		amount := light.GetLightingAt(pos)
+		if amount <= 0 {
+			continue
+		}
+		for c := range total {
+			total[c] = min(1, total[c]+amount*light.Color[c])
+		}
+	}
+	return total
+}
@@ -0,0 +1,53 @@
+package game
+
+import "image/color"
+
+// Mine tuning
+const (
+	MineArmTime     = 2.0 // Seconds after it's placed before it can go off
+	MineRange       = 0.7 // How close an enemy must come to set it off
+	MineDamage      = 70
+	MineBlastRadius = 1.5
+	MaxMines        = 3 // Most mines one player can have placed at once
+)
+
+// MineSprite is a small, dim shape resting on the floor, easy to miss
+var MineSprite = Sprite{Glyph: '▪', Color: color.RGBA{110, 70, 60, 255}, Scale: 0.12, MinSize: 1, Width: 2, FadeX: 0.3, Threshold: 0.05, Brightness: 0.9, OnFloor: true}
+
+// Mine explodes when an enemy of the player who placed it comes near, once
+// it's armed
+type Mine struct {
+	Owner  string  // Session ID of the player who placed it
+	Arming float64 // Seconds until it's armed
+}
+
+// Armed reports whether the mine can go off
+func (m *Mine) Armed() bool {
+	return m.Arming <= 0
+}
+
+// Blast returns the mine's explosion at a position, as an explosive that's
+// already stopped there. Its volley is the mine entity's ID, so each mine
+// counts one hit at most.
+func (m *Mine) Blast(e *Entity) Projectile {
+	return Projectile{
+		Position:    e.Position,
+		Floor:       e.Floor,
+		Owner:       m.Owner,
+		Volley:      e.ID,
+		Damage:      MineDamage,
+		BlastRadius: MineBlastRadius,
+	}
+}
+
+// NewMine creates a mine placed by a player, in the middle of the cell at a
+// position, which arms after MineArmTime
+func NewMine(pos Vector, owner string) *Entity {
+	sprite := MineSprite
+	return &Entity{
+		Kind:     KindMine,
+		Position: Vector{float64(int(pos.X)) + 0.5, float64(int(pos.Y)) + 0.5},
+		Sprite:   &sprite,
+		Mine:     &Mine{Owner: owner, Arming: MineArmTime},
+	}
+}
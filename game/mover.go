@@ -0,0 +1,147 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// Moving wall tuning
+const (
+	MoverSpeed    = 1.0  // How much of a cell a moving wall slides in a second
+	MoverPassable = 0.5  // How far open a moving wall has to be to walk or shoot through
+	CrushDamage   = 40.0 // Damage a second to a player caught in a closing wall
+)
+
+// Mover is a wall in one cell of the map that slides open and shut, either
+// on a timer or when a script triggers it. While it's less than
+// MoverPassable open, its cell holds its wall type and blocks like any wall;
+// otherwise the cell is open floor, though rays still stop at the part of
+// the wall that hasn't slid away.
+type Mover struct {
+	X, Y    int
+	Wall    int     // The wall type it's drawn as
+	Open    float64 // Seconds it stays open in each cycle, for movers on a timer
+	Shut    float64 // Seconds it stays shut in each cycle, for movers on a timer
+	Trigger string  // The name scripts open and shut it by, for triggered movers
+	Slide   float64 // How far it's slid open, from 0 (shut) to 1 (open)
+	Opening bool    // Whether it's sliding open, or shut
+}
+
+// Timed reports whether the mover opens and shuts on a timer rather than
+// when triggered
+func (m Mover) Timed() bool {
+	return m.Trigger == ""
+}
+
+// Passable reports whether the mover is open enough to walk through
+func (m Mover) Passable() bool {
+	return m.Slide >= MoverPassable
+}
+
+// Cycle sets which way a mover on a timer is heading as of a world time:
+// open for its first Open seconds of each cycle, then shut
+func (m *Mover) Cycle(worldTime float64) {
+	if m.Timed() {
+		m.Opening = math.Mod(worldTime, m.Open+m.Shut) < m.Open
+	}
+}
+
+// Step slides the mover toward open or shut, returning whether it moved
+func (m *Mover) Step(deltaTime float64) bool {
+	slide := m.Slide - MoverSpeed*deltaTime
+	if m.Opening {
+		slide = m.Slide + MoverSpeed*deltaTime
+	}
+	slide = min(max(slide, 0), 1)
+	if slide == m.Slide {
+		return false
+	}
+	m.Slide = slide
+	return true
+}
+
+// Blocks reports whether the mover stops a ray that meets its cell the
+// given fraction of the way along the cell's face: the part of the wall
+// that's slid away lets rays through
+func (m Mover) Blocks(offset float64) bool {
+	return offset >= m.Slide
+}
+
+// MoverAt returns the index of the moving wall in a cell of the map, if
+// there is one
+func (m *Map) MoverAt(x, y int) (int, bool) {
+	for i, mover := range m.Movers {
+		if mover.X == x && mover.Y == y {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// WithMovers returns a copy of the map with its moving walls replaced and
+// their cells opened or closed to match, leaving the original unchanged for
+// anyone still reading it
+func (m *Map) WithMovers(movers []Mover) *Map {
+	c := *m
+	c.Movers = slices.Clone(movers)
+	for i, mover := range movers {
+		if mover.Passable() == m.Movers[i].Passable() {
+			continue
+		}
+		value := mover.Wall
+		if mover.Passable() {
+			value = 0
+		}
+		c.Grid = c.WithCell(mover.X, mover.Y, value).Grid
+	}
+	return &c
+}
+
+// parseMover parses a mover line in a map file, which is either
+// "<x> <y> <wall type> timer <open seconds> <shut seconds>" or
+// "<x> <y> <wall type> trigger <name>"
+func parseMover(fields []string) (Mover, error) {
+	if len(fields) < 4 {
+		return Mover{}, fmt.Errorf("mover lines need an x, y, wall type, and timer or trigger")
+	}
+	x, errX := strconv.Atoi(fields[0])
+	y, errY := strconv.Atoi(fields[1])
+	if errX != nil || errY != nil {
+		return Mover{}, fmt.Errorf("invalid mover cell %s %s", fields[0], fields[1])
+	}
+	wall, err := strconv.Atoi(fields[2])
+	if err != nil || wall < 1 || wall > 8 {
+		return Mover{}, fmt.Errorf("invalid mover wall type %s; use 1-8", fields[2])
+	}
+	m := Mover{X: x, Y: y, Wall: wall}
+	switch fields[3] {
+	case "timer":
+		if len(fields) != 6 {
+			return Mover{}, fmt.Errorf("mover timers need seconds open and seconds shut")
+		}
+		open, errOpen := strconv.ParseFloat(fields[4], 64)
+		shut, errShut := strconv.ParseFloat(fields[5], 64)
+		if errOpen != nil || errShut != nil || open <= 0 || shut <= 0 {
+			return Mover{}, fmt.Errorf("invalid mover timer %s %s", fields[4], fields[5])
+		}
+		m.Open, m.Shut = open, shut
+	case "trigger":
+		if len(fields) != 5 {
+			return Mover{}, fmt.Errorf("mover triggers need a name")
+		}
+		m.Trigger = fields[4]
+	default:
+		return Mover{}, fmt.Errorf("movers need a timer or a trigger, not %q", fields[3])
+	}
+	return m, nil
+}
+
+// format formats the mover as the fields of its line in a map file
+func (m Mover) format() string {
+	if m.Timed() {
+		return fmt.Sprintf("%d %d %d timer %s %s", m.X, m.Y, m.Wall, formatCoord(m.Open), formatCoord(m.Shut))
+	}
+	return fmt.Sprintf("%d %d %d trigger %s", m.X, m.Y, m.Wall, m.Trigger)
+}
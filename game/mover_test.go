@@ -0,0 +1,146 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMoverStep(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		mover     Mover
+		deltaTime float64
+		wantSlide float64
+		wantMoved bool
+	}{
+		{"opening", Mover{Opening: true}, 0.25, 0.25, true},
+		{"closing", Mover{Slide: 0.5}, 0.25, 0.25, true},
+		{"opens no further than open", Mover{Slide: 0.9, Opening: true}, 0.5, 1, true},
+		{"shuts no further than shut", Mover{Slide: 0.1}, 0.5, 0, true},
+		{"already open", Mover{Slide: 1, Opening: true}, 0.5, 1, false},
+		{"already shut", Mover{}, 0.5, 0, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.mover
+			if moved := m.Step(tt.deltaTime); moved != tt.wantMoved || m.Slide != tt.wantSlide {
+				t.Errorf("Step(%v) = %t with slide %v, want %t with %v", tt.deltaTime, moved, m.Slide, tt.wantMoved, tt.wantSlide)
+			}
+		})
+	}
+}
+
+func TestMoverCycle(t *testing.T) {
+	timed := Mover{Open: 2, Shut: 3}
+	for _, tt := range []struct {
+		worldTime   float64
+		wantOpening bool
+	}{
+		{0, true},
+		{1.9, true},
+		{2, false},
+		{4.9, false},
+		{5, true},
+		{12, false},
+	} {
+		m := timed
+		if m.Cycle(tt.worldTime); m.Opening != tt.wantOpening {
+			t.Errorf("at %vs, opening = %t, want %t", tt.worldTime, m.Opening, tt.wantOpening)
+		}
+	}
+
+	// Triggered movers are left to their triggers
+	triggered := Mover{Trigger: "gate", Opening: true}
+	if triggered.Cycle(4); !triggered.Opening {
+		t.Error("Cycle changed which way a triggered mover was heading")
+	}
+}
+
+func TestMoverBlocks(t *testing.T) {
+	m := Mover{Slide: 0.25}
+	for _, tt := range []struct {
+		offset float64
+		want   bool
+	}{
+		{0, false},
+		{0.2, false},
+		{0.25, true},
+		{0.9, true},
+	} {
+		if got := m.Blocks(tt.offset); got != tt.want {
+			t.Errorf("slid %v open, Blocks(%v) = %t, want %t", m.Slide, tt.offset, got, tt.want)
+		}
+	}
+	if m.Passable() {
+		t.Errorf("slid %v open, it's passable, want blocked until %v", m.Slide, MoverPassable)
+	}
+}
+
+func TestWithMovers(t *testing.T) {
+	shut := Mover{X: 3, Y: 2, Wall: 4, Trigger: "gate"}
+	m := boxMap(6, 6)
+	m.Grid[2][3] = shut.Wall
+	m.Movers = []Mover{shut}
+
+	for _, tt := range []struct {
+		name     string
+		slide    float64
+		wantCell int
+	}{
+		{"sliding, still blocking", MoverPassable / 2, shut.Wall},
+		{"open enough to pass", MoverPassable, 0},
+		{"open", 1, 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			moved := shut
+			moved.Slide = tt.slide
+			got := m.WithMovers([]Mover{moved})
+			if got.Grid[2][3] != tt.wantCell {
+				t.Errorf("cell = %d, want %d", got.Grid[2][3], tt.wantCell)
+			}
+			if got.Movers[0].Slide != tt.slide {
+				t.Errorf("mover slide = %v, want %v", got.Movers[0].Slide, tt.slide)
+			}
+			// The original is unchanged for anyone still reading it
+			if m.Grid[2][3] != shut.Wall || m.Movers[0].Slide != 0 {
+				t.Errorf("original changed: cell %d, slide %v", m.Grid[2][3], m.Movers[0].Slide)
+			}
+		})
+	}
+}
+
+func TestParseMover(t *testing.T) {
+	for _, tt := range []struct {
+		line    string
+		want    Mover
+		wantErr bool
+	}{
+		{line: "3 4 2 timer 2 3", want: Mover{X: 3, Y: 4, Wall: 2, Open: 2, Shut: 3}},
+		{line: "3 4 2 timer 1.5 0.5", want: Mover{X: 3, Y: 4, Wall: 2, Open: 1.5, Shut: 0.5}},
+		{line: "3 4 8 trigger gate", want: Mover{X: 3, Y: 4, Wall: 8, Trigger: "gate"}},
+		{line: "3 4 2", wantErr: true},
+		{line: "x 4 2 timer 2 3", wantErr: true},
+		{line: "3 4 0 timer 2 3", wantErr: true},
+		{line: "3 4 9 timer 2 3", wantErr: true},
+		{line: "3 4 2 timer 2", wantErr: true},
+		{line: "3 4 2 timer 0 3", wantErr: true},
+		{line: "3 4 2 timer 2 -1", wantErr: true},
+		{line: "3 4 2 trigger", wantErr: true},
+		{line: "3 4 2 trigger a b", wantErr: true},
+		{line: "3 4 2 sometimes", wantErr: true},
+	} {
+		got, err := parseMover(strings.Fields(tt.line))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseMover(%q) error = %v, want error %t", tt.line, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseMover(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+		if formatted := got.format(); formatted != tt.line {
+			t.Errorf("format() = %q, want %q", formatted, tt.line)
+		}
+	}
+}
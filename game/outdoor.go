@@ -0,0 +1,53 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Area is a rectangle of a map's cells, from one corner cell to the other,
+// inclusive
+type Area struct {
+	X1, Y1, X2, Y2 int
+}
+
+// Contains reports whether a cell is in the area
+func (a Area) Contains(x, y int) bool {
+	return x >= a.X1 && x <= a.X2 && y >= a.Y1 && y <= a.Y2
+}
+
+// IsOutdoor reports whether a cell of the map is outdoors, under open sky
+// rather than a ceiling
+func (m *Map) IsOutdoor(x, y int) bool {
+	for _, a := range m.Outdoors {
+		if a.Contains(x, y) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseArea parses the corner cells of a line in a map file that marks an
+// area, like outdoors, in either order
+func parseArea(what string, fields []string) (Area, error) {
+	if len(fields) != 4 {
+		return Area{}, fmt.Errorf("%s lines need the x and y of two corner cells", what)
+	}
+	var corners [4]int
+	for i, field := range fields {
+		v, err := strconv.Atoi(field)
+		if err != nil {
+			return Area{}, fmt.Errorf("invalid %s cell %s", what, field)
+		}
+		corners[i] = v
+	}
+	return Area{
+		X1: min(corners[0], corners[2]), Y1: min(corners[1], corners[3]),
+		X2: max(corners[0], corners[2]), Y2: max(corners[1], corners[3]),
+	}, nil
+}
+
+// format formats the area as the fields of its line in a map file
+func (a Area) format() string {
+	return fmt.Sprintf("%d %d %d %d", a.X1, a.Y1, a.X2, a.Y2)
+}
@@ -0,0 +1,113 @@
+package game
+
+import "image/color"
+
+// PickupKind identifies what a pickup gives the player who takes it
+type PickupKind int
+
+const (
+	PickupHealth       PickupKind = iota // Restores health
+	PickupSpeed                          // Speed boost powerup
+	PickupInvisibility                   // Invisibility powerup
+	PickupQuadDamage                     // Quad damage powerup
+	PickupKey                            // Inventory items
+	PickupPotion
+	PickupGrenade
+)
+
+// PickupType describes a kind of pickup
+type PickupType struct {
+	Kind    PickupKind
+	Name    string       // Used in map files, like "pickup health 3.5 7.5"
+	Noun    string       // What players are told they picked up, and scene descriptions call it
+	Respawn float64      // Seconds until it reappears after being taken
+	Effect  StatusEffect // What a powerup pickup applies
+	Item    ItemType     // What an item pickup adds to the inventory
+	Glyph   rune         // How the pickup's sprite looks
+	Color   color.RGBA
+}
+
+// HealthPackAmount is how much health a health pack restores
+const HealthPackAmount = 25
+
+// PickupTypes lists every kind of pickup
+var PickupTypes = []PickupType{
+	{Kind: PickupHealth, Name: "health", Noun: "health pack", Respawn: 20, Glyph: '+', Color: color.RGBA{255, 60, 60, 255}},
+	{Kind: PickupSpeed, Name: "speed", Noun: "speed boost", Respawn: 45, Effect: SpeedBoost, Glyph: '»', Color: color.RGBA{80, 220, 255, 255}},
+	{Kind: PickupInvisibility, Name: "invisibility", Noun: "invisibility", Respawn: 60, Effect: Invisibility, Glyph: '◌', Color: color.RGBA{200, 200, 255, 255}},
+	{Kind: PickupQuadDamage, Name: "quad", Noun: "quad damage", Respawn: 90, Effect: QuadDamage, Glyph: '✦', Color: color.RGBA{200, 80, 255, 255}},
+	{Kind: PickupKey, Name: "key", Noun: "key", Respawn: 30, Item: ItemKey, Glyph: '⊸', Color: color.RGBA{255, 215, 0, 255}},
+	{Kind: PickupPotion, Name: "potion", Noun: "potion", Respawn: 30, Item: ItemPotion, Glyph: '◊', Color: color.RGBA{60, 255, 120, 255}},
+	{Kind: PickupGrenade, Name: "grenade", Noun: "grenade", Respawn: 30, Item: ItemGrenade, Glyph: '•', Color: color.RGBA{90, 160, 60, 255}},
+}
+
+// GetPickupType returns the description of a kind of pickup
+func GetPickupType(kind PickupKind) *PickupType {
+	for i := range PickupTypes {
+		if PickupTypes[i].Kind == kind {
+			return &PickupTypes[i]
+		}
+	}
+	return &PickupTypes[0]
+}
+
+// FindPickupType returns the kind of pickup with a name, as used in map files
+func FindPickupType(name string) (*PickupType, bool) {
+	for i := range PickupTypes {
+		if PickupTypes[i].Name == name {
+			return &PickupTypes[i], true
+		}
+	}
+	return nil, false
+}
+
+// PickupSpawn is where a map places a pickup
+type PickupSpawn struct {
+	Kind     PickupKind
+	Position Vector
+}
+
+// Pickup is taken by a player who walks over it, then reappears after a while
+type Pickup struct {
+	Kind    PickupKind
+	Respawn float64 // Time until it reappears; zero while it's there to take
+}
+
+// Ready reports whether the pickup is there to take
+func (p *Pickup) Ready() bool {
+	return p.Respawn <= 0
+}
+
+// Take removes the pickup until its respawn time has passed
+func (p *Pickup) Take() {
+	p.Respawn = GetPickupType(p.Kind).Respawn
+}
+
+// NewPickup creates a pickup at a map's spawn point
+func NewPickup(spawn PickupSpawn) *Entity {
+	t := GetPickupType(spawn.Kind)
+	sprite := PickupSprite
+	sprite.Glyph = t.Glyph
+	sprite.Color = t.Color
+	return &Entity{
+		Kind:     EntityKind(t.Noun),
+		Position: spawn.Position,
+		Sprite:   &sprite,
+		Pickup:   &Pickup{Kind: spawn.Kind},
+	}
+}
+
+// Apply gives the pickup's contents to the player, returning false if it's
+// no use to them, like a health pack at full health or an item they can't
+// carry more of
+func (t *PickupType) Apply(p *Player) bool {
+	switch t.Kind {
+	case PickupHealth:
+		return p.Heal(HealthPackAmount)
+	case PickupSpeed, PickupInvisibility, PickupQuadDamage:
+		p.ApplyEffect(t.Effect)
+		return true
+	default:
+		return p.Inventory.Add(t.Item, 1) > 0
+	}
+}
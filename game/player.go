@@ -1,11 +1,84 @@
 package game
 
+import (
+	"image/color"
+	"math"
+	"slices"
+)
+
+// Sprint tuning
+const (
+	SprintMultiplier  = 1.8 // Movement speed multiplier while sprinting
+	MaxStamina        = 1.0
+	StaminaDrainRate  = 0.4 // Stamina used per second of sprinting (2.5 seconds from full)
+	StaminaRegenRate  = 0.2 // Stamina recovered per second when not sprinting
+	ExhaustedDuration = 1.5 // Seconds a player can't sprint after running out of stamina
+)
+
+// PlayerMaxHealth is the health players spawn with
+const PlayerMaxHealth = 100
+
 type Player struct {
 	Position    Vector
+	Floor       int // Which of the map's floors the player is on, from 0 for the ground floor
 	Direction   Vector
 	CameraPlane Vector
 	MoveSpeed   float64
 	RotSpeed    float64
+
+	Health   float64
+	Velocity Vector // Movement from being knocked back, in cells per second, slowed by KnockbackDrag
+
+	Stamina        float64
+	ExhaustedTimer float64 // Time until the player can sprint again
+	Sprinting      bool    // Whether the player sprinted during the current tick
+
+	Weapon      WeaponType
+	LastWeapon  WeaponType
+	SwitchTimer float64                 // Time until the newly selected weapon is ready
+	Cooldowns   [numWeaponTypes]float64 // Time until each weapon can fire again, by WeaponType
+	Ammo        [numWeaponTypes]int     // Shots left in each weapon's magazine, by WeaponType
+	ReloadTimer float64                 // Time until the current weapon finishes reloading
+	ChargeTimer float64                 // Time until the current weapon's charged shot goes off
+
+	TorchLit  bool    // Whether the player's torch is lighting up their surroundings
+	TorchFuel float64 // Fuel left in the torch, up to MaxTorchFuel
+
+	Inventory Inventory      // Items carried, managed by the server
+	Effects   []StatusEffect // Active status effects, like powerups
+
+	Color color.RGBA // How other players see this player; zero for the default
+	Glyph rune       // The character other players see this player as; zero for the default
+}
+
+// DefaultPlayerColor is how players appear unless they pick a color
+var DefaultPlayerColor = color.RGBA{0, 255, 0, 255}
+
+// PlayerColors are the colors players can choose between
+var PlayerColors = map[string]color.RGBA{
+	"green":   DefaultPlayerColor,
+	"red":     {255, 60, 60, 255},
+	"blue":    {80, 120, 255, 255},
+	"yellow":  {255, 230, 0, 255},
+	"cyan":    {0, 230, 230, 255},
+	"magenta": {230, 0, 230, 255},
+	"orange":  {255, 140, 0, 255},
+	"white":   {240, 240, 240, 255},
+}
+
+// PlayerGlyphs are the characters players can choose to be drawn as
+var PlayerGlyphs = map[string]rune{
+	"at":      '@',
+	"amp":     '&',
+	"dollar":  '$',
+	"percent": '%',
+	"hash":    '#',
+	"smiley":  '☺',
+	"spade":   '♠',
+	"club":    '♣',
+	"heart":   '♥',
+	"diamond": '♦',
+	"star":    '★',
 }
 
 func NewPlayer(x, y float64) *Player {
@@ -15,11 +88,114 @@ func NewPlayer(x, y float64) *Player {
 		CameraPlane: Vector{0, 0.66}, // FOV of ~60 degrees
 		MoveSpeed:   5.0,
 		RotSpeed:    3.0,
+		Health:      PlayerMaxHealth,
+		Stamina:     MaxStamina,
+		Ammo:        FullMagazines(),
+		TorchFuel:   MaxTorchFuel,
+	}
+}
+
+// Clone returns a copy of the player that doesn't share their status effects,
+// so it stays the same while the simulation changes the original
+func (p *Player) Clone() Player {
+	c := *p
+	c.Effects = slices.Clone(p.Effects)
+	return c
+}
+
+// Interpolated returns a copy of the player with their view a fraction alpha
+// of the way from an earlier state of the same player to this one. Jumps
+// further than MaxInterpolation, like teleports and respawns, aren't smoothed.
+func (p *Player) Interpolated(from *Player, alpha float64) *Player {
+	view := *p
+	if !Interpolates(from.Position, p.Position) {
+		return &view
+	}
+	view.Position = from.Position.Lerp(p.Position, alpha)
+	view.Direction = from.Direction.Lerp(p.Direction, alpha)
+	view.CameraPlane = from.CameraPlane.Lerp(p.CameraPlane, alpha)
+	return &view
+}
+
+// Sprint makes the player's movement faster for the current tick, if they have stamina
+func (p *Player) Sprint() {
+	if p.ExhaustedTimer > 0 || p.Stamina <= 0 {
+		return
+	}
+	p.Sprinting = true
+}
+
+// IsExhausted reports whether the player ran out of stamina and is recovering
+func (p *Player) IsExhausted() bool {
+	return p.ExhaustedTimer > 0
+}
+
+// Heal restores health, up to PlayerMaxHealth, returning false if the player
+// was already at full health
+func (p *Player) Heal(amount float64) bool {
+	if p.Health >= PlayerMaxHealth {
+		return false
 	}
+	p.Health = min(PlayerMaxHealth, p.Health+amount)
+	return true
+}
+
+// Respawn puts the player back in the world at a position, with full health
+// stamina, ammo, and torch fuel, their torch out, and no status effects
+func (p *Player) Respawn(x, y float64) {
+	p.Position = Vector{x, y}
+	p.Floor = 0
+	p.Velocity = Vector{}
+	p.Health = PlayerMaxHealth
+	p.Ammo = FullMagazines()
+	p.ReloadTimer = 0
+	p.ChargeTimer = 0
+	p.TorchLit = false
+	p.TorchFuel = MaxTorchFuel
+	p.RestoreStamina()
+	p.Effects = nil
+}
+
+// RestoreStamina refills the player's stamina, ending any exhaustion
+func (p *Player) RestoreStamina() {
+	p.Stamina = MaxStamina
+	p.ExhaustedTimer = 0
+}
+
+// UpdateStamina drains stamina if the player sprinted this tick and regenerates it otherwise
+func (p *Player) UpdateStamina(deltaTime float64) {
+	if p.ExhaustedTimer > 0 {
+		p.ExhaustedTimer -= deltaTime
+	}
+
+	if p.Sprinting {
+		p.Stamina -= StaminaDrainRate * deltaTime
+		if p.Stamina <= 0 {
+			p.Stamina = 0
+			p.ExhaustedTimer = ExhaustedDuration
+		}
+	} else if p.ExhaustedTimer <= 0 {
+		p.Stamina += StaminaRegenRate * deltaTime
+		if p.Stamina > MaxStamina {
+			p.Stamina = MaxStamina
+		}
+	}
+
+	p.Sprinting = false
+}
+
+// moveSpeed returns the player's current movement speed, including sprinting
+// and speed effects
+func (p *Player) moveSpeed() float64 {
+	speed := p.MoveSpeed * p.SpeedMultiplier()
+	if p.Sprinting {
+		speed *= SprintMultiplier
+	}
+	return speed
 }
 
 func (p *Player) MoveForward(deltaTime float64, worldMap *Map) {
-	newPos := p.Position.Add(p.Direction.Scale(p.MoveSpeed * deltaTime))
+	newPos := p.Position.Add(p.Direction.Scale(p.moveSpeed() * deltaTime))
 	if !worldMap.IsWall(int(newPos.X), int(p.Position.Y)) {
 		p.Position.X = newPos.X
 	}
@@ -29,7 +205,7 @@ func (p *Player) MoveForward(deltaTime float64, worldMap *Map) {
 }
 
 func (p *Player) MoveBackward(deltaTime float64, worldMap *Map) {
-	newPos := p.Position.Sub(p.Direction.Scale(p.MoveSpeed * deltaTime))
+	newPos := p.Position.Sub(p.Direction.Scale(p.moveSpeed() * deltaTime))
 	if !worldMap.IsWall(int(newPos.X), int(p.Position.Y)) {
 		p.Position.X = newPos.X
 	}
@@ -41,7 +217,7 @@ func (p *Player) MoveBackward(deltaTime float64, worldMap *Map) {
 func (p *Player) StrafeLeft(deltaTime float64, worldMap *Map) {
 	// Perpendicular to direction (rotate 90 degrees counterclockwise)
 	strafe := Vector{-p.Direction.Y, p.Direction.X}
-	newPos := p.Position.Add(strafe.Scale(p.MoveSpeed * deltaTime))
+	newPos := p.Position.Add(strafe.Scale(p.moveSpeed() * deltaTime))
 	if !worldMap.IsWall(int(newPos.X), int(p.Position.Y)) {
 		p.Position.X = newPos.X
 	}
@@ -53,13 +229,53 @@ func (p *Player) StrafeLeft(deltaTime float64, worldMap *Map) {
 func (p *Player) StrafeRight(deltaTime float64, worldMap *Map) {
 	// Perpendicular to direction (rotate 90 degrees clockwise)
 	strafe := Vector{p.Direction.Y, -p.Direction.X}
-	newPos := p.Position.Add(strafe.Scale(p.MoveSpeed * deltaTime))
+	newPos := p.Position.Add(strafe.Scale(p.moveSpeed() * deltaTime))
+	if !worldMap.IsWall(int(newPos.X), int(p.Position.Y)) {
+		p.Position.X = newPos.X
+	}
+	if !worldMap.IsWall(int(p.Position.X), int(newPos.Y)) {
+		p.Position.Y = newPos.Y
+	}
+}
+
+// KnockbackDrag is the fraction of their knockback velocity players lose each second
+const KnockbackDrag = 4.0
+
+// Knockback pushes the player, adding an impulse to their velocity
+func (p *Player) Knockback(impulse Vector) {
+	p.Velocity = p.Velocity.Add(impulse)
+}
+
+// UpdateVelocity moves the player by their knockback velocity, stopping it
+// along any axis where they hit a wall, and slows it by KnockbackDrag
+func (p *Player) UpdateVelocity(deltaTime float64, worldMap *Map) {
+	if p.Velocity == (Vector{}) {
+		return
+	}
+	newPos := p.Position.Add(p.Velocity.Scale(deltaTime))
 	if !worldMap.IsWall(int(newPos.X), int(p.Position.Y)) {
 		p.Position.X = newPos.X
+	} else {
+		p.Velocity.X = 0
 	}
 	if !worldMap.IsWall(int(p.Position.X), int(newPos.Y)) {
 		p.Position.Y = newPos.Y
+	} else {
+		p.Velocity.Y = 0
 	}
+
+	p.Velocity = p.Velocity.Scale(max(0, 1-KnockbackDrag*deltaTime))
+	if p.Velocity.Length() < 0.05 {
+		p.Velocity = Vector{}
+	}
+}
+
+// Fly moves the player by offset without colliding with walls, for spectator
+// cameras, keeping them inside the map's bounds
+func (p *Player) Fly(offset Vector, worldMap *Map) {
+	const margin = 0.1
+	p.Position.X = math.Max(margin, math.Min(float64(worldMap.Width)-margin, p.Position.X+offset.X))
+	p.Position.Y = math.Max(margin, math.Min(float64(worldMap.Height)-margin, p.Position.Y+offset.Y))
 }
 
 func (p *Player) RotateLeft(deltaTime float64) {
@@ -73,3 +289,125 @@ func (p *Player) RotateRight(deltaTime float64) {
 	p.Direction = p.Direction.Rotate(rotSpeed)
 	p.CameraPlane = p.CameraPlane.Rotate(rotSpeed)
 }
+
+// FOV returns the player's horizontal field of view in degrees
+func (p *Player) FOV() float64 {
+	return 2 * math.Atan(p.CameraPlane.Length()) * 180 / math.Pi
+}
+
+// SetFOV sets the player's horizontal field of view in degrees by resizing the camera plane
+func (p *Player) SetFOV(degrees float64) {
+	planeLength := math.Tan(degrees / 2 * math.Pi / 180)
+	p.CameraPlane = p.CameraPlane.Normalize().Scale(planeLength)
+}
+
+// SwitchWeapon starts switching to another weapon, which can't fire until the
+// switch finishes. It returns false if that weapon is already selected.
+func (p *Player) SwitchWeapon(t WeaponType) bool {
+	if t == p.Weapon {
+		return false
+	}
+	p.LastWeapon = p.Weapon
+	p.Weapon = t
+	p.SwitchTimer = WeaponSwitchTime
+	p.ReloadTimer = 0
+	p.ChargeTimer = 0
+	if p.Ammo[t] == 0 {
+		p.Reload()
+	}
+	return true
+}
+
+// CanFire reports whether the current weapon is ready to fire: switched to,
+// cooled down since it last fired, loaded, and not already charging a shot
+func (p *Player) CanFire() bool {
+	return p.SwitchTimer <= 0 && p.Cooldowns[p.Weapon] <= 0 && p.ReloadTimer <= 0 && p.Ammo[p.Weapon] > 0 && p.ChargeTimer <= 0
+}
+
+// StartCharging starts charging a shot of the current weapon, which goes off
+// when UpdateWeapons finishes charging it
+func (p *Player) StartCharging() {
+	p.ChargeTimer = GetWeapon(p.Weapon).ChargeTime
+}
+
+// ChargeProgress returns how far along charging a shot of the current weapon
+// is, from 0 to 1, or 0 if it isn't charging
+func (p *Player) ChargeProgress() float64 {
+	if p.ChargeTimer <= 0 {
+		return 0
+	}
+	return 1 - p.ChargeTimer/GetWeapon(p.Weapon).ChargeTime
+}
+
+// Fired uses a shot from the current weapon's magazine and starts its
+// cooldown, reloading it if that emptied it
+func (p *Player) Fired() {
+	p.Cooldowns[p.Weapon] = GetWeapon(p.Weapon).Cooldown
+	p.Ammo[p.Weapon]--
+	if p.Ammo[p.Weapon] <= 0 {
+		p.Reload()
+	}
+}
+
+// Reload starts reloading the current weapon, returning false if it's
+// already full or reloading
+func (p *Player) Reload() bool {
+	weapon := GetWeapon(p.Weapon)
+	if p.ReloadTimer > 0 || p.Ammo[p.Weapon] >= weapon.Magazine {
+		return false
+	}
+	p.ReloadTimer = weapon.ReloadTime
+	return true
+}
+
+// ReloadProgress returns how far along reloading the current weapon is, from
+// 0 to 1, or 0 if it isn't reloading
+func (p *Player) ReloadProgress() float64 {
+	if p.ReloadTimer <= 0 {
+		return 0
+	}
+	return 1 - p.ReloadTimer/GetWeapon(p.Weapon).ReloadTime
+}
+
+// FullMagazines returns ammo for every weapon's full magazine
+func FullMagazines() [numWeaponTypes]int {
+	var ammo [numWeaponTypes]int
+	for _, w := range Weapons {
+		ammo[w.Type] = w.Magazine
+	}
+	return ammo
+}
+
+// Cooldown returns how far the current weapon is from being ready after
+// firing, from 1 just after it fired to 0 when it's ready
+func (p *Player) Cooldown() float64 {
+	cooldown := GetWeapon(p.Weapon).Cooldown
+	if cooldown <= 0 {
+		return 0
+	}
+	return max(0, p.Cooldowns[p.Weapon]/cooldown)
+}
+
+// UpdateWeapons advances weapon switching, cooldowns, reloading, and
+// charging, returning true when a charged shot is ready to go off
+func (p *Player) UpdateWeapons(deltaTime float64) (charged bool) {
+	if p.SwitchTimer > 0 {
+		p.SwitchTimer -= deltaTime
+	}
+	if p.ChargeTimer > 0 {
+		p.ChargeTimer -= deltaTime
+		charged = p.ChargeTimer <= 0
+	}
+	if p.ReloadTimer > 0 {
+		p.ReloadTimer -= deltaTime
+		if p.ReloadTimer <= 0 {
+			p.Ammo[p.Weapon] = GetWeapon(p.Weapon).Magazine
+		}
+	}
+	for i := range p.Cooldowns {
+		if p.Cooldowns[i] > 0 {
+			p.Cooldowns[i] -= deltaTime
+		}
+	}
+	return charged
+}
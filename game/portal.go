@@ -0,0 +1,64 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+)
+
+// KindPortal is the kind of entity that marks a portal
+const KindPortal EntityKind = "portal"
+
+// PortalSprite is how portals look: a swirl standing on the floor, labeled
+// with where it leads
+var PortalSprite = Sprite{Glyph: '◎', Color: color.RGBA{170, 90, 255, 255}, Scale: 0.9, MinSize: 3, Width: 0.6, FadeX: 0.6, Threshold: 0.05, Brightness: 1.5, OnFloor: true}
+
+// Portal is a spot on a map that takes players who step into its cell to a
+// shard of the world, the part of it another server hosts
+type Portal struct {
+	Position Vector
+	Shard    string  // The name of the shard it leads to, like "cave"
+	Arrival  *Vector // Where players arrive in the shard, or nil for one of its spawns
+}
+
+// NewPortal creates the entity that shows a map's portal, glowing faintly
+func NewPortal(p Portal) *Entity {
+	sprite := PortalSprite
+	sprite.Label = p.Shard
+	return &Entity{
+		Kind:     KindPortal,
+		Position: p.Position,
+		Sprite:   &sprite,
+		Light:    &Light{Radius: 2.5, Intensity: 0.6, Color: [3]float64{0.67, 0.35, 1}, Animation: LightPulse},
+	}
+}
+
+// PortalAt returns the portal in a cell of the map, if there is one
+func (m *Map) PortalAt(x, y int) (Portal, bool) {
+	for _, p := range m.Portals {
+		if int(p.Position.X) == x && int(p.Position.Y) == y {
+			return p, true
+		}
+	}
+	return Portal{}, false
+}
+
+// parsePortal parses the position, shard, and optional arrival position of a
+// portal line in a map file
+func parsePortal(fields []string) (Portal, error) {
+	if len(fields) != 3 && len(fields) != 5 {
+		return Portal{}, fmt.Errorf("portal lines need an x, y, and shard, and optionally where to arrive there")
+	}
+	pos, err := parsePosition("portal", fields[:2])
+	if err != nil {
+		return Portal{}, err
+	}
+	p := Portal{Position: pos, Shard: fields[2]}
+	if len(fields) == 5 {
+		arrival, err := parsePosition("portal arrival", fields[3:])
+		if err != nil {
+			return Portal{}, err
+		}
+		p.Arrival = &arrival
+	}
+	return p, nil
+}
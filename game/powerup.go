@@ -0,0 +1,8 @@
+package game
+
+// Status effects that powerups give, applied with Player.ApplyEffect
+var (
+	SpeedBoost   = StatusEffect{Kind: EffectSpeed, Source: "speed boost", Magnitude: 1.5, Remaining: 15}
+	Invisibility = StatusEffect{Kind: EffectInvisibility, Source: "invisibility", Remaining: 10}
+	QuadDamage   = StatusEffect{Kind: EffectDamage, Source: "quad damage", Magnitude: 4, Remaining: 20}
+)
@@ -1,19 +1,56 @@
 package game
 
+import (
+	"math/rand"
+	"sync"
+)
+
 type Projectile struct {
+	ID        uint64 // Assigned by the ProjectileManager, to track it between snapshots
 	Position  Vector
+	Floor     int // Which of the map's floors it's on, from 0 for the ground floor
 	Direction Vector
 	Speed     float64
 	Life      float64 // Time to live in seconds
 	MaxLife   float64
 	Active    bool
 	Type      ProjectileType
+	Owner     string  // Session ID of the player who fired it, if any
+	Volley    uint64  // ID of the first projectile fired in the same shot
+	Damage    float64 // Health it takes from what it hits
+
+	BlastRadius float64 // How far its explosion reaches when it stops, or 0 if it doesn't explode
+	Bounces     bool    // Whether it bounces off walls rather than stopping, exploding only when its time runs out
+	Friction    float64 // Fraction of its speed it loses each second
+	HurtsOwner  bool    // Whether its explosion also damages the player who fired it
+	Knockback   float64 // Speed its explosion pushes players away at, in cells per second, at its center
+	Ricochets   int     // Walls it can still reflect off before it stops at one
+
+	struck *WallHit // The wall it stopped at, if it did, for the manager to report
 }
 
+// ProjectileDamage is how much health a fireball takes from what it hits
+const ProjectileDamage = 25
+
+// GrenadeDamage is how much health a grenade's explosion takes from
+// everything within its blast radius
+const GrenadeDamage = 60
+
+// RocketDamage is how much health a rocket's explosion takes from everything
+// within its blast radius, including whoever fired it
+const RocketDamage = 50
+
+// FlameDamage is how much health each flame particle takes from what it
+// touches; a flamethrower sprays many of them every second
+const FlameDamage = 2
+
 type ProjectileType int
 
 const (
 	Fireball ProjectileType = iota
+	Grenade
+	Rocket
+	Flame
 )
 
 func NewFireball(startPos, direction Vector) *Projectile {
@@ -25,6 +62,62 @@ func NewFireball(startPos, direction Vector) *Projectile {
 		MaxLife:   3.0,
 		Active:    true,
 		Type:      Fireball,
+		Damage:    ProjectileDamage,
+	}
+}
+
+// NewGrenade creates a thrown grenade, which bounces off walls, rolls to a
+// stop, and explodes when its fuse runs out
+func NewGrenade(startPos, direction Vector) *Projectile {
+	return &Projectile{
+		Position:    startPos,
+		Direction:   direction.Normalize(),
+		Speed:       7.0,
+		Life:        2.0, // Fuse time
+		MaxLife:     2.0,
+		Active:      true,
+		Type:        Grenade,
+		Damage:      GrenadeDamage,
+		BlastRadius: 2.0,
+		Bounces:     true,
+		Friction:    0.8,
+	}
+}
+
+// NewRocket creates a rocket, which flies straight and explodes on contact,
+// hurting and knocking back everything nearby, including whoever fired it
+func NewRocket(startPos, direction Vector) *Projectile {
+	return &Projectile{
+		Position:    startPos,
+		Direction:   direction.Normalize(),
+		Speed:       12.0,
+		Life:        3.0,
+		MaxLife:     3.0,
+		Active:      true,
+		Type:        Rocket,
+		Damage:      RocketDamage,
+		BlastRadius: 1.5,
+		HurtsOwner:  true,
+		Knockback:   8.0,
+	}
+}
+
+// flameCone is how far, in radians, flame particles stray either side of
+// where the flamethrower points
+const flameCone = 0.3
+
+// NewFlame creates a short-lived flame particle, sprayed in a random
+// direction within a cone around the one given
+func NewFlame(startPos, direction Vector) *Projectile {
+	return &Projectile{
+		Position:  startPos,
+		Direction: direction.Normalize().Rotate((rand.Float64()*2 - 1) * flameCone),
+		Speed:     5.0 + rand.Float64()*2,
+		Life:      0.35,
+		MaxLife:   0.35,
+		Active:    true,
+		Type:      Flame,
+		Damage:    FlameDamage,
 	}
 }
 
@@ -45,74 +138,213 @@ func (p *Projectile) Update(deltaTime float64, worldMap *Map) {
 	newPos := p.Position.Add(movement)
 
 	// Check for wall collision
+	if p.Bounces {
+		p.bounce(newPos, worldMap)
+		p.Speed *= max(0, 1-p.Friction*deltaTime)
+		return
+	}
 	if worldMap.IsWall(int(newPos.X), int(newPos.Y)) {
+		if p.Ricochets > 0 {
+			p.ricochet(worldMap)
+			return
+		}
 		p.Active = false
+		p.struck = &WallHit{X: int(newPos.X), Y: int(newPos.Y), Floor: p.Floor, Damage: p.Damage}
 		return
 	}
 
 	p.Position = newPos
 }
 
+// ricochet moves the projectile up to the wall in its path and reflects it
+// off the face it hit, using one of its ricochets
+func (p *Projectile) ricochet(worldMap *Map) {
+	hit := CastRay(p.Position, p.Direction, worldMap)
+	p.Position = p.Position.Add(p.Direction.Scale(max(0, hit.Distance-0.01)))
+	p.Direction = p.Direction.Reflect(hit.Normal(p.Direction))
+	p.Ricochets--
+}
+
+// bounce moves the projectile toward a new position one axis at a time,
+// reflecting its direction along each axis that would take it into a wall
+func (p *Projectile) bounce(newPos Vector, worldMap *Map) {
+	if worldMap.IsWall(int(newPos.X), int(p.Position.Y)) {
+		p.Direction.X = -p.Direction.X
+	} else {
+		p.Position.X = newPos.X
+	}
+	if worldMap.IsWall(int(p.Position.X), int(newPos.Y)) {
+		p.Direction.Y = -p.Direction.Y
+	} else {
+		p.Position.Y = newPos.Y
+	}
+}
+
 func (p *Projectile) GetLightRadius() float64 {
-	if !p.Active || p.Type != Fireball {
+	if !p.Active {
 		return 0
 	}
 
 	// Light radius changes over lifetime (brighter when fresh)
 	lifeRatio := p.Life / p.MaxLife
-	return 2.0 + 1.5*lifeRatio // Radius from 2.0 to 3.5
+	switch p.Type {
+	case Fireball:
+		return 2.0 + 1.5*lifeRatio // Radius from 2.0 to 3.5
+	case Flame:
+		return 0.5 + lifeRatio // Radius from 0.5 to 1.5
+	}
+	return 0
 }
 
 func (p *Projectile) GetLightIntensity() float64 {
-	if !p.Active || p.Type != Fireball {
+	if !p.Active {
 		return 0
 	}
 
 	// Intensity fades over lifetime
 	lifeRatio := p.Life / p.MaxLife
-	return 0.8 * lifeRatio // Intensity from 0 to 0.8
+	switch p.Type {
+	case Fireball:
+		return 0.8 * lifeRatio // Intensity from 0 to 0.8
+	case Flame:
+		return 0.5 * lifeRatio // Dimmer, but there are many of them
+	}
+	return 0
 }
 
+// Light returns the light the projectile casts, if it's lit
+func (p *Projectile) Light() (LightSource, bool) {
+	if !p.Active || p.GetLightRadius() <= 0 {
+		return LightSource{}, false
+	}
+	return LightSource{
+		Position:  p.Position,
+		Radius:    p.GetLightRadius(),
+		Intensity: p.GetLightIntensity(),
+		Color:     p.lightColor(),
+		Floor:     p.Floor,
+	}, true
+}
+
+// lightColor is the color of the light the projectile casts
+func (p *Projectile) lightColor() [3]float64 {
+	if p.Type == Flame {
+		return [3]float64{1.0, 0.4, 0.1} // Deep orange flame light
+	}
+	return [3]float64{1.0, 0.6, 0.2} // Orange-red fireball light
+}
+
+// ProjectileManager owns the world's live projectiles. It's safe for
+// concurrent use: players fire from their own goroutines while the simulation
+// moves projectiles and renderers read them, so readers get copies from
+// Snapshot rather than the live projectiles.
 type ProjectileManager struct {
-	Projectiles []*Projectile
+	mu          sync.RWMutex
+	projectiles []*Projectile
+	lastID      uint64
 }
 
 func NewProjectileManager() *ProjectileManager {
 	return &ProjectileManager{
-		Projectiles: make([]*Projectile, 0),
+		projectiles: make([]*Projectile, 0),
 	}
 }
 
 func (pm *ProjectileManager) AddProjectile(p *Projectile) {
-	pm.Projectiles = append(pm.Projectiles, p)
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.add(p)
 }
 
-func (pm *ProjectileManager) Update(deltaTime float64, worldMap *Map) {
-	// Update all projectiles
-	for _, p := range pm.Projectiles {
-		p.Update(deltaTime, worldMap)
+// AddProjectiles adds a volley of projectiles unless there are already limit
+// or more in flight, returning false if the volley was dropped
+func (pm *ProjectileManager) AddProjectiles(limit int, projectiles ...*Projectile) bool {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	if len(pm.projectiles) >= limit {
+		return false
 	}
+	for _, p := range projectiles {
+		pm.add(p)
+		p.Volley = projectiles[0].ID
+	}
+	return true
+}
 
-	// Remove inactive projectiles
-	activeProjectiles := make([]*Projectile, 0)
-	for _, p := range pm.Projectiles {
+// Collide stops each active projectile for which hit returns true, like one
+// that reached a target
+func (pm *ProjectileManager) Collide(hit func(p *Projectile) bool) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	for _, p := range pm.projectiles {
+		if p.Active && hit(p) {
+			p.Active = false
+		}
+	}
+}
+
+// add numbers a projectile and adds it. Callers hold mu.
+func (pm *ProjectileManager) add(p *Projectile) {
+	pm.lastID++
+	p.ID = pm.lastID
+	pm.projectiles = append(pm.projectiles, p)
+}
+
+// Clear removes every projectile, like when the map changes
+func (pm *ProjectileManager) Clear() {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.projectiles = nil
+}
+
+// Snapshot returns copies of the projectiles in flight, which stay consistent
+// while the simulation moves on
+func (pm *ProjectileManager) Snapshot() []Projectile {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+	snapshot := make([]Projectile, 0, len(pm.projectiles))
+	for _, p := range pm.projectiles {
 		if p.Active {
-			activeProjectiles = append(activeProjectiles, p)
+			snapshot = append(snapshot, *p)
 		}
 	}
-	pm.Projectiles = activeProjectiles
+	return snapshot
+}
+
+// Update moves the projectiles, removing those that hit walls or ran out of
+// time. It returns copies of those removed that explode, for the caller to
+// detonate, and the damage those that stopped at walls did to them.
+func (pm *ProjectileManager) Update(deltaTime float64, worldMap *Map) (detonated []Projectile, struck []WallHit) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	// Update all projectiles, keeping the active ones
+	active := pm.projectiles[:0]
+	for _, p := range pm.projectiles {
+		wasActive := p.Active
+		p.Update(deltaTime, worldMap.Floor(p.Floor))
+		if p.Active {
+			active = append(active, p)
+		} else if wasActive && p.BlastRadius > 0 {
+			detonated = append(detonated, *p)
+		} else if p.struck != nil {
+			struck = append(struck, *p.struck)
+		}
+	}
+	// Clear the tail so removed projectiles can be garbage collected
+	clear(pm.projectiles[len(active):])
+	pm.projectiles = active
+	return detonated, struck
 }
 
 func (pm *ProjectileManager) GetActiveLights() []LightSource {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
 	lights := make([]LightSource, 0)
-	for _, p := range pm.Projectiles {
-		if p.Active && p.GetLightRadius() > 0 {
-			lights = append(lights, LightSource{
-				Position:  p.Position,
-				Radius:    p.GetLightRadius(),
-				Intensity: p.GetLightIntensity(),
-				Color:     [3]float64{1.0, 0.6, 0.2}, // Orange-red fireball light
-			})
+	for _, p := range pm.projectiles {
+		if light, ok := p.Light(); ok {
+			lights = append(lights, light)
 		}
 	}
 	return lights
@@ -123,6 +355,9 @@ type LightSource struct {
 	Radius    float64
 	Intensity float64
 	Color     [3]float64 // RGB values 0-1
+	Animation LightAnimation
+	Seed      float64 // Seconds its animation is offset by
+	Floor     int     // Which of the map's floors it lights
 }
 
 func (ls LightSource) GetLightingAt(pos Vector) float64 {
@@ -0,0 +1,164 @@
+package game
+
+import (
+	"math"
+	"testing"
+)
+
+// boxMap returns an open room of the given size inside one ring of walls
+func boxMap(width, height int) *Map {
+	grid := make([][]int, height)
+	for y := range grid {
+		grid[y] = make([]int, width)
+		for x := range grid[y] {
+			if x == 0 || y == 0 || x == width-1 || y == height-1 {
+				grid[y][x] = 1
+			}
+		}
+	}
+	return &Map{Width: width, Height: height, Grid: grid}
+}
+
+// near reports whether two vectors are within a small distance of each other
+func near(a, b Vector) bool {
+	return a.Sub(b).Length() < 1e-9
+}
+
+func TestProjectileUpdate(t *testing.T) {
+	m := boxMap(10, 10)
+	for _, tt := range []struct {
+		name          string
+		projectile    *Projectile
+		deltaTime     float64
+		wantPosition  Vector
+		wantDirection Vector
+		wantActive    bool
+		wantStruck    *WallHit
+	}{{
+		name:          "fireball flies",
+		projectile:    NewFireball(Vector{X: 2, Y: 5}, Vector{X: 1}),
+		deltaTime:     0.25,
+		wantPosition:  Vector{X: 4, Y: 5},
+		wantDirection: Vector{X: 1},
+		wantActive:    true,
+	}, {
+		name:          "fireball stops at a wall",
+		projectile:    NewFireball(Vector{X: 8.5, Y: 5.5}, Vector{X: 1}),
+		deltaTime:     0.1,
+		wantPosition:  Vector{X: 8.5, Y: 5.5},
+		wantDirection: Vector{X: 1},
+		wantStruck:    &WallHit{X: 9, Y: 5, Damage: ProjectileDamage},
+	}, {
+		name:          "fireball burns out",
+		projectile:    NewFireball(Vector{X: 2, Y: 5}, Vector{X: 1}),
+		deltaTime:     3,
+		wantPosition:  Vector{X: 2, Y: 5},
+		wantDirection: Vector{X: 1},
+	}, {
+		name: "ricochet reflects off a wall",
+		projectile: func() *Projectile {
+			p := NewFireball(Vector{X: 8.5, Y: 5.5}, Vector{X: 1})
+			p.Ricochets = 1
+			return p
+		}(),
+		deltaTime:     0.25,
+		wantPosition:  Vector{X: 8.99, Y: 5.5},
+		wantDirection: Vector{X: -1},
+		wantActive:    true,
+	}, {
+		name:          "grenade rolls",
+		projectile:    NewGrenade(Vector{X: 2, Y: 5}, Vector{Y: 1}),
+		deltaTime:     0.1,
+		wantPosition:  Vector{X: 2, Y: 5.7},
+		wantDirection: Vector{Y: 1},
+		wantActive:    true,
+	}, {
+		name:          "grenade bounces off a wall",
+		projectile:    NewGrenade(Vector{X: 8.5, Y: 5}, Vector{X: 1}),
+		deltaTime:     0.1,
+		wantPosition:  Vector{X: 8.5, Y: 5},
+		wantDirection: Vector{X: -1},
+		wantActive:    true,
+	}, {
+		name:          "grenade bounces out of a corner",
+		projectile:    NewGrenade(Vector{X: 8.8, Y: 8.8}, Vector{X: 1, Y: 1}),
+		deltaTime:     0.1,
+		wantPosition:  Vector{X: 8.8, Y: 8.8},
+		wantDirection: Vector{X: -1, Y: -1}.Normalize(),
+		wantActive:    true,
+	}, {
+		name:          "grenade slides along a wall",
+		projectile:    NewGrenade(Vector{X: 8.8, Y: 5}, Vector{X: 1, Y: 1}),
+		deltaTime:     0.1,
+		wantPosition:  Vector{X: 8.8, Y: 5 + 0.7/math.Sqrt2},
+		wantDirection: Vector{X: -1, Y: 1}.Normalize(),
+		wantActive:    true,
+	}, {
+		name:          "grenade explodes when its fuse runs out, without striking a wall",
+		projectile:    NewGrenade(Vector{X: 8.5, Y: 5}, Vector{X: 1}),
+		deltaTime:     2,
+		wantPosition:  Vector{X: 8.5, Y: 5},
+		wantDirection: Vector{X: 1},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.projectile
+			p.Update(tt.deltaTime, m)
+			if !near(p.Position, tt.wantPosition) {
+				t.Errorf("position = %v, want %v", p.Position, tt.wantPosition)
+			}
+			if !near(p.Direction, tt.wantDirection) {
+				t.Errorf("direction = %v, want %v", p.Direction, tt.wantDirection)
+			}
+			if p.Active != tt.wantActive {
+				t.Errorf("active = %t, want %t", p.Active, tt.wantActive)
+			}
+			if (p.struck == nil) != (tt.wantStruck == nil) || (p.struck != nil && *p.struck != *tt.wantStruck) {
+				t.Errorf("struck = %+v, want %+v", p.struck, tt.wantStruck)
+			}
+		})
+	}
+}
+
+func TestRicochetsRunOut(t *testing.T) {
+	// Bouncing between the walls of a corridor uses a ricochet at each
+	m := boxMap(6, 3)
+	p := NewFireball(Vector{X: 2.5, Y: 1.5}, Vector{X: 1})
+	p.Ricochets = 2
+	p.Life, p.MaxLife = 100, 100
+	for range 1000 {
+		if p.Update(0.05, m); !p.Active {
+			break
+		}
+	}
+	if p.Active || p.Ricochets != 0 || p.struck == nil {
+		t.Fatalf("after flying back and forth, active = %t with %d ricochets left and struck %+v, want stopped at a wall", p.Active, p.Ricochets, p.struck)
+	}
+	if !near(p.Direction, Vector{X: 1}) || p.struck.X != 5 {
+		t.Errorf("stopped heading %v at column %d, want the east wall after bouncing off both", p.Direction, p.struck.X)
+	}
+}
+
+func TestGrenadeFriction(t *testing.T) {
+	m := boxMap(40, 40)
+	for _, tt := range []struct {
+		name      string
+		deltaTime float64
+		steps     int
+		wantSpeed float64
+	}{
+		{"one step", 0.1, 1, 7 * 0.92},
+		{"several steps", 0.1, 3, 7 * 0.92 * 0.92 * 0.92},
+		{"a long step stops it rather than reversing it", 1.5, 1, 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewGrenade(Vector{X: 20, Y: 20}, Vector{X: 1})
+			p.Life = 100
+			for range tt.steps {
+				p.Update(tt.deltaTime, m)
+			}
+			if math.Abs(p.Speed-tt.wantSpeed) > 1e-9 {
+				t.Errorf("speed = %v, want %v", p.Speed, tt.wantSpeed)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package game
+
+import "math"
+
+// RayHit describes where a ray cast through the map hit a wall
+type RayHit struct {
+	MapX, MapY int
+	Side       int     // 0 for a NS wall, 1 for an EW wall
+	Distance   float64 // Perpendicular distance along the ray direction
+	Wall       int     // The wall type hit, which for a moving wall may not be in its cell
+}
+
+// Normal returns the unit vector pointing out of the face of the wall that a
+// ray in the given direction hit
+func (h RayHit) Normal(rayDir Vector) Vector {
+	if h.Side == 0 {
+		return Vector{-math.Copysign(1, rayDir.X), 0}
+	}
+	return Vector{0, -math.Copysign(1, rayDir.Y)}
+}
+
+// CastRay steps a ray from origin through the map grid using DDA until it
+// hits a wall, passing through the part of any moving wall that's slid open
+func CastRay(origin, rayDir Vector, worldMap *Map) RayHit {
+	// Which box of the map we're in
+	mapX := int(origin.X)
+	mapY := int(origin.Y)
+
+	// Length of ray from current position to next x or y side
+	var sideDistX, sideDistY float64
+
+	// Length of ray from one x-side to next x-side, or from one y-side to next y-side
+	var deltaDistX, deltaDistY float64
+	if rayDir.X == 0 {
+		deltaDistX = 1e30
+	} else {
+		deltaDistX = math.Abs(1 / rayDir.X)
+	}
+	if rayDir.Y == 0 {
+		deltaDistY = 1e30
+	} else {
+		deltaDistY = math.Abs(1 / rayDir.Y)
+	}
+
+	// What direction to step in x or y-direction (either +1 or -1)
+	var stepX, stepY int
+
+	var side int // was a NS or a EW wall hit?
+
+	// Calculate step and initial sideDist
+	if rayDir.X < 0 {
+		stepX = -1
+		sideDistX = (origin.X - float64(mapX)) * deltaDistX
+	} else {
+		stepX = 1
+		sideDistX = (float64(mapX) + 1.0 - origin.X) * deltaDistX
+	}
+	if rayDir.Y < 0 {
+		stepY = -1
+		sideDistY = (origin.Y - float64(mapY)) * deltaDistY
+	} else {
+		stepY = 1
+		sideDistY = (float64(mapY) + 1.0 - origin.Y) * deltaDistY
+	}
+
+	// Perform DDA
+	for {
+		// Jump to next map square, either in x-direction, or in y-direction
+		if sideDistX < sideDistY {
+			sideDistX += deltaDistX
+			mapX += stepX
+			side = 0
+		} else {
+			sideDistY += deltaDistY
+			mapY += stepY
+			side = 1
+		}
+		// Check if ray has hit a moving wall, where it meets the cell's face
+		if i, ok := worldMap.MoverAt(mapX, mapY); ok {
+			mover := worldMap.Movers[i]
+			dist := faceDistance(origin, rayDir, mapX, mapY, stepX, stepY, side)
+			offset := origin.Y + dist*rayDir.Y
+			if side == 1 {
+				offset = origin.X + dist*rayDir.X
+			}
+			if mover.Blocks(offset - math.Floor(offset)) {
+				return RayHit{MapX: mapX, MapY: mapY, Side: side, Distance: dist, Wall: mover.Wall}
+			}
+			continue
+		}
+		// Check if ray has hit a wall
+		if worldMap.IsWall(mapX, mapY) {
+			break
+		}
+	}
+
+	perpWallDist := faceDistance(origin, rayDir, mapX, mapY, stepX, stepY, side)
+	return RayHit{MapX: mapX, MapY: mapY, Side: side, Distance: perpWallDist, Wall: worldMap.GetWallType(mapX, mapY)}
+}
+
+// faceDistance returns the distance, projected on the ray direction, to the
+// face of a cell that a ray stepping through the grid entered it by
+func faceDistance(origin, rayDir Vector, mapX, mapY, stepX, stepY, side int) float64 {
+	if side == 0 {
+		return (float64(mapX) - origin.X + (1-float64(stepX))/2) / rayDir.X
+	}
+	return (float64(mapY) - origin.Y + (1-float64(stepY))/2) / rayDir.Y
+}
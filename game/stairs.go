@@ -0,0 +1,109 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"slices"
+	"strconv"
+)
+
+// KindStairs is the kind of entity that marks stairs or a ladder
+const KindStairs EntityKind = "stairs"
+
+// Stairs and ladder sprites, drawn on the floor of each of the floors they join
+var (
+	StairsSprite = Sprite{Glyph: '≡', Color: color.RGBA{200, 180, 140, 255}, Scale: 0.8, MinSize: 3, Width: 0.8, FadeX: 0.8, Threshold: 0.05, Brightness: 1.2, OnFloor: true}
+	LadderSprite = Sprite{Glyph: 'H', Color: color.RGBA{160, 110, 60, 255}, Scale: 1, MinSize: 3, Width: 0.5, FadeX: 0.6, Threshold: 0.05, Brightness: 1.2, OnFloor: true}
+)
+
+// Stairs join the same cell on two of a map's floors: a player who steps
+// into the cell on one of them climbs or descends to the other
+type Stairs struct {
+	Position  Vector
+	Floor, To int  // The floors they join, from 0 for the ground floor
+	Ladder    bool // Whether it's a ladder, which only changes how it looks
+}
+
+// Other returns the floor the stairs lead to from one of their floors
+func (s Stairs) Other(floor int) int {
+	if floor == s.Floor {
+		return s.To
+	}
+	return s.Floor
+}
+
+// NewStairs creates the entities that show stairs on both of their floors,
+// labeled with which way they go
+func NewStairs(s Stairs) []*Entity {
+	var entities []*Entity
+	for _, floor := range []int{s.Floor, s.To} {
+		sprite := StairsSprite
+		if s.Ladder {
+			sprite = LadderSprite
+		}
+		sprite.Label = "down"
+		if s.Other(floor) > floor {
+			sprite.Label = "up"
+		}
+		entities = append(entities, &Entity{Kind: KindStairs, Position: s.Position, Floor: floor, Sprite: &sprite})
+	}
+	return entities
+}
+
+// Floor returns one of the map's floors, from 0 for the ground floor, which
+// is the map itself. Floors it doesn't have are the ground floor.
+func (m *Map) Floor(n int) *Map {
+	if n <= 0 || n > len(m.Floors) {
+		return m
+	}
+	return m.Floors[n-1]
+}
+
+// WithFloorCell is WithCell for a cell of one of the map's floors
+func (m *Map) WithFloorCell(floor, x, y, value int) *Map {
+	if floor <= 0 || floor > len(m.Floors) {
+		return m.WithCell(x, y, value)
+	}
+	c := *m
+	c.Floors = slices.Clone(m.Floors)
+	c.Floors[floor-1] = m.Floors[floor-1].WithCell(x, y, value)
+	return &c
+}
+
+// StairsAt returns the stairs in a cell of one of the map's floors, if
+// there are some
+func (m *Map) StairsAt(floor, x, y int) (Stairs, bool) {
+	for _, s := range m.Stairs {
+		if int(s.Position.X) == x && int(s.Position.Y) == y && (s.Floor == floor || s.To == floor) {
+			return s, true
+		}
+	}
+	return Stairs{}, false
+}
+
+// parseStairs parses the position and floors of a stairs or ladder line in
+// a map file, which numbers floors from 1 for the ground floor
+func parseStairs(kind string, fields []string) (Stairs, error) {
+	if len(fields) != 4 {
+		return Stairs{}, fmt.Errorf("%s lines need an x, y, and the two floors they join", kind)
+	}
+	pos, err := parsePosition(kind, fields[:2])
+	if err != nil {
+		return Stairs{}, err
+	}
+	from, errFrom := strconv.Atoi(fields[2])
+	to, errTo := strconv.Atoi(fields[3])
+	if errFrom != nil || errTo != nil || from < 1 || to < 1 || from == to {
+		return Stairs{}, fmt.Errorf("invalid %s floors %s %s", kind, fields[2], fields[3])
+	}
+	return Stairs{Position: pos, Floor: from - 1, To: to - 1, Ladder: kind == "ladder"}, nil
+}
+
+// format formats the stairs as their line in a map file
+func (s Stairs) format() string {
+	kind := "stairs"
+	if s.Ladder {
+		kind = "ladder"
+	}
+	return fmt.Sprintf("%s %s %s %d %d", kind, formatCoord(s.Position.X), formatCoord(s.Position.Y), s.Floor+1, s.To+1)
+}
@@ -0,0 +1,50 @@
+package game
+
+// Torch tuning. Fuel is a fraction of a full torch: it burns down while the
+// torch is lit and slowly comes back while it's out.
+const (
+	MaxTorchFuel     = 1.0
+	TorchBurnRate    = 1.0 / 30 // Fuel used per second lit (30 seconds from full)
+	TorchRefuelRate  = 1.0 / 60 // Fuel recovered per second unlit
+	TorchRadius      = 3.5
+	TorchIntensity   = 0.7
+	torchEyeDistance = 0.3 // How far ahead of the player the torch is held
+)
+
+// ToggleTorch lights the player's torch or puts it out, returning whether
+// it's lit. A torch without fuel won't light.
+func (p *Player) ToggleTorch() bool {
+	p.TorchLit = !p.TorchLit && p.TorchFuel > 0
+	return p.TorchLit
+}
+
+// UpdateTorch burns the player's torch fuel while it's lit, putting it out
+// when the fuel runs out, and refuels it while it isn't
+func (p *Player) UpdateTorch(deltaTime float64) {
+	if !p.TorchLit {
+		p.TorchFuel = min(MaxTorchFuel, p.TorchFuel+TorchRefuelRate*deltaTime)
+		return
+	}
+	p.TorchFuel -= TorchBurnRate * deltaTime
+	if p.TorchFuel <= 0 {
+		p.TorchFuel = 0
+		p.TorchLit = false
+	}
+}
+
+// TorchLight returns the light the player's torch casts, if it's lit. It
+// lights up the walls around the player for everyone, even if the player is
+// invisible.
+func (p *Player) TorchLight() (LightSource, bool) {
+	if !p.TorchLit {
+		return LightSource{}, false
+	}
+	return LightSource{
+		Position:  p.Position.Add(p.Direction.Normalize().Scale(torchEyeDistance)),
+		Radius:    TorchRadius,
+		Intensity: TorchIntensity,
+		Color:     [3]float64{1.0, 0.8, 0.5}, // Warm firelight
+		Animation: LightFlicker,
+		Floor:     p.Floor,
+	}, true
+}
@@ -38,3 +38,18 @@ func (v Vector) Rotate(angle float64) Vector {
 		v.X*sin + v.Y*cos,
 	}
 }
+
+// Lerp returns the point a fraction t of the way from v to other
+func (v Vector) Lerp(other Vector, t float64) Vector {
+	return Vector{v.X + (other.X-v.X)*t, v.Y + (other.Y-v.Y)*t}
+}
+
+// Dot returns the dot product of v and other
+func (v Vector) Dot(other Vector) float64 {
+	return v.X*other.X + v.Y*other.Y
+}
+
+// Reflect returns v mirrored off a surface with the given unit normal
+func (v Vector) Reflect(normal Vector) Vector {
+	return v.Sub(normal.Scale(2 * v.Dot(normal)))
+}
@@ -0,0 +1,103 @@
+package game
+
+// WeaponType identifies a weapon
+type WeaponType int
+
+const (
+	WeaponFireball     WeaponType = iota // Single fireball
+	WeaponScatter                        // Spread of three fireballs
+	WeaponGrenade                        // Bouncing grenade on a fuse
+	WeaponRocket                         // Rocket that explodes on contact
+	WeaponRicochet                       // Fireball that ricochets off walls
+	WeaponRailgun                        // Charged beam that pierces everything up to a wall
+	WeaponFlamethrower                   // Cone of short-lived flames sprayed while fire is held
+
+	numWeaponTypes
+)
+
+// RailgunDamage is how much health a railgun shot takes from everything along it
+const RailgunDamage = 75
+
+// RicochetBounces is how many walls a ricochet fireball reflects off before
+// it stops at one
+const RicochetBounces = 3
+
+// WeaponSwitchTime is how long it takes to lower one weapon and raise another, in seconds
+const WeaponSwitchTime = 0.5
+
+// Weapon describes a weapon players can select and fire
+type Weapon struct {
+	Type WeaponType
+	Name string
+	Slot int // Number key that selects this weapon
+
+	Cooldown   float64 // Seconds after firing before it can fire again
+	Magazine   int     // Shots it holds before it needs reloading
+	ReloadTime float64 // Seconds it takes to reload
+	ChargeTime float64 // Seconds it charges after the trigger before it fires, for charged weapons
+	Continuous bool    // Whether it keeps firing, as often as its cooldown allows, while fire is held
+}
+
+// Weapons lists all weapons in slot order
+var Weapons = []Weapon{
+	{Type: WeaponFireball, Name: "Fireball", Slot: 1, Cooldown: 0.25, Magazine: 12, ReloadTime: 1.2},
+	{Type: WeaponScatter, Name: "Scatter", Slot: 2, Cooldown: 0.6, Magazine: 6, ReloadTime: 1.5},
+	{Type: WeaponGrenade, Name: "Grenades", Slot: 3, Cooldown: 1.0, Magazine: 3, ReloadTime: 2.0},
+	{Type: WeaponRocket, Name: "Rockets", Slot: 4, Cooldown: 0.9, Magazine: 2, ReloadTime: 2.5},
+	{Type: WeaponRicochet, Name: "Ricochet", Slot: 5, Cooldown: 0.4, Magazine: 8, ReloadTime: 1.5},
+	{Type: WeaponRailgun, Name: "Railgun", Slot: 6, Cooldown: 1.5, Magazine: 4, ReloadTime: 2.5, ChargeTime: 0.6},
+	{Type: WeaponFlamethrower, Name: "Flamethrower", Slot: 7, Cooldown: 0.05, Magazine: 40, ReloadTime: 2.0, Continuous: true},
+}
+
+// GetWeapon returns the weapon of the given type
+func GetWeapon(t WeaponType) *Weapon {
+	for i := range Weapons {
+		if Weapons[i].Type == t {
+			return &Weapons[i]
+		}
+	}
+	return &Weapons[0]
+}
+
+// WeaponInSlot returns the weapon selected by a number key, if there is one
+func WeaponInSlot(slot int) (*Weapon, bool) {
+	for i := range Weapons {
+		if Weapons[i].Slot == slot {
+			return &Weapons[i], true
+		}
+	}
+	return nil, false
+}
+
+// Fire creates the projectiles for one shot of the weapon. Railguns fire a
+// beam rather than projectiles, so they create none.
+func (w *Weapon) Fire(pos, direction Vector) []*Projectile {
+	switch w.Type {
+	case WeaponScatter:
+		const spread = 0.15 // Radians between fireballs
+		return []*Projectile{
+			NewFireball(pos, direction.Rotate(-spread)),
+			NewFireball(pos, direction),
+			NewFireball(pos, direction.Rotate(spread)),
+		}
+	case WeaponGrenade:
+		return []*Projectile{NewGrenade(pos, direction)}
+	case WeaponRocket:
+		return []*Projectile{NewRocket(pos, direction)}
+	case WeaponRailgun:
+		return nil
+	case WeaponFlamethrower:
+		const particles = 3 // Flames sprayed per burst
+		flames := make([]*Projectile, particles)
+		for i := range flames {
+			flames[i] = NewFlame(pos, direction)
+		}
+		return flames
+	case WeaponRicochet:
+		fireball := NewFireball(pos, direction)
+		fireball.Ricochets = RicochetBounces
+		return []*Projectile{fireball}
+	default:
+		return []*Projectile{NewFireball(pos, direction)}
+	}
+}
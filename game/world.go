@@ -2,16 +2,49 @@ package game
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 )
 
+// LockedDoor is the grid value of a door, drawn like a wall, that opens with a key
+const LockedDoor = 9
+
+// BreakableWall is the grid value of a cracked wall that crumbles into open
+// floor once projectiles and explosions do BreakableWallHealth damage to it
+const BreakableWall = 10
+
+// BreakableWallHealth is how much damage a breakable wall takes to crumble
+const BreakableWallHealth = 100
+
+// WallHit is damage done to a wall cell, like by a fireball striking it
+type WallHit struct {
+	X, Y   int
+	Floor  int
+	Damage float64
+}
+
 type Map struct {
-	Width  int
-	Height int
-	Grid   [][]int
+	Width    int
+	Height   int
+	Grid     [][]int
+	Comment  string        // The comment lines at the top of the map's file, kept when it's saved
+	Spawns   []Vector      // Where players appear, or anywhere open if empty
+	NPCs     []Vector      // Where NPCs appear, or a few random places if empty
+	Pickups  []PickupSpawn // Where pickups appear
+	Lights   []LightSpawn  // Lights fixed in the world
+	Portals  []Portal      // Ways to other shards of the world
+	Movers   []Mover       // Walls that slide open and shut
+	Floors   []*Map        // The floors above this one, the ground floor, on maps with more than one
+	Stairs   []Stairs      // Ways between floors
+	Outdoors []Area        // Where the ground floor is under open sky
+	Lightmap *Lightmap     // Light baked from the steady Lights
+	DayNight bool          // Whether the world's light follows the server's day/night cycle
+	Darkness float64       // How far players can see without light, or 0 if the map isn't dark
 }
 
 func NewMap() *Map {
@@ -61,19 +94,53 @@ func (m *Map) GetWallType(x, y int) int {
 	return m.Grid[y][x]
 }
 
+// WithCell returns a copy of the map with one cell changed, like a door
+// opened, leaving the original unchanged for anyone still reading it
+func (m *Map) WithCell(x, y, value int) *Map {
+	grid := make([][]int, len(m.Grid))
+	for i, row := range m.Grid {
+		grid[i] = append([]int(nil), row...)
+	}
+	grid[y][x] = value
+	c := *m
+	c.Grid = grid
+	return &c
+}
+
 func LoadMapFromFile(filename string) (*Map, error) {
 	file, err := os.Open(filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open map file %s: %w", filename, err)
 	}
 	defer file.Close()
+	return LoadMap(file)
+}
 
-	scanner := bufio.NewScanner(file)
+// LoadMap reads a map in the format of map files, like one fetched from
+// remote storage
+func LoadMap(r io.Reader) (*Map, error) {
+	scanner := bufio.NewScanner(r)
 	var grid [][]int
+	var floors [][][]int // The grids of the floors before the current one
+	var stairs []Stairs
+	var outdoors []Area
+	var comment []string
+	var spawns, npcs []Vector
+	var pickups []PickupSpawn
+	var lights []LightSpawn
+	var portals []Portal
+	var movers []Mover
 	var width, height int
+	dayNight := false
+	darkness := 0.0
 
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") && grid == nil && floors == nil {
+			// Keep the description at the top of the file, before the grid
+			comment = append(comment, strings.TrimSpace(strings.TrimPrefix(line, "#")))
+			continue
+		}
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue // Skip empty lines and comments
 		}
@@ -84,6 +151,120 @@ func LoadMapFromFile(filename string) (*Map, error) {
 			continue
 		}
 
+		// Pickups are placed by lines like "pickup health 3.5 7.5"
+		if parts[0] == "pickup" {
+			spawn, err := parsePickup(parts[1:])
+			if err != nil {
+				return nil, err
+			}
+			pickups = append(pickups, spawn)
+			continue
+		}
+
+		// Player spawns and NPCs are placed by lines like "spawn 1.5 1.5" and
+		// "npc 8.5 3.5"
+		if parts[0] == "spawn" || parts[0] == "npc" {
+			pos, err := parsePosition(parts[0], parts[1:])
+			if err != nil {
+				return nil, err
+			}
+			if parts[0] == "spawn" {
+				spawns = append(spawns, pos)
+			} else {
+				npcs = append(npcs, pos)
+			}
+			continue
+		}
+
+		// Lights are placed by lines like "light 3.5 7.5 3 0.8 orange flicker"
+		if parts[0] == "light" {
+			spawn, err := parseLight(parts[1:])
+			if err != nil {
+				return nil, err
+			}
+			lights = append(lights, spawn)
+			continue
+		}
+
+		// Portals to other shards are placed by lines like "portal 18.5 1.5 cave"
+		// or "portal 18.5 1.5 cave 2.5 2.5", giving where to arrive there
+		if parts[0] == "portal" {
+			portal, err := parsePortal(parts[1:])
+			if err != nil {
+				return nil, err
+			}
+			portals = append(portals, portal)
+			continue
+		}
+
+		// Moving walls are placed by lines like "mover 5 3 2 timer 4 2", open
+		// for 4 seconds and shut for 2, or "mover 5 3 2 trigger gate", which
+		// scripts open and shut by name
+		if parts[0] == "mover" {
+			mover, err := parseMover(parts[1:])
+			if err != nil {
+				return nil, err
+			}
+			movers = append(movers, mover)
+			continue
+		}
+
+		// A line of just "floor" ends one floor's grid, and the next floor
+		// up's grid follows
+		if parts[0] == "floor" {
+			if len(parts) != 1 || grid == nil {
+				return nil, fmt.Errorf("floor lines go alone after each floor's grid but the top one")
+			}
+			floors = append(floors, grid)
+			grid = nil
+			continue
+		}
+
+		// Stairs and ladders between floors are placed by lines like
+		// "stairs 5.5 3.5 1 2", joining floors 1 and 2
+		if parts[0] == "stairs" || parts[0] == "ladder" {
+			s, err := parseStairs(parts[0], parts[1:])
+			if err != nil {
+				return nil, err
+			}
+			stairs = append(stairs, s)
+			continue
+		}
+
+		// Courtyards and other open-air areas are marked by lines like
+		// "outdoor 2 2 8 6", giving opposite corner cells
+		if parts[0] == "outdoor" {
+			area, err := parseArea(parts[0], parts[1:])
+			if err != nil {
+				return nil, err
+			}
+			outdoors = append(outdoors, area)
+			continue
+		}
+
+		// Everything beyond a radius is pitch black unless lit on maps with a
+		// line like "darkness 3"
+		if parts[0] == "darkness" {
+			if len(parts) != 2 {
+				return nil, fmt.Errorf("darkness lines need a vision radius")
+			}
+			radius, err := strconv.ParseFloat(parts[1], 64)
+			if err != nil || radius <= 0 {
+				return nil, fmt.Errorf("invalid darkness radius %s", parts[1])
+			}
+			darkness = radius
+			continue
+		}
+
+		// The day/night cycle is turned on or off by "daynight on" or "daynight off"
+		if parts[0] == "daynight" {
+			if len(parts) != 2 || (parts[1] != "on" && parts[1] != "off") {
+				return nil, fmt.Errorf("daynight lines need on or off")
+			}
+			dayNight = parts[1] == "on"
+			continue
+		}
+
 		row := make([]int, len(parts))
 		for i, part := range parts {
 			val, err := strconv.Atoi(part)
@@ -105,14 +286,241 @@ func LoadMapFromFile(filename string) (*Map, error) {
 		return nil, fmt.Errorf("error reading map file: %w", err)
 	}
 
+	if grid == nil && floors != nil {
+		return nil, fmt.Errorf("the top floor has no grid")
+	}
+	floors = append(floors, grid)
+	grid = floors[0]
 	height = len(grid)
 	if height == 0 || width == 0 {
 		return nil, fmt.Errorf("empty map file")
 	}
+	var upper []*Map
+	for i, g := range floors[1:] {
+		if len(g) != height {
+			return nil, fmt.Errorf("floor %d has %d rows, but the ground floor has %d", i+2, len(g), height)
+		}
+		upper = append(upper, &Map{Width: width, Height: height, Grid: g, Lightmap: NewLightmap(width, height, nil), DayNight: dayNight, Darkness: darkness})
+	}
+
+	m := &Map{Width: width, Height: height, Grid: grid, Floors: upper}
+	for _, pos := range spawns {
+		if m.IsWall(int(pos.X), int(pos.Y)) {
+			return nil, fmt.Errorf("spawn at (%.1f, %.1f) isn't in open space", pos.X, pos.Y)
+		}
+	}
+	for _, pos := range npcs {
+		if m.IsWall(int(pos.X), int(pos.Y)) {
+			return nil, fmt.Errorf("npc at (%.1f, %.1f) isn't in open space", pos.X, pos.Y)
+		}
+	}
+	for _, spawn := range pickups {
+		if m.IsWall(int(spawn.Position.X), int(spawn.Position.Y)) {
+			return nil, fmt.Errorf("pickup at (%.1f, %.1f) isn't in open space", spawn.Position.X, spawn.Position.Y)
+		}
+	}
+	for _, spawn := range lights {
+		if m.IsWall(int(spawn.Position.X), int(spawn.Position.Y)) {
+			return nil, fmt.Errorf("light at (%.1f, %.1f) isn't in open space", spawn.Position.X, spawn.Position.Y)
+		}
+	}
+
+	for _, portal := range portals {
+		if m.IsWall(int(portal.Position.X), int(portal.Position.Y)) {
+			return nil, fmt.Errorf("portal at (%.1f, %.1f) isn't in open space", portal.Position.X, portal.Position.Y)
+		}
+	}
+
+	for _, s := range stairs {
+		if s.Floor > len(upper) || s.To > len(upper) {
+			return nil, fmt.Errorf("stairs at (%.1f, %.1f) lead to a floor the map doesn't have", s.Position.X, s.Position.Y)
+		}
+		if m.Floor(s.Floor).IsWall(int(s.Position.X), int(s.Position.Y)) || m.Floor(s.To).IsWall(int(s.Position.X), int(s.Position.Y)) {
+			return nil, fmt.Errorf("stairs at (%.1f, %.1f) aren't in open space on both floors", s.Position.X, s.Position.Y)
+		}
+	}
+
+	for _, mover := range movers {
+		if mover.X <= 0 || mover.X >= width-1 || mover.Y <= 0 || mover.Y >= height-1 {
+			return nil, fmt.Errorf("mover at (%d, %d) isn't inside the map's outer walls", mover.X, mover.Y)
+		}
+		if _, ok := m.MoverAt(mover.X, mover.Y); ok {
+			return nil, fmt.Errorf("there's more than one mover at (%d, %d)", mover.X, mover.Y)
+		}
+		m.Movers = append(m.Movers, mover)
+		grid[mover.Y][mover.X] = mover.Wall // Movers start shut
+	}
 
 	return &Map{
-		Width:  width,
-		Height: height,
-		Grid:   grid,
+		Width:    width,
+		Height:   height,
+		Grid:     grid,
+		Comment:  strings.Join(comment, "\n"),
+		Spawns:   spawns,
+		NPCs:     npcs,
+		Pickups:  pickups,
+		Lights:   lights,
+		Portals:  portals,
+		Movers:   movers,
+		Floors:   upper,
+		Stairs:   stairs,
+		Outdoors: outdoors,
+		Lightmap: NewLightmap(width, height, lights),
+		DayNight: dayNight,
+		Darkness: darkness,
 	}, nil
 }
+
+// parsePosition parses the x and y of a line in a map file that only places
+// something, like a spawn
+func parsePosition(what string, fields []string) (Vector, error) {
+	if len(fields) != 2 {
+		return Vector{}, fmt.Errorf("%s lines need an x and y", what)
+	}
+	x, errX := strconv.ParseFloat(fields[0], 64)
+	y, errY := strconv.ParseFloat(fields[1], 64)
+	if errX != nil || errY != nil {
+		return Vector{}, fmt.Errorf("invalid %s position %s %s", what, fields[0], fields[1])
+	}
+	return Vector{x, y}, nil
+}
+
+// parsePickup parses the kind and position of a pickup line in a map file
+func parsePickup(fields []string) (PickupSpawn, error) {
+	if len(fields) != 3 {
+		return PickupSpawn{}, fmt.Errorf("pickup lines need a kind, x, and y")
+	}
+	t, ok := FindPickupType(fields[0])
+	if !ok {
+		return PickupSpawn{}, fmt.Errorf("unknown pickup %q", fields[0])
+	}
+	x, errX := strconv.ParseFloat(fields[1], 64)
+	y, errY := strconv.ParseFloat(fields[2], 64)
+	if errX != nil || errY != nil {
+		return PickupSpawn{}, fmt.Errorf("invalid pickup position %s %s", fields[1], fields[2])
+	}
+	return PickupSpawn{Kind: t.Kind, Position: Vector{x, y}}, nil
+}
+
+// Write writes the map in the format LoadMapFromFile reads
+func (m *Map) Write(w io.Writer) error {
+	var b bytes.Buffer
+	if m.Comment != "" {
+		for _, line := range strings.Split(m.Comment, "\n") {
+			b.WriteString(strings.TrimRight("# "+line, " ") + "\n")
+		}
+		b.WriteString("\n")
+	}
+	writeGrid(&b, m.Grid)
+	for _, floor := range m.Floors {
+		b.WriteString("\nfloor\n")
+		writeGrid(&b, floor.Grid)
+	}
+
+	if len(m.Spawns) > 0 {
+		b.WriteString("\n# Player spawns: spawn <x> <y>\n")
+		for _, pos := range m.Spawns {
+			fmt.Fprintf(&b, "spawn %s %s\n", formatCoord(pos.X), formatCoord(pos.Y))
+		}
+	}
+	if len(m.NPCs) > 0 {
+		b.WriteString("\n# NPCs: npc <x> <y>\n")
+		for _, pos := range m.NPCs {
+			fmt.Fprintf(&b, "npc %s %s\n", formatCoord(pos.X), formatCoord(pos.Y))
+		}
+	}
+	if len(m.Pickups) > 0 {
+		b.WriteString("\n# Pickups: pickup <health|speed|invisibility|quad|key|potion|grenade> <x> <y>\n")
+		for _, spawn := range m.Pickups {
+			fmt.Fprintf(&b, "pickup %s %s %s\n", GetPickupType(spawn.Kind).Name, formatCoord(spawn.Position.X), formatCoord(spawn.Position.Y))
+		}
+	}
+	if len(m.Lights) > 0 {
+		b.WriteString("\n# Lights: light <x> <y> <radius> <intensity> <color> [steady|flicker|pulse|strobe]\n")
+		for _, spawn := range m.Lights {
+			fmt.Fprintf(&b, "light %s %s %s %s %s %s\n", formatCoord(spawn.Position.X), formatCoord(spawn.Position.Y),
+				formatCoord(spawn.Radius), formatCoord(spawn.Intensity), spawn.colorName(), spawn.Animation)
+		}
+	}
+	if len(m.Portals) > 0 {
+		b.WriteString("\n# Portals: portal <x> <y> <shard> [<arrival x> <arrival y>]\n")
+		for _, portal := range m.Portals {
+			fmt.Fprintf(&b, "portal %s %s %s", formatCoord(portal.Position.X), formatCoord(portal.Position.Y), portal.Shard)
+			if portal.Arrival != nil {
+				fmt.Fprintf(&b, " %s %s", formatCoord(portal.Arrival.X), formatCoord(portal.Arrival.Y))
+			}
+			b.WriteByte('\n')
+		}
+	}
+	if len(m.Stairs) > 0 {
+		b.WriteString("\n# Stairs and ladders: stairs <x> <y> <floor> <other floor>, or ladder <x> <y> <floor> <other floor>\n")
+		for _, s := range m.Stairs {
+			b.WriteString(s.format() + "\n")
+		}
+	}
+	if len(m.Outdoors) > 0 {
+		b.WriteString("\n# Outdoor areas, under open sky: outdoor <x> <y> <x> <y>\n")
+		for _, a := range m.Outdoors {
+			fmt.Fprintf(&b, "outdoor %s\n", a.format())
+		}
+	}
+	if len(m.Movers) > 0 {
+		b.WriteString("\n# Moving walls: mover <x> <y> <wall type> timer <open seconds> <shut seconds>, or mover <x> <y> <wall type> trigger <name>\n")
+		for _, mover := range m.Movers {
+			fmt.Fprintf(&b, "mover %s\n", mover.format())
+		}
+	}
+	if m.Darkness > 0 || m.DayNight {
+		b.WriteString("\n")
+	}
+	if m.Darkness > 0 {
+		fmt.Fprintf(&b, "darkness %s\n", formatCoord(m.Darkness))
+	}
+	if m.DayNight {
+		b.WriteString("daynight on\n")
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// writeGrid writes the rows of a floor's grid in a map file
+func writeGrid(b *bytes.Buffer, grid [][]int) {
+	for _, row := range grid {
+		for x, v := range row {
+			if x > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(strconv.Itoa(v))
+		}
+		b.WriteByte('\n')
+	}
+}
+
+// formatCoord formats a number in a map file as briefly as it reads back
+func formatCoord(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// SaveMapToFile writes the map to a file, replacing it atomically so a
+// server loading it never sees part of one
+func SaveMapToFile(m *Map, filename string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save map file %s: %w", filename, err)
+	}
+	defer os.Remove(tmp.Name())
+	err = tmp.Chmod(0644)
+	if err == nil {
+		err = m.Write(tmp)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filename)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save map file %s: %w", filename, err)
+	}
+	return nil
+}
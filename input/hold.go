@@ -0,0 +1,79 @@
+package input
+
+import "sync"
+
+// Key hold tuning. Terminals only report key presses and auto-repeats, never
+// releases, so a key counts as held for HoldWindow after its latest press. The
+// window covers the gap between a press and the first auto-repeat, and movement
+// fades out over the final HoldDecay seconds so stopping doesn't feel abrupt.
+const (
+	HoldWindow = 0.3
+	HoldDecay  = 0.1
+)
+
+// opposites lists actions that cancel each other when pressed
+var opposites = map[Action]Action{
+	ActionMoveForward:  ActionMoveBackward,
+	ActionMoveBackward: ActionMoveForward,
+	ActionStrafeLeft:   ActionStrafeRight,
+	ActionStrafeRight:  ActionStrafeLeft,
+	ActionTurnLeft:     ActionTurnRight,
+	ActionTurnRight:    ActionTurnLeft,
+}
+
+// HoldTracker infers which actions are held down from a stream of key presses.
+// It's safe for one goroutine to press keys while another advances time.
+type HoldTracker struct {
+	mu        sync.Mutex
+	remaining map[Action]float64 // Seconds left until each action is released
+}
+
+// NewHoldTracker creates a tracker with nothing held
+func NewHoldTracker() *HoldTracker {
+	return &HoldTracker{remaining: make(map[Action]float64)}
+}
+
+// Press marks an action as held, releasing its opposite
+func (h *HoldTracker) Press(a Action) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remaining[a] = HoldWindow
+	if opposite, ok := opposites[a]; ok {
+		delete(h.remaining, opposite)
+	}
+}
+
+// Release stops holding an action immediately
+func (h *HoldTracker) Release(a Action) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.remaining, a)
+}
+
+// Strength returns how strongly an action is held, from 0 (released) to 1
+func (h *HoldTracker) Strength(a Action) float64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	remaining := h.remaining[a]
+	if remaining <= 0 {
+		return 0
+	}
+	if remaining >= HoldDecay {
+		return 1
+	}
+	return remaining / HoldDecay
+}
+
+// Update advances time, releasing actions whose hold window has passed
+func (h *HoldTracker) Update(deltaTime float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for a, remaining := range h.remaining {
+		remaining -= deltaTime
+		if remaining <= 0 {
+			delete(h.remaining, a)
+		} else {
+			h.remaining[a] = remaining
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package input
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// EscapeTimeout is how long to wait after an ESC byte for the rest of an escape
+// sequence before treating it as a lone ESC key press
+const EscapeTimeout = 50 * time.Millisecond
+
+// PasteTimeout is how long to wait for more of a bracketed paste, which can
+// arrive in packets spread out over a slow link, before giving up on its end
+const PasteTimeout = 5 * time.Second
+
+// KeyCode identifies a decoded key press
+type KeyCode int
+
+const (
+	KeyRune   KeyCode = iota // A printable or control character, stored in Key.Rune
+	KeyEscape                // A lone ESC key press
+	KeyUp                    // Arrow keys
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyHome
+	KeyEnd
+	KeyF1 // Function keys F1-F12 are consecutive
+	KeyF2
+	KeyF3
+	KeyF4
+	KeyF5
+	KeyF6
+	KeyF7
+	KeyF8
+	KeyF9
+	KeyF10
+	KeyF11
+	KeyF12
+	KeySequence // An escape sequence that isn't a known key, stored in Key.Seq
+	KeyPaste    // Text pasted in bracketed paste mode, stored in Key.Seq
+
+	keyDropped KeyCode = -1 // A sequence too long to keep, which next skips
+)
+
+// Key is a single decoded key press or terminal sequence
+type Key struct {
+	Code KeyCode
+	Rune rune   // Set for KeyRune
+	Seq  string // Raw bytes of the sequence, set for KeySequence and KeyPaste
+}
+
+// Is reports whether the key is the given character
+func (k Key) Is(r rune) bool {
+	return k.Code == KeyRune && k.Rune == r
+}
+
+// csiKeys maps the final byte of parameterless CSI and SS3 sequences to keys
+var csiKeys = map[byte]KeyCode{
+	'A': KeyUp,
+	'B': KeyDown,
+	'C': KeyRight,
+	'D': KeyLeft,
+	'H': KeyHome,
+	'F': KeyEnd,
+	'P': KeyF1, // SS3 P-S are F1-F4
+	'Q': KeyF2,
+	'R': KeyF3,
+	'S': KeyF4,
+}
+
+// tildeKeys maps the numeric parameter of "CSI n ~" sequences to keys
+var tildeKeys = map[string]KeyCode{
+	"1": KeyHome, "7": KeyHome,
+	"4": KeyEnd, "8": KeyEnd,
+	"11": KeyF1, "12": KeyF2, "13": KeyF3, "14": KeyF4, "15": KeyF5,
+	"17": KeyF6, "18": KeyF7, "19": KeyF8, "20": KeyF9, "21": KeyF10,
+	"23": KeyF11, "24": KeyF12,
+}
+
+// ReadKeys reads bytes from r, decodes them into keys, and sends them to keys.
+// Keys are dropped if the channel is full. It returns when r returns an error
+// or ctx is done, whichever comes first. Reads can't be interrupted, so the
+// goroutine reading r may outlive it until r's next Read returns, as it does
+// once a connection is closed; nothing is sent to keys after ReadKeys returns.
+func ReadKeys(ctx context.Context, r io.Reader, keys chan<- Key) error {
+	bytesCh := make(chan byte, 64)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(bytesCh)
+		buf := make([]byte, 64)
+		for {
+			n, err := r.Read(buf)
+			for _, b := range buf[:n] {
+				select {
+				case bytesCh <- b:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err != nil {
+				errCh <- err
+				return
+			}
+		}
+	}()
+
+	p := &parser{bytes: bytesCh, done: ctx.Done()}
+	for {
+		key, ok := p.next()
+		if !ok {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			return <-errCh
+		}
+		if key, ok = Sanitize(key); !ok {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		select {
+		case keys <- key:
+		default:
+			// Drop input if channel is full
+		}
+	}
+}
+
+// parser decodes keys from a stream of bytes
+type parser struct {
+	bytes   <-chan byte
+	done    <-chan struct{} // Closed to stop decoding, as if the input ended
+	pending []byte          // Bytes read ahead that haven't been decoded yet
+}
+
+// read returns the next byte, waiting at most timeout if it's non-zero
+func (p *parser) read(timeout time.Duration) (byte, bool) {
+	if len(p.pending) > 0 {
+		b := p.pending[0]
+		p.pending = p.pending[1:]
+		return b, true
+	}
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case b, ok := <-p.bytes:
+		return b, ok
+	case <-expired:
+		return 0, false
+	case <-p.done:
+		return 0, false
+	}
+}
+
+// maxSequence is the longest escape sequence or control string kept; longer
+// ones are read to their end and dropped
+const maxSequence = 256
+
+// next decodes the next key, returning false once the input is exhausted
+func (p *parser) next() (Key, bool) {
+	for {
+		key, ok := p.decode()
+		if !ok || key.Code != keyDropped {
+			return key, ok
+		}
+	}
+}
+
+// decode decodes the next key or sequence, returning false once the input is
+// exhausted
+func (p *parser) decode() (Key, bool) {
+	b, ok := p.read(0)
+	if !ok {
+		return Key{}, false
+	}
+	if b != 0x1b {
+		return p.decodeRune(b), true
+	}
+
+	// ESC on its own, or the start of a sequence?
+	b2, ok := p.read(EscapeTimeout)
+	if !ok {
+		return Key{Code: KeyEscape}, true
+	}
+	switch b2 {
+	case '[':
+		return p.readCSI(), true
+	case 'O':
+		b3, ok := p.read(EscapeTimeout)
+		if !ok {
+			return Key{Code: KeySequence, Seq: "\x1bO"}, true
+		}
+		if code, known := csiKeys[b3]; known {
+			return Key{Code: code}, true
+		}
+		return Key{Code: KeySequence, Seq: "\x1bO" + string(b3)}, true
+	case 'P', ']', '_', '^':
+		// Device control and operating system strings run until a string terminator
+		return p.readString(b2), true
+	case 0x1b:
+		// Two ESCs in a row: the first was a lone ESC
+		p.pending = append([]byte{b2}, p.pending...)
+		return Key{Code: KeyEscape}, true
+	default:
+		// Alt+key: drop the modifier and deliver the key itself
+		return p.decodeRune(b2), true
+	}
+}
+
+// readCSI reads the rest of a "ESC [" control sequence
+func (p *parser) readCSI() Key {
+	seq := []byte("\x1b[")
+	dropped := false
+	for {
+		b, ok := p.read(EscapeTimeout)
+		if !ok {
+			if dropped {
+				return Key{Code: keyDropped}
+			}
+			return Key{Code: KeySequence, Seq: string(seq)}
+		}
+		if b >= 0x40 && b <= 0x7e {
+			seq = append(seq, b)
+			break // Final byte
+		}
+		if len(seq) >= maxSequence-1 {
+			dropped = true
+			continue
+		}
+		seq = append(seq, b)
+	}
+	if dropped {
+		return Key{Code: keyDropped}
+	}
+
+	params := string(seq[2 : len(seq)-1])
+	final := seq[len(seq)-1]
+	switch {
+	case final == '~' && params == "200":
+		return p.readPaste()
+	case final == '~':
+		if code, known := tildeKeys[params]; known {
+			return Key{Code: code}
+		}
+	case params == "" || params == "1":
+		if code, known := csiKeys[final]; known && final != 'P' && final != 'Q' && final != 'R' && final != 'S' {
+			return Key{Code: code}
+		}
+	}
+	return Key{Code: KeySequence, Seq: string(seq)}
+}
+
+// pasteEnd marks the end of bracketed paste content
+const pasteEnd = "\x1b[201~"
+
+// maxPaste is the most pasted content kept; the rest is read and discarded
+const maxPaste = 4096
+
+// readPaste reads bracketed paste content up to the end marker, however
+// slowly it arrives, so none of it is taken for key presses
+func (p *parser) readPaste() Key {
+	var content []byte
+	matched := 0 // Bytes of pasteEnd matched so far
+	for matched < len(pasteEnd) {
+		b, ok := p.read(PasteTimeout)
+		if !ok {
+			break
+		}
+		if b == pasteEnd[matched] {
+			matched++
+			continue
+		}
+		// Not the end marker after all: keep what we thought might be
+		content = appendPaste(content, []byte(pasteEnd[:matched])...)
+		matched = 0
+		if b == pasteEnd[0] {
+			matched = 1
+			continue
+		}
+		content = appendPaste(content, b)
+	}
+	return Key{Code: KeyPaste, Seq: string(content)}
+}
+
+// appendPaste appends bytes to pasted content, dropping those past maxPaste
+func appendPaste(content []byte, b ...byte) []byte {
+	return append(content, b[:min(len(b), maxPaste-len(content))]...)
+}
+
+// readString reads a DCS/OSC/APC/PM string up to its terminator (ESC \ or BEL)
+func (p *parser) readString(introducer byte) Key {
+	seq := []byte{0x1b, introducer}
+	dropped := false
+	last := byte(0) // The byte before b, which may start the ESC \ terminator
+	for {
+		b, ok := p.read(EscapeTimeout)
+		if !ok {
+			break
+		}
+		terminated := b == 0x07 || (b == '\\' && last == 0x1b)
+		last = b
+		if len(seq) < maxSequence {
+			seq = append(seq, b)
+		} else {
+			dropped = true
+		}
+		if terminated {
+			break
+		}
+	}
+	if dropped {
+		return Key{Code: keyDropped}
+	}
+	return Key{Code: KeySequence, Seq: string(seq)}
+}
+
+// decodeRune decodes a plain character, including multi-byte UTF-8
+func (p *parser) decodeRune(b byte) Key {
+	var size int
+	switch {
+	case b < 0x80:
+		return Key{Code: KeyRune, Rune: rune(b)}
+	case b&0xe0 == 0xc0:
+		size = 2
+	case b&0xf0 == 0xe0:
+		size = 3
+	case b&0xf8 == 0xf0:
+		size = 4
+	default:
+		return Key{Code: KeyRune, Rune: 0xfffd}
+	}
+
+	buf := []byte{b}
+	for len(buf) < size {
+		next, ok := p.read(EscapeTimeout)
+		if !ok {
+			break
+		}
+		buf = append(buf, next)
+	}
+	r := []rune(string(buf))
+	if len(r) != 1 {
+		return Key{Code: KeyRune, Rune: 0xfffd}
+	}
+	return Key{Code: KeyRune, Rune: r[0]}
+}
@@ -0,0 +1,153 @@
+package input
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// parseAll decodes every key in data, as if it arrived all at once and then
+// the input ended
+func parseAll(data string) []Key {
+	bytes := make(chan byte, len(data))
+	for i := range len(data) {
+		bytes <- data[i]
+	}
+	close(bytes)
+
+	p := &parser{bytes: bytes}
+	var keys []Key
+	for {
+		key, ok := p.next()
+		if !ok {
+			return keys
+		}
+		keys = append(keys, key)
+	}
+}
+
+func TestParser(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		data string
+		want []Key
+	}{
+		{"letter", "w", []Key{{Code: KeyRune, Rune: 'w'}}},
+		{"utf-8", "é", []Key{{Code: KeyRune, Rune: 'é'}}},
+		{"invalid utf-8", "\xff", []Key{{Code: KeyRune, Rune: 0xfffd}}},
+		{"lone escape", "\x1b", []Key{{Code: KeyEscape}}},
+		{"two escapes", "\x1b\x1b", []Key{{Code: KeyEscape}, {Code: KeyEscape}}},
+		{"alt key", "\x1bw", []Key{{Code: KeyRune, Rune: 'w'}}},
+		{"arrow", "\x1b[A", []Key{{Code: KeyUp}}},
+		{"arrow with modifier 1", "\x1b[1D", []Key{{Code: KeyLeft}}},
+		{"ss3 arrow", "\x1bOB", []Key{{Code: KeyDown}}},
+		{"ss3 function key", "\x1bOP", []Key{{Code: KeyF1}}},
+		{"csi P isn't F1", "\x1b[P", []Key{{Code: KeySequence, Seq: "\x1b[P"}}},
+		{"tilde function key", "\x1b[15~", []Key{{Code: KeyF5}}},
+		{"tilde home", "\x1b[1~", []Key{{Code: KeyHome}}},
+		{"unknown tilde", "\x1b[99~", []Key{{Code: KeySequence, Seq: "\x1b[99~"}}},
+		{"modified arrow", "\x1b[1;5A", []Key{{Code: KeySequence, Seq: "\x1b[1;5A"}}},
+		{"cut off csi", "\x1b[1", []Key{{Code: KeySequence, Seq: "\x1b[1"}}},
+		{"osc with bel", "\x1b]0;title\x07w", []Key{{Code: KeySequence, Seq: "\x1b]0;title\x07"}, {Code: KeyRune, Rune: 'w'}}},
+		{"dcs with st", "\x1bPdata\x1b\\w", []Key{{Code: KeySequence, Seq: "\x1bPdata\x1b\\"}, {Code: KeyRune, Rune: 'w'}}},
+		{"keys after a sequence", "\x1b[Cw", []Key{{Code: KeyRight}, {Code: KeyRune, Rune: 'w'}}},
+		{"paste", "\x1b[200~hello\x1b[201~w", []Key{{Code: KeyPaste, Seq: "hello"}, {Code: KeyRune, Rune: 'w'}}},
+		{"empty paste", "\x1b[200~\x1b[201~", []Key{{Code: KeyPaste}}},
+		{"paste with escapes", "\x1b[200~a\x1b[Ab\x1b[201~", []Key{{Code: KeyPaste, Seq: "a\x1b[Ab"}}},
+		{"paste with part of the end", "\x1b[200~a\x1b[20x\x1b[201~", []Key{{Code: KeyPaste, Seq: "a\x1b[20x"}}},
+		{"paste with escape before the end", "\x1b[200~a\x1b\x1b[201~", []Key{{Code: KeyPaste, Seq: "a\x1b"}}},
+		{"paste with broken end then the end", "\x1b[200~a\x1b[2\x1b[201~", []Key{{Code: KeyPaste, Seq: "a\x1b[2"}}},
+		{"unterminated paste", "\x1b[200~wasd", []Key{{Code: KeyPaste, Seq: "wasd"}}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseAll(tt.data)
+			if len(got) != len(tt.want) {
+				t.Fatalf("parse(%q) = %q, want %q", tt.data, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("parse(%q)[%d] = %q, want %q", tt.data, i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPasteLimit(t *testing.T) {
+	long := strings.Repeat("x", maxPaste+10)
+	for _, tt := range []struct {
+		name string
+		data string
+		want string
+	}{
+		{"too long", "\x1b[200~" + long + "\x1b[201~", long[:maxPaste]},
+		{"part of the end past the limit", "\x1b[200~" + long + "\x1b[20y\x1b[201~", long[:maxPaste]},
+		{"broken end past the limit", "\x1b[200~" + long + "\x1b[2\x1b[201~", long[:maxPaste]},
+		{"part of the end at the limit", "\x1b[200~" + long[:maxPaste-2] + "\x1b[20y\x1b[201~", long[:maxPaste-2] + "\x1b["},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseAll(tt.data)
+			if len(got) != 1 || got[0].Code != KeyPaste {
+				t.Fatalf("got %d keys, want one paste", len(got))
+			}
+			if got[0].Seq != tt.want {
+				t.Errorf("paste of %d bytes ending %q, want %d bytes ending %q", len(got[0].Seq), got[0].Seq[max(0, len(got[0].Seq)-4):], len(tt.want), tt.want[len(tt.want)-4:])
+			}
+		})
+	}
+}
+
+func TestSlowPaste(t *testing.T) {
+	// A paste split into packets further apart than EscapeTimeout is still one paste
+	bytes := make(chan byte)
+	go func() {
+		defer close(bytes)
+		for _, chunk := range []string{"\x1b[200~wa", "sd\x1b", "[201~w"} {
+			for i := range len(chunk) {
+				bytes <- chunk[i]
+			}
+			time.Sleep(2 * EscapeTimeout)
+		}
+	}()
+
+	p := &parser{bytes: bytes}
+	want := []Key{{Code: KeyPaste, Seq: "wasd"}, {Code: KeyRune, Rune: 'w'}}
+	for i, w := range want {
+		got, ok := p.next()
+		if !ok || got != w {
+			t.Fatalf("key %d = %q, %t, want %q", i, got, ok, w)
+		}
+	}
+}
+
+func TestOversizedSequence(t *testing.T) {
+	long := strings.Repeat("1", 10*maxSequence)
+	csiAtLimit := "\x1b[" + strings.Repeat("1", maxSequence-3) + "m"
+	oscAtLimit := "\x1b]" + strings.Repeat("x", maxSequence-3) + "\x07"
+	for _, tt := range []struct {
+		name string
+		data string
+		want []Key
+	}{
+		{"csi", "\x1b[" + long + "Aw", []Key{{Code: KeyRune, Rune: 'w'}}},
+		{"osc with bel", "\x1b]" + long + "\x07w", []Key{{Code: KeyRune, Rune: 'w'}}},
+		{"dcs with st", "\x1bP" + long + "\x1b\\w", []Key{{Code: KeyRune, Rune: 'w'}}},
+		{"cut off csi", "\x1b[" + long, nil},
+		{"cut off osc", "\x1b]" + long, nil},
+		{"several in a row", strings.Repeat("\x1b["+long+"A", 3) + "w", []Key{{Code: KeyRune, Rune: 'w'}}},
+		{"csi at the limit", csiAtLimit + "w", []Key{{Code: KeySequence, Seq: csiAtLimit}, {Code: KeyRune, Rune: 'w'}}},
+		{"osc at the limit", oscAtLimit + "w", []Key{{Code: KeySequence, Seq: oscAtLimit}, {Code: KeyRune, Rune: 'w'}}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseAll(tt.data)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d keys, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("key %d = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package input
+
+import (
+	"sort"
+	"unicode"
+)
+
+// Action is a gameplay action that keys can be bound to
+type Action int
+
+const (
+	// Continuous actions, which can be held down
+	ActionMoveForward Action = iota
+	ActionMoveBackward
+	ActionStrafeLeft
+	ActionStrafeRight
+	ActionTurnLeft
+	ActionTurnRight
+	ActionSprint // Held by the game while a player who toggled sprinting on moves
+
+	// Discrete actions, which happen once per press
+	ActionFire
+	ActionLastWeapon
+	ActionToggleAccess
+	ActionSwapTurnStrafe
+	ActionReload
+	ActionTorch
+)
+
+// actionNames are human-readable descriptions of each action
+var actionNames = map[Action]string{
+	ActionMoveForward:    "Move forward",
+	ActionMoveBackward:   "Move backward",
+	ActionStrafeLeft:     "Strafe left",
+	ActionStrafeRight:    "Strafe right",
+	ActionTurnLeft:       "Turn left",
+	ActionTurnRight:      "Turn right",
+	ActionSprint:         "Sprint on/off",
+	ActionFire:           "Fire",
+	ActionLastWeapon:     "Previous weapon",
+	ActionToggleAccess:   "Text descriptions",
+	ActionSwapTurnStrafe: "Swap turn/strafe keys",
+	ActionReload:         "Reload",
+	ActionTorch:          "Torch",
+}
+
+// String returns a human-readable description of the action
+func (a Action) String() string {
+	if name, ok := actionNames[a]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// IsMovement reports whether the action moves the player, so it sprints while
+// sprinting is toggled on
+func (a Action) IsMovement() bool {
+	switch a {
+	case ActionMoveForward, ActionMoveBackward, ActionStrafeLeft, ActionStrafeRight:
+		return true
+	}
+	return false
+}
+
+// IsContinuous reports whether the action is held rather than triggered once
+func (a Action) IsContinuous() bool {
+	return a <= ActionSprint
+}
+
+// Keymap binds keys to actions. Keys are stored lowercase and match either
+// case, so caps lock doesn't change what they do.
+type Keymap struct {
+	Name string
+	Keys map[rune]Action
+
+	// TurnStrafeSwapped is set when the strafe and turn keys have been swapped,
+	// e.g. so A/D turn and Q/E strafe like classic Wolfenstein 3D
+	TurnStrafeSwapped bool
+}
+
+// turnStrafeSwaps pairs each strafe action with the turn action in the same direction
+var turnStrafeSwaps = map[Action]Action{
+	ActionStrafeLeft:  ActionTurnLeft,
+	ActionTurnLeft:    ActionStrafeLeft,
+	ActionStrafeRight: ActionTurnRight,
+	ActionTurnRight:   ActionStrafeRight,
+}
+
+// SwapTurnStrafe exchanges the bindings of the strafe and turn keys
+func (k *Keymap) SwapTurnStrafe() {
+	for r, a := range k.Keys {
+		if swapped, ok := turnStrafeSwaps[a]; ok {
+			k.Keys[r] = swapped
+		}
+	}
+	k.TurnStrafeSwapped = !k.TurnStrafeSwapped
+}
+
+// Lookup returns the action bound to a key, in either case
+func (k Keymap) Lookup(r rune) (Action, bool) {
+	action, ok := k.Keys[unicode.ToLower(r)]
+	return action, ok
+}
+
+// KeysFor returns the keys bound to an action, sorted
+func (k Keymap) KeysFor(a Action) []rune {
+	var keys []rune
+	for r, action := range k.Keys {
+		if action == a {
+			keys = append(keys, r)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// Clone returns a copy of the keymap that can be modified independently
+func (k Keymap) Clone() Keymap {
+	keys := make(map[rune]Action, len(k.Keys))
+	for r, a := range k.Keys {
+		keys[r] = a
+	}
+	return Keymap{Name: k.Name, Keys: keys, TurnStrafeSwapped: k.TurnStrafeSwapped}
+}
+
+// presets are the built-in keymaps
+var presets = map[string]Keymap{
+	"wasd": {Name: "wasd", Keys: map[rune]Action{
+		'w': ActionMoveForward, 's': ActionMoveBackward,
+		'a': ActionStrafeLeft, 'd': ActionStrafeRight,
+		'q': ActionTurnLeft, 'e': ActionTurnRight,
+		' ': ActionFire, 'x': ActionLastWeapon, 't': ActionToggleAccess, 'c': ActionSwapTurnStrafe, 'r': ActionReload, 'f': ActionTorch, 'z': ActionSprint,
+	}},
+	"esdf": {Name: "esdf", Keys: map[rune]Action{
+		'e': ActionMoveForward, 'd': ActionMoveBackward,
+		's': ActionStrafeLeft, 'f': ActionStrafeRight,
+		'w': ActionTurnLeft, 'r': ActionTurnRight,
+		' ': ActionFire, 'x': ActionLastWeapon, 't': ActionToggleAccess, 'c': ActionSwapTurnStrafe, 'g': ActionReload, 'v': ActionTorch, 'a': ActionSprint,
+	}},
+	"azerty": {Name: "azerty", Keys: map[rune]Action{
+		'z': ActionMoveForward, 's': ActionMoveBackward,
+		'q': ActionStrafeLeft, 'd': ActionStrafeRight,
+		'a': ActionTurnLeft, 'e': ActionTurnRight,
+		' ': ActionFire, 'x': ActionLastWeapon, 't': ActionToggleAccess, 'c': ActionSwapTurnStrafe, 'r': ActionReload, 'f': ActionTorch, 'w': ActionSprint,
+	}},
+	"vim": {Name: "vim", Keys: map[rune]Action{
+		'k': ActionMoveForward, 'j': ActionMoveBackward,
+		'y': ActionStrafeLeft, 'u': ActionStrafeRight,
+		'h': ActionTurnLeft, 'l': ActionTurnRight,
+		' ': ActionFire, 'x': ActionLastWeapon, 't': ActionToggleAccess, 'c': ActionSwapTurnStrafe, 'r': ActionReload, 'f': ActionTorch, 'z': ActionSprint,
+	}},
+	"lefty": {Name: "lefty", Keys: map[rune]Action{
+		'i': ActionMoveForward, 'k': ActionMoveBackward,
+		'j': ActionStrafeLeft, 'l': ActionStrafeRight,
+		'u': ActionTurnLeft, 'o': ActionTurnRight,
+		' ': ActionFire, 'n': ActionLastWeapon, 'p': ActionToggleAccess, 'm': ActionSwapTurnStrafe, 'h': ActionReload, 'y': ActionTorch, ';': ActionSprint,
+	}},
+}
+
+// DefaultPreset is the keymap new sessions start with
+const DefaultPreset = "wasd"
+
+// Preset returns a copy of the named built-in keymap
+func Preset(name string) (Keymap, bool) {
+	k, ok := presets[name]
+	if !ok {
+		return Keymap{}, false
+	}
+	return k.Clone(), true
+}
+
+// DefaultKeymap returns a copy of the default keymap
+func DefaultKeymap() Keymap {
+	k, _ := Preset(DefaultPreset)
+	return k
+}
+
+// PresetNames returns the names of the built-in keymaps, sorted
+func PresetNames() []string {
+	var names []string
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
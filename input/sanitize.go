@@ -0,0 +1,33 @@
+package input
+
+import "unicode"
+
+// allowedControls are the control characters the game and console respond to
+var allowedControls = map[rune]bool{
+	'\r': true, // Enter
+	'\n': true, // Enter on some terminals
+	'\t': true, // Tab completion
+	3:    true, // Ctrl+C
+	8:    true, // Backspace
+	21:   true, // Ctrl+U
+	127:  true, // Backspace (DEL)
+}
+
+// Sanitize filters a decoded key before it reaches the game or console. Pasted
+// text and stray control characters are dropped so they can't be mistaken for
+// gameplay keys or injected into chat. Terminal responses (KeySequence) are
+// passed through for capability probing, and ignored by the game otherwise.
+func Sanitize(k Key) (Key, bool) {
+	switch k.Code {
+	case KeyPaste:
+		return Key{}, false
+	case KeyRune:
+		if k.Rune == unicode.ReplacementChar {
+			return Key{}, false
+		}
+		if !unicode.IsPrint(k.Rune) && !allowedControls[k.Rune] {
+			return Key{}, false
+		}
+	}
+	return k, true
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/imjasonh/terminus/bots"
+)
+
+// loadTestUsage is the first line of `terminus loadtest -h`
+const loadTestUsage = "Usage: %s loadtest [flags]\n\nConnects bots that play on a running server over SSH, then reports frame timing and bandwidth.\n\n"
+
+// runLoadTest runs the loadtest subcommand with its arguments, returning the exit code
+func runLoadTest(args []string) int {
+	opts := bots.DefaultOptions(10)
+	fs := flag.NewFlagSet("loadtest", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), loadTestUsage, os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&opts.Addr, "addr", opts.Addr, "SSH address of the server to test")
+	fs.IntVar(&opts.Count, "n", opts.Count, "number of bots to connect")
+	fs.DurationVar(&opts.Duration, "duration", opts.Duration, "how long each bot plays")
+	fs.DurationVar(&opts.Ramp, "ramp", opts.Ramp, "delay between connecting bots")
+	fs.IntVar(&opts.KeyRate, "key-rate", opts.KeyRate, "keys each bot presses per second")
+	fs.StringVar(&opts.Code, "invite-code", "", "the server's invite code, if it has one")
+	fs.StringVar(&opts.Term, "term", opts.Term, "TERM bots request, which decides the colors they're sent")
+	fs.IntVar(&opts.Width, "cols", opts.Width, "bots' terminal width")
+	fs.IntVar(&opts.Height, "rows", opts.Height, "bots' terminal height")
+	debugAddr := fs.String("debug-addr", "", "the server's -debug-addr, to also report its frame times and memory under load")
+	fs.Parse(args)
+	if opts.Count < 1 || opts.KeyRate < 1 || opts.Duration <= 0 || opts.Width < 1 || opts.Height < 1 {
+		fmt.Fprintln(os.Stderr, "-n, -key-rate, -duration, -cols, and -rows must be positive")
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Sample the server while the most players are connected
+	var busiest *runtimeStats
+	var sampling sync.WaitGroup
+	sampleCtx, stopSampling := context.WithCancel(ctx)
+	if *debugAddr != "" {
+		sampling.Add(1)
+		go func() {
+			defer sampling.Done()
+			busiest = sampleServer(sampleCtx, *debugAddr)
+		}()
+	}
+
+	fmt.Printf("Connecting %d bots to %s for %s...\n", opts.Count, opts.Addr, opts.Duration)
+	report := bots.Run(ctx, opts, func(id int, stats bots.Stats) {
+		if stats.Err != nil {
+			fmt.Fprintf(os.Stderr, "bot%d: %v\n", id, stats.Err)
+		}
+	})
+	stopSampling()
+	sampling.Wait()
+
+	fmt.Println()
+	report.Write(os.Stdout)
+	if busiest != nil {
+		fmt.Printf("Server:         %d players, %d goroutines, %.1f MB heap\n",
+			busiest.Players, busiest.Goroutines, float64(busiest.HeapAllocBytes)/(1<<20))
+		if len(busiest.Sessions) > 0 {
+			slowest := busiest.Sessions[0]
+			fmt.Printf("Server frames:  slowest session %s averages %.2f ms to draw and send, max %.2f ms\n",
+				slowest.Name, slowest.AverageMs, slowest.MaxMs)
+		}
+	}
+	if report.Joined == 0 {
+		return 1
+	}
+	return 0
+}
+
+// sampleServer polls the server's runtime stats every second until ctx is
+// done, returning the sample with the most players
+func sampleServer(ctx context.Context, addr string) *runtimeStats {
+	client := &http.Client{Timeout: 2 * time.Second}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	var busiest *runtimeStats
+	for {
+		select {
+		case <-ctx.Done():
+			return busiest
+		case <-ticker.C:
+		}
+		resp, err := client.Get("http://" + addr + "/debug/runtime")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Sampling server: %v\n", err)
+			continue
+		}
+		var stats runtimeStats
+		err = json.NewDecoder(resp.Body).Decode(&stats)
+		resp.Body.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Sampling server: %v\n", err)
+			continue
+		}
+		if busiest == nil || stats.Players >= busiest.Players {
+			busiest = &stats
+		}
+	}
+}
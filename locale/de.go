@@ -0,0 +1,95 @@
+package locale
+
+// german is the German catalog
+var german = Catalog{
+	// HUD
+	"F%d":          "E%d",
+	"HP":           "LP",
+	"STA":          "AUS",
+	"AMMO":         "MUN",
+	"TIRED":        "MÜDE",
+	"Fireball":     "Feuerball",
+	"Scatter":      "Streuung",
+	"Grenades":     "Granaten",
+	"Rockets":      "Raketen",
+	"Ricochet":     "Querschläger",
+	"Railgun":      "Railgun",
+	"Flamethrower": "Flammenwerfer",
+	"Key":          "Schlüssel",
+	"Potion":       "Trank",
+	"Grenade":      "Granate",
+	"Mine":         "Mine",
+
+	// Sounds
+	"BANG":  "PENG",
+	"BOOM":  "BUMM",
+	"step":  "Schritt",
+	"CLUNK": "KLONK",
+
+	// Menus
+	"Terminal: %s, %dx%d":      "Terminal: %s, %dx%d",
+	"Colors: %s | Unicode: %s": "Farben: %s | Unicode: %s",
+	"yes":                      "ja",
+	"no":                       "nein",
+	"Press ENTER to start, or change settings:":                                           "ENTER zum Starten drücken, oder Einstellungen ändern:",
+	"  [1] 24-bit color  [2] 256 colors  [3] 16 colors  [4] no color  [U] toggle Unicode": "  [1] 24-Bit-Farbe  [2] 256 Farben  [3] 16 Farben  [4] keine Farbe  [U] Unicode an/aus",
+	"  [F] Frame rate: %s": "  [F] Bildrate: %s",
+	"%d FPS":               "%d FPS",
+	"server default":       "Server-Standard",
+	"  [L] Language: %s":   "  [L] Sprache: %s",
+	"Help: %s (%d/%d)":     "Hilfe: %s (%d/%d)",
+	"Keys":                 "Tasten",
+	"Rules":                "Regeln",
+	"Map":                  "Karte",
+	"Left/Right: turn pages   ? or Esc: close":             "Links/Rechts: blättern   ? oder Esc: schließen",
+	"Text mode. Press %s to return to graphics.":           "Textmodus. %s drücken, um zur Grafik zurückzukehren.",
+	"Classic controls: strafe keys turn, turn keys strafe": "Klassische Steuerung: Seitschritt-Tasten drehen, Dreh-Tasten gehen seitwärts",
+	"Standard controls restored":                           "Standardsteuerung wiederhergestellt",
+	"Language: %s":                                         "Sprache: %s",
+
+	// Notices
+	"You died":                 "Du bist gestorben",
+	"You were killed by %s":    "Du wurdest von %s getötet",
+	"You climb up to floor %d": "Du steigst hinauf in Etage %d",
+	"You go down to floor %d":  "Du steigst hinab in Etage %d",
+	"Sprint on":                "Sprinten an",
+	"Sprint off":               "Sprinten aus",
+	"You picked up %s":         "Aufgehoben: %s",
+	"%s joined your party":     "%s ist deiner Gruppe beigetreten",
+	"%s left your party":       "%s hat deine Gruppe verlassen",
+	"%s invited you to their party; type /accept to join":                         "%s hat dich in die Gruppe eingeladen; /accept zum Beitreten",
+	"Your recording reached its limit; /ghost stop to keep it":                    "Deine Aufnahme hat ihr Limit erreicht; /ghost stop, um sie zu behalten",
+	"Practicing alone: /speed 0.25 to 2 to change the pace, /freeze to stop NPCs": "Training allein: /speed 0.25 bis 2 ändert das Tempo, /freeze hält die NPCs an",
+
+	// Events
+	"%s joined":                        "%s ist beigetreten",
+	"%s left":                          "%s ist gegangen",
+	"%s is now %s":                     "%s heißt jetzt %s",
+	"%s fired %s":                      "%s hat geschossen: %s",
+	"Map changed to %s":                "Karte gewechselt zu %s",
+	"%s waves":                         "%s winkt",
+	"%s taunts":                        "%s stichelt",
+	"%s laughs":                        "%s lacht",
+	"%s died":                          "%s ist gestorben",
+	"%s was killed by %s":              "%s wurde von %s getötet",
+	"The sun is up":                    "Die Sonne ist aufgegangen",
+	"Dusk falls":                       "Die Dämmerung bricht herein",
+	"Night has fallen":                 "Die Nacht ist hereingebrochen",
+	"Dawn breaks":                      "Der Morgen graut",
+	"%s picked up %s":                  "%s hat aufgehoben: %s",
+	"%s used %s":                       "%s hat benutzt: %s",
+	"A match started on %s":            "Ein Match hat auf %s begonnen",
+	"The match on %s ended":            "Das Match auf %s ist vorbei",
+	"%s set a server record: %s":       "%s hat einen Serverrekord aufgestellt: %s",
+	"%s stepped into the portal to %s": "%s ist durch das Portal nach %s gegangen",
+	"%s won the match on %s with a score of %d": "%s hat das Match auf %s mit %d Punkten gewonnen",
+
+	// Pickups
+	"health pack":  "Medipack",
+	"speed boost":  "Tempo-Bonus",
+	"invisibility": "Unsichtbarkeit",
+	"quad damage":  "Vierfacher Schaden",
+	"key":          "Schlüssel",
+	"potion":       "Trank",
+	"grenade":      "Granate",
+}
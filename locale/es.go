@@ -0,0 +1,95 @@
+package locale
+
+// spanish is the Spanish catalog
+var spanish = Catalog{
+	// HUD
+	"F%d":          "P%d",
+	"HP":           "PV",
+	"STA":          "RES",
+	"AMMO":         "MUN",
+	"TIRED":        "AGOT.",
+	"Fireball":     "Bola de fuego",
+	"Scatter":      "Dispersión",
+	"Grenades":     "Granadas",
+	"Rockets":      "Cohetes",
+	"Ricochet":     "Rebote",
+	"Railgun":      "Cañón de riel",
+	"Flamethrower": "Lanzallamas",
+	"Key":          "Llave",
+	"Potion":       "Poción",
+	"Grenade":      "Granada",
+	"Mine":         "Mina",
+
+	// Sounds
+	"BANG":  "PUM",
+	"BOOM":  "BUM",
+	"step":  "paso",
+	"CLUNK": "CLAC",
+
+	// Menus
+	"Terminal: %s, %dx%d":      "Terminal: %s, %dx%d",
+	"Colors: %s | Unicode: %s": "Colores: %s | Unicode: %s",
+	"yes":                      "sí",
+	"no":                       "no",
+	"Press ENTER to start, or change settings:":                                           "Pulsa ENTER para empezar, o cambia los ajustes:",
+	"  [1] 24-bit color  [2] 256 colors  [3] 16 colors  [4] no color  [U] toggle Unicode": "  [1] color de 24 bits  [2] 256 colores  [3] 16 colores  [4] sin color  [U] activar/desactivar Unicode",
+	"  [F] Frame rate: %s": "  [F] Tasa de fotogramas: %s",
+	"%d FPS":               "%d FPS",
+	"server default":       "predeterminada del servidor",
+	"  [L] Language: %s":   "  [L] Idioma: %s",
+	"Help: %s (%d/%d)":     "Ayuda: %s (%d/%d)",
+	"Keys":                 "Teclas",
+	"Rules":                "Reglas",
+	"Map":                  "Mapa",
+	"Left/Right: turn pages   ? or Esc: close":             "Izquierda/Derecha: pasar página   ? o Esc: cerrar",
+	"Text mode. Press %s to return to graphics.":           "Modo texto. Pulsa %s para volver a los gráficos.",
+	"Classic controls: strafe keys turn, turn keys strafe": "Controles clásicos: las teclas de desplazamiento giran y las de giro se desplazan",
+	"Standard controls restored":                           "Controles estándar restablecidos",
+	"Language: %s":                                         "Idioma: %s",
+
+	// Notices
+	"You died":                 "Has muerto",
+	"You were killed by %s":    "Te ha matado %s",
+	"You climb up to floor %d": "Subes a la planta %d",
+	"You go down to floor %d":  "Bajas a la planta %d",
+	"Sprint on":                "Esprint activado",
+	"Sprint off":               "Esprint desactivado",
+	"You picked up %s":         "Has recogido: %s",
+	"%s joined your party":     "%s se ha unido a tu grupo",
+	"%s left your party":       "%s ha dejado tu grupo",
+	"%s invited you to their party; type /accept to join":                         "%s te ha invitado a su grupo; escribe /accept para unirte",
+	"Your recording reached its limit; /ghost stop to keep it":                    "Tu grabación ha llegado al límite; /ghost stop para guardarla",
+	"Practicing alone: /speed 0.25 to 2 to change the pace, /freeze to stop NPCs": "Practicando a solas: /speed de 0.25 a 2 para cambiar el ritmo, /freeze para detener a los PNJ",
+
+	// Events
+	"%s joined":                        "%s ha entrado",
+	"%s left":                          "%s se ha ido",
+	"%s is now %s":                     "%s ahora se llama %s",
+	"%s fired %s":                      "%s ha disparado %s",
+	"Map changed to %s":                "El mapa ha cambiado a %s",
+	"%s waves":                         "%s saluda",
+	"%s taunts":                        "%s provoca",
+	"%s laughs":                        "%s se ríe",
+	"%s died":                          "%s ha muerto",
+	"%s was killed by %s":              "%s ha muerto a manos de %s",
+	"The sun is up":                    "Ha salido el sol",
+	"Dusk falls":                       "Cae el crepúsculo",
+	"Night has fallen":                 "Ha caído la noche",
+	"Dawn breaks":                      "Amanece",
+	"%s picked up %s":                  "%s ha recogido: %s",
+	"%s used %s":                       "%s ha usado: %s",
+	"A match started on %s":            "Ha empezado una partida en %s",
+	"The match on %s ended":            "La partida en %s ha terminado",
+	"%s set a server record: %s":       "%s ha batido un récord del servidor: %s",
+	"%s stepped into the portal to %s": "%s ha entrado en el portal a %s",
+	"%s won the match on %s with a score of %d": "%s ha ganado la partida en %s con %d puntos",
+
+	// Pickups
+	"health pack":  "botiquín",
+	"speed boost":  "velocidad extra",
+	"invisibility": "invisibilidad",
+	"quad damage":  "daño cuádruple",
+	"key":          "llave",
+	"potion":       "poción",
+	"grenade":      "granada",
+}
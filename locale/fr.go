@@ -0,0 +1,95 @@
+package locale
+
+// french is the French catalog
+var french = Catalog{
+	// HUD
+	"F%d":          "É%d",
+	"HP":           "PV",
+	"STA":          "END",
+	"AMMO":         "MUN",
+	"TIRED":        "LAS",
+	"Fireball":     "Boule de feu",
+	"Scatter":      "Dispersion",
+	"Grenades":     "Grenades",
+	"Rockets":      "Roquettes",
+	"Ricochet":     "Ricochet",
+	"Railgun":      "Fusil à rail",
+	"Flamethrower": "Lance-flammes",
+	"Key":          "Clé",
+	"Potion":       "Potion",
+	"Grenade":      "Grenade",
+	"Mine":         "Mine",
+
+	// Sounds
+	"BANG":  "PAN",
+	"BOOM":  "BOUM",
+	"step":  "pas",
+	"CLUNK": "CLAC",
+
+	// Menus
+	"Terminal: %s, %dx%d":      "Terminal : %s, %dx%d",
+	"Colors: %s | Unicode: %s": "Couleurs : %s | Unicode : %s",
+	"yes":                      "oui",
+	"no":                       "non",
+	"Press ENTER to start, or change settings:":                                           "Appuyez sur ENTRÉE pour commencer, ou changez les réglages :",
+	"  [1] 24-bit color  [2] 256 colors  [3] 16 colors  [4] no color  [U] toggle Unicode": "  [1] couleurs 24 bits  [2] 256 couleurs  [3] 16 couleurs  [4] sans couleur  [U] activer/désactiver Unicode",
+	"  [F] Frame rate: %s": "  [F] Images par seconde : %s",
+	"%d FPS":               "%d IPS",
+	"server default":       "celle du serveur",
+	"  [L] Language: %s":   "  [L] Langue : %s",
+	"Help: %s (%d/%d)":     "Aide : %s (%d/%d)",
+	"Keys":                 "Touches",
+	"Rules":                "Règles",
+	"Map":                  "Carte",
+	"Left/Right: turn pages   ? or Esc: close":             "Gauche/Droite : tourner les pages   ? ou Échap : fermer",
+	"Text mode. Press %s to return to graphics.":           "Mode texte. Appuyez sur %s pour revenir aux graphismes.",
+	"Classic controls: strafe keys turn, turn keys strafe": "Commandes classiques : les touches de pas de côté tournent, celles de rotation font des pas de côté",
+	"Standard controls restored":                           "Commandes standard rétablies",
+	"Language: %s":                                         "Langue : %s",
+
+	// Notices
+	"You died":                 "Vous êtes mort",
+	"You were killed by %s":    "Vous avez été tué par %s",
+	"You climb up to floor %d": "Vous montez à l'étage %d",
+	"You go down to floor %d":  "Vous descendez à l'étage %d",
+	"Sprint on":                "Sprint activé",
+	"Sprint off":               "Sprint désactivé",
+	"You picked up %s":         "Vous avez ramassé : %s",
+	"%s joined your party":     "%s a rejoint votre groupe",
+	"%s left your party":       "%s a quitté votre groupe",
+	"%s invited you to their party; type /accept to join":                         "%s vous invite dans son groupe ; tapez /accept pour le rejoindre",
+	"Your recording reached its limit; /ghost stop to keep it":                    "Votre enregistrement a atteint sa limite ; /ghost stop pour le garder",
+	"Practicing alone: /speed 0.25 to 2 to change the pace, /freeze to stop NPCs": "Entraînement en solo : /speed de 0.25 à 2 pour changer le rythme, /freeze pour figer les PNJ",
+
+	// Events
+	"%s joined":                        "%s est arrivé",
+	"%s left":                          "%s est parti",
+	"%s is now %s":                     "%s s'appelle maintenant %s",
+	"%s fired %s":                      "%s a tiré : %s",
+	"Map changed to %s":                "La carte est maintenant %s",
+	"%s waves":                         "%s salue",
+	"%s taunts":                        "%s provoque",
+	"%s laughs":                        "%s rit",
+	"%s died":                          "%s est mort",
+	"%s was killed by %s":              "%s a été tué par %s",
+	"The sun is up":                    "Le soleil se lève",
+	"Dusk falls":                       "Le crépuscule tombe",
+	"Night has fallen":                 "La nuit est tombée",
+	"Dawn breaks":                      "L'aube se lève",
+	"%s picked up %s":                  "%s a ramassé : %s",
+	"%s used %s":                       "%s a utilisé : %s",
+	"A match started on %s":            "Une partie a commencé sur %s",
+	"The match on %s ended":            "La partie sur %s est terminée",
+	"%s set a server record: %s":       "%s a établi un record du serveur : %s",
+	"%s stepped into the portal to %s": "%s est entré dans le portail vers %s",
+	"%s won the match on %s with a score of %d": "%s a gagné la partie sur %s avec un score de %d",
+
+	// Pickups
+	"health pack":  "trousse de soins",
+	"speed boost":  "bonus de vitesse",
+	"invisibility": "invisibilité",
+	"quad damage":  "dégâts quadruplés",
+	"key":          "clé",
+	"potion":       "potion",
+	"grenade":      "grenade",
+}
@@ -0,0 +1,119 @@
+// Package locale translates what players read, like HUD labels, menus, and
+// notices, into their language. Messages are looked up by their English
+// format string, so code reads as it always has, and anything a catalog
+// doesn't have is shown in English.
+package locale
+
+import (
+	"fmt"
+	"strings"
+)
+
+// English is the language messages are written in, and the default
+const English = "en"
+
+// Catalog translates English format strings into a language. Translations
+// take the same arguments, in the same order unless they say otherwise, like
+// %[2]s.
+type Catalog map[string]string
+
+// Language is a language players can read the game in
+type Language struct {
+	Tag     string  // Like "es"
+	Name    string  // In the language itself, like "Español"
+	Catalog Catalog // Nil for English
+}
+
+// Languages lists the languages there are catalogs for, English first
+var Languages = []Language{
+	{Tag: English, Name: "English"},
+	{Tag: "es", Name: "Español", Catalog: spanish},
+	{Tag: "fr", Name: "Français", Catalog: french},
+	{Tag: "de", Name: "Deutsch", Catalog: german},
+}
+
+// Find returns the language with a tag
+func Find(tag string) (*Language, bool) {
+	for i := range Languages {
+		if Languages[i].Tag == tag {
+			return &Languages[i], true
+		}
+	}
+	return nil, false
+}
+
+// Name returns a language's name in itself, or English's for an unknown tag
+func Name(tag string) string {
+	if l, ok := Find(tag); ok {
+		return l.Name
+	}
+	return Languages[0].Name
+}
+
+// Tags returns the tags of the languages, in order
+func Tags() []string {
+	tags := make([]string, len(Languages))
+	for i, l := range Languages {
+		tags[i] = l.Tag
+	}
+	return tags
+}
+
+// Next returns the language after one, wrapping around, for menus that cycle
+// through them
+func Next(tag string) string {
+	for i, l := range Languages {
+		if l.Tag == tag {
+			return Languages[(i+1)%len(Languages)].Tag
+		}
+	}
+	return Languages[0].Tag
+}
+
+// Parse returns the language of a locale name, like "es_MX.UTF-8" or "fr-CA",
+// if there's a catalog for it
+func Parse(name string) (string, bool) {
+	name, _, _ = strings.Cut(name, ".") // Drop the encoding, like .UTF-8
+	name, _, _ = strings.Cut(name, "@") // And the modifier, like @euro
+	tag, _, _ := strings.Cut(strings.ReplaceAll(name, "-", "_"), "_")
+	tag = strings.ToLower(tag)
+	if _, ok := Find(tag); ok {
+		return tag, true
+	}
+	return "", false
+}
+
+// FromEnviron returns the language a terminal's environment asks for, from
+// LC_ALL, LC_MESSAGES, or LANG in order of precedence, or English if it asks
+// for none with a catalog
+func FromEnviron(environ []string) string {
+	env := make(map[string]string)
+	for _, kv := range environ {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			env[k] = v
+		}
+	}
+	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if name := env[key]; name != "" {
+			if tag, ok := Parse(name); ok {
+				return tag
+			}
+			return English
+		}
+	}
+	return English
+}
+
+// T translates a message into a language and formats it with its arguments,
+// like fmt.Sprintf. Messages without a translation are formatted in English.
+func T(tag, format string, args ...any) string {
+	if l, ok := Find(tag); ok {
+		if translated, ok := l.Catalog[format]; ok {
+			format = translated
+		}
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
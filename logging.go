@@ -0,0 +1,28 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// Logging flags
+var (
+	logLevelFlag = flag.String("log-level", "info", "minimum level to log: debug, info, warn, or error")
+	logJSONFlag  = flag.Bool("log-json", false, "write logs as JSON")
+)
+
+// setupLogging configures the default logger from the logging flags
+func setupLogging() error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(*logLevelFlag)); err != nil {
+		return fmt.Errorf("invalid -log-level %q: want debug, info, warn, or error", *logLevelFlag)
+	}
+	if *logJSONFlag {
+		slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+		return nil
+	}
+	slog.SetLogLoggerLevel(level)
+	return nil
+}
@@ -1,25 +1,49 @@
 package main
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
+	"io/fs"
+	"net"
 	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"text/template"
 	"time"
 
 	"github.com/chainguard-dev/clog"
 	"github.com/google/uuid"
 
 	"github.com/gliderlabs/ssh"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	gossh "golang.org/x/crypto/ssh"
 
+	"github.com/imjasonh/terminus/ai"
+	"github.com/imjasonh/terminus/auth"
+	"github.com/imjasonh/terminus/cluster"
+	"github.com/imjasonh/terminus/discord"
+	"github.com/imjasonh/terminus/engine"
 	"github.com/imjasonh/terminus/game"
-	"github.com/imjasonh/terminus/renderer"
-	"github.com/imjasonh/terminus/screen"
+	"github.com/imjasonh/terminus/input"
+	"github.com/imjasonh/terminus/locale"
+	"github.com/imjasonh/terminus/mapsource"
+	"github.com/imjasonh/terminus/results"
+	"github.com/imjasonh/terminus/script"
 	"github.com/imjasonh/terminus/server"
+	"github.com/imjasonh/terminus/stream"
+	"github.com/imjasonh/terminus/wasmplugin"
+	"github.com/imjasonh/terminus/webhook"
 )
 
 var gameServer *server.GameServer
@@ -56,224 +80,610 @@ func loadOrCreateHostKey(filename string) (ssh.Signer, error) {
 	return gossh.ParsePrivateKey(keyData)
 }
 
+// Command line flags
+var (
+	addrFlag       = flag.String("addr", ":2222", "comma-separated addresses for the SSH server to listen on, e.g. :22,:2222 (ignored under systemd socket activation)")
+	mapFlag        = flag.String("map", "maze.map", "map file to load, or a URL to fetch it from: http(s)://, s3://bucket/key, or oci://registry/repo:tag, optionally pinned with #sha256=<checksum>")
+	worldFlag      = flag.String("world", "", "file where the map is saved when players change it for good, like by crumbling walls, and loaded from instead of -map when it exists, so the changes outlast restarts")
+	mapCacheFlag   = flag.String("map-cache", "map-cache", "directory where maps fetched from URLs are cached, to start with the last copy when the storage is down (empty to disable)")
+	maxPlayersFlag = flag.Int("max-players", 10, "maximum number of concurrent players")
+	maxSpecFlag    = flag.Int("max-spectators", server.DefaultMaxSpectators, "maximum number of spectators (ssh spectate@host), not counting admins")
+	tickRateFlag   = flag.Int("tickrate", 30, "fixed simulation steps per second")
+	fpsFlag        = flag.Int("fps", 30, "frames per second drawn for each player, independent of -tickrate")
+	bandwidthFlag  = flag.Int("bandwidth-budget", 0, "kilobytes per second each player may be sent before frames are degraded (changes only, fewer colors, lower frame rate); 0 for no limit")
+	hostKeyFlag    = flag.String("hostkey", "terminus_host_key", "SSH host key file, created if it doesn't exist")
+	authKeysFlag   = flag.String("authorized-keys", "", "only allow SSH public keys listed in this authorized_keys-style file")
+	inviteCodeFlag = flag.String("invite-code", "", "require this code (as the SSH password) to join, unless the key is allowlisted")
+	adminKeysFlag  = flag.String("admin-keys", "", "grant the admin role to SSH public keys listed in this authorized_keys-style file")
+	profilesFlag   = flag.String("profiles", "profiles.json", "file where returning players' names, settings, and stats are saved, keyed by SSH key")
+	statsDBFlag    = flag.String("stats-db", "stats.db", "database where match results and the leaderboard are kept (empty to disable)")
+	banFileFlag    = flag.String("ban-file", "bans.json", "file where bans are saved")
+	replayDirFlag  = flag.String("replay-dir", "replays", "directory where /ghost saves recorded runs, for anyone to replay as ghosts (empty not to save them)")
+	chatFilterFlag = flag.String("chat-filter", "", "file of words to mask in chat, one per line")
+	scriptFlag     = flag.String("script", "", "Lua script of custom game rules, with hooks like onKill and onTick (see script/script.go)")
+	pluginsFlag    = flag.String("plugins", "", "comma-separated WebAssembly plugins of custom game rules (see wasmplugin/plugin.go)")
+	webhooksFlag   = flag.String("webhooks", "", "JSON file of URLs to post events like joins, match results, and records to (see webhook/webhook.go)")
+	eventsURLFlag  = flag.String("events-url", "", "publish gameplay events and scores to a NATS or MQTT broker, e.g. nats://localhost:4222 or mqtt://localhost:1883")
+	clusterFlag    = flag.String("cluster", "", "host a shard of a world spread over several servers, coordinating through this NATS server with JetStream, e.g. nats://localhost:4222; portals to other shards send players there")
+	shardFlag      = flag.String("shard", "", "name of this server's shard of the -cluster world, which portals to it give (default: the map file's name without its extension)")
+	shardAddrFlag  = flag.String("shard-addr", "", "host:port where the other -cluster servers can reach this one over SSH, e.g. game1.example.com:2222")
+	eventsPfxFlag  = flag.String("events-prefix", "terminus", "first part of every -events-url topic, like terminus.events.killed or terminus/events/killed")
+	resultsDirFlag = flag.String("results-dir", "", "directory to write each match's results to when it ends, for tournaments and stats sites")
+	resultsFmtFlag = flag.String("results-format", "json", "comma-separated formats of -results-dir files: json (with a timeline of events) and csv (a row per player)")
+	resultsOutFlag = flag.Bool("results-stdout", false, "also write each match's results to stdout as a line of JSON")
+	otelFlag       = flag.Bool("otel", false, "export traces of sessions and metrics like tick, render, and write times over OTLP/gRPC, configured by the standard OTEL_EXPORTER_OTLP_* environment variables")
+	discordKeyFlag = flag.String("discord-token-file", "", "file holding a Discord bot token, to bridge the game's chat with -discord-channel")
+	discordIDFlag  = flag.String("discord-channel", "", "ID of the Discord channel to mirror the game's chat to and from (requires -discord-token-file)")
+	motdFlag       = flag.String("motd", "", "welcome screen template shown before joining (default: built-in rules and controls)")
+	dayLengthFlag  = flag.Duration("day-length", server.DefaultDayLength, "how long a day and night last on maps with a day/night cycle (a \"daynight on\" line); 0 for always day")
+	sessionCapFlag = flag.Duration("session-cap", 0, "when players are queued, rotate out the longest-connected player after this much play time, e.g. 30m (0 to disable)")
+	wsAddrFlag     = flag.String("ws-addr", "", "accept browser terminals over WebSocket at this address's /ws, e.g. :8081")
+	wsOriginsFlag  = flag.String("ws-origins", "", "comma-separated host patterns of web pages allowed to connect over WebSocket, e.g. example.com,*.example.com")
+	telnetAddrFlag = flag.String("telnet-addr", "", "also accept plain telnet connections at this address, e.g. :2323")
+	botFillFlag    = flag.Int("bot-fill", 0, "play server-side bots while fewer than this many people are on, so the server never feels empty; bots leave as people join (0 to disable)")
+	botSkillFlag   = flag.String("bot-skill", "normal", "how well -bot-fill bots play: easy, normal, or hard")
+	recordDirFlag  = flag.String("record-dir", "", "record each session's terminal output to an asciinema .cast file in this directory, to replay with asciinema or embed on the web")
+	recordOnlyFlag = flag.String("record-only", "", "with -record-dir, record only \"players\" or only \"spectators\" (empty for both)")
+	botAddrFlag    = flag.String("bot-addr", "", "accept bot controllers over gRPC at this address (see botrpc/bot.proto), e.g. :9090")
+	statusAddrFlag = flag.String("status-addr", "", "serve JSON server status over HTTP at this address's /status, e.g. :8080")
+	watchAddrFlag  = flag.String("watch-addr", "", "serve a live top-down web view of the match at this address, e.g. :8082")
+	apiAddrFlag    = flag.String("api-addr", "", "serve the admin HTTP API at this address's /api/, e.g. localhost:8083 (requires -api-tokens)")
+	apiTokensFlag  = flag.String("api-tokens", "", "file of \"<token> <name>\" lines; each token lets an operator use the admin API as an admin named <name>")
+	auditLogFlag   = flag.String("audit-log", "", "append admin commands and denied attempts to this file (default: server log)")
+	debugAddrFlag  = flag.String("debug-addr", "", "serve pprof profiles and runtime stats at this localhost address's /debug/, e.g. localhost:6060")
+)
+
+// motd is the welcome screen template shown to players before they join
+var motd *template.Template
+
+// adminKeys holds the keys granted the admin role, if -admin-keys is set
+var adminKeys *auth.Allowlist
+
+// worldCluster is this server's membership in a world of shards, if -cluster is set
+var worldCluster *cluster.Cluster
+
 func main() {
-	// Parse command line arguments
-	mapFile := "maze.map" // Default map
-	if len(os.Args) > 1 {
-		mapFile = os.Args[1]
+	if len(os.Args) > 1 && os.Args[1] == "loadtest" {
+		os.Exit(runLoadTest(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "edit" {
+		os.Exit(runEditor(os.Args[2:]))
+	}
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [map file]\n       %s loadtest [flags]\n       %s edit [flags] <map file>\n", os.Args[0], os.Args[0], os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprintf(flag.CommandLine.Output(), "\nEvery flag can also be set by an environment variable named after it, like %s for -max-players or %s for -hostkey; flags given on the command line win.\n", envName("max-players"), envName("hostkey"))
+	}
+	flag.Parse()
+	if err := applyEnv(flag.CommandLine); err != nil {
+		clog.Fatalf("%v", err)
+	}
+	if err := setupLogging(); err != nil {
+		clog.Fatalf("%v", err)
+	}
+
+	// A positional map argument is still accepted for compatibility
+	mapFile := *mapFlag
+	if flag.NArg() > 0 {
+		mapFile = flag.Arg(0)
+	}
+	if *maxPlayersFlag < 1 {
+		clog.Fatalf("-max-players must be at least 1")
+	}
+	if *tickRateFlag < 1 || *tickRateFlag > 240 {
+		clog.Fatalf("-tickrate must be between 1 and 240")
+	}
+	if *fpsFlag < 1 || *fpsFlag > 240 {
+		clog.Fatalf("-fps must be between 1 and 240")
+	}
+	if *bandwidthFlag < 0 {
+		clog.Fatalf("-bandwidth-budget must not be negative")
+	}
+	if *dayLengthFlag < 0 {
+		clog.Fatalf("-day-length must not be negative")
+	}
+	if *apiAddrFlag != "" && *apiTokensFlag == "" {
+		clog.Fatalf("-api-addr requires -api-tokens")
+	}
+	if (*discordKeyFlag == "") != (*discordIDFlag == "") {
+		clog.Fatalf("-discord-token-file and -discord-channel must be used together")
+	}
+	if *clusterFlag != "" && *shardAddrFlag == "" {
+		clog.Fatalf("-cluster requires -shard-addr")
+	}
+	if *botFillFlag < 0 {
+		clog.Fatalf("-bot-fill must not be negative")
+	}
+	botSkill, err := ai.FindSkill(*botSkillFlag)
+	if err != nil {
+		clog.Fatalf("-bot-skill: %v", err)
+	}
+	if *recordOnlyFlag != "" && *recordOnlyFlag != recordPlayers && *recordOnlyFlag != recordSpectators {
+		clog.Fatalf("-record-only must be %q or %q", recordPlayers, recordSpectators)
+	}
+	resultsFormats := strings.Split(*resultsFmtFlag, ",")
+	for _, format := range resultsFormats {
+		if !slices.Contains(results.Formats, format) {
+			clog.Fatalf("-results-format: unknown format %q; use %s", format, strings.Join(results.Formats, " or "))
+		}
+	}
+	if *debugAddrFlag != "" {
+		if err := checkLoopback(*debugAddrFlag); err != nil {
+			clog.Fatalf("-debug-addr: %v", err)
+		}
 	}
 
-	// Load map from file
-	worldMap, err := game.LoadMapFromFile(mapFile)
+	// Load map from a file or remote storage
+	maps := &mapsource.Loader{CacheDir: *mapCacheFlag}
+	worldMap, err := maps.Load(context.Background(), mapFile)
 	if err != nil {
 		clog.Fatalf("Failed to load map %s: %v", mapFile, err)
 	}
+	if *worldFlag != "" {
+		if saved, err := game.LoadMapFromFile(*worldFlag); err == nil {
+			clog.Infof("Resuming the world saved in %s", *worldFlag)
+			worldMap = saved
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			clog.Fatalf("Failed to load the world: %v", err)
+		}
+	}
+
+	motd, err = loadMOTD(*motdFlag)
+	if err != nil {
+		clog.Fatalf("%v", err)
+	}
+
+	// Initialize game server with the player limit
+	gameServer = server.NewGameServer(worldMap, *maxPlayersFlag)
+	gameServer.MapName = mapFile
+	gameServer.MapLoader = maps.Load
+	gameServer.WorldFile = *worldFlag
+	if *replayDirFlag != "" {
+		gameServer.Replays = server.NewReplayStore(*replayDirFlag)
+	}
+	gameServer.SessionCap = *sessionCapFlag
+	gameServer.DayLength = *dayLengthFlag
+	gameServer.MaxSpectators = *maxSpecFlag
+	gameServer.Profiles, err = server.LoadProfileStore(*profilesFlag)
+	if err != nil {
+		clog.Fatalf("Failed to load profiles: %v", err)
+	}
+	if *statsDBFlag != "" {
+		gameServer.Leaderboard, err = server.OpenLeaderboard(*statsDBFlag)
+		if err != nil {
+			clog.Fatalf("%v", err)
+		}
+		defer gameServer.Leaderboard.Close()
+	}
+	gameServer.Bans, err = server.LoadBanList(*banFileFlag)
+	if err != nil {
+		clog.Fatalf("Failed to load bans: %v", err)
+	}
+	if *chatFilterFlag != "" {
+		gameServer.ChatFilter, err = server.LoadWordFilter(*chatFilterFlag)
+		if err != nil {
+			clog.Fatalf("%v", err)
+		}
+	}
+	if *scriptFlag != "" {
+		rules, err := script.Load(gameServer, *scriptFlag)
+		if err != nil {
+			clog.Fatalf("%v", err)
+		}
+		gameServer.Rules = append(gameServer.Rules, rules)
+		clog.Infof("Game rules from %s", *scriptFlag)
+	}
+	if *pluginsFlag != "" {
+		for _, path := range strings.Split(*pluginsFlag, ",") {
+			plugin, err := wasmplugin.Load(gameServer, strings.TrimSpace(path))
+			if err != nil {
+				clog.Fatalf("%v", err)
+			}
+			gameServer.Rules = append(gameServer.Rules, plugin)
+			clog.Infof("Game rules from plugin %s", path)
+		}
+	}
+	if *webhooksFlag != "" {
+		hooks, err := webhook.Load(*webhooksFlag)
+		if err != nil {
+			clog.Fatalf("%v", err)
+		}
+		go webhook.Run(gameServer.Events, hooks)
+		clog.Infof("Posting events to %d webhooks", len(hooks))
+	}
+	if *eventsURLFlag != "" {
+		events, err := stream.Dial(*eventsURLFlag, *eventsPfxFlag)
+		if err != nil {
+			clog.Fatalf("-events-url: %v", err)
+		}
+		go events.Run(gameServer)
+	}
+	if *resultsDirFlag != "" || *resultsOutFlag {
+		exporter := &results.Exporter{Dir: *resultsDirFlag, Formats: resultsFormats}
+		if *resultsOutFlag {
+			exporter.Stdout = os.Stdout
+		}
+		if exporter.Dir != "" {
+			if err := os.MkdirAll(exporter.Dir, 0755); err != nil {
+				clog.Fatalf("Failed to create -results-dir: %v", err)
+			}
+		}
+		go exporter.Run(gameServer.Events)
+	}
+	if *recordDirFlag != "" {
+		if err := os.MkdirAll(*recordDirFlag, 0755); err != nil {
+			clog.Fatalf("Failed to create -record-dir: %v", err)
+		}
+	}
+	if *discordIDFlag != "" {
+		token, err := discord.LoadToken(*discordKeyFlag)
+		if err != nil {
+			clog.Fatalf("%v", err)
+		}
+		// Play goes on without the bridge if Discord can't be reached
+		if _, err := discord.Open(gameServer, token, *discordIDFlag); err != nil {
+			clog.Errorf("Discord chat bridge disabled: %v", err)
+		} else {
+			clog.Infof("Bridging chat with Discord channel %s", *discordIDFlag)
+		}
+	}
 
-	// Initialize game server with 10 player limit
-	gameServer = server.NewGameServer(worldMap, 10)
+	// Shut down on interrupt by ending every session first, so they save their
+	// players' profiles and stats
+	var stop context.CancelFunc
+	serverCtx, stop = signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Start the global game update loop
-	go globalGameLoop()
+	if *otelFlag {
+		stopTelemetry, err := startTelemetry(serverCtx)
+		if err != nil {
+			clog.Fatalf("Failed to start telemetry: %v", err)
+		}
+		defer stopTelemetry()
+		clog.Info("Exporting telemetry over OTLP")
+	}
+
+	// Run the simulation in fixed steps; sessions draw frames at their own rate
+	go gameServer.Run(context.Background(), tickInterval())
+
+	// Fill empty slots with bots, which leave as people join
+	if *botFillFlag > 0 {
+		filler := &ai.Filler{Server: gameServer, Fill: *botFillFlag, NewBrain: func() ai.Brain { return ai.NewFighter(botSkill) }}
+		go filler.Run(serverCtx)
+		clog.Infof("Filling the server with %s bots up to %d players", botSkill.Name, *botFillFlag)
+	}
 
 	// Load or generate SSH host key
-	hostKey, err := loadOrCreateHostKey("terminus_host_key")
+	hostKey, err := loadOrCreateHostKey(*hostKeyFlag)
 	if err != nil {
 		clog.Fatalf("Failed to load or create host key: %v", err)
 	}
 
 	// Setup SSH server
 	sshServer := &ssh.Server{
-		Addr:        ":2222",
 		Handler:     handleSSHSession,
 		HostSigners: []ssh.Signer{hostKey},
 	}
 
-	clog.Info("Terminus SSH server starting on port 2222...")
-	clog.Info("Connect with: ssh -p 2222 localhost")
-	clog.Fatalf("ListenAndServe: %v", sshServer.ListenAndServe())
-}
+	// Restrict who may join with an allowlist of keys and/or an invite code
+	authOpts := auth.Options{InviteCode: *inviteCodeFlag}
+	if *authKeysFlag != "" {
+		authOpts.Allowlist, err = auth.LoadAllowlist(*authKeysFlag)
+		if err != nil {
+			clog.Fatalf("Failed to load authorized keys: %v", err)
+		}
+		clog.Infof("Allowlist mode: %d authorized keys from %s", authOpts.Allowlist.Len(), *authKeysFlag)
+	}
+	if authOpts.InviteCode != "" {
+		clog.Info("Invite code required for players without an allowlisted key")
+	}
 
-// globalGameLoop runs the shared game state updates
-func globalGameLoop() {
-	ticker := time.NewTicker(time.Second / 30) // 30 FPS
-	defer ticker.Stop()
+	// Host a shard of a world spread over several servers, letting in players
+	// the others send through portals
+	if *clusterFlag != "" {
+		name := *shardFlag
+		if name == "" {
+			name = mapsource.Name(mapFile)
+		}
+		worldCluster, err = cluster.Join(serverCtx, *clusterFlag, cluster.Shard{
+			Name:    name,
+			Address: *shardAddrFlag,
+			HostKey: strings.TrimSpace(string(gossh.MarshalAuthorizedKey(hostKey.PublicKey()))),
+		})
+		if err != nil {
+			clog.Fatalf("-cluster: %v", err)
+		}
+		authOpts.Handoff = worldCluster.Admit
+		go worldCluster.Run(serverCtx, gameServer)
+		clog.Infof("Hosting shard %s of the world at %s", name, *shardAddrFlag)
+	}
+	auth.Configure(sshServer, authOpts)
+
+	// Admin role and audit trail for admin commands
+	if *adminKeysFlag != "" {
+		adminKeys, err = auth.LoadAllowlist(*adminKeysFlag)
+		if err != nil {
+			clog.Fatalf("Failed to load admin keys: %v", err)
+		}
+		clog.Infof("%d admin keys from %s", adminKeys.Len(), *adminKeysFlag)
+	}
+	if *auditLogFlag != "" {
+		auditFile, err := os.OpenFile(*auditLogFlag, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			clog.Fatalf("Failed to open audit log: %v", err)
+		}
+		defer auditFile.Close()
+		commands.SetAuditLog(auditFile)
+	} else {
+		commands.SetAuditLog(auditWriter{})
+	}
 
-	lastTime := time.Now()
+	if *wsAddrFlag != "" {
+		var origins []string
+		if *wsOriginsFlag != "" {
+			origins = strings.Split(*wsOriginsFlag, ",")
+		}
+		go serveWebSocket(*wsAddrFlag, authOpts, origins)
+	}
+	if *botAddrFlag != "" {
+		go serveBots(*botAddrFlag, authOpts)
+	}
+	if *telnetAddrFlag != "" {
+		go serveTelnet(*telnetAddrFlag, authOpts)
+	}
+	if *statusAddrFlag != "" {
+		go serveStatus(*statusAddrFlag, authOpts.Mode())
+	}
+	if *apiAddrFlag != "" {
+		apiTokens, err := auth.LoadTokens(*apiTokensFlag)
+		if err != nil {
+			clog.Fatalf("Failed to load API tokens: %v", err)
+		}
+		go serveAPI(*apiAddrFlag, apiTokens, authOpts.Mode())
+	}
+	if *watchAddrFlag != "" {
+		go serveWatch(*watchAddrFlag)
+	}
+	if *debugAddrFlag != "" {
+		go serveDebug(*debugAddrFlag)
+	}
 
-	for range ticker.C {
-		currentTime := time.Now()
-		deltaTime := currentTime.Sub(lastTime).Seconds()
-		lastTime = currentTime
+	listeners, err := server.Listen(*addrFlag)
+	if err != nil {
+		clog.Fatalf("Failed to listen: %v", err)
+	}
+	for _, l := range listeners {
+		clog.Infof("Terminus SSH server listening on %s with map %s...", l.Addr(), mapFile)
+		if tcpAddr, ok := l.Addr().(*net.TCPAddr); ok {
+			clog.Infof("Connect with: ssh -p %d localhost", tcpAddr.Port)
+		}
+	}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- server.Serve(sshServer, listeners) }()
+	select {
+	case err := <-serveErr:
+		clog.Fatalf("Serve: %v", err)
+	case <-serverCtx.Done():
+	}
 
-		// Update shared game state (projectiles, etc.)
-		gameServer.Update(deltaTime)
+	clog.Info("Shutting down, ending sessions...")
+	if !waitForSessions(shutdownTimeout) {
+		clog.Warnf("%d sessions still running after %s, closing them", liveSessions.Load(), shutdownTimeout)
 	}
+	sshServer.Close()
 }
 
-// handleSSHSession handles incoming SSH connections
-func handleSSHSession(s ssh.Session) {
-	// Generate unique session ID
-	sessionID := uuid.New().String()
+// tickInterval returns the length of a simulation step for the configured tick rate
+func tickInterval() time.Duration {
+	return time.Second / time.Duration(*tickRateFlag)
+}
 
-	// Add player to server
-	playerSession, err := gameServer.AddPlayer(sessionID)
-	if err != nil {
-		fmt.Fprintf(s, "Connection rejected: %s\n", err.Error())
+// frameInterval returns the time between frames drawn for each session
+func frameInterval() time.Duration {
+	return time.Second / time.Duration(*fpsFlag)
+}
+
+// handleConn runs a player's connection, from the ban check through the game
+func handleConn(s conn) {
+	liveSessions.Add(1)
+	defer liveSessions.Add(-1)
+
+	// Everything started for the connection stops when it's been handled, or
+	// sooner if the client disconnects or the server shuts down
+	ctx, cancel := sessionContext(s)
+	var workers sync.WaitGroup
+	defer workers.Wait()
+	defer cancel()
+	if serverCtx.Err() != nil {
+		fmt.Fprintf(s, "Connection rejected: the server is shutting down\r\n")
 		s.Close()
 		return
 	}
 
-	// Clean up on disconnect
-	defer func() {
-		gameServer.RemovePlayer(sessionID)
-		clog.Infof("Player %s disconnected", sessionID[:8])
-	}()
+	// Generate unique session ID
+	sessionID := uuid.New().String()
 
-	clog.Infof("Player %s connected from %s", sessionID[:8], s.RemoteAddr())
+	// Turn away banned keys and addresses before they take a player slot. Admins
+	// are exempt so a shared address can't lock them out.
+	fingerprint := s.KeyFingerprint()
+	remoteIP := s.RemoteAddr().String()
+	if host, _, err := net.SplitHostPort(remoteIP); err == nil {
+		remoteIP = host
+	}
+
+	// Players arriving through a portal are relayed by the server they came
+	// from, and are who they were there
+	arrival, arrived := cluster.Arrival(s.Context())
+	if arrived {
+		fingerprint, remoteIP = arrival.Fingerprint, arrival.RemoteIP
+	}
+	origin, _ := s.(sshConn)
+	isAdmin := adminKeys != nil && adminKeys.AllowedFingerprint(fingerprint)
+	ctx, span := tracer.Start(ctx, "session", trace.WithAttributes(
+		attribute.String("terminus.transport", transportName(s)),
+		attribute.String("terminus.session_id", sessionID),
+		attribute.String("client.address", remoteIP),
+		attribute.Bool("terminus.spectator", s.User() == spectatorUser),
+		attribute.Bool("terminus.arrived", arrived)))
+	defer span.End()
+	if ban, banned := gameServer.Bans.Check(fingerprint, remoteIP); banned && !isAdmin {
+		span.AddEvent("banned")
+		clog.Info("Rejected banned player", "key", fingerprint, "remote", remoteIP)
+		fmt.Fprintf(s, "Connection rejected: %s\n", ban.Message())
+		s.Close()
+		return
+	}
 
 	// Get terminal size
 	ptyReq, winCh, isPty := s.Pty()
 	if !isPty {
+		span.AddEvent("no pty")
+		clog.Info("Rejected session without a PTY", "remote", remoteIP)
 		fmt.Fprintf(s, "No PTY requested.\n")
-		s.Exit(1)
+		s.Close()
 		return
 	}
 
-	// Initialize screen and renderer with PTY dimensions
-	width, height := int(ptyReq.Window.Width), int(ptyReq.Window.Height)
-	if width <= 0 || height <= 0 {
-		width, height = 80, 24 // Default fallback
+	// Start reading input; the queue and capability negotiation read it before the game does
+	log := clog.With("session", sessionID[:8], "remote", remoteIP, "key", fingerprint)
+	if shouldRecord(s) {
+		var stopRecording func()
+		s, winCh, stopRecording = startRecording(ctx, s, sessionID, log, ptyReq, winCh, &workers)
+		defer stopRecording()
+	}
+	var tap *inputTap
+	currentWindow := func() winSize { return ptyReq.Window }
+	if worldCluster != nil && s.User() != spectatorUser && s.User() != practiceUser {
+		tap = &inputTap{conn: s}
+		s = tap
+		winCh, currentWindow = watchWindow(ctx, winCh, ptyReq.Window, &workers)
 	}
+	inputCh := startInputReader(ctx, s, log, &workers)
 
-	gameScreen := screen.NewScreen(width, height)
-	gameRenderer := renderer.NewRenderer(width, height)
+	// Spectators watch without taking a player slot
+	if s.User() == spectatorUser {
+		spectate(ctx, s, sessionID, log, isAdmin, inputCh, winCh, ptyReq)
+		return
+	}
 
-	// Start player session
-	runPlayerSession(s, playerSession, gameScreen, gameRenderer, winCh)
-}
+	// Practicing players get a world of their own, which stops when they leave
+	gs := gameServer
+	if s.User() == practiceUser && !arrived {
+		gs = startPractice(ctx, log, &workers)
+	}
 
-// runPlayerSession runs the game loop for a single player
-func runPlayerSession(s ssh.Session, playerSession *server.PlayerSession, gameScreen *screen.Screen, gameRenderer *renderer.Renderer, winCh <-chan ssh.Window) {
-	player := playerSession.Player
+	// Add player to server, waiting in line if it's full
+	connection := server.Connection{RemoteIP: remoteIP, CanTravel: tap != nil, Language: locale.FromEnviron(ptyReq.Environ)}
+	if isAdmin {
+		connection.Role = server.RoleAdmin
+	}
+	playerSession, err := gs.AddPlayer(sessionID, fingerprint, s.User(), connection)
+	if errors.Is(err, server.ErrServerFull) {
+		_, queued := tracer.Start(ctx, "queue")
+		playerSession, ptyReq.Window, err = waitInQueue(ctx, s, sessionID, connection, log, inputCh, winCh, ptyReq)
+		queued.End()
+	}
+	if err != nil {
+		span.AddEvent("rejected", trace.WithAttributes(attribute.String("error", err.Error())))
+		log.Info("Rejected player", "error", err)
+		fmt.Fprintf(s, "\x1b[2J\x1b[HConnection rejected: %s\r\n", err.Error())
+		s.Close()
+		return
+	}
 
-	// Hide cursor and clear screen
-	fmt.Fprint(s, "\x1b[?25l\x1b[2J\x1b[H")
-	defer fmt.Fprint(s, "\x1b[?25h") // Show cursor on exit
+	if arrived {
+		gs.Arrive(playerSession, arrival.Traveler, arrival.Arrival)
+		playerSession.Log.Infof("Arrived through the portal from %s", arrival.From)
+	}
+	if gs.Practicing() {
+		playerSession.Notify(playerSession.T("Practicing alone: /speed 0.25 to 2 to change the pace, /freeze to stop NPCs"))
+	}
+	span.SetAttributes(attribute.String("terminus.player", playerSession.Name))
 
-	// Input channel for non-blocking input
-	inputCh := make(chan byte, 10)
-	go func() {
-		buf := make([]byte, 1)
-		for {
-			n, err := s.Read(buf)
-			if err != nil {
-				if err != io.EOF {
-					clog.Infof("Input error for player %s: %v", playerSession.ID[:8], err)
-				}
-				return
-			}
-			if n > 0 {
-				select {
-				case inputCh <- buf[0]:
-				default:
-					// Drop input if channel is full
-				}
-			}
-		}
+	// Clean up on disconnect
+	defer func() {
+		gs.RemovePlayer(sessionID)
+		playerSession.Log.Infof("Player disconnected after %s", time.Since(playerSession.ConnectedAt).Round(time.Second))
 	}()
 
-	// Game loop
-	ticker := time.NewTicker(time.Second / 30) // 30 FPS
-	defer ticker.Stop()
-
-	lastTime := time.Now()
-
-	for {
-		select {
-		case <-ticker.C:
-			currentTime := time.Now()
-			deltaTime := currentTime.Sub(lastTime).Seconds()
-			lastTime = currentTime
-
-			// Process input
-			if !processPlayerInput(inputCh, player, deltaTime, gameServer, s) {
-				return // Player requested exit
-			}
-
-			// Create debug message including server info
-			playerCount := gameServer.GetPlayerCount()
-			activeCount := 0
-			var nearestFireball *game.Projectile
-			for _, p := range gameServer.ProjectileManager.Projectiles {
-				if p.Active && p.Type == game.Fireball {
-					activeCount++
-					if nearestFireball == nil {
-						nearestFireball = p
-					}
-				}
-			}
-
-			debugMsg := fmt.Sprintf("Player: (%.1f,%.1f) | Players: %d/10 | FB: %d",
-				player.Position.X, player.Position.Y, playerCount, activeCount)
-
-			if nearestFireball != nil {
-				debugMsg = fmt.Sprintf("Player: (%.1f,%.1f) | Players: %d/10 | FB: %d at (%.1f,%.1f)",
-					player.Position.X, player.Position.Y, playerCount, activeCount,
-					nearestFireball.Position.X, nearestFireball.Position.Y)
-			}
+	playerSession.Log.Info("Player connected", "admin", playerSession.IsAdmin())
+
+	// Hide cursor for the rest of the session, and turn on bracketed paste so
+	// pasted text can be told apart from typed keys and filtered out
+	fmt.Fprint(s, "\x1b[?25l\x1b[?2004h")
+	defer fmt.Fprint(s, "\x1b[?2004l\x1b[?25h") // Restore terminal modes on exit
+
+	// Detect terminal capabilities and let the player override them. Players
+	// arriving through a portal already have, and go straight into the game.
+	caps, window, ok := arrivalCapabilities(ptyReq), ptyReq.Window, true
+	if !arrived {
+		_, negotiating := tracer.Start(ctx, "negotiate")
+		lang, frameRate := playerSession.Language, playerSession.FrameRate
+		caps, window, ok = negotiateTerminal(ctx, s, inputCh, winCh, ptyReq, &lang, &frameRate)
+		gs.SetLanguage(playerSession, lang)
+		gs.SetFrameRate(playerSession, frameRate)
+		negotiating.SetAttributes(attribute.String("terminus.term", caps.Term), attribute.String("terminus.color_depth", caps.ColorDepth.String()))
+		negotiating.End()
+		if ok {
+			_, welcome := tracer.Start(ctx, "motd")
+			window, ok = showMOTD(ctx, s, motd, playerSession, inputCh, winCh, window)
+			welcome.End()
+		}
+	}
+	playerSession.Log.Debugf("Terminal %q at %dx%d: %s, unicode %t", caps.Term, window.Width, window.Height, caps.ColorDepth, caps.Unicode)
+	if !ok {
+		fmt.Fprint(s, "\x1b[2J\x1b[H")
+		return
+	}
 
-			gameScreen.SetDebugMessage(debugMsg)
-
-			// Render the game with shared projectiles, other players, and NPCs
-			lights := gameServer.ProjectileManager.GetActiveLights()
-			otherPlayers := gameServer.GetOtherPlayers(playerSession.ID)
-			npcs := gameServer.GetNPCs()
-			gameRenderer.Render(player, gameServer.Map, gameScreen, lights, gameServer.ProjectileManager.Projectiles, otherPlayers, npcs)
-			fmt.Fprint(s, gameScreen.Render())
-
-		case win := <-winCh:
-			// Handle terminal resize
-			width, height := int(win.Width), int(win.Height)
-			if width > 0 && height > 0 {
-				gameScreen = screen.NewScreen(width, height)
-				gameRenderer = renderer.NewRenderer(width, height)
+	// Play until the player leaves
+	session := &engine.Session{
+		Server:          gs,
+		Player:          playerSession,
+		Commands:        commands,
+		Input:           connInput{ctx, inputCh, winCh},
+		Output:          connSink{s},
+		Clock:           engine.SystemClock{},
+		FrameInterval:   frameInterval(),
+		BandwidthBudget: *bandwidthFlag * 1024,
+	}
+	_, playing := tracer.Start(ctx, "play")
+	if err := session.Run(caps, window); err != nil {
+		playerSession.Log.Debugf("Ending session: %v", err)
+	}
+	playing.End()
+
+	// Players who left through a portal go on to the server hosting the shard
+	// it leads to, freeing their slot here first
+	if t, ok := playerSession.Transferred(); ok && serverCtx.Err() == nil {
+		gs.RemovePlayer(sessionID)
+		if arrived {
+			if err := handOff(origin, t); err != nil {
+				playerSession.Log.Warnf("Failed to hand off to shard %s: %v", t.Shard, err)
 			}
+			return
 		}
+		relay(ctx, s, tap, winCh, currentWindow, ptyReq.Term, relayEnv(caps), playerSession.Name, t, playerSession.Log)
+		return
+	}
+	if serverCtx.Err() != nil {
+		fmt.Fprint(s, "\x1b[0m\x1b[2J\x1b[HThe server is shutting down. Thanks for playing!\r\n")
 	}
+	showMatchSummary(s, playerSession)
 }
 
-// processPlayerInput handles input for a single player
-func processPlayerInput(inputCh chan byte, player *game.Player, deltaTime float64, gameServer *server.GameServer, s ssh.Session) bool {
-	// Process all available input
-	for {
-		select {
-		case key := <-inputCh:
-			switch key {
-			case 'w', 'W':
-				player.MoveForward(deltaTime, gameServer.Map)
-			case 's', 'S':
-				player.MoveBackward(deltaTime, gameServer.Map)
-			case 'a', 'A':
-				player.StrafeLeft(deltaTime, gameServer.Map)
-			case 'd', 'D':
-				player.StrafeRight(deltaTime, gameServer.Map)
-			case 'q', 'Q':
-				player.RotateRight(deltaTime)
-			case 'e', 'E':
-				player.RotateLeft(deltaTime)
-			case ' ':
-				// Shoot fireball (shared projectile system)
-				fireball := game.NewFireball(player.Position, player.Direction)
-				gameServer.ProjectileManager.AddProjectile(fireball)
-			case 27: // ESC key
-				fmt.Fprint(s, "\x1b[?25h\x1b[2J\x1b[H") // Show cursor and clear screen
-				return false
-			case 3: // Ctrl+C
-				fmt.Fprint(s, "\x1b[?25h\x1b[2J\x1b[H") // Show cursor and clear screen
-				return false
-			}
-		default:
-			return true // No more input to process, continue game loop
+// startInputReader decodes keys from the session into a channel for non-blocking
+// consumption, until ctx is done. The reader is added to workers.
+func startInputReader(ctx context.Context, s conn, log *clog.Logger, workers *sync.WaitGroup) chan input.Key {
+	// Buffered generously so bursts like terminal probe responses aren't dropped
+	inputCh := make(chan input.Key, 64)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		if err := input.ReadKeys(ctx, s, inputCh); err != io.EOF && ctx.Err() == nil {
+			log.Warnf("Input error: %v", err)
 		}
-	}
+	}()
+	return inputCh
 }
@@ -0,0 +1,290 @@
+// Package mapsource loads maps from local files or remote storage, so a
+// server can pull its maps at startup and on /map changes: web servers
+// (http:// and https://), S3 buckets (s3://), and OCI registries (oci://).
+// Remote maps can be pinned to a checksum, and are cached on disk so a
+// server can still start when the storage is briefly down.
+package mapsource
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+
+	"github.com/imjasonh/terminus/game"
+)
+
+// Limits on fetching maps
+const (
+	maxMapSize   = 4 << 20 // Bytes; real maps are a few kilobytes
+	fetchTimeout = 30 * time.Second
+)
+
+// checksumPrefix starts the fragment that pins a remote map to a checksum,
+// like https://example.com/cave.map#sha256=<hex>
+const checksumPrefix = "sha256="
+
+// Loader loads maps by reference: a file path, or a URL of remote storage
+type Loader struct {
+	CacheDir string       // Where fetched maps are kept; empty not to cache them
+	Client   *http.Client // Used for remote storage; http.DefaultClient if nil
+}
+
+// Remote reports whether a map reference names remote storage rather than a
+// local file
+func Remote(ref string) bool {
+	scheme, _, ok := strings.Cut(ref, "://")
+	if !ok {
+		return false
+	}
+	switch strings.ToLower(scheme) {
+	case "http", "https", "s3", "oci":
+		return true
+	}
+	return false
+}
+
+// Name returns a short name for the map a reference names: its file name
+// without the extension, like "cave" for both cave.map and
+// https://example.com/maps/cave.map#sha256=...
+func Name(ref string) string {
+	base := filepath.Base(ref)
+	if Remote(ref) {
+		ref, _, _ = strings.Cut(ref, "#")
+		_, rest, _ := strings.Cut(ref, "://")
+		if i := strings.IndexAny(rest, "@?"); i >= 0 {
+			rest = rest[:i]
+		}
+		if i := strings.LastIndex(rest, ":"); i > strings.LastIndex(rest, "/") {
+			rest = rest[:i] // An OCI tag
+		}
+		base = path.Base(rest)
+	}
+	return strings.TrimSuffix(base, path.Ext(base))
+}
+
+// Load loads the map a reference names. Files are read as they are; remote
+// maps are fetched (see Fetch).
+func (l *Loader) Load(ctx context.Context, ref string) (*game.Map, error) {
+	if !Remote(ref) {
+		return game.LoadMapFromFile(ref)
+	}
+	data, err := l.Fetch(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	m, err := game.LoadMap(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("bad map %s: %w", redact(ref), err)
+	}
+	return m, nil
+}
+
+// Fetch returns the contents of a remote map. A reference can end with a
+// fragment like #sha256=<hex> that the contents must match; a cached copy
+// that matches is used without fetching it again. Unpinned maps are fetched
+// every time, but if the storage can't be reached, the last copy fetched is
+// used instead.
+func (l *Loader) Fetch(ctx context.Context, ref string) ([]byte, error) {
+	ref, want, err := splitChecksum(ref)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+
+	if want != "" {
+		if data, ok := l.cached(want); ok {
+			return data, nil
+		}
+	}
+
+	var data []byte
+	scheme, _, _ := strings.Cut(ref, "://")
+	switch strings.ToLower(scheme) {
+	case "http", "https":
+		data, err = l.get(ctx, ref, nil)
+	case "s3":
+		var u string
+		if u, err = s3URL(ref); err == nil {
+			data, err = l.get(ctx, u, nil)
+		}
+	case "oci":
+		data, err = l.pull(ctx, ref)
+	default:
+		return nil, fmt.Errorf("%s isn't a remote map", redact(ref))
+	}
+	if err != nil {
+		stale, ok := l.cached(refKey(ref))
+		if !ok || (want != "" && digest(stale) != want) {
+			return nil, fmt.Errorf("failed to fetch map %s: %w", redact(ref), err)
+		}
+		clog.Warnf("Failed to fetch map %s, using the copy fetched before: %v", redact(ref), err)
+		return stale, nil
+	}
+
+	if want != "" {
+		if got := digest(data); got != want {
+			return nil, fmt.Errorf("map %s has checksum sha256=%s, not sha256=%s", redact(ref), got, want)
+		}
+		l.cache(want, data)
+	}
+	l.cache(refKey(ref), data)
+	return data, nil
+}
+
+// splitChecksum splits a reference from the checksum it's pinned to, if any
+func splitChecksum(ref string) (string, string, error) {
+	ref, fragment, ok := strings.Cut(ref, "#")
+	if !ok {
+		return ref, "", nil
+	}
+	want, ok := strings.CutPrefix(fragment, checksumPrefix)
+	if _, err := hex.DecodeString(want); !ok || err != nil || len(want) != 2*sha256.Size {
+		return "", "", fmt.Errorf("bad checksum %q in map %s; want #sha256=<64 hex digits>", fragment, redact(ref))
+	}
+	return ref, strings.ToLower(want), nil
+}
+
+// get fetches a URL, with headers like Authorization
+func (l *Loader) get(ctx context.Context, u string, header http.Header) ([]byte, error) {
+	resp, err := l.do(ctx, u, header)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", redact(u), resp.Status)
+	}
+	return readLimited(resp.Body)
+}
+
+// do sends a GET request
+func (l *Loader) do(ctx context.Context, u string, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	client := l.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return client.Do(req)
+}
+
+// readLimited reads a response body, refusing ones too big to be a map
+func readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxMapSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxMapSize {
+		return nil, fmt.Errorf("bigger than %d bytes", maxMapSize)
+	}
+	return data, nil
+}
+
+// s3URL returns the HTTPS URL of an object named like s3://bucket/key. The
+// endpoint can be changed, for S3-compatible storage like MinIO, by the
+// standard AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL variables, and the
+// region by AWS_REGION. Objects are fetched anonymously, so they must be
+// public; use a presigned https:// URL for private ones.
+func s3URL(ref string) (string, error) {
+	u, err := url.Parse(ref)
+	if err != nil || u.Host == "" || strings.Trim(u.Path, "/") == "" {
+		return "", fmt.Errorf("%s isn't an S3 object, like s3://bucket/maps/cave.map", redact(ref))
+	}
+	bucket, key := u.Host, strings.TrimPrefix(u.Path, "/")
+
+	for _, env := range []string{"AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"} {
+		if endpoint := os.Getenv(env); endpoint != "" {
+			return strings.TrimSuffix(endpoint, "/") + "/" + bucket + "/" + key, nil
+		}
+	}
+	host := "s3.amazonaws.com"
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		host = "s3." + region + ".amazonaws.com"
+	}
+	return "https://" + bucket + "." + host + "/" + key, nil
+}
+
+// digest returns the hex SHA-256 checksum of data
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// refKey returns the cache key of the last copy of a reference fetched
+func refKey(ref string) string {
+	return "ref-" + digest([]byte(ref))
+}
+
+// cached returns a cached map. Maps cached by checksum are checked against
+// it, so a corrupted cache is fetched again.
+func (l *Loader) cached(key string) ([]byte, bool) {
+	if l.CacheDir == "" {
+		return nil, false
+	}
+	data, err := os.ReadFile(filepath.Join(l.CacheDir, key+".map"))
+	if err != nil {
+		return nil, false
+	}
+	if !strings.HasPrefix(key, "ref-") && digest(data) != key {
+		return nil, false
+	}
+	return data, true
+}
+
+// cache saves a fetched map under a key, replacing the file atomically so
+// servers sharing the directory never read half of one
+func (l *Loader) cache(key string, data []byte) {
+	if l.CacheDir == "" {
+		return
+	}
+	err := func() error {
+		if err := os.MkdirAll(l.CacheDir, 0o755); err != nil {
+			return err
+		}
+		tmp, err := os.CreateTemp(l.CacheDir, key+".tmp")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		if _, err := tmp.Write(data); err != nil {
+			tmp.Close()
+			return err
+		}
+		if err := tmp.Close(); err != nil {
+			return err
+		}
+		return os.Rename(tmp.Name(), filepath.Join(l.CacheDir, key+".map"))
+	}()
+	if err != nil {
+		clog.Warnf("Failed to cache map in %s: %v", l.CacheDir, err)
+	}
+}
+
+// redact returns a reference without any password or query, which for
+// presigned URLs holds a signature
+func redact(ref string) string {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	u.RawQuery = ""
+	return u.Redacted()
+}
@@ -0,0 +1,201 @@
+package mapsource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MediaType is the media type of an OCI artifact layer holding a map, as
+// pushed by something like
+//
+//	oras push registry.example.com/maps/cave:v1 cave.map:application/vnd.terminus.map
+const MediaType = "application/vnd.terminus.map"
+
+// errNoMap is returned for artifacts without a map in them
+var errNoMap = errors.New("the artifact has no map layer")
+
+// manifestTypes are the manifests a registry may return for an artifact
+var manifestTypes = []string{
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// manifest is the part of an OCI image manifest that lists its layers
+type manifest struct {
+	Layers []struct {
+		MediaType   string            `json:"mediaType"`
+		Digest      string            `json:"digest"`
+		Annotations map[string]string `json:"annotations"`
+	} `json:"layers"`
+}
+
+// ociRef is a parsed reference like oci://registry.example.com/maps/cave:v1
+// or oci://registry.example.com/maps/cave@sha256:<hex>
+type ociRef struct {
+	registry   string // Where to send requests, like https://registry.example.com
+	repository string
+	reference  string // A tag or digest
+}
+
+// parseOCI parses an oci:// map reference, with the tag "latest" if it has
+// neither tag nor digest
+func parseOCI(ref string) (ociRef, error) {
+	rest := strings.TrimPrefix(ref, "oci://")
+	host, repo, ok := strings.Cut(rest, "/")
+	if !ok || host == "" || repo == "" {
+		return ociRef{}, fmt.Errorf("%s isn't an OCI artifact, like oci://registry.example.com/maps/cave:v1", ref)
+	}
+
+	r := ociRef{registry: "https://" + host, reference: "latest"}
+	if strings.HasPrefix(host, "localhost") || strings.HasPrefix(host, "127.0.0.1") {
+		r.registry = "http://" + host // Local registries for testing rarely have TLS
+	}
+	if host == "docker.io" {
+		r.registry = "https://registry-1.docker.io"
+	}
+	if name, digest, ok := strings.Cut(repo, "@"); ok {
+		repo, r.reference = name, digest
+	} else if i := strings.LastIndex(repo, ":"); i >= 0 {
+		repo, r.reference = repo[:i], repo[i+1:]
+	}
+	r.repository = repo
+	return r, nil
+}
+
+// pull fetches the map in an OCI artifact: its layer of MediaType, or its
+// only layer. Everything fetched by digest is checked against it.
+func (l *Loader) pull(ctx context.Context, ref string) ([]byte, error) {
+	r, err := parseOCI(ref)
+	if err != nil {
+		return nil, err
+	}
+	header := http.Header{"Accept": {strings.Join(manifestTypes, ", ")}}
+	data, err := l.registryGet(ctx, r, "manifests/"+r.reference, header)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDigest(r.reference, data); err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("bad manifest: %w", err)
+	}
+	layer := -1
+	for i, desc := range m.Layers {
+		if desc.MediaType == MediaType || strings.HasSuffix(desc.Annotations["org.opencontainers.image.title"], ".map") {
+			layer = i
+			break
+		}
+	}
+	if layer < 0 && len(m.Layers) == 1 {
+		layer = 0
+	}
+	if layer < 0 {
+		return nil, errNoMap
+	}
+
+	blobDigest := m.Layers[layer].Digest
+	blob, err := l.registryGet(ctx, r, "blobs/"+blobDigest, header)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDigest(blobDigest, blob); err != nil {
+		return nil, fmt.Errorf("map layer: %w", err)
+	}
+	return blob, nil
+}
+
+// registryGet fetches a manifest or blob from a registry, getting an
+// anonymous pull token first if the registry asks for one. The token is
+// kept in header for later requests.
+func (l *Loader) registryGet(ctx context.Context, r ociRef, what string, header http.Header) ([]byte, error) {
+	u := r.registry + "/v2/" + r.repository + "/" + what
+	resp, err := l.do(ctx, u, header)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		token, err := l.token(ctx, challenge, r.repository)
+		if err != nil {
+			return nil, fmt.Errorf("failed to authenticate to %s: %w", r.registry, err)
+		}
+		header.Set("Authorization", "Bearer "+token)
+		if resp, err = l.do(ctx, u, header); err != nil {
+			return nil, err
+		}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return readLimited(resp.Body)
+}
+
+// token gets an anonymous pull token as a Bearer challenge directs, like
+// `Bearer realm="https://auth.example.com/token",service="registry.example.com"`
+func (l *Loader) token(ctx context.Context, challenge, repository string) (string, error) {
+	params, ok := strings.CutPrefix(challenge, "Bearer ")
+	if !ok {
+		return "", fmt.Errorf("unsupported challenge %q", challenge)
+	}
+	q := url.Values{}
+	var realm string
+	for _, param := range strings.Split(params, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+		v = strings.Trim(v, `"`)
+		switch k {
+		case "realm":
+			realm = v
+		case "service":
+			q.Set("service", v)
+		}
+	}
+	if realm == "" {
+		return "", fmt.Errorf("challenge %q has no realm", challenge)
+	}
+	q.Set("scope", "repository:"+repository+":pull")
+
+	data, err := l.get(ctx, realm+"?"+q.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return "", fmt.Errorf("bad token response: %w", err)
+	}
+	if resp.Token != "" {
+		return resp.Token, nil
+	}
+	if resp.AccessToken != "" {
+		return resp.AccessToken, nil
+	}
+	return "", fmt.Errorf("no token in the response")
+}
+
+// checkDigest checks data against a reference if it's a sha256 digest;
+// tags aren't checked
+func checkDigest(reference string, data []byte) error {
+	want, ok := strings.CutPrefix(reference, "sha256:")
+	if !ok {
+		if strings.Contains(reference, ":") {
+			return fmt.Errorf("unsupported digest %s", reference)
+		}
+		return nil
+	}
+	if got := digest(data); got != strings.ToLower(want) {
+		return fmt.Errorf("digest is sha256:%s, not %s", got, reference)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/imjasonh/terminus/input"
+	"github.com/imjasonh/terminus/server"
+)
+
+// defaultMOTD is the welcome screen shown when no -motd file is given
+const defaultMOTD = `
+  {{bold}}T E R M I N U S{{reset}}
+  A multiplayer terminal FPS
+
+  Map: {{.Map}}    Players online: {{.Players}}/{{.MaxPlayers}}
+
+  {{bold}}Rules{{reset}}
+   * Be kind to other players
+   * No flooding or pasting spam
+
+  {{bold}}Controls{{reset}}
+   W/S move   A/D strafe   Q/E turn   Z sprint on/off
+   SPACE fire   1-9 weapons   / console   ESC quit
+
+  Press any key to play
+`
+
+// motdData is the data available to MOTD templates
+type motdData struct {
+	Map        string // Current map name
+	Players    int    // Players online, including this one
+	MaxPlayers int
+	Name       string // The connecting player's name
+}
+
+// motdFuncs are helpers for styling MOTD templates
+var motdFuncs = template.FuncMap{
+	"bold":  func() string { return "\x1b[1m" },
+	"reset": func() string { return "\x1b[0m" },
+}
+
+// loadMOTD parses the MOTD template from a file, or the built-in one if path is empty
+func loadMOTD(path string) (*template.Template, error) {
+	text := defaultMOTD
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read MOTD %s: %w", path, err)
+		}
+		text = string(data)
+	}
+	tmpl, err := template.New("motd").Funcs(motdFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse MOTD: %w", err)
+	}
+	return tmpl, nil
+}
+
+// showMOTD draws the welcome screen and waits for a key press. It returns the
+// latest window size, and false if the player disconnected or pressed Ctrl+C.
+func showMOTD(ctx context.Context, s conn, tmpl *template.Template, playerSession *server.PlayerSession, inputCh <-chan input.Key, winCh <-chan winSize, window winSize) (winSize, bool) {
+	var b strings.Builder
+	data := motdData{
+		Map:        gameServer.MapName,
+		Players:    gameServer.GetPlayerCount(),
+		MaxPlayers: gameServer.MaxPlayers,
+		Name:       playerSession.Name,
+	}
+	if err := tmpl.Execute(&b, data); err != nil {
+		playerSession.Log.Warnf("Failed to render MOTD: %v", err)
+		return window, true // Skip the banner rather than keep the player out
+	}
+
+	// Terminals in raw mode need explicit carriage returns
+	text := strings.ReplaceAll(strings.ReplaceAll(b.String(), "\r\n", "\n"), "\n", "\r\n")
+	fmt.Fprint(s, "\x1b[0m\x1b[2J\x1b[H"+text+"\x1b[0m")
+
+	for {
+		select {
+		case key := <-inputCh:
+			if key.Code == input.KeySequence {
+				continue // Late terminal responses aren't key presses
+			}
+			return window, !key.Is(3) // Ctrl+C
+		case win := <-winCh:
+			if win.Width > 0 && win.Height > 0 {
+				window = win
+			}
+		case <-ctx.Done():
+			return window, false
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/imjasonh/terminus/input"
+	"github.com/imjasonh/terminus/locale"
+	"github.com/imjasonh/terminus/screen"
+	"github.com/imjasonh/terminus/server"
+)
+
+// probeTimeout is how long to wait for the terminal to answer capability queries
+const probeTimeout = 500 * time.Millisecond
+
+// negotiateTerminal detects the client terminal's capabilities from the session
+// environment and an optional probe, then lets the player override them, and
+// change the language the menu and game are shown in and, if frameRate isn't
+// nil, the frame rate they're drawn at, before the game starts. It returns the chosen capabilities, the latest window size, and false if the
+// player disconnected or aborted.
+func negotiateTerminal(ctx context.Context, s conn, inputCh <-chan input.Key, winCh <-chan winSize, ptyReq ptyInfo, lang *string, frameRate *int) (screen.Capabilities, winSize, bool) {
+	window := ptyReq.Window
+	caps := screen.DetectCapabilities(ptyReq.Term, ptyReq.Environ)
+
+	// Probe for true color support: set an RGB foreground, ask for the current SGR
+	// state with DECRQSS, then send a Primary Device Attributes query which every
+	// terminal answers, so we know when to stop waiting.
+	fmt.Fprint(s, "\x1b[0;38;2;1;2;3m\x1bP$qm\x1b\\\x1b[0m\x1b[c")
+	response := readProbeResponse(inputCh)
+	if strings.Contains(response, "1:2:3") || strings.Contains(response, "1;2;3") {
+		caps.ColorDepth = screen.TrueColor
+	}
+
+	// Override prompt
+	for {
+		fmt.Fprint(s, "\x1b[2J\x1b[H")
+		term := caps.Term
+		if term == "" {
+			term = "unknown"
+		}
+		t := func(format string, args ...any) string { return locale.T(*lang, format, args...) }
+		unicode := t("no")
+		if caps.Unicode {
+			unicode = t("yes")
+		}
+		fmt.Fprint(s, t("Terminal: %s, %dx%d", term, window.Width, window.Height)+"\r\n")
+		fmt.Fprint(s, t("Colors: %s | Unicode: %s", caps.ColorDepth, unicode)+"\r\n\r\n")
+		fmt.Fprint(s, t("Press ENTER to start, or change settings:")+"\r\n")
+		fmt.Fprint(s, t("  [1] 24-bit color  [2] 256 colors  [3] 16 colors  [4] no color  [U] toggle Unicode")+"\r\n")
+		fmt.Fprint(s, t("  [L] Language: %s", locale.Name(*lang))+"\r\n")
+		if frameRate != nil {
+			rate := t("server default")
+			if *frameRate > 0 {
+				rate = t("%d FPS", *frameRate)
+			}
+			fmt.Fprint(s, t("  [F] Frame rate: %s", rate)+"\r\n")
+		}
+
+		select {
+		case key := <-inputCh:
+			if key.Code != input.KeyRune {
+				continue // Ignore late probe responses and special keys
+			}
+			switch key.Rune {
+			case '\r', '\n', ' ':
+				return caps, window, true
+			case '1':
+				caps.ColorDepth = screen.TrueColor
+			case '2':
+				caps.ColorDepth = screen.Color256
+			case '3':
+				caps.ColorDepth = screen.Color16
+			case '4':
+				caps.ColorDepth = screen.Monochrome
+			case 'u', 'U':
+				caps.Unicode = !caps.Unicode
+			case 'l', 'L':
+				*lang = locale.Next(*lang)
+			case 'f', 'F':
+				if frameRate != nil {
+					*frameRate = server.NextFrameRate(*frameRate)
+				}
+			case 3: // Ctrl+C
+				return caps, window, false
+			}
+		case win := <-winCh:
+			if win.Width > 0 && win.Height > 0 {
+				window = win
+			}
+		case <-ctx.Done():
+			return caps, window, false
+		}
+	}
+}
+
+// readProbeResponse collects the terminal's replies to a capability probe, stopping
+// at the Primary Device Attributes response or after probeTimeout
+func readProbeResponse(inputCh <-chan input.Key) string {
+	var response strings.Builder
+	timeout := time.After(probeTimeout)
+	for {
+		select {
+		case key := <-inputCh:
+			if key.Code != input.KeySequence {
+				continue // Keys typed during the probe are discarded
+			}
+			response.WriteString(key.Seq)
+			// DA1 responses look like ESC [ ? ... c
+			if strings.HasPrefix(key.Seq, "\x1b[?") && strings.HasSuffix(key.Seq, "c") {
+				return response.String()
+			}
+		case <-timeout:
+			return response.String()
+		}
+	}
+}
@@ -0,0 +1,144 @@
+//go:build wasip1
+
+// Hunters is an example plugin: enemies stop wandering and chase the nearest
+// player, and every kill adds another enemy where the player fell. Build and
+// run it with:
+//
+//	GOOS=wasip1 GOARCH=wasm go build -buildmode=c-shared -o hunters.wasm ./plugins/hunters
+//	./terminus -plugins hunters.wasm
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"unsafe"
+)
+
+// chaseInterval is how often, in seconds, enemies turn toward a player
+const (
+	chaseInterval = 0.5
+	chaseSpeed    = 1.5
+)
+
+//go:wasmimport terminus log
+func hostLog(ptr unsafe.Pointer, size uint32)
+
+//go:wasmimport terminus broadcast
+func hostBroadcast(ptr unsafe.Pointer, size uint32)
+
+//go:wasmimport terminus players
+func hostPlayers(buf unsafe.Pointer, size uint32) int32
+
+//go:wasmimport terminus entities
+func hostEntities(kind unsafe.Pointer, kindSize uint32, buf unsafe.Pointer, size uint32) int32
+
+//go:wasmimport terminus spawn
+func hostSpawn(kind unsafe.Pointer, kindSize uint32, x, y float64) int32
+
+//go:wasmimport terminus steer
+func hostSteer(id uint64, dx, dy, speed float64) int32
+
+type event struct {
+	Kind   string  `json:"kind"`
+	Name   string  `json:"name"`
+	Target string  `json:"target"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+}
+
+type position struct {
+	ID   uint64  `json:"id"`
+	Name string  `json:"name"`
+	X    float64 `json:"x"`
+	Y    float64 `json:"y"`
+}
+
+var (
+	sinceChase float64
+	buffers    [][]byte // Memory handed to the host, kept until it's used
+	scratch    = make([]byte, 4096)
+)
+
+func main() {}
+
+//go:wasmexport alloc
+func alloc(size uint32) unsafe.Pointer {
+	buf := make([]byte, size)
+	buffers = append(buffers, buf)
+	return unsafe.Pointer(unsafe.SliceData(buf))
+}
+
+//go:wasmexport on_event
+func onEvent(ptr unsafe.Pointer, size uint32) {
+	data := unsafe.Slice((*byte)(ptr), size)
+	buffers = nil
+
+	var e event
+	if err := json.Unmarshal(data, &e); err != nil {
+		logLine("bad event: " + err.Error())
+		return
+	}
+	switch e.Kind {
+	case "joined":
+		broadcast(e.Name + " joined the hunt")
+	case "killed":
+		kind := "npc"
+		if hostSpawn(unsafe.Pointer(unsafe.StringData(kind)), uint32(len(kind)), e.X, e.Y) == 0 {
+			broadcast("Another hunter rises where " + e.Name + " fell")
+		}
+	}
+}
+
+//go:wasmexport on_tick
+func onTick(dt float64) {
+	if sinceChase += dt; sinceChase < chaseInterval {
+		return
+	}
+	sinceChase = 0
+
+	var players, enemies []position
+	query(func(buf []byte) int32 { return hostPlayers(unsafe.Pointer(&buf[0]), uint32(len(buf))) }, &players)
+	kind := "enemy"
+	query(func(buf []byte) int32 {
+		return hostEntities(unsafe.Pointer(unsafe.StringData(kind)), uint32(len(kind)), unsafe.Pointer(&buf[0]), uint32(len(buf)))
+	}, &enemies)
+	if len(players) == 0 {
+		return
+	}
+
+	for _, enemy := range enemies {
+		nearest, best := players[0], math.Inf(1)
+		for _, p := range players {
+			if d := math.Hypot(p.X-enemy.X, p.Y-enemy.Y); d < best {
+				nearest, best = p, d
+			}
+		}
+		if best > 0 {
+			hostSteer(enemy.ID, nearest.X-enemy.X, nearest.Y-enemy.Y, chaseSpeed)
+		}
+	}
+}
+
+// query calls a host function that writes JSON into a buffer, growing the
+// buffer if it's too small, and decodes the result into v
+func query(call func(buf []byte) int32, v any) {
+	n := call(scratch)
+	if n < 0 {
+		return
+	}
+	if int(n) > len(scratch) {
+		scratch = make([]byte, n)
+		if n = call(scratch); n < 0 || int(n) > len(scratch) {
+			return
+		}
+	}
+	json.Unmarshal(scratch[:n], v)
+}
+
+func broadcast(msg string) {
+	hostBroadcast(unsafe.Pointer(unsafe.StringData(msg)), uint32(len(msg)))
+}
+
+func logLine(msg string) {
+	hostLog(unsafe.Pointer(unsafe.StringData(msg)), uint32(len(msg)))
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/chainguard-dev/clog"
+
+	"github.com/imjasonh/terminus/server"
+)
+
+// practiceUser is the login name that connects to a practice instance of its
+// own, e.g. ssh practice@host
+const practiceUser = "practice"
+
+// startPractice creates a practice instance of the game server's world and
+// runs its simulation until ctx is done. The simulation is added to workers.
+func startPractice(ctx context.Context, log *clog.Logger, workers *sync.WaitGroup) *server.GameServer {
+	practice := server.NewPracticeServer(gameServer)
+	log.Infof("Practicing on %s", practice.MapName)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		practice.Run(ctx, tickInterval())
+	}()
+	return practice
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+
+	"github.com/imjasonh/terminus/input"
+	"github.com/imjasonh/terminus/screen"
+	"github.com/imjasonh/terminus/server"
+)
+
+// queuePollInterval is how often a waiting connection checks for a free slot
+const queuePollInterval = 250 * time.Millisecond
+
+// waitInQueue holds a connection in line until a player slot frees up, showing
+// its position and letting it spectate while it waits, then joins it as the
+// connection. It returns the new player session, the latest window size, and
+// an error if the player left or couldn't be queued.
+func waitInQueue(ctx context.Context, s conn, sessionID string, connection server.Connection, log *clog.Logger, inputCh <-chan input.Key, winCh <-chan winSize, ptyReq ptyInfo) (*server.PlayerSession, winSize, error) {
+	window := ptyReq.Window
+	if err := gameServer.Enqueue(sessionID); err != nil {
+		return nil, window, err
+	}
+	defer gameServer.Dequeue(sessionID)
+	log.Infof("Server full, queued at position %d", gameServer.QueuePosition(sessionID))
+
+	ticker := time.NewTicker(queuePollInterval)
+	defer ticker.Stop()
+
+	lastScreen := ""
+	for {
+		if playerSession, err := gameServer.AddPlayer(sessionID, s.KeyFingerprint(), s.User(), connection); err == nil {
+			return playerSession, window, nil
+		}
+
+		status := fmt.Sprintf("\x1b[2J\x1b[HServer full (%d/%d players).\r\n\r\nYou are #%d in line. You'll join automatically when a slot opens.\r\nPress S to spectate while you wait, or ESC or Ctrl+C to leave.\r\n",
+			gameServer.GetPlayerCount(), gameServer.MaxPlayers, gameServer.QueuePosition(sessionID))
+		if status != lastScreen {
+			fmt.Fprint(s, status)
+			lastScreen = status
+		}
+
+		select {
+		case <-ticker.C:
+		case key := <-inputCh:
+			if key.Code == input.KeyEscape || key.Is(3) {
+				return nil, window, fmt.Errorf("left the queue")
+			}
+			if key.Is('s') || key.Is('S') {
+				// Watch with the detected capabilities; the full negotiation runs once there's a slot
+				log.Debug("Spectating while queued")
+				caps := screen.DetectCapabilities(ptyReq.Term, ptyReq.Environ)
+				var playerSession *server.PlayerSession
+				playerSession, window = runSpectator(ctx, s, sessionID, log, inputCh, winCh, window, caps, &connection)
+				if playerSession != nil {
+					return playerSession, window, nil
+				}
+				if ctx.Err() != nil {
+					return nil, window, fmt.Errorf("disconnected while queued")
+				}
+				return nil, window, fmt.Errorf("left the queue")
+			}
+		case win := <-winCh:
+			if win.Width > 0 && win.Height > 0 {
+				window = win
+			}
+		case <-ctx.Done():
+			return nil, window, fmt.Errorf("disconnected while queued")
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+
+	"github.com/imjasonh/terminus/cast"
+)
+
+// Values of -record-only
+const (
+	recordPlayers    = "players"
+	recordSpectators = "spectators"
+)
+
+// recordingConn is a connection whose output is also recorded
+type recordingConn struct {
+	conn
+	rec *cast.Recorder
+}
+
+func (c recordingConn) Write(p []byte) (int, error) {
+	c.rec.Output(p)
+	return c.conn.Write(p)
+}
+
+// shouldRecord reports whether -record-dir and -record-only call for
+// recording a connection
+func shouldRecord(s conn) bool {
+	if *recordDirFlag == "" {
+		return false
+	}
+	switch *recordOnlyFlag {
+	case recordPlayers:
+		return s.User() != spectatorUser
+	case recordSpectators:
+		return s.User() == spectatorUser
+	default:
+		return true
+	}
+}
+
+// startRecording records everything sent to a connection, and each time its
+// terminal is resized, to an asciinema cast file in -record-dir named like
+// 20240102-150405-3f2a9c1e.cast, by when it started and the session ID. It
+// returns the connection and resizes to use in place of the originals, and
+// a function that ends the recording. If the file can't be created, the
+// session goes on unrecorded.
+func startRecording(ctx context.Context, s conn, sessionID string, log *clog.Logger, ptyReq ptyInfo, winCh <-chan winSize, workers *sync.WaitGroup) (conn, <-chan winSize, func()) {
+	started := time.Now()
+	path := filepath.Join(*recordDirFlag, started.UTC().Format("20060102-150405")+"-"+sessionID[:8]+".cast")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		log.Warnf("Not recording session: %v", err)
+		return s, winCh, func() {}
+	}
+	title := "Terminus"
+	if s.User() == spectatorUser {
+		title = "Terminus (spectating)"
+	} else if s.User() != "" {
+		title = fmt.Sprintf("Terminus: %s", s.User())
+	}
+	header := cast.Header{
+		Width:     ptyReq.Window.Width,
+		Height:    ptyReq.Window.Height,
+		Timestamp: started.Unix(),
+		Title:     title,
+	}
+	if ptyReq.Term != "" {
+		header.Env = map[string]string{"TERM": ptyReq.Term}
+	}
+	rec, err := cast.NewRecorder(f, header)
+	if err != nil {
+		f.Close()
+		log.Warnf("Not recording session: %v", err)
+		return s, winCh, func() {}
+	}
+	log.Infof("Recording session to %s", path)
+
+	// Pass resizes on to whoever's reading them, recording each
+	resizes := make(chan winSize)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case win, ok := <-winCh:
+				if !ok {
+					return
+				}
+				rec.Resize(win.Width, win.Height)
+				select {
+				case resizes <- win:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return recordingConn{s, rec}, resizes, func() {
+		if err := rec.Close(); err != nil {
+			log.Warnf("Failed to record session to %s: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/imjasonh/terminus/cluster"
+	"github.com/imjasonh/terminus/screen"
+	"github.com/imjasonh/terminus/server"
+)
+
+// handoffRequest is the SSH channel request a server sends down a relayed
+// connection when the player leaves it through another portal, with the
+// JSON server.Transfer for the relay to follow
+const handoffRequest = "handoff@terminus"
+
+// relayTimeout bounds connecting and signing in to the next server
+const relayTimeout = 10 * time.Second
+
+// The relay passes the terminal settings a player chose where they first
+// joined to each server it carries them to in these environment variables,
+// so they aren't asked again
+const (
+	colorsEnv  = "TERMINUS_COLORS"  // One of screen.ColorDepthNames
+	unicodeEnv = "TERMINUS_UNICODE" // "yes" or "no"
+)
+
+// inputTap passes a connection's input to the game, or to the server a
+// relay carried the player to while one is running
+type inputTap struct {
+	conn
+
+	mu    sync.Mutex
+	relay io.Writer
+}
+
+func (t *inputTap) Read(p []byte) (int, error) {
+	for {
+		n, err := t.conn.Read(p)
+		t.mu.Lock()
+		w := t.relay
+		t.mu.Unlock()
+		if w == nil || n == 0 {
+			return n, err
+		}
+		w.Write(p[:n]) // A dead relay notices on its own
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// redirect sends input to w instead of the game, or back to the game if w
+// is nil
+func (t *inputTap) redirect(w io.Writer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.relay = w
+}
+
+// watchWindow passes resizes through, keeping track of the terminal's size
+// for when the player is relayed to another server. The watcher is added
+// to workers.
+func watchWindow(ctx context.Context, winCh <-chan winSize, window winSize, workers *sync.WaitGroup) (<-chan winSize, func() winSize) {
+	var mu sync.Mutex
+	out := make(chan winSize)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case win := <-winCh:
+				mu.Lock()
+				window = win
+				mu.Unlock()
+				select {
+				case out <- win:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, func() winSize {
+		mu.Lock()
+		defer mu.Unlock()
+		return window
+	}
+}
+
+// relayEnv returns the environment that tells the servers a player is
+// relayed to what terminal settings they chose
+func relayEnv(caps screen.Capabilities) []string {
+	unicode := "no"
+	if caps.Unicode {
+		unicode = "yes"
+	}
+	return []string{
+		colorsEnv + "=" + screen.ColorDepthNames[caps.ColorDepth],
+		unicodeEnv + "=" + unicode,
+	}
+}
+
+// arrivalCapabilities returns the terminal settings a player arriving
+// through a portal chose where they first joined, falling back on what
+// their terminal says it can do
+func arrivalCapabilities(ptyReq ptyInfo) screen.Capabilities {
+	caps := screen.DetectCapabilities(ptyReq.Term, ptyReq.Environ)
+	for _, kv := range ptyReq.Environ {
+		k, v, _ := strings.Cut(kv, "=")
+		switch k {
+		case colorsEnv:
+			if depth, ok := screen.ParseColorDepth(v); ok {
+				caps.ColorDepth = depth
+			}
+		case unicodeEnv:
+			caps.Unicode = v == "yes"
+		}
+	}
+	return caps
+}
+
+// relay carries a player who left through a portal to the server hosting
+// the shard it leads to, signing in there with their handoff token and
+// passing their terminal through, until they leave that server. If they
+// step through another portal there, the relay follows them on, so the
+// player's own connection never changes.
+func relay(ctx context.Context, s conn, tap *inputTap, winCh <-chan winSize, window func() winSize, term string, env []string, user string, t server.Transfer, log *clog.Logger) {
+	ctx, span := tracer.Start(ctx, "relay")
+	defer span.End()
+	for {
+		span.AddEvent("portal", trace.WithAttributes(attribute.String("terminus.shard", t.Shard)))
+		log.Infof("Relaying to shard %s at %s", t.Shard, t.Address)
+		next, err := relayTo(ctx, s, tap, winCh, window, term, env, user, t)
+		if err != nil {
+			log.Warnf("Failed to relay to shard %s: %v", t.Shard, err)
+			fmt.Fprintf(s, "\x1b[0m\x1b[2J\x1b[HThe portal to %s collapsed behind you: %v\r\n", t.Shard, err)
+			return
+		}
+		if next == nil {
+			return
+		}
+		t = *next
+	}
+}
+
+// relayTo passes the player's terminal through to one server until they
+// leave it, returning where they're going next if they left through a
+// portal
+func relayTo(ctx context.Context, s conn, tap *inputTap, winCh <-chan winSize, window func() winSize, term string, env []string, user string, t server.Transfer) (*server.Transfer, error) {
+	hostKey, _, _, _, err := gossh.ParseAuthorizedKey([]byte(t.HostKey))
+	if err != nil {
+		return nil, fmt.Errorf("bad host key: %w", err)
+	}
+	client, err := gossh.Dial("tcp", t.Address, &gossh.ClientConfig{
+		User:            user,
+		Auth:            []gossh.AuthMethod{gossh.Password(cluster.HandoffPrefix + t.Token)},
+		HostKeyCallback: gossh.FixedHostKey(hostKey),
+		Timeout:         relayTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	ch, reqs, err := client.OpenChannel("session", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer ch.Close()
+
+	for _, kv := range env {
+		k, v, _ := strings.Cut(kv, "=")
+		ch.SendRequest("env", false, gossh.Marshal(struct{ Name, Value string }{k, v}))
+	}
+	size := window()
+	if ok, err := ch.SendRequest("pty-req", true, gossh.Marshal(ptyRequest{Term: term, Columns: uint32(size.Width), Rows: uint32(size.Height)})); err != nil || !ok {
+		return nil, errors.New("the server refused the terminal")
+	}
+	if ok, err := ch.SendRequest("shell", true, nil); err != nil || !ok {
+		return nil, errors.New("the server refused the session")
+	}
+
+	tap.redirect(ch)
+	defer tap.redirect(nil)
+	done := make(chan struct{})
+	go func() {
+		io.Copy(s, ch)
+		close(done)
+	}()
+
+	var next *server.Transfer
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-done:
+			return next, nil
+		case size := <-winCh:
+			ch.SendRequest("window-change", false, gossh.Marshal(windowChange{Columns: uint32(size.Width), Rows: uint32(size.Height)}))
+		case req, ok := <-reqs:
+			if !ok {
+				reqs = nil // Output may still be draining
+				continue
+			}
+			if req.Type == handoffRequest {
+				var t server.Transfer
+				err := json.Unmarshal(req.Payload, &t)
+				if err == nil {
+					next = &t
+				}
+				req.Reply(err == nil, nil)
+			} else if req.WantReply {
+				req.Reply(false, nil)
+			}
+		}
+	}
+}
+
+// handOff asks the server relaying an arriving player's connection to carry
+// them on to where they're going next
+func handOff(s sshConn, t server.Transfer) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	ok, err := s.SendRequest(handoffRequest, true, data)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("the relay refused the handoff")
+	}
+	return nil
+}
+
+// ptyRequest is the payload of an SSH "pty-req" request (RFC 4254 6.2)
+type ptyRequest struct {
+	Term          string
+	Columns, Rows uint32
+	Width, Height uint32 // In pixels
+	Modes         string
+}
+
+// windowChange is the payload of an SSH "window-change" request (RFC 4254 6.7)
+type windowChange struct {
+	Columns, Rows uint32
+	Width, Height uint32 // In pixels
+}
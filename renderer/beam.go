@@ -0,0 +1,48 @@
+package renderer
+
+import (
+	"image/color"
+
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/screen"
+)
+
+// beamStep is how far apart, in map cells, points along a beam are projected
+const beamStep = 0.05
+
+// renderBeams draws each beam as a bright streak at eye level, projecting
+// points along it like tiny sprites so walls hide the parts behind them. Beams
+// fade out over their lifetime.
+func (r *Renderer) renderBeams(player *game.Player, screen *screen.Screen, entities []*game.Entity) {
+	gameHeight := screen.GameHeight
+	cameraPlaneLength := player.CameraPlane.Length()
+	for _, e := range entities {
+		if e.Beam == nil || e.Floor != player.Floor {
+			continue
+		}
+		fade := e.Fade()
+		beamColor, glow := scaleColor(e.Beam.Color, fade), scaleColor(e.Beam.Color, fade*0.4)
+
+		span := e.Beam.To.Sub(e.Position)
+		steps := int(span.Length() / beamStep)
+		for i := 0; i <= steps; i++ {
+			relativePos := e.Position.Add(span.Scale(float64(i) / float64(max(steps, 1)))).Sub(player.Position)
+			transformedY := relativePos.X*player.Direction.X + relativePos.Y*player.Direction.Y
+			transformedX := relativePos.X*player.Direction.Y + relativePos.Y*(-player.Direction.X)
+			if transformedY <= 0.1 {
+				continue
+			}
+
+			screenX := int(float64(r.screenWidth) / 2 * (1.0 + transformedX/transformedY/cameraPlaneLength))
+			if screenX < 0 || screenX >= r.screenWidth || transformedY >= r.zBuffer[screenX]+0.1 {
+				continue
+			}
+			screen.SetCell(screenX, gameHeight/2, '━', beamColor, glow)
+		}
+	}
+}
+
+// scaleColor darkens a color by a factor from 0 to 1
+func scaleColor(c color.RGBA, factor float64) color.RGBA {
+	return color.RGBA{uint8(float64(c.R) * factor), uint8(float64(c.G) * factor), uint8(float64(c.B) * factor), 255}
+}
@@ -0,0 +1,121 @@
+package renderer
+
+import (
+	"math"
+
+	"github.com/imjasonh/terminus/game"
+)
+
+// compassSpan is the number of degrees visible across the compass strip
+const compassSpan = 180.0
+
+// CompassMarker is a point of interest shown on the compass strip
+type CompassMarker struct {
+	Position game.Vector
+	Glyph    rune
+}
+
+// Bearing returns the compass bearing in degrees (0-360) of a direction vector.
+// North is +Y and east is +X, which matches the orientation of the rendered view.
+func Bearing(v game.Vector) float64 {
+	deg := math.Atan2(v.X, v.Y) * 180 / math.Pi
+	if deg < 0 {
+		deg += 360
+	}
+	return deg
+}
+
+// RelativeBearing returns the bearing in degrees from the player toward a
+// position relative to where they're facing, from -180 to 180, with positive
+// clockwise, so 0 is straight ahead and 90 is to their right
+func RelativeBearing(player *game.Player, target game.Vector) float64 {
+	offset := Bearing(target.Sub(player.Position)) - Bearing(player.Direction)
+	return math.Mod(offset+540, 360) - 180
+}
+
+// directionArrows point toward bearings relative to the player's heading, in
+// 45 degree steps clockwise from straight ahead
+var directionArrows = []rune{'↑', '↗', '→', '↘', '↓', '↙', '←', '↖'}
+
+// DirectionArrow returns an arrow pointing from the player toward a position,
+// relative to where they're facing, so ↑ is straight ahead
+func DirectionArrow(player *game.Player, target game.Vector) rune {
+	step := int(math.Round(RelativeBearing(player, target)/45)) % len(directionArrows)
+	if step < 0 {
+		step += len(directionArrows)
+	}
+	return directionArrows[step]
+}
+
+// BuildCompass renders a one-line compass strip centered on the player's heading,
+// with cardinal directions, tick marks, and the given markers
+func BuildCompass(width int, player *game.Player, markers []CompassMarker) string {
+	if width <= 0 {
+		return ""
+	}
+
+	heading := Bearing(player.Direction)
+	degPerCol := compassSpan / float64(width)
+	center := width / 2
+
+	line := make([]rune, width)
+	for i := range line {
+		line[i] = ' '
+	}
+
+	// columnFor maps a bearing to a column on the strip, or -1 if it's out of range
+	columnFor := func(bearing float64) int {
+		offset := math.Mod(bearing-heading+540, 360) - 180 // -180 to 180
+		if math.Abs(offset) > compassSpan/2 {
+			return -1
+		}
+		col := center + int(math.Round(offset/degPerCol))
+		if col < 0 || col >= width {
+			return -1
+		}
+		return col
+	}
+
+	// Tick marks every 15 degrees
+	for deg := 0.0; deg < 360; deg += 15 {
+		if col := columnFor(deg); col >= 0 {
+			line[col] = '·'
+		}
+	}
+
+	// Cardinal and intercardinal labels
+	labels := []struct {
+		deg   float64
+		label string
+	}{
+		{0, "N"}, {45, "NE"}, {90, "E"}, {135, "SE"},
+		{180, "S"}, {225, "SW"}, {270, "W"}, {315, "NW"},
+	}
+	for _, l := range labels {
+		col := columnFor(l.deg)
+		if col < 0 {
+			continue
+		}
+		for i, ch := range l.label {
+			if col+i < width {
+				line[col+i] = ch
+			}
+		}
+	}
+
+	// Markers for other points of interest take priority over labels
+	for _, m := range markers {
+		rel := m.Position.Sub(player.Position)
+		if rel.Length() == 0 {
+			continue
+		}
+		if col := columnFor(Bearing(rel)); col >= 0 {
+			line[col] = m.Glyph
+		}
+	}
+
+	// Heading indicator in the center
+	line[center] = '▼'
+
+	return string(line)
+}
@@ -0,0 +1,98 @@
+package renderer
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/imjasonh/terminus/game"
+)
+
+// describeRange is how far away (in map cells) entities are mentioned in scene descriptions
+const describeRange = 8.0
+
+// DescribeScene builds a short textual summary of what the player can see, for
+// screen-reader users. It uses the same raycasts as the renderer, so only walls
+// and entities actually in line of sight are described.
+func DescribeScene(player *game.Player, worldMap *game.Map, entities []*game.Entity) string {
+	var parts []string
+
+	// Open sky overhead
+	if worldMap.IsOutdoor(int(player.Position.X), int(player.Position.Y)) {
+		parts = append(parts, "Outdoors")
+	}
+
+	// Wall straight ahead
+	ahead := game.CastRay(player.Position, player.Direction, worldMap)
+	_, moving := worldMap.MoverAt(ahead.MapX, ahead.MapY)
+	switch {
+	case moving:
+		parts = append(parts, fmt.Sprintf("Moving wall %s ahead", formatDistance(ahead.Distance)))
+	case ahead.Wall == game.LockedDoor:
+		parts = append(parts, fmt.Sprintf("Locked door %s ahead", formatDistance(ahead.Distance)))
+	case ahead.Wall == game.BreakableWall:
+		parts = append(parts, fmt.Sprintf("Cracked wall %s ahead", formatDistance(ahead.Distance)))
+	default:
+		parts = append(parts, fmt.Sprintf("Wall %s ahead", formatDistance(ahead.Distance)))
+	}
+
+	// Openings to either side (the camera plane points to the right of the view)
+	right := player.CameraPlane.Normalize()
+	left := right.Scale(-1)
+	if game.CastRay(player.Position, left, worldMap).Distance > 1.5 {
+		parts = append(parts, "opening on your left")
+	}
+	if game.CastRay(player.Position, right, worldMap).Distance > 1.5 {
+		parts = append(parts, "opening on your right")
+	}
+
+	// Visible entities, in the order given
+	for _, e := range entities {
+		if e.Floor != player.Floor {
+			continue
+		}
+		if desc, ok := describeEntity(string(e.Kind), e.Position, player, worldMap); ok {
+			parts = append(parts, desc)
+		}
+	}
+
+	return strings.Join(parts, ", ") + "."
+}
+
+// describeEntity describes an entity's relative direction and distance, if it's in range and line of sight
+func describeEntity(kind string, pos game.Vector, player *game.Player, worldMap *game.Map) (string, bool) {
+	rel := pos.Sub(player.Position)
+	distance := rel.Length()
+	if distance == 0 || distance > describeRange {
+		return "", false
+	}
+
+	// Hidden behind a wall
+	if game.CastRay(player.Position, rel.Normalize(), worldMap).Distance < distance {
+		return "", false
+	}
+
+	// Same camera-space transform the sprite renderer uses
+	transformedY := rel.X*player.Direction.X + rel.Y*player.Direction.Y
+	transformedX := rel.X*player.Direction.Y + rel.Y*(-player.Direction.X)
+	angle := math.Atan2(transformedX, transformedY) * 180 / math.Pi
+
+	var where string
+	switch {
+	case math.Abs(angle) <= 30:
+		where = "ahead"
+	case angle > 30 && angle <= 135:
+		where = "to your right"
+	case angle < -30 && angle >= -135:
+		where = "to your left"
+	default:
+		where = "behind you"
+	}
+
+	return fmt.Sprintf("%s %s %s", kind, where, formatDistance(distance)), true
+}
+
+// formatDistance formats a distance in map cells as whole meters
+func formatDistance(d float64) string {
+	return fmt.Sprintf("%dm", int(math.Round(d)))
+}
@@ -0,0 +1,91 @@
+package renderer
+
+import (
+	"image/color"
+
+	"github.com/imjasonh/terminus/game"
+)
+
+// Shading ramps for monochrome terminals, from darkest to brightest, in ASCII
+// since terminals without color rarely have Unicode either. Floors and
+// ceilings use a sparser ramp than walls so the two read apart.
+const (
+	monoWallRamp    = " .:-=+*#%@"
+	monoSurfaceRamp = " .,:;"
+)
+
+// ditherPattern is a tile of thresholds from 0 to 1 deciding which cells of
+// a surface round up to the next shade, so shades between two on the ramp
+// come out as a mix of both, and so different materials get their own texture
+type ditherPattern [4][4]float64
+
+var (
+	// ditherStone is an ordered (Bayer) dither, spreading shades evenly
+	ditherStone = ditherPattern{
+		{0.0 / 16, 8.0 / 16, 2.0 / 16, 10.0 / 16},
+		{12.0 / 16, 4.0 / 16, 14.0 / 16, 6.0 / 16},
+		{3.0 / 16, 11.0 / 16, 1.0 / 16, 9.0 / 16},
+		{15.0 / 16, 7.0 / 16, 13.0 / 16, 5.0 / 16},
+	}
+
+	// ditherBrick shades in rows, like courses of bricks
+	ditherBrick = ditherPattern{
+		{0.1, 0.1, 0.1, 0.1},
+		{0.6, 0.6, 0.6, 0.6},
+		{0.35, 0.35, 0.35, 0.35},
+		{0.85, 0.85, 0.85, 0.85},
+	}
+
+	// ditherCracked shades in diagonals, like cracks, for walls that crumble
+	ditherCracked = ditherPattern{
+		{0.1, 0.6, 0.35, 0.85},
+		{0.6, 0.35, 0.85, 0.1},
+		{0.35, 0.85, 0.1, 0.6},
+		{0.85, 0.1, 0.6, 0.35},
+	}
+
+	// ditherWood shades in columns, like the grain of planks
+	ditherWood = ditherPattern{
+		{0.1, 0.6, 0.35, 0.85},
+		{0.1, 0.6, 0.35, 0.85},
+		{0.1, 0.6, 0.35, 0.85},
+		{0.1, 0.6, 0.35, 0.85},
+	}
+)
+
+// wallDither returns the dithering pattern for a type of wall's material:
+// wood for doors, cracks for breakable walls, and stone or brick alternating
+// between the other types
+func wallDither(wallType int) *ditherPattern {
+	switch {
+	case wallType == game.LockedDoor:
+		return &ditherWood
+	case wallType == game.BreakableWall:
+		return &ditherCracked
+	case wallType%2 == 0:
+		return &ditherBrick
+	default:
+		return &ditherStone
+	}
+}
+
+// monoGlyph returns the character shading a cell of a surface its color
+// would light to on a monochrome terminal, by the color's brightest channel
+// so every hue shades alike, dithered by the surface's pattern
+func monoGlyph(c color.RGBA, ramp string, pattern *ditherPattern, x, y int) rune {
+	level := float64(max(c.R, c.G, c.B)) / 255 * float64(len(ramp)-1)
+	i := int(level)
+	if level-float64(i) > pattern[y%4][x%4] {
+		i++
+	}
+	return rune(ramp[min(i, len(ramp)-1)])
+}
+
+// surfaceGlyph returns the character to fill a cell of floor or ceiling
+// with: blank, showing its color, or its shade on monochrome terminals
+func (r *Renderer) surfaceGlyph(c color.RGBA, x, y int) rune {
+	if !r.Monochrome {
+		return ' '
+	}
+	return monoGlyph(c, monoSurfaceRamp, &ditherStone, x, y)
+}
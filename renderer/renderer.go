@@ -11,7 +11,21 @@ import (
 type Renderer struct {
 	screenWidth  int
 	screenHeight int
-	zBuffer      []float64 // Z-buffer for depth testing
+	zBuffer      []float64          // Z-buffer for depth testing
+	sprites      []sprite           // Visible sprites, reused by each frame
+	lights       []game.LightSource // Lights on the floor being drawn, reused by each frame
+	worldMap     *game.Map          // The map the frame being drawn is of
+
+	// Ambient is how lit the world is by the time of day, from 0 to 1,
+	// scaling the light walls, floors, and ceilings get besides lights
+	Ambient float64
+
+	// Theme is how the world is colored
+	Theme Theme
+
+	// Monochrome shades walls, floors, and ceilings with characters instead
+	// of colors, for terminals without color
+	Monochrome bool
 }
 
 func NewRenderer(width, height int) *Renderer {
@@ -19,11 +33,32 @@ func NewRenderer(width, height int) *Renderer {
 		screenWidth:  width,
 		screenHeight: height,
 		zBuffer:      make([]float64, width), // Initialize Z-buffer
+		Ambient:      1,
+	}
+}
+
+// Resize changes the size the renderer draws at, reusing its buffers where
+// they're big enough
+func (r *Renderer) Resize(width, height int) {
+	r.screenWidth, r.screenHeight = width, height
+	if cap(r.zBuffer) < width {
+		r.zBuffer = make([]float64, width)
 	}
+	r.zBuffer = r.zBuffer[:width]
 }
 
-func (r *Renderer) Render(player *game.Player, worldMap *game.Map, screen *screen.Screen, lights []game.LightSource, projectiles []*game.Projectile, otherPlayers []*game.Player, npcs []*game.NPC) {
+// Render draws the player's view of a map, which is the floor of the world
+// they're on: only the lights and entities on their floor show
+func (r *Renderer) Render(player *game.Player, worldMap *game.Map, screen *screen.Screen, lights []game.LightSource, entities []*game.Entity) {
 	screen.Clear()
+	r.worldMap = worldMap
+	r.lights = r.lights[:0]
+	for _, light := range lights {
+		if light.Floor == player.Floor {
+			r.lights = append(r.lights, light)
+		}
+	}
+	lights = r.lights
 
 	// Clear Z-buffer (initialize with max depth)
 	for i := range r.zBuffer {
@@ -33,80 +68,17 @@ func (r *Renderer) Render(player *game.Player, worldMap *game.Map, screen *scree
 	// Update renderer to use game area height
 	gameHeight := screen.GameHeight
 
-	// Cast rays for each column of the screen
+	// Cast rays for each column of the screen, remembering which wall face
+	// the last one hit to outline where faces meet
+	lastMapX, lastMapY, lastSide := -1, -1, -1
 	for x := 0; x < r.screenWidth; x++ {
 		// Calculate ray direction
 		cameraX := 2*float64(x)/float64(r.screenWidth) - 1 // x-coordinate in camera space
 		rayDir := player.Direction.Add(player.CameraPlane.Scale(cameraX))
 
-		// Which box of the map we're in
-		mapX := int(player.Position.X)
-		mapY := int(player.Position.Y)
-
-		// Length of ray from current position to next x or y side
-		var sideDistX, sideDistY float64
-
-		// Length of ray from one x-side to next x-side, or from one y-side to next y-side
-		var deltaDistX, deltaDistY float64
-		if rayDir.X == 0 {
-			deltaDistX = 1e30
-		} else {
-			deltaDistX = math.Abs(1 / rayDir.X)
-		}
-		if rayDir.Y == 0 {
-			deltaDistY = 1e30
-		} else {
-			deltaDistY = math.Abs(1 / rayDir.Y)
-		}
-
-		var perpWallDist float64
-
-		// What direction to step in x or y-direction (either +1 or -1)
-		var stepX, stepY int
-
-		var hit int  // was there a wall hit?
-		var side int // was a NS or a EW wall hit?
-
-		// Calculate step and initial sideDist
-		if rayDir.X < 0 {
-			stepX = -1
-			sideDistX = (player.Position.X - float64(mapX)) * deltaDistX
-		} else {
-			stepX = 1
-			sideDistX = (float64(mapX) + 1.0 - player.Position.X) * deltaDistX
-		}
-		if rayDir.Y < 0 {
-			stepY = -1
-			sideDistY = (player.Position.Y - float64(mapY)) * deltaDistY
-		} else {
-			stepY = 1
-			sideDistY = (float64(mapY) + 1.0 - player.Position.Y) * deltaDistY
-		}
-
-		// Perform DDA
-		for hit == 0 {
-			// Jump to next map square, either in x-direction, or in y-direction
-			if sideDistX < sideDistY {
-				sideDistX += deltaDistX
-				mapX += stepX
-				side = 0
-			} else {
-				sideDistY += deltaDistY
-				mapY += stepY
-				side = 1
-			}
-			// Check if ray has hit a wall
-			if worldMap.IsWall(mapX, mapY) {
-				hit = 1
-			}
-		}
-
-		// Calculate distance projected on camera direction
-		if side == 0 {
-			perpWallDist = (float64(mapX) - player.Position.X + (1-float64(stepX))/2) / rayDir.X
-		} else {
-			perpWallDist = (float64(mapY) - player.Position.Y + (1-float64(stepY))/2) / rayDir.Y
-		}
+		// Find the wall this ray hits
+		hit := game.CastRay(player.Position, rayDir, worldMap)
+		mapX, mapY, side, perpWallDist := hit.MapX, hit.MapY, hit.Side, hit.Distance
 
 		// Calculate height of line to draw on screen
 		lineHeight := int(float64(gameHeight) / perpWallDist)
@@ -132,16 +104,27 @@ func (r *Renderer) Render(player *game.Player, worldMap *game.Map, screen *scree
 		// Store wall distance in Z-buffer for sprite depth testing
 		r.zBuffer[x] = perpWallDist
 
-		// Choose wall color based on wall type, side, distance, and lighting
-		wallType := worldMap.GetWallType(mapX, mapY)
-		wallColor := r.getWallColor(wallType, side, perpWallDist, wallPos, lights)
+		// Choose wall color based on wall type, side, distance, and lighting,
+		// lit as outdoors if the open cell in front of it is
+		wallType := hit.Wall
+		front := player.Position.Add(rayDir.Scale(perpWallDist - 0.01))
+		wallColor := r.getWallColor(wallType, side, perpWallDist, wallPos, lights, r.outdoor(front))
 
 		// Draw the wall strip
 		for y := drawStart; y <= drawEnd; y++ {
+			if r.Monochrome {
+				screen.SetCell(x, y, monoGlyph(wallColor, monoWallRamp, wallDither(wallType), x, y), wallColor, wallColor)
+				continue
+			}
 			screen.SetCell(x, y, '█', wallColor, wallColor)
 		}
+		if r.Theme == ThemeHighContrast {
+			edge := x > 0 && (mapX != lastMapX || mapY != lastMapY || side != lastSide)
+			outlineWall(screen, x, drawStart, drawEnd, wallColor, edge)
+		}
+		lastMapX, lastMapY, lastSide = mapX, mapY, side
 
-		// Draw ceiling with proper distance-based shading
+		// Draw ceiling with proper distance-based shading, or sky where it's outdoors
 		for y := 0; y < drawStart; y++ {
 			// Calculate actual distance to ceiling at this pixel
 			// The further from the center line, the further away the ceiling appears
@@ -150,8 +133,9 @@ func (r *Renderer) Render(player *game.Player, worldMap *game.Map, screen *scree
 				rowDistance = perpWallDist // Fallback for edge cases
 			}
 
-			ceilingColor := r.getCeilingColor(rowDistance)
-			screen.SetCell(x, y, ' ', ceilingColor, ceilingColor)
+			ceilingPos := player.Position.Add(rayDir.Scale(rowDistance))
+			ceilingColor := r.getCeilingColor(rowDistance, y, gameHeight, r.outdoor(ceilingPos))
+			screen.SetCell(x, y, r.surfaceGlyph(ceilingColor, x, y), ceilingColor, ceilingColor)
 		}
 
 		// Draw floor with proper distance-based shading
@@ -163,27 +147,33 @@ func (r *Renderer) Render(player *game.Player, worldMap *game.Map, screen *scree
 				rowDistance = perpWallDist // Fallback for edge cases
 			}
 
-			floorColor := r.getFloorColor(rowDistance)
-			screen.SetCell(x, y, ' ', floorColor, floorColor)
+			floorPos := player.Position.Add(rayDir.Scale(rowDistance))
+			floorColor := r.getFloorColor(rowDistance, floorPos, lights)
+			screen.SetCell(x, y, r.surfaceGlyph(floorColor, x, y), floorColor, floorColor)
 		}
 	}
 
-	// Render all sprites (projectiles, other players, and NPCs)
-	r.renderAllSprites(player, screen, projectiles, otherPlayers, npcs)
+	// Render every entity with a sprite (other players, NPCs, projectiles, ...)
+	r.renderAllSprites(player, screen, entities, lights)
+
+	// Draw beams, like railgun shots, over them
+	r.renderBeams(player, screen, entities)
+
+	// Draw the player's weapon over the scene
+	r.renderWeapon(player, screen)
 }
 
-func (r *Renderer) renderAllSprites(player *game.Player, screen *screen.Screen, projectiles []*game.Projectile, otherPlayers []*game.Player, npcs []*game.NPC) {
-	// Collect and sort sprites by distance (far to near)
-	var sprites []sprite
+func (r *Renderer) renderAllSprites(player *game.Player, screen *screen.Screen, entities []*game.Entity, lights []game.LightSource) {
+	// Collect and sort sprites by distance (far to near), reusing last frame's slice
+	sprites := r.sprites[:0]
 
-	// Add projectile sprites
-	for _, projectile := range projectiles {
-		if !projectile.Active || projectile.Type != game.Fireball {
+	for _, e := range entities {
+		if e.Sprite == nil || e.Floor != player.Floor {
 			continue
 		}
 
-		// Transform fireball position relative to player
-		relativePos := projectile.Position.Sub(player.Position)
+		// Transform entity position relative to player
+		relativePos := e.Position.Sub(player.Position)
 
 		// Rotate relative to player's view direction using proper 2D rotation
 		// We want transformedY to be the distance in front of the player
@@ -195,56 +185,22 @@ func (r *Renderer) renderAllSprites(player *game.Player, screen *screen.Screen,
 			continue
 		}
 
-		sprites = append(sprites, sprite{
-			pos:          projectile.Position,
-			transformedX: transformedX,
-			transformedY: transformedY,
-			spriteType:   "fireball",
-		})
-	}
-
-	// Add other player sprites
-	for _, otherPlayer := range otherPlayers {
-		// Transform other player position relative to current player
-		relativePos := otherPlayer.Position.Sub(player.Position)
-
-		// Rotate relative to player's view direction using proper 2D rotation
-		transformedY := relativePos.X*player.Direction.X + relativePos.Y*player.Direction.Y
-		transformedX := relativePos.X*player.Direction.Y + relativePos.Y*(-player.Direction.X)
-
-		// Skip if behind player
-		if transformedY <= 0.1 {
+		// In the dark, skip sprites out of sight unless something lights them
+		if !Visible(player, e.Position, r.worldMap, lights) {
 			continue
 		}
 
-		sprites = append(sprites, sprite{
-			pos:          otherPlayer.Position,
+		spr := sprite{
+			pos:          e.Position,
 			transformedX: transformedX,
 			transformedY: transformedY,
-			spriteType:   "player",
-		})
-	}
-
-	// Add NPC sprites
-	for _, npc := range npcs {
-		// Transform NPC position relative to current player
-		relativePos := npc.Position.Sub(player.Position)
-
-		// Rotate relative to player's view direction using proper 2D rotation
-		transformedY := relativePos.X*player.Direction.X + relativePos.Y*player.Direction.Y
-		transformedX := relativePos.X*player.Direction.Y + relativePos.Y*(-player.Direction.X)
-
-		// Skip if behind player
-		if transformedY <= 0.1 {
-			continue
+			look:         e.Sprite,
+			color:        e.Sprite.Color,
 		}
-
-		sprites = append(sprites, sprite{
-			pos:          npc.Position,
-			transformedX: transformedX,
-			transformedY: transformedY,
-			spriteType:   "npc",
-		})
+		if r.Theme == ThemeHighContrast {
+			spr.color = contrastSpriteColor(e)
+		}
+		sprites = append(sprites, spr)
 	}
 
 	// Sort sprites from farthest to nearest (painter's algorithm)
@@ -260,6 +216,7 @@ func (r *Renderer) renderAllSprites(player *game.Player, screen *screen.Screen,
 	for _, spr := range sprites {
 		r.renderSprite(spr, player, screen)
 	}
+	r.sprites = sprites
 }
 
 // sprite represents a renderable sprite in 3D space
@@ -267,12 +224,14 @@ type sprite struct {
 	pos          game.Vector
 	transformedX float64
 	transformedY float64
-	spriteType   string
+	look         *game.Sprite
+	color        color.RGBA // The look's color, or the theme's for it
 }
 
 // renderSprite renders a single sprite with proper Z-buffer testing
 func (r *Renderer) renderSprite(spr sprite, player *game.Player, screen *screen.Screen) {
 	gameHeight := screen.GameHeight
+	look := spr.look
 
 	// Project to screen coordinates using same method as wall renderer
 	// Calculate where this sprite appears on screen relative to camera plane
@@ -285,38 +244,10 @@ func (r *Renderer) renderSprite(spr sprite, player *game.Player, screen *screen.
 		return
 	}
 
-	// Calculate sprite size based on distance
-	var spriteSize int
-	var spriteChar rune
-	var spriteColor color.RGBA
-
-	switch spr.spriteType {
-	case "fireball":
-		spriteSize = int(float64(gameHeight) / spr.transformedY * 0.5) // Good size for fireballs
-		spriteChar = '●'
-		spriteColor = color.RGBA{255, 150, 0, 255} // Bright orange fireball
-	case "player":
-		// More stable size calculation - less sensitive to small distance changes
-		baseSize := float64(gameHeight) / spr.transformedY * 1.2
-		spriteSize = int(baseSize + 0.5) // Round properly
-		// Clamp to reasonable bounds for stability
-		if spriteSize < 4 {
-			spriteSize = 4
-		}
-		spriteChar = '@'
-		spriteColor = color.RGBA{0, 255, 0, 255} // Green player
-	case "npc":
-		// NPCs are slightly smaller than players
-		baseSize := float64(gameHeight) / spr.transformedY * 1.0
-		spriteSize = int(baseSize + 0.5) // Round properly
-		// Clamp to reasonable bounds for stability
-		if spriteSize < 3 {
-			spriteSize = 3
-		}
-		spriteChar = '◐'                           // Half-filled circle
-		spriteColor = color.RGBA{0, 150, 255, 255} // Blue NPC
-	default:
-		return
+	// Calculate sprite size based on distance, rounded so it's stable as the distance changes slightly
+	spriteSize := int(float64(gameHeight)/spr.transformedY*look.Scale + 0.5)
+	if spriteSize < look.MinSize {
+		spriteSize = look.MinSize
 	}
 
 	// Clamp size
@@ -327,9 +258,14 @@ func (r *Renderer) renderSprite(spr sprite, player *game.Player, screen *screen.
 		spriteSize = gameHeight / 2
 	}
 
-	// Calculate vertical bounds
+	// Calculate vertical bounds, centered at eye level or resting on the
+	// floor where walls at the same distance meet it
 	startY := gameHeight/2 - spriteSize/2
 	endY := gameHeight/2 + spriteSize/2
+	if look.OnFloor {
+		endY = gameHeight/2 + int(float64(gameHeight)/spr.transformedY/2)
+		startY = endY - spriteSize + 1
+	}
 
 	if startY < 0 {
 		startY = 0
@@ -338,16 +274,8 @@ func (r *Renderer) renderSprite(spr sprite, player *game.Player, screen *screen.
 		endY = gameHeight - 1
 	}
 
-	// Calculate horizontal width - different widths for different sprite types
-	var spriteWidth int
-	switch spr.spriteType {
-	case "player":
-		spriteWidth = (spriteSize * 3) / 4 // Players are much wider - almost as wide as they are tall
-	case "npc":
-		spriteWidth = spriteSize / 2 // NPCs are medium width
-	default: // fireballs and others
-		spriteWidth = spriteSize / 3 // Fireballs stay normal width
-	}
+	// Calculate horizontal width from the sprite's proportions
+	spriteWidth := int(float64(spriteSize) * look.Width)
 	if spriteWidth < 1 {
 		spriteWidth = 1
 	}
@@ -364,66 +292,86 @@ func (r *Renderer) renderSprite(spr sprite, player *game.Player, screen *screen.
 				distFromCenter := math.Abs(float64(y-centerY)) / float64(spriteSize/2+1)
 				distFromCenterX := math.Abs(float64(xOffset)) / float64(spriteWidth/2+1)
 
-				var intensity float64
-				var threshold float64
-				var brightnessMult float64
-
-				switch spr.spriteType {
-				case "fireball":
-					// Simple circular pattern for fireballs
-					intensity = 1.0 - math.Sqrt(distFromCenter*distFromCenter+distFromCenterX*distFromCenterX)
-					threshold = 0.1 // Low threshold for visibility
-					brightnessMult = 1.2
-				case "player":
-					// Make player sprites more solid and visible
-					intensity = 1.0 - math.Sqrt(distFromCenter*distFromCenter+distFromCenterX*distFromCenterX*0.5) // Less fade on X axis
-					threshold = 0.05                                                                               // Very low threshold for maximum visibility
-					brightnessMult = 1.5
-				case "npc":
-					// NPCs are visible but not as prominent as players
-					intensity = 1.0 - math.Sqrt(distFromCenter*distFromCenter+distFromCenterX*distFromCenterX*0.7) // Medium fade
-					threshold = 0.15                                                                               // Medium threshold
-					brightnessMult = 1.3
-				default:
+				// Brightest at the center, fading toward the edges
+				intensity := 1.0 - math.Sqrt(distFromCenter*distFromCenter+distFromCenterX*distFromCenterX*look.FadeX)
+				if intensity <= look.Threshold {
 					continue
 				}
 
-				if intensity > threshold {
-					finalColor := color.RGBA{
-						uint8(math.Min(255, float64(spriteColor.R)*intensity*brightnessMult)),
-						uint8(math.Min(255, float64(spriteColor.G)*intensity*brightnessMult)),
-						uint8(math.Min(255, float64(spriteColor.B)*intensity*brightnessMult)),
-						255,
+				// High contrast fills the sprite solidly, outlining its edge
+				// with its glyph on black
+				if r.Theme == ThemeHighContrast {
+					if intensity < look.Threshold+contrastEdge {
+						screen.SetCell(drawX, y, look.Glyph, spr.color, contrastOutline)
+					} else {
+						screen.SetCell(drawX, y, look.Glyph, spr.color, spr.color)
 					}
-					screen.SetCell(drawX, y, spriteChar, finalColor, finalColor)
+					continue
+				}
+
+				finalColor := color.RGBA{
+					uint8(math.Min(255, float64(spr.color.R)*intensity*look.Brightness)),
+					uint8(math.Min(255, float64(spr.color.G)*intensity*look.Brightness)),
+					uint8(math.Min(255, float64(spr.color.B)*intensity*look.Brightness)),
+					255,
 				}
+				screen.SetCell(drawX, y, look.Glyph, finalColor, finalColor)
+			}
+		}
+	}
+
+	// Draw the sprite's label, like an emote bubble, just above it
+	if look.Label != "" {
+		labelY := max(startY-1, 0)
+		label := []rune(look.Label)
+		for i, ch := range label {
+			drawX := screenX - len(label)/2 + i
+			if drawX >= 0 && drawX < r.screenWidth && spr.transformedY < r.zBuffer[drawX]+0.1 {
+				screen.SetCell(drawX, labelY, ch, labelForeground, labelBackground)
 			}
 		}
 	}
 }
 
-func (r *Renderer) getWallColor(wallType int, side int, distance float64, pos game.Vector, lights []game.LightSource) color.RGBA {
-	var baseColor color.RGBA
+// Colors for sprite labels, which contrast with anything behind them
+var (
+	labelForeground = color.RGBA{0, 0, 0, 255}
+	labelBackground = color.RGBA{255, 255, 255, 255}
+)
 
+// WallColor returns the color of a type of wall before any shading, for
+// drawing the map from above as well as in 3D
+func WallColor(wallType int) color.RGBA {
 	switch wallType {
 	case 1:
-		baseColor = color.RGBA{180, 32, 32, 255} // Dark red walls
+		return color.RGBA{180, 32, 32, 255} // Dark red walls
 	case 2:
-		baseColor = color.RGBA{32, 180, 32, 255} // Dark green walls
+		return color.RGBA{32, 180, 32, 255} // Dark green walls
 	case 3:
-		baseColor = color.RGBA{32, 32, 180, 255} // Dark blue walls
+		return color.RGBA{32, 32, 180, 255} // Dark blue walls
 	case 4:
-		baseColor = color.RGBA{180, 180, 32, 255} // Dark yellow walls
+		return color.RGBA{180, 180, 32, 255} // Dark yellow walls
 	case 5:
-		baseColor = color.RGBA{180, 32, 180, 255} // Dark magenta walls
+		return color.RGBA{180, 32, 180, 255} // Dark magenta walls
 	case 6:
-		baseColor = color.RGBA{32, 180, 180, 255} // Cyan walls
+		return color.RGBA{32, 180, 180, 255} // Cyan walls
 	case 7:
-		baseColor = color.RGBA{180, 100, 32, 255} // Orange walls
+		return color.RGBA{180, 100, 32, 255} // Orange walls
 	case 8:
-		baseColor = color.RGBA{100, 32, 180, 255} // Purple walls
+		return color.RGBA{100, 32, 180, 255} // Purple walls
+	case game.LockedDoor:
+		return color.RGBA{120, 70, 20, 255} // Brown wooden doors
+	case game.BreakableWall:
+		return color.RGBA{150, 130, 100, 255} // Sandy cracked walls
 	default:
-		baseColor = color.RGBA{120, 120, 120, 255} // Gray walls
+		return color.RGBA{120, 120, 120, 255} // Gray walls
+	}
+}
+
+func (r *Renderer) getWallColor(wallType int, side int, distance float64, pos game.Vector, lights []game.LightSource, outdoor bool) color.RGBA {
+	baseColor := WallColor(wallType)
+	if r.Theme == ThemeHighContrast {
+		baseColor = contrastWallColor(wallType)
 	}
 
 	// Make EW walls darker than NS walls for better depth perception
@@ -433,43 +381,107 @@ func (r *Renderer) getWallColor(wallType int, side int, distance float64, pos ga
 	}
 
 	// Apply distance-based fog/shading (closer = brighter)
-	maxDistance := 8.0 // Objects beyond this distance are very dark
-	distanceFactor := 1.0 - (distance / maxDistance)
-	if distanceFactor < 0.2 {
-		distanceFactor = 0.2 // Minimum visibility
+	distanceFactor := r.fog(distance, 8.0, 0.2, outdoor) // Very dark by 8 cells away
+
+	// Combine the distance and side shading with the colored light falling
+	// on the wall, like a fireball's orange glow
+	return shade(baseColor, sideFactor*distanceFactor, r.lightAt(pos, lights), sideFactor*wallLightStrength)
+}
+
+// fog returns how lit a surface at a distance is by the world's ambient light,
+// before any lights: fading to a minimum by maxDistance, or on dark maps to
+// pitch black at the edge of their vision radius. Outdoors, the ambient
+// light is stronger and fades over a longer distance.
+func (r *Renderer) fog(distance, maxDistance, minimum float64, outdoor bool) float64 {
+	ambient := r.Ambient
+	if outdoor {
+		ambient = min(1, ambient*outdoorAmbient)
+		maxDistance *= outdoorReach
 	}
+	if darkness := r.worldMap.Darkness; darkness > 0 {
+		return max(0, 1-distance/darkness) * ambient
+	}
+	if r.Theme == ThemeHighContrast {
+		minimum = max(minimum, contrastMinimum)
+	}
+	return max(minimum, 1-distance/maxDistance) * ambient
+}
 
-	// Calculate lighting from fireballs
-	lightFactor := 0.0
-	for _, light := range lights {
-		lightFactor += light.GetLightingAt(pos)
+// Outdoor lighting tuning
+const (
+	outdoorAmbient = 1.5 // How much stronger the ambient light is outdoors, up to full daylight
+	outdoorReach   = 1.5 // How much further surfaces stay lit outdoors
+)
+
+// outdoor reports whether a position in the map being drawn is outdoors
+func (r *Renderer) outdoor(pos game.Vector) bool {
+	return r.worldMap.IsOutdoor(int(pos.X), int(pos.Y))
+}
+
+// litThreshold is how much light must fall on something in the dark to see it
+const litThreshold = 0.1
+
+// Visible reports whether a player can make out something at a position by
+// light: always, unless the map is dark and it's beyond the map's vision
+// radius with too little light, baked or dynamic, falling on it
+func Visible(player *game.Player, pos game.Vector, worldMap *game.Map, lights []game.LightSource) bool {
+	if worldMap.Darkness <= 0 || pos.Sub(player.Position).Length() <= worldMap.Darkness {
+		return true
 	}
-	if lightFactor > 1.0 {
-		lightFactor = 1.0
+	if static := worldMap.Lightmap.At(pos); max(static[0], static[1], static[2]) >= litThreshold {
+		return true
 	}
+	for _, light := range lights {
+		if light.GetLightingAt(pos) >= litThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+// How strongly colored light tints walls and floors, as a fraction of full
+// brightness added by a light at full intensity
+const (
+	wallLightStrength  = 0.6
+	floorLightStrength = 0.4
+)
 
-	// Combine all factors (distance, side, and lighting)
-	finalFactor := sideFactor * (distanceFactor + lightFactor*0.8) // Lighting adds brightness
-	if finalFactor > 1.0 {
-		finalFactor = 1.0
+// lightAt returns the color of the light falling on a position: the map's
+// baked static light plus every dynamic light, each channel from 0 to 1
+func (r *Renderer) lightAt(pos game.Vector, lights []game.LightSource) [3]float64 {
+	total := r.worldMap.Lightmap.At(pos)
+	dynamic := game.LightAt(pos, lights)
+	for c := range total {
+		total[c] = min(1, total[c]+dynamic[c])
 	}
+	return total
+}
 
-	return color.RGBA{
-		uint8(float64(baseColor.R) * finalFactor),
-		uint8(float64(baseColor.G) * finalFactor),
-		uint8(float64(baseColor.B) * finalFactor),
-		255,
+// shade darkens a surface's base color by a factor, then adds the colored
+// light falling on it at a strength, clamping each channel at full brightness
+func shade(base color.RGBA, factor float64, light [3]float64, strength float64) color.RGBA {
+	channel := func(value uint8, light float64) uint8 {
+		return uint8(min(255, float64(value)*factor+255*light*strength))
 	}
+	return color.RGBA{channel(base.R, light[0]), channel(base.G, light[1]), channel(base.B, light[2]), 255}
 }
 
-func (r *Renderer) getCeilingColor(distance float64) color.RGBA {
-	baseColor := color.RGBA{80, 100, 140, 255} // Bluish ceiling
+// Sky colors in full daylight, at the top of the view and at the horizon
+var (
+	skyZenith  = color.RGBA{40, 90, 170, 255}
+	skyHorizon = color.RGBA{150, 190, 230, 255}
+)
 
-	maxDistance := 10.0
-	distanceFactor := 1.0 - (distance / maxDistance)
-	if distanceFactor < 0.1 {
-		distanceFactor = 0.1
+func (r *Renderer) getCeilingColor(distance float64, y, gameHeight int, outdoor bool) color.RGBA {
+	if outdoor {
+		return r.getSkyColor(y, gameHeight)
+	}
+	if r.Theme == ThemeHighContrast {
+		return contrastCeiling
 	}
+	baseColor := color.RGBA{80, 100, 140, 255} // Bluish ceiling
+
+	distanceFactor := r.fog(distance, 10.0, 0.1, false)
 
 	return color.RGBA{
 		uint8(float64(baseColor.R) * distanceFactor),
@@ -479,19 +491,27 @@ func (r *Renderer) getCeilingColor(distance float64) color.RGBA {
 	}
 }
 
-func (r *Renderer) getFloorColor(distance float64) color.RGBA {
-	baseColor := color.RGBA{60, 40, 20, 255} // Brownish floor
-
-	maxDistance := 10.0
-	distanceFactor := 1.0 - (distance / maxDistance)
-	if distanceFactor < 0.1 {
-		distanceFactor = 0.1
+// getSkyColor returns the color of the open sky in a row of the view,
+// lightening toward the horizon and darkening with the time of day
+func (r *Renderer) getSkyColor(y, gameHeight int) color.RGBA {
+	if r.Theme == ThemeHighContrast {
+		return contrastSky
 	}
+	t := min(1, float64(y)/max(1, float64(gameHeight/2)))
+	channel := func(zenith, horizon uint8) uint8 {
+		return uint8((float64(zenith) + (float64(horizon)-float64(zenith))*t) * min(1, r.Ambient*outdoorAmbient))
+	}
+	return color.RGBA{channel(skyZenith.R, skyHorizon.R), channel(skyZenith.G, skyHorizon.G), channel(skyZenith.B, skyHorizon.B), 255}
+}
 
-	return color.RGBA{
-		uint8(float64(baseColor.R) * distanceFactor),
-		uint8(float64(baseColor.G) * distanceFactor),
-		uint8(float64(baseColor.B) * distanceFactor),
-		255,
+func (r *Renderer) getFloorColor(distance float64, pos game.Vector, lights []game.LightSource) color.RGBA {
+	baseColor := color.RGBA{60, 40, 20, 255} // Brownish floor
+	if r.Theme == ThemeHighContrast {
+		baseColor = contrastFloor
 	}
+
+	distanceFactor := r.fog(distance, 10.0, 0.1, r.outdoor(pos))
+
+	// Lights tint the floor around them too
+	return shade(baseColor, distanceFactor, r.lightAt(pos, lights), floorLightStrength)
 }
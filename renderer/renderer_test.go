@@ -0,0 +1,47 @@
+package renderer
+
+import (
+	"testing"
+
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/screen"
+)
+
+func TestResize(t *testing.T) {
+	worldMap := game.NewMap()
+	player := game.NewPlayer(3.5, 1.5)
+	npc := game.NewNPC(1.5, 1.5) // In view, to the player's left
+	lights := []game.LightSource{{Position: player.Position, Radius: 3, Intensity: 1, Color: [3]float64{1, 1, 1}}}
+
+	for _, tt := range []struct {
+		name          string
+		width, height int
+	}{
+		{"larger", 200, 60},
+		{"smaller", 40, 12},
+		{"narrow", 1, 24},
+		{"one row", 80, 1},
+		{"tiny", 1, 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, diff := range []bool{false, true} {
+				s := screen.NewScreen(80, 24)
+				s.SetDiff(diff)
+				r := NewRenderer(80, 24)
+				r.Render(player, worldMap, s, lights, []*game.Entity{npc})
+				s.Frame()
+
+				// The session resizes both, as engine.Session does
+				s.Resize(tt.width, tt.height)
+				r.Resize(tt.width, tt.height)
+				if len(r.zBuffer) != tt.width {
+					t.Errorf("diff %t: z-buffer is %d wide, want %d", diff, len(r.zBuffer), tt.width)
+				}
+				for range 2 {
+					r.Render(player, worldMap, s, lights, []*game.Entity{npc})
+					s.Frame()
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package renderer
+
+import (
+	"image/color"
+	"strings"
+
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/screen"
+)
+
+// Theme is how the renderer colors the world
+type Theme int
+
+const (
+	ThemeClassic      Theme = iota // Shaded, softly lit colors
+	ThemeHighContrast              // Bright, distinct colors with outlines, for low-vision players and terminals with few colors
+)
+
+// themeNames names each theme, as players choose them
+var themeNames = []string{"classic", "contrast"}
+
+// ThemeNames returns the names of the themes, for choosing one
+func ThemeNames() []string {
+	return append([]string(nil), themeNames...)
+}
+
+// String returns the theme's name
+func (t Theme) String() string {
+	if int(t) < len(themeNames) {
+		return themeNames[t]
+	}
+	return themeNames[ThemeClassic]
+}
+
+// ParseTheme returns the theme with a name, or the default for an empty one
+func ParseTheme(name string) (Theme, bool) {
+	if name == "" {
+		return ThemeClassic, true
+	}
+	for i, n := range themeNames {
+		if strings.EqualFold(name, n) {
+			return Theme(i), true
+		}
+	}
+	return ThemeClassic, false
+}
+
+// High-contrast colors, close to the bright ANSI colors so they survive being
+// sent to terminals with only 16
+var (
+	contrastOutline = color.RGBA{0, 0, 0, 255}
+	contrastCeiling = color.RGBA{0, 0, 0, 255}
+	contrastSky     = color.RGBA{0, 0, 170, 255} // Blue, so open sky reads apart from ceilings
+	contrastFloor   = color.RGBA{40, 40, 40, 255}
+	contrastNPC     = color.RGBA{255, 85, 255, 255}  // Bright magenta
+	contrastHazard  = color.RGBA{255, 255, 85, 255}  // Bright yellow: projectiles, mines, and flames
+	contrastPickup  = color.RGBA{85, 255, 85, 255}   // Bright green
+	contrastOther   = color.RGBA{255, 255, 255, 255} // Anything else, like portals and ghosts
+)
+
+// contrastEdge is how far above a sprite's threshold its intensity must be
+// to be filled rather than outlined, in the high-contrast theme
+const contrastEdge = 0.15
+
+// contrastMinimum is the least a high-contrast surface is darkened with
+// distance, except in the dark, so walls stay readable far away
+const contrastMinimum = 0.6
+
+// outlineWall outlines a column of wall in the high-contrast theme: its top
+// and bottom, unless they're cut off by the screen, and the whole column if
+// it's the edge of a face
+func outlineWall(s *screen.Screen, x, top, bottom int, wallColor color.RGBA, edge bool) {
+	if edge {
+		for y := top; y <= bottom; y++ {
+			s.SetCell(x, y, '▌', contrastOutline, wallColor)
+		}
+	}
+	if top > 0 {
+		s.SetCell(x, top, '▀', contrastOutline, wallColor)
+	}
+	if bottom < s.GameHeight-1 {
+		s.SetCell(x, bottom, '▄', contrastOutline, wallColor)
+	}
+}
+
+// contrastWallColor returns the high-contrast color of a type of wall: each
+// a different bright color, with doors brown so they stand out from walls
+func contrastWallColor(wallType int) color.RGBA {
+	switch wallType {
+	case 1:
+		return color.RGBA{255, 85, 85, 255}
+	case 2:
+		return color.RGBA{85, 200, 85, 255}
+	case 3:
+		return color.RGBA{85, 85, 255, 255}
+	case 4:
+		return color.RGBA{230, 230, 85, 255}
+	case 5:
+		return color.RGBA{200, 85, 200, 255}
+	case 6:
+		return color.RGBA{85, 230, 230, 255}
+	case 7:
+		return color.RGBA{255, 150, 50, 255}
+	case 8:
+		return color.RGBA{160, 100, 255, 255}
+	case game.LockedDoor:
+		return color.RGBA{170, 85, 0, 255}
+	case game.BreakableWall:
+		return color.RGBA{230, 200, 150, 255}
+	default:
+		return color.RGBA{200, 200, 200, 255}
+	}
+}
+
+// contrastSpriteColor returns the high-contrast color of an entity by what it
+// is: players keep their own color, at full brightness, so they can still be
+// told apart
+func contrastSpriteColor(e *game.Entity) color.RGBA {
+	if e.Pickup != nil {
+		return contrastPickup
+	}
+	c := e.Sprite.Color
+	switch e.Kind {
+	case game.KindPlayer:
+		brightest := max(c.R, c.G, c.B)
+		if brightest == 0 {
+			return contrastOther
+		}
+		scale := 255 / float64(brightest)
+		return color.RGBA{uint8(float64(c.R) * scale), uint8(float64(c.G) * scale), uint8(float64(c.B) * scale), 255}
+	case game.KindNPC:
+		return contrastNPC
+	case game.KindFireball, game.KindRocket, game.KindGrenade, game.KindFlame, game.KindMine:
+		return contrastHazard
+	default:
+		return contrastOther
+	}
+}
@@ -0,0 +1,100 @@
+package renderer
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/screen"
+)
+
+// weaponArt is the first-person view of each weapon, drawn at the bottom center
+// of the game area. Spaces are transparent.
+var weaponArt = map[game.WeaponType][]string{
+	game.WeaponFireball: {
+		" ● ",
+		"/█\\",
+		"███",
+	},
+	game.WeaponScatter: {
+		"●●●",
+		"\\█/",
+		"███",
+	},
+	game.WeaponGrenade: {
+		" • ",
+		"(█)",
+		"███",
+	},
+	game.WeaponRocket: {
+		" ◆ ",
+		"[█]",
+		"███",
+	},
+	game.WeaponRicochet: {
+		" ● ",
+		"<█>",
+		"███",
+	},
+	game.WeaponRailgun: {
+		" ║ ",
+		"[█]",
+		"███",
+	},
+	game.WeaponFlamethrower: {
+		" ▲ ",
+		"{█}",
+		"███",
+	},
+}
+
+var (
+	weaponColor = color.RGBA{170, 120, 80, 255}  // Wooden staff
+	weaponOrb   = color.RGBA{255, 150, 0, 255}   // Fireball glow
+	weaponBg    = color.RGBA{40, 30, 20, 255}    // Shadow behind the art
+	weaponDim   = color.RGBA{120, 120, 120, 255} // Orb while the weapon isn't ready
+)
+
+// renderWeapon draws the player's current weapon. While switching, the weapon
+// drops out of view and the new one rises back up.
+func (r *Renderer) renderWeapon(player *game.Player, screen *screen.Screen) {
+	art, ok := weaponArt[player.Weapon]
+	if !ok {
+		return
+	}
+
+	// Lower by up to the art's height, following a smooth down-and-up curve
+	offset := 0
+	if player.SwitchTimer > 0 {
+		progress := 1 - player.SwitchTimer/game.WeaponSwitchTime // 0 to 1
+		offset = int(math.Round(math.Sin(progress*math.Pi) * float64(len(art))))
+	}
+
+	startX := r.screenWidth/2 - len([]rune(art[0]))/2
+	startY := screen.GameHeight - len(art) + offset
+	for row, line := range art {
+		for col, ch := range []rune(line) {
+			if ch == ' ' {
+				continue
+			}
+			fg := weaponColor
+			if ch == '●' || ch == '•' || ch == '◆' || ch == '║' || ch == '▲' {
+				fg = weaponOrb
+				switch ch {
+				case '•':
+					fg = game.GrenadeSprite.Color
+				case '◆':
+					fg = game.RocketSprite.Color
+				case '║':
+					fg = game.RailColor
+				case '▲':
+					fg = game.FlameSprite.Color
+				}
+				if !player.CanFire() {
+					fg = weaponDim
+				}
+			}
+			screen.SetCell(startX+col, startY+row, ch, fg, weaponBg)
+		}
+	}
+}
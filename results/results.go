@@ -0,0 +1,184 @@
+// Package results writes each match's results when it ends, as JSON with a
+// timeline of what happened and as a CSV table of players, for tournaments
+// and stats sites to ingest.
+package results
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+
+	"github.com/imjasonh/terminus/server"
+)
+
+// Formats are the file formats results can be written in
+var Formats = []string{"json", "csv"}
+
+// timelineKinds are the events recorded in a match's timeline
+var timelineKinds = []server.EventKind{
+	server.EventJoined,
+	server.EventLeft,
+	server.EventRenamed,
+	server.EventKilled,
+	server.EventPickedUp,
+	server.EventUsedItem,
+}
+
+// Results are a finished match's results and what happened in it
+type Results struct {
+	server.Match
+	Timeline []Entry `json:"timeline"`
+}
+
+// Entry is something that happened in a match
+type Entry struct {
+	Time   time.Time `json:"time"`
+	Event  string    `json:"event"` // The kind of event, like "killed"
+	Player string    `json:"player,omitempty"`
+	Detail string    `json:"detail,omitempty"`
+	Target string    `json:"target,omitempty"` // Who killed the player, for kills
+	X      float64   `json:"x,omitempty"`      // Where it happened, for kills
+	Y      float64   `json:"y,omitempty"`
+}
+
+// Exporter writes the results of matches
+type Exporter struct {
+	Dir     string    // Where result files are written, or "" for none
+	Formats []string  // Which Formats files are written in
+	Stdout  io.Writer // If set, each match's results are also written here as a line of JSON
+}
+
+// Run writes the results of each match that ends, until the process exits
+func (x *Exporter) Run(events *server.EventBus) {
+	sub := events.Subscribe(append([]server.EventKind{server.EventMatchStarted, server.EventMatchEnded}, timelineKinds...)...)
+	defer sub.Close()
+
+	var timeline []Entry
+	for e := range sub.Events() {
+		switch e.Kind {
+		case server.EventMatchStarted:
+			timeline = nil
+		case server.EventMatchEnded:
+			if e.Match != nil {
+				x.write(Results{Match: *e.Match, Timeline: timeline})
+			}
+			timeline = nil
+		default:
+			timeline = append(timeline, Entry{
+				Time:   e.Time,
+				Event:  e.Kind.String(),
+				Player: e.Name,
+				Detail: e.Detail,
+				Target: e.Target,
+				X:      e.Position.X,
+				Y:      e.Position.Y,
+			})
+		}
+	}
+}
+
+// write writes a match's results everywhere the exporter is configured to,
+// logging any failures
+func (x *Exporter) write(r Results) {
+	if x.Stdout != nil {
+		if data, err := json.Marshal(r); err != nil {
+			clog.Warnf("Failed to encode match results: %v", err)
+		} else {
+			x.Stdout.Write(append(data, '\n'))
+		}
+	}
+	if x.Dir == "" {
+		return
+	}
+
+	// Named so they sort by when the match started, like 20240102-150405-maze.json
+	mapName := strings.TrimSuffix(filepath.Base(r.Map), filepath.Ext(r.Map))
+	base := filepath.Join(x.Dir, r.Started.UTC().Format("20060102-150405")+"-"+mapName)
+	for _, format := range x.Formats {
+		var data []byte
+		var err error
+		switch format {
+		case "json":
+			data, err = json.MarshalIndent(r, "", "  ")
+		case "csv":
+			data, err = table(r.Match)
+		}
+		if err == nil {
+			err = writeFile(base+"."+format, data)
+		}
+		if err != nil {
+			clog.Warnf("Failed to write %s match results: %v", format, err)
+			continue
+		}
+		clog.Infof("Wrote match results to %s.%s", base, format)
+	}
+}
+
+// table lays a match's players out as CSV, one row each, best score first
+func table(m server.Match) ([]byte, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	w.Write([]string{"map", "started", "ended", "rank", "name", "kills", "deaths", "best_streak", "shots_fired", "hits", "accuracy", "weapon_shots"})
+	for i, p := range m.Players {
+		w.Write([]string{
+			m.Map,
+			m.Started.UTC().Format(time.RFC3339),
+			m.Ended.UTC().Format(time.RFC3339),
+			strconv.Itoa(i + 1),
+			p.Name,
+			strconv.Itoa(p.Kills),
+			strconv.Itoa(p.Deaths),
+			strconv.Itoa(p.BestStreak),
+			strconv.Itoa(p.ShotsFired),
+			strconv.Itoa(p.Hits),
+			strconv.FormatFloat(p.Accuracy(), 'f', 3, 64),
+			weaponShots(p.WeaponShots),
+		})
+	}
+	w.Flush()
+	return b.Bytes(), w.Error()
+}
+
+// weaponShots lists shots by weapon in one CSV field, like "pistol:12;shotgun:3"
+func weaponShots(shots map[string]int) string {
+	weapons := make([]string, 0, len(shots))
+	for weapon := range shots {
+		weapons = append(weapons, weapon)
+	}
+	sort.Strings(weapons)
+	for i, weapon := range weapons {
+		weapons[i] = fmt.Sprintf("%s:%d", weapon, shots[weapon])
+	}
+	return strings.Join(weapons, ";")
+}
+
+// writeFile writes a results file atomically, so readers never see part of one
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
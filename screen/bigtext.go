@@ -0,0 +1,50 @@
+package screen
+
+import "strings"
+
+// BigTextRows is how many rows tall BigText draws
+const BigTextRows = 3
+
+// bigGlyphs are the block letters BigText draws, each three cells wide and
+// BigTextRows tall
+var bigGlyphs = map[rune][BigTextRows]string{
+	'0': {"█▀█", "█ █", "▀▀▀"},
+	'1': {"▄█ ", " █ ", "▀▀▀"},
+	'2': {"▀▀█", "█▀▀", "▀▀▀"},
+	'3': {"▀▀█", " ▀█", "▀▀▀"},
+	'4': {"█ █", "▀▀█", "  ▀"},
+	'5': {"█▀▀", "▀▀█", "▀▀▀"},
+	'6': {"█▀▀", "█▀█", "▀▀▀"},
+	'7': {"▀▀█", "  █", "  ▀"},
+	'8': {"█▀█", "█▀█", "▀▀▀"},
+	'9': {"█▀█", "▀▀█", "▀▀▀"},
+	'/': {"  █", " █ ", "▀  "},
+	'-': {"   ", "▀▀▀", "   "},
+	' ': {"   ", "   ", "   "},
+}
+
+// BigText draws digits, spaces, and slashes in block letters BigTextRows
+// tall, like a figlet banner, for reading numbers on small or distant
+// screens. Other characters are skipped.
+func BigText(text string) [BigTextRows]string {
+	var rows [BigTextRows]strings.Builder
+	first := true
+	for _, r := range text {
+		glyph, ok := bigGlyphs[r]
+		if !ok {
+			continue
+		}
+		for i := range rows {
+			if !first {
+				rows[i].WriteByte(' ')
+			}
+			rows[i].WriteString(glyph[i])
+		}
+		first = false
+	}
+	var lines [BigTextRows]string
+	for i := range rows {
+		lines[i] = rows[i].String()
+	}
+	return lines
+}
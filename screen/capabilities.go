@@ -0,0 +1,300 @@
+package screen
+
+import (
+	"fmt"
+	"image/color"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ColorDepth is the number of colors a terminal can display
+type ColorDepth int
+
+const (
+	TrueColor  ColorDepth = iota // 24-bit RGB
+	Color256                     // xterm 256-color palette
+	Color16                      // Basic ANSI colors
+	Monochrome                   // No colors; shades are drawn with characters
+)
+
+// String returns a human-readable name for the color depth
+func (d ColorDepth) String() string {
+	switch d {
+	case Color256:
+		return "256 colors"
+	case Color16:
+		return "16 colors"
+	case Monochrome:
+		return "no color"
+	default:
+		return "24-bit color"
+	}
+}
+
+// ColorDepthNames are the names ParseColorDepth accepts, from most to fewest colors
+var ColorDepthNames = []string{"full", "256", "16", "mono"}
+
+// ParseColorDepth parses one of ColorDepthNames
+func ParseColorDepth(name string) (ColorDepth, bool) {
+	switch strings.ToLower(name) {
+	case "full":
+		return TrueColor, true
+	case "256":
+		return Color256, true
+	case "16":
+		return Color16, true
+	case "mono":
+		return Monochrome, true
+	}
+	return 0, false
+}
+
+// Capabilities describes what a client terminal can display
+type Capabilities struct {
+	Term       string
+	ColorDepth ColorDepth
+	Unicode    bool
+}
+
+// DefaultCapabilities assumes a modern terminal with true color and Unicode
+func DefaultCapabilities() Capabilities {
+	return Capabilities{ColorDepth: TrueColor, Unicode: true}
+}
+
+// limitedTerms are TERM values known to only support basic ANSI colors
+var limitedTerms = []string{"vt100", "vt102", "vt220", "ansi", "linux", "screen", "cons25"}
+
+// monochromeTerms are TERM values known to support no colors at all
+var monochromeTerms = []string{"dumb", "vt52"}
+
+// DetectCapabilities guesses terminal capabilities from TERM and the session environment
+// (entries in KEY=VALUE form), going without color for terminals that have
+// none or when NO_COLOR is set. Anything that can't be determined keeps the
+// default.
+func DetectCapabilities(term string, environ []string) Capabilities {
+	env := make(map[string]string)
+	for _, kv := range environ {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			env[k] = v
+		}
+	}
+	if term == "" {
+		term = env["TERM"]
+	}
+
+	caps := DefaultCapabilities()
+	caps.Term = term
+
+	// Color depth
+	colorTerm := strings.ToLower(env["COLORTERM"])
+	switch {
+	case env["NO_COLOR"] != "" || slices.Contains(monochromeTerms, term):
+		caps.ColorDepth = Monochrome
+	case colorTerm == "truecolor" || colorTerm == "24bit":
+		caps.ColorDepth = TrueColor
+	case strings.Contains(term, "256color"):
+		caps.ColorDepth = Color256
+	default:
+		for _, t := range limitedTerms {
+			if term == t {
+				caps.ColorDepth = Color16
+			}
+		}
+	}
+
+	// Unicode support from the locale, checked in order of precedence
+	for _, key := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if locale := env[key]; locale != "" {
+			locale = strings.ToLower(locale)
+			caps.Unicode = strings.Contains(locale, "utf-8") || strings.Contains(locale, "utf8")
+			break
+		}
+	}
+
+	return caps
+}
+
+// SetCapabilities sets the terminal capabilities used when rendering
+func (s *Screen) SetCapabilities(caps Capabilities) {
+	if caps != s.caps {
+		s.Invalidate() // Colors and glyphs already drawn may no longer match
+	}
+	s.caps = caps
+}
+
+// Capabilities returns the terminal capabilities used when rendering
+func (s *Screen) Capabilities() Capabilities {
+	return s.caps
+}
+
+// Precomputed SGR sequences, so frames can be encoded without formatting
+var (
+	fg256, bg256 [256][]byte // Select a 256-color palette entry
+	fg16, bg16   [16][]byte  // Select a basic ANSI color
+	decimals     [256][]byte // Decimal text of each color channel value
+)
+
+func init() {
+	for i := range 256 {
+		fg256[i] = fmt.Appendf(nil, "\x1b[38;5;%dm", i)
+		bg256[i] = fmt.Appendf(nil, "\x1b[48;5;%dm", i)
+		decimals[i] = strconv.AppendInt(nil, int64(i), 10)
+	}
+	for i := range 16 {
+		fgBase, bgBase := 30+i, 40+i
+		if i >= 8 {
+			fgBase, bgBase = 90+i-8, 100+i-8
+		}
+		fg16[i] = fmt.Appendf(nil, "\x1b[%dm", fgBase)
+		bg16[i] = fmt.Appendf(nil, "\x1b[%dm", bgBase)
+	}
+}
+
+// appendFg appends the SGR sequence selecting c as the foreground color
+func (s *Screen) appendFg(b []byte, c color.RGBA) []byte {
+	switch s.caps.ColorDepth {
+	case Color256:
+		return append(b, fg256[rgbTo256(c)]...)
+	case Color16:
+		return append(b, fg16[rgbTo16(c)]...)
+	case Monochrome:
+		return b
+	default:
+		return appendRGB(append(b, "\x1b[38;2;"...), c)
+	}
+}
+
+// appendBg appends the SGR sequence selecting c as the background color
+func (s *Screen) appendBg(b []byte, c color.RGBA) []byte {
+	switch s.caps.ColorDepth {
+	case Color256:
+		return append(b, bg256[rgbTo256(c)]...)
+	case Color16:
+		return append(b, bg16[rgbTo16(c)]...)
+	case Monochrome:
+		return b
+	default:
+		return appendRGB(append(b, "\x1b[48;2;"...), c)
+	}
+}
+
+// appendRGB appends the "R;G;Bm" end of a 24-bit color sequence
+func appendRGB(b []byte, c color.RGBA) []byte {
+	b = append(b, decimals[c.R]...)
+	b = append(b, ';')
+	b = append(b, decimals[c.G]...)
+	b = append(b, ';')
+	b = append(b, decimals[c.B]...)
+	return append(b, 'm')
+}
+
+// quantize returns the color the terminal will actually show for c at the
+// screen's color depth, so colors that look the same share one SGR sequence
+func (s *Screen) quantize(c color.RGBA) color.RGBA {
+	switch s.caps.ColorDepth {
+	case Color256:
+		i := rgbTo256(c) - 16
+		return color.RGBA{cubeLevels[i/36], cubeLevels[i/6%6], cubeLevels[i%6], 255}
+	case Color16:
+		return ansi16[rgbTo16(c)]
+	case Monochrome:
+		return color.RGBA{} // Cells only differ by character
+	default:
+		return c
+	}
+}
+
+// quantizeCell returns the cell as the terminal will show it
+func (s *Screen) quantizeCell(c Cell) Cell {
+	return Cell{Char: s.glyph(c.Char), FgColor: s.quantize(c.FgColor), BgColor: s.quantize(c.BgColor)}
+}
+
+// cubeLevels are the channel values of the 6x6x6 color cube as rgbTo256 maps them
+var cubeLevels = [6]uint8{0, 51, 102, 153, 204, 255}
+
+// rgbTo256 maps a color onto the 6x6x6 color cube of the 256-color palette
+func rgbTo256(c color.RGBA) int {
+	toCube := func(v uint8) int {
+		return (int(v)*5 + 127) / 255
+	}
+	return 16 + 36*toCube(c.R) + 6*toCube(c.G) + toCube(c.B)
+}
+
+// ansi16 are the approximate RGB values of the 16 basic ANSI colors
+var ansi16 = [16]color.RGBA{
+	{0, 0, 0, 255}, {170, 0, 0, 255}, {0, 170, 0, 255}, {170, 85, 0, 255},
+	{0, 0, 170, 255}, {170, 0, 170, 255}, {0, 170, 170, 255}, {170, 170, 170, 255},
+	{85, 85, 85, 255}, {255, 85, 85, 255}, {85, 255, 85, 255}, {255, 255, 85, 255},
+	{85, 85, 255, 255}, {255, 85, 255, 255}, {85, 255, 255, 255}, {255, 255, 255, 255},
+}
+
+// rgbTo16 returns the index of the nearest basic ANSI color
+func rgbTo16(c color.RGBA) int {
+	best, bestDist := 0, -1
+	for i, a := range ansi16 {
+		dr := int(c.R) - int(a.R)
+		dg := int(c.G) - int(a.G)
+		db := int(c.B) - int(a.B)
+		dist := dr*dr + dg*dg + db*db
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = i, dist
+		}
+	}
+	return best
+}
+
+// asciiFallbacks replaces the Unicode glyphs used by the game on terminals without Unicode support
+var asciiFallbacks = map[rune]rune{
+	'█': '#',
+	'░': '-',
+	'●': 'o',
+	'◐': 'O',
+	'·': '.',
+	'▼': 'v',
+	'◀': '<',
+	'▶': '>',
+	'◣': '<',
+	'◢': '>',
+	'☺': '@', // Player glyphs
+	'♠': 'S',
+	'♣': 'C',
+	'♥': 'H',
+	'♦': 'D',
+	'★': '*',
+	'↑': '^', // Direction arrows
+	'↗': '/',
+	'→': '>',
+	'↘': '\\',
+	'↓': 'v',
+	'↙': '/',
+	'←': '<',
+	'↖': '\\',
+	'»': '>', // Pickups and status effects
+	'◌': 'o',
+	'✦': '*',
+	'≈': '~',
+	'⊸': 'k',
+	'◊': '!',
+	'•': '.',
+	'◆': '*', // Rockets
+	'▪': '_', // Mines
+	'║': '|', // Railgun
+	'━': '-', // Railgun beams
+	'▲': '^', // Flames
+	'≡': '=', // Stairs
+	'▀': '#', // Large HUD digits
+	'▄': '#',
+	'▌': '|', // High-contrast wall edges
+}
+
+// glyph returns the rune to draw for r given the terminal's Unicode support
+func (s *Screen) glyph(r rune) rune {
+	if s.caps.Unicode || r < 128 {
+		return r
+	}
+	if fallback, ok := asciiFallbacks[r]; ok {
+		return fallback
+	}
+	return '?'
+}
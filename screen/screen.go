@@ -1,9 +1,10 @@
 package screen
 
 import (
-	"fmt"
 	"image/color"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 type Cell struct {
@@ -18,39 +19,98 @@ type Screen struct {
 	GameHeight int // Height available for game rendering (excludes HUD)
 	Buffer     [][]Cell
 	debugMsg   string
+	status     string
+	compass    string
+	panel      []string // Extra HUD rows above the status row, if any
+	caps       Capabilities
+	frame      []byte   // Encoded output, reused by each Frame call
+	line       []rune   // Scratch space for composing HUD rows
+	diff       bool     // Whether frames only include cells changed since the last one
+	sent       [][]Cell // The game area as of the last frame, when diffing
+	sentValid  bool     // Whether sent matches what the terminal shows
 }
 
-func NewScreen(width, height int) *Screen {
-	buffer := make([][]Cell, height)
-	for y := range buffer {
-		buffer[y] = make([]Cell, width)
-		for x := range buffer[y] {
-			buffer[y][x] = Cell{
-				Char:    ' ',
-				FgColor: color.RGBA{255, 255, 255, 255},
-				BgColor: color.RGBA{0, 0, 0, 255},
-			}
-		}
-	}
+// blankCell is an empty cell, white on black
+var blankCell = Cell{
+	Char:    ' ',
+	FgColor: color.RGBA{255, 255, 255, 255},
+	BgColor: color.RGBA{0, 0, 0, 255},
+}
 
+// MinHeight is the shortest screen drawn: a row of the game above the two HUD
+// rows. Shorter sizes are drawn at this height, and the terminal cuts them off.
+const MinHeight = 3
+
+func NewScreen(width, height int) *Screen {
+	width, height = max(width, 1), max(height, MinHeight)
 	return &Screen{
 		Width:      width,
 		Height:     height,
 		GameHeight: height - 2, // Reserve 2 bottom rows for HUD
-		Buffer:     buffer,
+		Buffer:     resizeCells(nil, width, height),
 		debugMsg:   "",
+		caps:       DefaultCapabilities(),
+	}
+}
+
+// Resize changes the screen's size in place, keeping the HUD messages,
+// capabilities, and settings, and reusing buffers where they're big enough.
+// The screen is cleared, and the next frame redraws all of it, since the
+// terminal may have reflowed what it showed. Sizes are kept to at least
+// MinHeight rows and one column.
+func (s *Screen) Resize(width, height int) {
+	width, height = max(width, 1), max(height, MinHeight)
+	if width == s.Width && height == s.Height {
+		return
+	}
+	s.Width, s.Height = width, height
+	s.Buffer = resizeCells(s.Buffer, width, height)
+	s.layout()
+	s.Invalidate()
+}
+
+// hudRows is how many rows at the bottom of the screen the HUD takes: the
+// status and compass rows, and the panel when there's room for it
+func (s *Screen) hudRows() int {
+	if s.Height-2-len(s.panel) < 1 {
+		return 2
+	}
+	return 2 + len(s.panel)
+}
+
+// layout sizes the game area to what the HUD leaves, after the screen or the
+// panel changes size
+func (s *Screen) layout() {
+	s.GameHeight = s.Height - s.hudRows()
+	if s.sent != nil {
+		s.sent = resizeCells(s.sent, s.Width, s.GameHeight)
+	}
+}
+
+// resizeCells returns rows of blank cells in the given size, reusing the
+// memory of the given rows where it's big enough
+func resizeCells(rows [][]Cell, width, height int) [][]Cell {
+	if cap(rows) < height {
+		rows = append(rows[:cap(rows)], make([][]Cell, height-cap(rows))...)
 	}
+	rows = rows[:height]
+	for y := range rows {
+		if cap(rows[y]) < width {
+			rows[y] = make([]Cell, width)
+		}
+		rows[y] = rows[y][:width]
+		for x := range rows[y] {
+			rows[y][x] = blankCell
+		}
+	}
+	return rows
 }
 
 func (s *Screen) Clear() {
 	// Only clear the game area, not the HUD
 	for y := 0; y < s.GameHeight; y++ {
 		for x := 0; x < s.Width; x++ {
-			s.Buffer[y][x] = Cell{
-				Char:    ' ',
-				FgColor: color.RGBA{255, 255, 255, 255},
-				BgColor: color.RGBA{0, 0, 0, 255},
-			}
+			s.Buffer[y][x] = blankCell
 		}
 	}
 }
@@ -59,6 +119,40 @@ func (s *Screen) SetDebugMessage(msg string) {
 	s.debugMsg = msg
 }
 
+// SetStatus sets the player status shown right-aligned on the HUD row, after
+// the debug message and cut short if they don't both fit
+func (s *Screen) SetStatus(status string) {
+	s.status = status
+}
+
+// SetCompass sets the compass strip shown on the bottom HUD row
+func (s *Screen) SetCompass(line string) {
+	s.compass = line
+}
+
+// SetPanel sets extra HUD rows shown above the status row, like the large
+// status readout, taking them from the bottom of the game area. Nil removes
+// the panel. It's left out on screens too short to spare the rows.
+func (s *Screen) SetPanel(rows []string) {
+	if len(rows) != len(s.panel) {
+		s.panel = append(s.panel[:0], rows...)
+		s.layout()
+		s.Invalidate()
+		return
+	}
+	copy(s.panel, rows)
+}
+
+// Meter formats a labeled bar like "STA ██████░░░░" for showing a value on the HUD
+func Meter(label string, value, maxValue float64, width int) string {
+	filled := 0
+	if maxValue > 0 {
+		filled = int(value / maxValue * float64(width))
+	}
+	filled = min(max(filled, 0), width)
+	return label + " " + strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
 func (s *Screen) SetCell(x, y int, char rune, fg, bg color.RGBA) {
 	// Only allow drawing in the game area, not the HUD area
 	if x >= 0 && x < s.Width && y >= 0 && y < s.GameHeight {
@@ -70,54 +164,188 @@ func (s *Screen) SetCell(x, y int, char rune, fg, bg color.RGBA) {
 	}
 }
 
+// DrawText writes text into the game area starting at (x, y), clipped to the screen
+func (s *Screen) DrawText(x, y int, text string, fg, bg color.RGBA) {
+	for _, r := range text {
+		s.SetCell(x, y, r, fg, bg)
+		x++
+	}
+}
+
+// SetDiff sets whether frames only redraw the cells that changed since the
+// previous frame, rather than the whole game area, to save bandwidth. Diff
+// frames must all reach the terminal, in order, to draw correctly.
+func (s *Screen) SetDiff(diff bool) {
+	if diff != s.diff {
+		s.diff = diff
+		s.Invalidate()
+	}
+}
+
+// Invalidate makes the next frame redraw the whole screen, for when the
+// terminal's contents changed some other way, like being cleared
+func (s *Screen) Invalidate() {
+	s.sentValid = false
+}
+
+// Render returns the screen as ANSI output
 func (s *Screen) Render() string {
-	var builder strings.Builder
+	return string(s.Frame())
+}
+
+// Frame encodes the screen as ANSI output into a buffer that's reused by the
+// next call, so drawing frames doesn't allocate once the buffer has grown
+func (s *Screen) Frame() []byte {
+	b := s.frame[:0]
 
 	// Move cursor to top-left and render game area
-	builder.WriteString("\x1b[H")
+	b = append(b, "\x1b[H"...)
 
 	var lastFg, lastBg color.RGBA
+	full := !s.diff || !s.sentValid
+	if s.diff && s.sent == nil {
+		s.sent = make([][]Cell, s.GameHeight)
+		for y := range s.sent {
+			s.sent[y] = make([]Cell, s.Width)
+		}
+	}
 	for y := 0; y < s.GameHeight; y++ {
 		// Position cursor at start of this row
-		builder.WriteString(fmt.Sprintf("\x1b[%d;1H", y+1))
+		if full {
+			b = appendCursorRow(b, y+1)
+		}
 
+		moved := true // Whether the cursor needs positioning before the next changed cell
 		for x := 0; x < s.Width; x++ {
-			cell := s.Buffer[y][x]
+			cell := s.quantizeCell(s.Buffer[y][x])
+
+			// When diffing, skip cells the terminal already shows
+			if !full {
+				if cell == s.sent[y][x] {
+					moved = true
+					continue
+				}
+				if moved {
+					b = appendCursor(b, y+1, x+1)
+					moved = false
+				}
+			}
 
 			// Only set colors if they changed (optimization)
 			if cell.FgColor != lastFg {
-				builder.WriteString(fmt.Sprintf("\x1b[38;2;%d;%d;%dm", cell.FgColor.R, cell.FgColor.G, cell.FgColor.B))
+				b = s.appendFg(b, cell.FgColor)
 				lastFg = cell.FgColor
 			}
 			if cell.BgColor != lastBg {
-				builder.WriteString(fmt.Sprintf("\x1b[48;2;%d;%d;%dm", cell.BgColor.R, cell.BgColor.G, cell.BgColor.B))
+				b = s.appendBg(b, cell.BgColor)
 				lastBg = cell.BgColor
 			}
 
-			builder.WriteRune(cell.Char)
+			b = utf8.AppendRune(b, cell.Char)
+			if s.diff {
+				s.sent[y][x] = cell
+			}
 		}
 	}
 
+	if s.diff {
+		s.sentValid = true
+	}
+
 	// Render HUD at bottom
-	s.renderHUD(&builder)
+	b = s.appendHUD(b)
 
 	// Reset colors at the end
-	builder.WriteString("\x1b[0m")
-	return builder.String()
+	b = append(b, "\x1b[0m"...)
+	s.frame = b
+	return b
 }
 
-func (s *Screen) renderHUD(builder *strings.Builder) {
+// HUD colors
+var (
+	hudFg     = color.RGBA{255, 255, 255, 255} // White text...
+	hudBg     = color.RGBA{0, 0, 100, 255}     // ...on dark blue
+	compassFg = color.RGBA{255, 200, 80, 255}  // Amber text...
+	compassBg = color.RGBA{0, 0, 0, 255}       // ...on black
+)
+
+func (s *Screen) appendHUD(b []byte) []byte {
+	line := s.line[:0]
+	b = s.appendFg(b, hudFg)
+	b = s.appendBg(b, hudBg)
+
+	// Panel rows between the game area and the status row
+	for i := 0; i < s.hudRows()-2; i++ {
+		b = appendCursorRow(b, s.GameHeight+i+1)
+		line = line[:0]
+		for _, r := range s.panel[i] {
+			line = append(line, r)
+		}
+		for len(line) < s.Width {
+			line = append(line, ' ')
+		}
+		for _, r := range line[:s.Width] {
+			b = utf8.AppendRune(b, s.glyph(r))
+		}
+	}
+
 	// Position cursor at HUD area (second to last row)
-	hudRow := s.Height - 1
-	fmt.Fprintf(builder, "\x1b[%d;1H", hudRow)
+	b = appendCursorRow(b, s.Height-1)
 
-	// Set HUD colors (white text on dark blue background)
-	builder.WriteString("\x1b[38;2;255;255;255m\x1b[48;2;0;0;100m")
+	// Clear the HUD line and write debug message, with the status right-aligned
+	line = line[:0]
+	for _, r := range s.debugMsg {
+		line = append(line, r)
+	}
+	for len(line) < s.Width {
+		line = append(line, ' ')
+	}
+	if n := utf8.RuneCountInString(s.status); n > 0 && n < s.Width {
+		// The debug message keeps its place, with a space before the status
+		i := max(s.Width-n-1, utf8.RuneCountInString(s.debugMsg)+1)
+		for _, r := range s.status {
+			if i >= s.Width-1 {
+				break
+			}
+			line[i] = r
+			i++
+		}
+		line[s.Width-1] = ' '
+	}
+	for _, r := range line[:s.Width] {
+		b = utf8.AppendRune(b, s.glyph(r))
+	}
 
-	// Clear the HUD line and write debug message
-	hudLine := fmt.Sprintf("%-*s", s.Width, s.debugMsg)
-	if len(hudLine) > s.Width {
-		hudLine = hudLine[:s.Width]
+	// Compass strip on the last row
+	b = appendCursorRow(b, s.Height)
+	b = s.appendFg(b, compassFg)
+	b = s.appendBg(b, compassBg)
+	line = line[:0]
+	for _, r := range s.compass {
+		line = append(line, r)
+	}
+	for len(line) < s.Width {
+		line = append(line, ' ')
+	}
+	for _, r := range line[:s.Width] {
+		b = utf8.AppendRune(b, s.glyph(r))
 	}
-	builder.WriteString(hudLine)
+	s.line = line
+	return b
+}
+
+// appendCursorRow appends the sequence moving the cursor to the start of a row (1-based)
+func appendCursorRow(b []byte, row int) []byte {
+	b = append(b, "\x1b["...)
+	b = strconv.AppendInt(b, int64(row), 10)
+	return append(b, ";1H"...)
+}
+
+// appendCursor appends the sequence moving the cursor to a row and column (1-based)
+func appendCursor(b []byte, row, col int) []byte {
+	b = append(b, "\x1b["...)
+	b = strconv.AppendInt(b, int64(row), 10)
+	b = append(b, ';')
+	b = strconv.AppendInt(b, int64(col), 10)
+	return append(b, 'H')
 }
@@ -0,0 +1,101 @@
+package screen
+
+import (
+	"bytes"
+	"image/color"
+	"testing"
+)
+
+var red = color.RGBA{255, 0, 0, 255}
+
+func TestResize(t *testing.T) {
+	for _, tt := range []struct {
+		name           string
+		width, height  int
+		panel          int // Panel rows
+		wantWidth      int
+		wantHeight     int
+		wantGameHeight int
+	}{
+		{"larger", 120, 40, 0, 120, 40, 38},
+		{"smaller", 40, 10, 0, 40, 10, 8},
+		{"with a panel", 80, 24, 3, 80, 24, 19},
+		{"panel that doesn't fit", 80, 5, 3, 80, 5, 3},
+		{"smallest", 1, MinHeight, 0, 1, MinHeight, 1},
+		{"one row", 80, 1, 0, 80, MinHeight, 1},
+		{"one row with a panel", 80, 1, 3, 80, MinHeight, 1},
+		{"zero", 0, 0, 0, 1, MinHeight, 1},
+		{"negative", -5, -5, 0, 1, MinHeight, 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, diff := range []bool{false, true} {
+				s := NewScreen(80, 24)
+				s.SetDiff(diff)
+				s.SetPanel(make([]string, tt.panel))
+				s.Frame()
+
+				s.Resize(tt.width, tt.height)
+				if s.Width != tt.wantWidth || s.Height != tt.wantHeight || s.GameHeight != tt.wantGameHeight {
+					t.Errorf("diff %t: Resize(%d, %d) = %dx%d with %d game rows, want %dx%d with %d", diff, tt.width, tt.height, s.Width, s.Height, s.GameHeight, tt.wantWidth, tt.wantHeight, tt.wantGameHeight)
+				}
+				if len(s.Buffer) != s.Height || len(s.Buffer[0]) != s.Width {
+					t.Errorf("diff %t: buffer is %dx%d, want %dx%d", diff, len(s.Buffer[0]), len(s.Buffer), s.Width, s.Height)
+				}
+
+				// Drawing every cell and a couple of frames at the new size mustn't panic
+				for y := range s.Height {
+					for x := range s.Width {
+						s.SetCell(x, y, '#', red, red)
+					}
+				}
+				s.Frame()
+				s.Frame()
+			}
+		})
+	}
+}
+
+func TestNewScreenMinimumSize(t *testing.T) {
+	s := NewScreen(80, 1)
+	s.SetDiff(true)
+	s.Frame()
+	if s.Height != MinHeight || s.GameHeight != 1 {
+		t.Errorf("NewScreen(80, 1) = %d rows with %d game rows, want %d with 1", s.Height, s.GameHeight, MinHeight)
+	}
+}
+
+func TestResizeRedrawsEverything(t *testing.T) {
+	s := NewScreen(10, 5)
+	s.SetDiff(true)
+	s.SetDebugMessage("hi")
+	full := len(s.Frame())
+	if unchanged := len(s.Frame()); unchanged >= full {
+		t.Fatalf("unchanged diff frame is %d bytes, want fewer than the full frame's %d", unchanged, full)
+	}
+
+	s.Resize(10, 6)
+	frame := s.Frame()
+	if rows := bytes.Count(frame, []byte(";1H")); rows != s.Height {
+		t.Errorf("frame after resize positions the cursor at %d row starts, want all %d", rows, s.Height)
+	}
+	if !bytes.Contains(frame, []byte("hi")) {
+		t.Error("frame after resize lost the debug message")
+	}
+}
+
+func TestDiffFrame(t *testing.T) {
+	s := NewScreen(10, 5)
+	s.SetDiff(true)
+	s.Frame()
+
+	s.SetCell(3, 1, 'x', red, red)
+	frame := s.Frame()
+	if !bytes.Contains(frame, []byte("\x1b[2;4H")) {
+		t.Errorf("diff frame %q doesn't draw the changed cell at row 2, column 4", frame)
+	}
+	for _, row := range []string{"\x1b[1;1H", "\x1b[2;1H", "\x1b[3;1H"} {
+		if bytes.Contains(frame, []byte(row)) {
+			t.Errorf("diff frame %q redraws an unchanged row of the game", frame)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package script
+
+import (
+	lua "github.com/yuin/gopher-lua"
+
+	"github.com/imjasonh/terminus/game"
+)
+
+// api returns the terminus table of functions scripts call to change the game:
+//
+//	terminus.broadcast(msg)           send a message to every player
+//	terminus.tell(name, msg)          send a message to one player
+//	terminus.players()                the names of the connected players
+//	terminus.player(name)             {name, x, y, health} for a player, or nil
+//	terminus.teleport(name, x, y)     move a player
+//	terminus.spawn(kind, x, y)        add an NPC ("npc") or a pickup ("health", "speed", ...)
+//	terminus.cell(x, y)               the map cell at a position: 0 for floor, or a wall type
+//	terminus.set_cell(x, y, value)    change a map cell, like building a wall
+//	terminus.open(trigger)            slide open the moving walls the map gives a trigger name
+//	terminus.close(trigger)           slide them shut, crushing anyone caught
+//
+// Functions that can fail raise a Lua error, which scripts can catch with pcall.
+func (s *Script) api() *lua.LTable {
+	return s.L.SetFuncs(s.L.NewTable(), map[string]lua.LGFunction{
+		"broadcast": s.broadcast,
+		"tell":      s.tell,
+		"players":   s.players,
+		"player":    s.player,
+		"teleport":  s.teleport,
+		"spawn":     s.spawn,
+		"cell":      s.cell,
+		"set_cell":  s.setCell,
+		"open":      s.openMovers,
+		"close":     s.closeMovers,
+	})
+}
+
+func (s *Script) broadcast(L *lua.LState) int {
+	s.gs.Broadcast(L.CheckString(1))
+	return 0
+}
+
+func (s *Script) tell(L *lua.LState) int {
+	name, msg := L.CheckString(1), L.CheckString(2)
+	session, ok := s.gs.FindPlayer(name)
+	if !ok {
+		L.RaiseError("no player named %s", name)
+	}
+	session.Notify(msg)
+	return 0
+}
+
+func (s *Script) players(L *lua.LState) int {
+	names := L.NewTable()
+	for _, name := range s.gs.PlayerNames() {
+		names.Append(lua.LString(name))
+	}
+	L.Push(names)
+	return 1
+}
+
+func (s *Script) player(L *lua.LState) int {
+	session, ok := s.gs.FindPlayer(L.CheckString(1))
+	if !ok {
+		L.Push(lua.LNil)
+		return 1
+	}
+	p := L.NewTable()
+	p.RawSetString("name", lua.LString(session.Name))
+	p.RawSetString("x", lua.LNumber(session.Player.Position.X))
+	p.RawSetString("y", lua.LNumber(session.Player.Position.Y))
+	p.RawSetString("health", lua.LNumber(session.Player.Health))
+	L.Push(p)
+	return 1
+}
+
+func (s *Script) teleport(L *lua.LState) int {
+	name := L.CheckString(1)
+	dest := game.Vector{X: float64(L.CheckNumber(2)), Y: float64(L.CheckNumber(3))}
+	session, ok := s.gs.FindPlayer(name)
+	if !ok {
+		L.RaiseError("no player named %s", name)
+	}
+	if err := s.gs.TeleportPlayer(session, dest); err != nil {
+		L.RaiseError("%v", err)
+	}
+	return 0
+}
+
+func (s *Script) spawn(L *lua.LState) int {
+	kind := L.CheckString(1)
+	pos := game.Vector{X: float64(L.CheckNumber(2)), Y: float64(L.CheckNumber(3))}
+	if s.gs.Cell(int(pos.X), int(pos.Y)) != 0 {
+		L.RaiseError("(%.1f, %.1f) is inside a wall", pos.X, pos.Y)
+	}
+	if kind == "npc" {
+		s.gs.SpawnEntity(game.NewNPC(pos.X, pos.Y))
+		return 0
+	}
+	t, ok := game.FindPickupType(kind)
+	if !ok {
+		L.RaiseError("can't spawn %q; spawn \"npc\" or a pickup", kind)
+	}
+	s.gs.SpawnEntity(game.NewPickup(game.PickupSpawn{Kind: t.Kind, Position: pos}))
+	return 0
+}
+
+func (s *Script) cell(L *lua.LState) int {
+	L.Push(lua.LNumber(s.gs.Cell(L.CheckInt(1), L.CheckInt(2))))
+	return 1
+}
+
+func (s *Script) setCell(L *lua.LState) int {
+	if err := s.gs.SetCell(L.CheckInt(1), L.CheckInt(2), L.CheckInt(3)); err != nil {
+		L.RaiseError("%v", err)
+	}
+	return 0
+}
+
+func (s *Script) openMovers(L *lua.LState) int {
+	if err := s.gs.TriggerMovers(L.CheckString(1), true); err != nil {
+		L.RaiseError("%v", err)
+	}
+	return 0
+}
+
+func (s *Script) closeMovers(L *lua.LState) int {
+	if err := s.gs.TriggerMovers(L.CheckString(1), false); err != nil {
+		L.RaiseError("%v", err)
+	}
+	return 0
+}
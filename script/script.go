@@ -0,0 +1,145 @@
+// Package script runs game rules written in Lua, so operators can build
+// custom modes without changing the server. A script defines any of these
+// global functions, which the server calls from the simulation:
+//
+//	onPlayerJoin(name)            a player joined
+//	onKill(victim, killer, x, y)  a player died at (x, y); killer is nil if nobody killed them
+//	onTick(dt)                    after every simulation step of dt seconds
+//	onUse(name, item)             a player used an inventory item, like "Key"
+//	onPickup(name, pickup)        a player took a pickup, like "health pack"
+//
+// and calls the functions in the terminus table (see api.go) to change the
+// game. A script may also set a global rules string telling players how its
+// mode is played, which they see in the in-game help. Scripts can't read or
+// write files.
+package script
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	lua "github.com/yuin/gopher-lua"
+
+	"github.com/imjasonh/terminus/server"
+)
+
+// Time limits that keep a slow or stuck script from stalling the simulation
+const (
+	loadTimeout = time.Second
+	stepTimeout = 20 * time.Millisecond // For all the hooks run in a step
+)
+
+// unsafeGlobals are base library functions scripts may not use, since they
+// read files or run code other than the script's own hooks
+var unsafeGlobals = []string{"dofile", "loadfile", "load", "loadstring", "require", "module"}
+
+// Script is a Lua script that runs as a game server's Rules
+type Script struct {
+	path        string
+	gs          *server.GameServer
+	L           *lua.LState
+	events      *server.Subscription
+	description string // The script's rules global, as of when it loaded
+}
+
+// Load runs a Lua script's top level, which defines its hooks, and returns
+// it ready to add to the server's Rules
+func Load(gs *server.GameServer, path string) (*Script, error) {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	for _, name := range unsafeGlobals {
+		L.SetGlobal(name, lua.LNil)
+	}
+
+	s := &Script{path: path, gs: gs, L: L}
+	L.SetGlobal("terminus", s.api())
+
+	ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+	defer cancel()
+	L.SetContext(ctx)
+	defer L.RemoveContext()
+	if err := L.DoFile(path); err != nil {
+		L.Close()
+		return nil, fmt.Errorf("failed to run script %s: %w", path, err)
+	}
+	if rules, ok := L.GetGlobal("rules").(lua.LString); ok {
+		s.description = string(rules)
+	}
+
+	s.events = gs.Events.Subscribe(server.EventJoined, server.EventKilled, server.EventUsedItem, server.EventPickedUp)
+	return s, nil
+}
+
+// Describe returns how the script's mode is played, as it told players with
+// its rules global
+func (s *Script) Describe() string {
+	return s.description
+}
+
+// Close stops the script
+func (s *Script) Close() {
+	s.events.Close()
+	s.L.Close()
+}
+
+// Step calls the script's hooks for what happened since the last step, then
+// onTick. The simulation calls it after each step.
+func (s *Script) Step(deltaTime float64) {
+	ctx, cancel := context.WithTimeout(context.Background(), stepTimeout)
+	defer cancel()
+	s.L.SetContext(ctx)
+	defer s.L.RemoveContext()
+
+	for {
+		select {
+		case e := <-s.events.Events():
+			s.handle(e)
+		default:
+			s.call("onTick", lua.LNumber(deltaTime))
+			return
+		}
+	}
+}
+
+// handle calls the hook for an event
+func (s *Script) handle(e server.Event) {
+	switch e.Kind {
+	case server.EventJoined:
+		s.call("onPlayerJoin", lua.LString(e.Name))
+	case server.EventKilled:
+		var killer lua.LValue = lua.LNil
+		if e.Target != "" {
+			killer = lua.LString(e.Target)
+		}
+		s.call("onKill", lua.LString(e.Name), killer, lua.LNumber(e.Position.X), lua.LNumber(e.Position.Y))
+	case server.EventUsedItem:
+		s.call("onUse", lua.LString(e.Name), lua.LString(e.Detail))
+	case server.EventPickedUp:
+		s.call("onPickup", lua.LString(e.Name), lua.LString(e.Detail))
+	}
+}
+
+// call calls a hook if the script defines it, logging any error
+func (s *Script) call(hook string, args ...lua.LValue) {
+	fn, ok := s.L.GetGlobal(hook).(*lua.LFunction)
+	if !ok {
+		return
+	}
+	if err := s.L.CallByParam(lua.P{Fn: fn, Protect: true}, args...); err != nil {
+		clog.Warnf("Script %s: %s failed: %v", s.path, hook, err)
+	}
+}
@@ -0,0 +1,202 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/input"
+)
+
+// PlayerInfo describes a connected player for operators
+type PlayerInfo struct {
+	ID               string  `json:"id"`
+	Name             string  `json:"name"`
+	Admin            bool    `json:"admin"`
+	Bot              bool    `json:"bot,omitempty"`
+	RemoteIP         string  `json:"remote_ip"`
+	KeyFingerprint   string  `json:"key_fingerprint,omitempty"`
+	Team             string  `json:"team,omitempty"`
+	Health           float64 `json:"health"`
+	X                float64 `json:"x"`
+	Y                float64 `json:"y"`
+	ConnectedSeconds int64   `json:"connected_seconds"`
+	Session          Stats   `json:"session"`  // This session's stats
+	Lifetime         Stats   `json:"lifetime"` // Including earlier sessions with the same key
+}
+
+// PlayerList describes every connected player, ordered by name
+func (gs *GameServer) PlayerList() []PlayerInfo {
+	gs.PlayersMutex.RLock()
+	defer gs.PlayersMutex.RUnlock()
+
+	players := make([]PlayerInfo, 0, len(gs.Players))
+	for _, session := range gs.Players {
+		players = append(players, PlayerInfo{
+			ID:               session.ID,
+			Name:             session.Name,
+			Admin:            session.IsAdmin(),
+			Bot:              session.Bot,
+			RemoteIP:         session.RemoteIP,
+			KeyFingerprint:   session.KeyFingerprint,
+			Team:             session.Team,
+			Health:           session.Player.Health,
+			X:                session.Player.Position.X,
+			Y:                session.Player.Position.Y,
+			ConnectedSeconds: int64(time.Since(session.ConnectedAt).Seconds()),
+			Session:          session.SessionStats(),
+			Lifetime:         session.Stats(),
+		})
+	}
+	sort.Slice(players, func(i, j int) bool { return players[i].Name < players[j].Name })
+	return players
+}
+
+// NewOperator returns an admin session for someone running commands from
+// outside the game, like through the admin API. It isn't one of the server's
+// players, so it has no slot and nobody sees it, but commands check and
+// audit it the same way as an admin in the game.
+func NewOperator(name string) *PlayerSession {
+	session := &PlayerSession{
+		ID:          "operator:" + name,
+		Name:        name,
+		Role:        RoleAdmin,
+		Player:      game.NewPlayer(0, 0),
+		ConnectedAt: time.Now(),
+		Keymap:      input.DefaultKeymap(),
+		Holds:       input.NewHoldTracker(),
+		notices:     make(chan string, 16),
+		kicked:      make(chan struct{}),
+	}
+	session.UpdateLogger()
+	return session
+}
+
+// FindPlayer looks up a connected player by name (case-insensitive) or session ID prefix
+func (gs *GameServer) FindPlayer(nameOrID string) (*PlayerSession, bool) {
+	gs.PlayersMutex.RLock()
+	defer gs.PlayersMutex.RUnlock()
+
+	for _, session := range gs.Players {
+		if strings.EqualFold(session.Name, nameOrID) {
+			return session, true
+		}
+	}
+	for id, session := range gs.Players {
+		if len(nameOrID) >= 4 && strings.HasPrefix(id, nameOrID) {
+			return session, true
+		}
+	}
+	return nil, false
+}
+
+// PlayerNames returns the names of all connected players
+func (gs *GameServer) PlayerNames() []string {
+	gs.PlayersMutex.RLock()
+	defer gs.PlayersMutex.RUnlock()
+
+	var names []string
+	for _, session := range gs.Players {
+		names = append(names, session.Name)
+	}
+	return names
+}
+
+// KickPlayer disconnects a player, showing them the reason
+func (gs *GameServer) KickPlayer(nameOrID, reason string) error {
+	session, ok := gs.FindPlayer(nameOrID)
+	if !ok {
+		return fmt.Errorf("no player named %s", nameOrID)
+	}
+	if reason == "" {
+		reason = "Kicked by an admin"
+	}
+	session.Kick(reason)
+	return nil
+}
+
+// Broadcast sends a message to every connected player
+func (gs *GameServer) Broadcast(msg string) {
+	gs.PlayersMutex.RLock()
+	defer gs.PlayersMutex.RUnlock()
+
+	for _, session := range gs.Players {
+		session.Notify(msg)
+	}
+	gs.Events.Publish(Event{Kind: EventBroadcast, Detail: msg})
+}
+
+// TeleportPlayer moves a player to a position on their floor, which must not
+// be inside a wall
+func (gs *GameServer) TeleportPlayer(session *PlayerSession, pos game.Vector) error {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+
+	if gs.Map.Floor(session.Player.Floor).IsWall(int(pos.X), int(pos.Y)) {
+		return fmt.Errorf("(%.1f, %.1f) is inside a wall", pos.X, pos.Y)
+	}
+	session.Log.Infof("Teleported to (%.1f, %.1f)", pos.X, pos.Y)
+	session.Player.Position = pos
+	return nil
+}
+
+// PlayerPosition returns where a player is
+func (gs *GameServer) PlayerPosition(session *PlayerSession) game.Vector {
+	gs.PlayersMutex.RLock()
+	defer gs.PlayersMutex.RUnlock()
+	return session.Player.Position
+}
+
+// SpawnNPCs adds NPCs at random empty locations
+func (gs *GameServer) SpawnNPCs(count int) {
+	gs.EntitiesMutex.Lock()
+	defer gs.EntitiesMutex.Unlock()
+
+	for range count {
+		spawnX, spawnY := gs.findRandomSpawnPoint()
+		gs.addEntity(game.NewNPC(spawnX, spawnY))
+	}
+}
+
+// ClearNPCs removes all NPCs from the world, leaving other entities
+func (gs *GameServer) ClearNPCs() {
+	gs.EntitiesMutex.Lock()
+	defer gs.EntitiesMutex.Unlock()
+
+	for _, e := range gs.Entities {
+		if e.Kind == game.KindNPC {
+			e.Removed = true
+		}
+	}
+}
+
+// BanPlayer kicks a player and bans their SSH key and IP address. A zero
+// duration bans them permanently.
+func (gs *GameServer) BanPlayer(nameOrID, reason, by string, duration time.Duration) error {
+	session, ok := gs.FindPlayer(nameOrID)
+	if !ok {
+		return fmt.Errorf("no player named %s", nameOrID)
+	}
+	if reason == "" {
+		reason = "Banned by an admin"
+	}
+
+	ban := Ban{
+		Fingerprint: session.KeyFingerprint,
+		IP:          session.RemoteIP,
+		Name:        session.Name,
+		Reason:      reason,
+		By:          by,
+		Created:     time.Now(),
+	}
+	if duration > 0 {
+		ban.Expires = ban.Created.Add(duration)
+	}
+	// Kick even if the ban can't be saved, since it's still in effect until restart
+	err := gs.Bans.Add(ban)
+	session.Log.Infof("%s (by %s)", ban.Message(), by)
+	session.Kick(ban.Message())
+	return err
+}
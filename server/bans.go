@@ -0,0 +1,156 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Ban keeps an SSH key and/or IP address off the server
+type Ban struct {
+	Fingerprint string    `json:"fingerprint,omitempty"`
+	IP          string    `json:"ip,omitempty"`
+	Name        string    `json:"name,omitempty"` // Player name at the time of the ban, for reference
+	Reason      string    `json:"reason"`
+	By          string    `json:"by,omitempty"`
+	Created     time.Time `json:"created"`
+	Expires     time.Time `json:"expires,omitzero"` // Zero for a permanent ban
+}
+
+// Expired reports whether a temporary ban has run out
+func (b Ban) Expired(now time.Time) bool {
+	return !b.Expires.IsZero() && now.After(b.Expires)
+}
+
+// Matches reports whether the ban covers a key fingerprint or IP address
+func (b Ban) Matches(fingerprint, ip string) bool {
+	return (b.Fingerprint != "" && b.Fingerprint == fingerprint) || (b.IP != "" && b.IP == ip)
+}
+
+// Message describes the ban for the banned player
+func (b Ban) Message() string {
+	if b.Expires.IsZero() {
+		return "Banned: " + b.Reason
+	}
+	return fmt.Sprintf("Banned until %s: %s", b.Expires.UTC().Format(time.RFC1123), b.Reason)
+}
+
+// BanList is the set of active bans, saved to a JSON file if it has a path
+type BanList struct {
+	path string
+
+	mu   sync.Mutex
+	bans []Ban
+}
+
+// NewBanList creates an empty ban list that's only kept in memory
+func NewBanList() *BanList {
+	return &BanList{}
+}
+
+// LoadBanList reads bans from a JSON file. A missing file is an empty list.
+func LoadBanList(path string) (*BanList, error) {
+	b := &BanList{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ban list %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &b.bans); err != nil {
+		return nil, fmt.Errorf("failed to parse ban list %s: %w", path, err)
+	}
+	return b, nil
+}
+
+// Add records a ban and saves the list
+func (b *BanList) Add(ban Ban) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bans = append(b.bans, ban)
+	return b.save()
+}
+
+// Remove lifts all bans on a key fingerprint or IP address, returning how many were removed
+func (b *BanList) Remove(target string) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	kept := b.bans[:0]
+	for _, ban := range b.bans {
+		if ban.Fingerprint != target && ban.IP != target {
+			kept = append(kept, ban)
+		}
+	}
+	removed := len(b.bans) - len(kept)
+	b.bans = kept
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, b.save()
+}
+
+// Check returns the ban covering a key fingerprint or IP address, if any
+func (b *BanList) Check(fingerprint, ip string) (Ban, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	for _, ban := range b.bans {
+		if !ban.Expired(now) && ban.Matches(fingerprint, ip) {
+			return ban, true
+		}
+	}
+	return Ban{}, false
+}
+
+// List returns the bans that haven't expired, dropping expired ones from the list
+func (b *BanList) List() []Ban {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	active := b.bans[:0]
+	for _, ban := range b.bans {
+		if !ban.Expired(now) {
+			active = append(active, ban)
+		}
+	}
+	if len(active) != len(b.bans) {
+		b.bans = active
+		b.save() // Best effort; the expired bans no longer apply either way
+	}
+	return append([]Ban(nil), b.bans...)
+}
+
+// save writes the list to its file, replacing the old one atomically. Callers hold mu.
+func (b *BanList) save() error {
+	if b.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(b.bans, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(b.path), filepath.Base(b.path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save ban list: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save ban list: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save ban list: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), b.path); err != nil {
+		return fmt.Errorf("failed to save ban list: %w", err)
+	}
+	return nil
+}
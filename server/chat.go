@@ -0,0 +1,227 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/chainguard-dev/clog"
+)
+
+// Chat tuning
+const (
+	ChatHistory   = 50  // Messages and announcements kept for players who join later
+	maxChatLength = 100 // Longest chat message, in characters
+)
+
+// ChatKinds are the events shown in the chat: messages to everyone, teams, and
+// single players, and players joining and leaving
+var ChatKinds = []EventKind{EventChat, EventTeamChat, EventWhisper, EventJoined, EventLeft}
+
+// ChatFilter checks a chat message before it's sent, returning the text to
+// send, which may be changed (e.g. to mask words), or an error explaining to
+// the sender why it wasn't sent
+type ChatFilter func(text string) (string, error)
+
+// ChatLog keeps the latest chat events, so players joining see what was said
+type ChatLog struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// NewChatLog creates an empty chat log
+func NewChatLog() *ChatLog {
+	return &ChatLog{}
+}
+
+// add records an event, dropping the oldest past ChatHistory
+func (c *ChatLog) add(e Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, e)
+	if len(c.events) > ChatHistory {
+		c.events = c.events[len(c.events)-ChatHistory:]
+	}
+}
+
+// History returns the recorded chat events for a session, oldest first
+func (c *ChatLog) History(sessionID string) []Event {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var events []Event
+	for _, e := range c.events {
+		if e.For(sessionID) {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// isChatKind reports whether events of a kind belong in the chat
+func isChatKind(kind EventKind) bool {
+	for _, k := range ChatKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Say sends a chat message from the player to everyone on the server
+func (gs *GameServer) Say(session *PlayerSession, text string) error {
+	return gs.sendChat(session, Event{Kind: EventChat}, text)
+}
+
+// SayToTeam sends a chat message from the player to their team
+func (gs *GameServer) SayToTeam(session *PlayerSession, text string) error {
+	teammates := gs.Teammates(session)
+	if teammates == nil {
+		return errors.New("you're not on a team; join one with /jointeam <name>")
+	}
+	return gs.sendChat(session, Event{Kind: EventTeamChat, Target: session.Team, Recipients: teammates}, text)
+}
+
+// Whisper sends a chat message from the player to another player only
+func (gs *GameServer) Whisper(session *PlayerSession, nameOrID, text string) error {
+	target, ok := gs.FindPlayer(nameOrID)
+	if !ok {
+		return fmt.Errorf("no player named %s", nameOrID)
+	}
+	if target == session {
+		return errors.New("you can't whisper to yourself")
+	}
+	return gs.sendChat(session, Event{Kind: EventWhisper, Target: target.Name, Recipients: []string{session.ID, target.ID}}, text)
+}
+
+// sendChat sends a chat event from the player with the message, after the
+// server's ChatFilter, if any. Messages are rate limited and logged.
+func (gs *GameServer) sendChat(session *PlayerSession, e Event, text string) error {
+	text = normalizeChat(text)
+	if text == "" {
+		return errors.New("nothing to say")
+	}
+	if n := len([]rune(text)); n > maxChatLength {
+		return fmt.Errorf("messages must be at most %d characters, not %d", maxChatLength, n)
+	}
+	if gs.ChatFilter != nil {
+		filtered, err := gs.ChatFilter(text)
+		if err != nil {
+			session.Log.Info("Chat message filtered", "message", text, "error", err)
+			return err
+		}
+		text = filtered
+	}
+	if !session.Allow(ActionChat) {
+		return errors.New("you're sending messages too quickly")
+	}
+
+	session.Log.Info("Chat", "channel", e.Kind, "to", e.Target, "message", text)
+	e.SessionID, e.Name, e.Detail = session.ID, session.Name, text
+	gs.publish(e)
+	return nil
+}
+
+// RelayChat sends a chat message to everyone on the server from someone
+// outside the game, like a member of a bridged Discord channel, after the
+// server's ChatFilter. Messages too long for the chat are cut short. Its
+// event has no SessionID, so bridges can tell it apart from players' chat.
+func (gs *GameServer) RelayChat(name, text string) error {
+	text = normalizeChat(text)
+	if text == "" {
+		return errors.New("nothing to say")
+	}
+	if runes := []rune(text); len(runes) > maxChatLength {
+		text = string(runes[:maxChatLength-1]) + "…"
+	}
+	if gs.ChatFilter != nil {
+		filtered, err := gs.ChatFilter(text)
+		if err != nil {
+			return err
+		}
+		text = filtered
+	}
+
+	clog.Info("Relayed chat", "player", name, "message", text)
+	gs.publish(Event{Kind: EventChat, Name: name, Detail: text})
+	return nil
+}
+
+// normalizeChat collapses runs of whitespace and control characters in a
+// chat message to single spaces, trimming them from the ends
+func normalizeChat(text string) string {
+	return strings.Join(strings.FieldsFunc(text, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsControl(r)
+	}), " ")
+}
+
+// MaskWords returns a ChatFilter that replaces the given words, matched
+// case-insensitively as whole words, with asterisks
+func MaskWords(words []string) ChatFilter {
+	masked := make(map[string]bool, len(words))
+	for _, w := range words {
+		masked[strings.ToLower(w)] = true
+	}
+	return func(text string) (string, error) {
+		runes := []rune(text)
+		for start := 0; start < len(runes); {
+			if !unicode.IsLetter(runes[start]) && !unicode.IsDigit(runes[start]) {
+				start++
+				continue
+			}
+			end := start
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end])) {
+				end++
+			}
+			if masked[strings.ToLower(string(runes[start:end]))] {
+				for i := start; i < end; i++ {
+					runes[i] = '*'
+				}
+			}
+			start = end
+		}
+		return string(runes), nil
+	}
+}
+
+// LoadWordFilter reads a file of words to mask in chat, one per line, with
+// blank lines and lines starting with # ignored
+func LoadWordFilter(path string) (ChatFilter, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chat filter %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read chat filter %s: %w", path, err)
+	}
+	return MaskWords(words), nil
+}
+
+// ChatLine formats a chat event for display to players reading a language,
+// e.g. "<alice> hi" or "* bob joined"
+func ChatLine(e Event, lang string) string {
+	switch e.Kind {
+	case EventChat:
+		return "<" + e.Name + "> " + e.Detail
+	case EventTeamChat:
+		return "[" + e.Target + "] <" + e.Name + "> " + e.Detail
+	case EventWhisper:
+		return "<" + e.Name + " -> " + e.Target + "> " + e.Detail
+	default:
+		return "* " + e.Text(lang)
+	}
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// DefaultDayLength is how long a day and night last on maps with a day/night
+// cycle, unless the server's configured otherwise
+const DefaultDayLength = 20 * time.Minute
+
+// Day/night tuning. The day starts at dawn, peaks at noon, and the world
+// starts at noon so players don't join in the dark.
+const (
+	nightAmbient = 0.3  // Light level at midnight, where 1 is full daylight
+	worldStart   = 0.25 // How far through the day the world starts
+)
+
+// TimeOfDay is a part of the world clock's day
+type TimeOfDay int
+
+const (
+	Day TimeOfDay = iota
+	Dusk
+	Night
+	Dawn
+)
+
+// String returns the time of day's name
+func (t TimeOfDay) String() string {
+	switch t {
+	case Day:
+		return "day"
+	case Dusk:
+		return "dusk"
+	case Night:
+		return "night"
+	default:
+		return "dawn"
+	}
+}
+
+// announcements tell players when each time of day starts, by its name
+var announcements = map[string]string{
+	Day.String():   "The sun is up",
+	Dusk.String():  "Dusk falls",
+	Night.String(): "Night has fallen",
+	Dawn.String():  "Dawn breaks",
+}
+
+// timeOfDay returns the part of the day a fraction of the way through it
+// falls in: dawn and dusk are the eighths either side of sunrise and sunset
+func timeOfDay(fraction float64) TimeOfDay {
+	switch {
+	case fraction < 1.0/8 || fraction >= 7.0/8:
+		return Dawn
+	case fraction < 3.0/8:
+		return Day
+	case fraction < 5.0/8:
+		return Dusk
+	default:
+		return Night
+	}
+}
+
+// ambientLight returns how lit the world is a fraction of the way through the
+// day, brightest at noon and darkest at midnight
+func ambientLight(fraction float64) float64 {
+	daylight := 0.5 + 0.5*math.Sin(2*math.Pi*fraction)
+	return nightAmbient + (1-nightAmbient)*daylight
+}
+
+// worldClock tracks how long the simulation has run, which animates lights,
+// and the time of day. The simulation advances it, while snapshots may be
+// taken from other goroutines.
+type worldClock struct {
+	mu      sync.Mutex
+	elapsed float64 // Seconds the clock has run
+	phase   TimeOfDay
+}
+
+// dayFraction returns how far through the day the clock is, from 0 at dawn
+// to 1 at the next dawn. Callers hold clock.mu.
+func (gs *GameServer) dayFraction() float64 {
+	return math.Mod(gs.clock.elapsed/gs.DayLength.Seconds()+worldStart, 1)
+}
+
+// dayNight reports whether the world has a day/night cycle: the map has one,
+// and the server has a day length. Callers hold clock.mu.
+func (gs *GameServer) dayNight() bool {
+	return gs.Map.DayNight && gs.DayLength > 0
+}
+
+// advanceClock moves the world clock on, announcing each new time of day if
+// the world has a day/night cycle
+func (gs *GameServer) advanceClock(deltaTime float64) {
+	gs.clock.mu.Lock()
+	gs.clock.elapsed += deltaTime
+	if !gs.dayNight() {
+		gs.clock.mu.Unlock()
+		return
+	}
+	phase := timeOfDay(gs.dayFraction())
+	changed := phase != gs.clock.phase
+	gs.clock.phase = phase
+	gs.clock.mu.Unlock()
+
+	if changed {
+		gs.publish(Event{Kind: EventTimeOfDay, Detail: phase.String()})
+	}
+}
+
+// WorldTime returns how many seconds of simulation the world clock has run
+func (gs *GameServer) WorldTime() float64 {
+	gs.clock.mu.Lock()
+	defer gs.clock.mu.Unlock()
+	return gs.clock.elapsed
+}
+
+// Ambient returns how lit the world is by the time of day, from nightAmbient
+// at midnight to 1 at noon, or 1 if the world has no day/night cycle
+func (gs *GameServer) Ambient() float64 {
+	gs.clock.mu.Lock()
+	defer gs.clock.mu.Unlock()
+	if !gs.dayNight() {
+		return 1
+	}
+	return ambientLight(gs.dayFraction())
+}
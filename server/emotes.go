@@ -0,0 +1,65 @@
+package server
+
+import (
+	"time"
+
+	"github.com/imjasonh/terminus/game"
+)
+
+// Emote shows an emote above the player's sprite to viewers nearby, by
+// publishing it for their render loops. It returns false if the player is
+// emoting too often.
+func (gs *GameServer) Emote(session *PlayerSession, emote game.Emote) bool {
+	if !session.Allow(ActionEmote) {
+		return false
+	}
+	gs.publishPlayerEvent(EventEmote, session, emote.Name)
+	return true
+}
+
+// EmoteBubbles tracks the emotes players are showing, for a render loop to
+// draw. Feed it the EventEmote events from a subscription.
+type EmoteBubbles struct {
+	shown map[string]shownEmote // By session ID
+}
+
+// shownEmote is an emote bubble and when it disappears
+type shownEmote struct {
+	bubble string
+	until  time.Time
+}
+
+// Show starts showing an emote event's bubble
+func (b *EmoteBubbles) Show(e Event) {
+	emote, ok := game.FindEmote(e.Detail)
+	if !ok {
+		return
+	}
+	if b.shown == nil {
+		b.shown = make(map[string]shownEmote)
+	}
+	b.shown[e.SessionID] = shownEmote{
+		bubble: emote.Bubble,
+		until:  e.Time.Add(time.Duration(game.EmoteSeconds * float64(time.Second))),
+	}
+}
+
+// Labels returns the bubbles to draw over players in the snapshot who are
+// emoting within game.EmoteRange of the viewer, by session ID, forgetting
+// emotes that have ended
+func (b *EmoteBubbles) Labels(snap *Snapshot, viewer game.Vector, now time.Time) map[string]string {
+	if len(b.shown) == 0 {
+		return nil
+	}
+	labels := make(map[string]string, len(b.shown))
+	for id, shown := range b.shown {
+		if now.After(shown.until) {
+			delete(b.shown, id)
+			continue
+		}
+		if state, ok := snap.Player(id); ok && state.Player.Position.Sub(viewer).Length() <= game.EmoteRange {
+			labels[id] = shown.bubble
+		}
+	}
+	return labels
+}
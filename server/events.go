@@ -0,0 +1,288 @@
+package server
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/locale"
+)
+
+// EventKind identifies what happened in a gameplay event
+type EventKind int
+
+const (
+	EventJoined       EventKind = iota // A player joined the game
+	EventLeft                          // A player left the game
+	EventRenamed                       // A player changed their name; Detail is the old name
+	EventFired                         // A player fired; Detail is the weapon's name, and Position where they fired from
+	EventBroadcast                     // A message was sent to every player; Detail is the message
+	EventMapChanged                    // The server switched maps; Detail is the map's name
+	EventEmote                         // A player emoted; Detail is the game.Emote's name
+	EventChat                          // A player said something in chat; Detail is the message
+	EventTeamChat                      // A player said something to their team; Target is the team
+	EventWhisper                       // A player said something to another; Target is their name
+	EventKilled                        // A player ran out of health at Position; Target is who killed them, if anyone
+	EventTimeOfDay                     // The world clock reached a new time of day; Detail is its name
+	EventExploded                      // Something exploded at Position, reaching Radius; SessionID is whose it was, if anyone's
+	EventPickedUp                      // A player took a pickup; Detail is what it was, like "health pack"
+	EventUsedItem                      // A player used an inventory item at Position, like the door a key opened; Detail is the item's name
+	EventMatchStarted                  // A match started; Detail is the map's name
+	EventMatchEnded                    // A match ended; Detail is the map's name, and Match the results
+	EventRecordBroken                  // A player set a server record; Detail describes it, and Target is its name
+	EventPortal                        // A player stepped into a portal at Position; Detail is the shard it leads to
+
+	numEventKinds // Not a kind; counts them
+)
+
+// String returns a short name for the kind of event, e.g. for logs and webhooks
+func (k EventKind) String() string {
+	switch k {
+	case EventJoined:
+		return "joined"
+	case EventLeft:
+		return "left"
+	case EventRenamed:
+		return "renamed"
+	case EventFired:
+		return "fired"
+	case EventBroadcast:
+		return "broadcast"
+	case EventMapChanged:
+		return "map_changed"
+	case EventEmote:
+		return "emote"
+	case EventChat:
+		return "chat"
+	case EventTeamChat:
+		return "team_chat"
+	case EventWhisper:
+		return "whisper"
+	case EventKilled:
+		return "killed"
+	case EventTimeOfDay:
+		return "time_of_day"
+	case EventExploded:
+		return "exploded"
+	case EventPickedUp:
+		return "picked_up"
+	case EventUsedItem:
+		return "used_item"
+	case EventMatchStarted:
+		return "match_started"
+	case EventMatchEnded:
+		return "match_ended"
+	case EventRecordBroken:
+		return "record_broken"
+	case EventPortal:
+		return "portal"
+	default:
+		return fmt.Sprintf("event(%d)", int(k))
+	}
+}
+
+// EventKinds returns every kind of event, like for subscribing to them all
+func EventKinds() []EventKind {
+	kinds := make([]EventKind, 0, numEventKinds)
+	for k := range numEventKinds {
+		kinds = append(kinds, k)
+	}
+	return kinds
+}
+
+// ParseEventKind returns the kind of event with a name from EventKind.String
+func ParseEventKind(name string) (EventKind, bool) {
+	for k := range numEventKinds {
+		if k.String() == name {
+			return k, true
+		}
+	}
+	return 0, false
+}
+
+// Event is something that happened in the game, published to every subscriber
+// interested in its kind
+type Event struct {
+	Kind      EventKind
+	Time      time.Time
+	SessionID string // The player the event is about, if any
+	Name      string // That player's name at the time
+	Detail    string // Depends on the kind
+	Target    string // Who the event is aimed at, like a whisper's recipient, if anyone
+
+	// Where in the world it happened, on which floor, and how far it
+	// reached, for events like shots and explosions
+	Position game.Vector
+	Floor    int
+	Radius   float64
+
+	Match *Match // The results, for match ended events
+
+	// Recipients are the session IDs that should see the event, or empty if
+	// everyone should, like for a message to a team
+	Recipients []string
+}
+
+// For reports whether the event is meant for a session
+func (e Event) For(sessionID string) bool {
+	return len(e.Recipients) == 0 || slices.Contains(e.Recipients, sessionID)
+}
+
+// String describes the event for players, in English
+func (e Event) String() string {
+	return e.Text(locale.English)
+}
+
+// Text describes the event for players reading a language
+func (e Event) Text(lang string) string {
+	t := func(format string, args ...any) string { return locale.T(lang, format, args...) }
+	switch e.Kind {
+	case EventJoined:
+		return t("%s joined", e.Name)
+	case EventLeft:
+		return t("%s left", e.Name)
+	case EventRenamed:
+		return t("%s is now %s", e.Detail, e.Name)
+	case EventFired:
+		return t("%s fired %s", e.Name, t(e.Detail))
+	case EventBroadcast:
+		return e.Detail
+	case EventMapChanged:
+		return t("Map changed to %s", e.Detail)
+	case EventEmote:
+		if emote, ok := game.FindEmote(e.Detail); ok {
+			return t("%s "+emote.Verb, e.Name)
+		}
+		return e.Name + " " + e.Detail
+	case EventChat:
+		return e.Name + ": " + e.Detail
+	case EventTeamChat:
+		return e.Name + " to " + e.Target + ": " + e.Detail
+	case EventWhisper:
+		return e.Name + " whispers to " + e.Target + ": " + e.Detail
+	case EventKilled:
+		if e.Target == "" {
+			return t("%s died", e.Name)
+		}
+		return t("%s was killed by %s", e.Name, e.Target)
+	case EventTimeOfDay:
+		return t(announcements[e.Detail])
+	case EventPickedUp:
+		return t("%s picked up %s", e.Name, t(e.Detail))
+	case EventUsedItem:
+		return t("%s used %s", e.Name, t(e.Detail))
+	case EventMatchStarted:
+		return t("A match started on %s", e.Detail)
+	case EventMatchEnded:
+		if e.Match != nil && len(e.Match.Players) > 0 && e.Match.Players[0].Kills > 0 {
+			winner := e.Match.Players[0]
+			return t("%s won the match on %s with a score of %d", winner.Name, e.Detail, winner.Kills)
+		}
+		return t("The match on %s ended", e.Detail)
+	case EventRecordBroken:
+		return t("%s set a server record: %s", e.Name, e.Detail)
+	case EventPortal:
+		return t("%s stepped into the portal to %s", e.Name, e.Detail)
+	default:
+		return e.Kind.String()
+	}
+}
+
+// eventBuffer is how many events a subscriber may fall behind by before more
+// are dropped
+const eventBuffer = 64
+
+// EventBus delivers gameplay events to subscribers, like HUD overlays, stats,
+// and webhooks, so what happens in the game doesn't need to know who's
+// interested. Publishing never blocks: a subscriber that falls too far behind
+// misses events.
+type EventBus struct {
+	mu          sync.RWMutex
+	subscribers map[*Subscription]struct{}
+}
+
+// NewEventBus creates an event bus with no subscribers
+func NewEventBus() *EventBus {
+	return &EventBus{subscribers: make(map[*Subscription]struct{})}
+}
+
+// Subscription receives the events a subscriber asked for until it's closed
+type Subscription struct {
+	bus    *EventBus
+	kinds  map[EventKind]bool // Empty for every kind
+	events chan Event
+}
+
+// Subscribe starts receiving events of the given kinds, or of every kind if
+// none are given. Close the subscription when done with it.
+func (b *EventBus) Subscribe(kinds ...EventKind) *Subscription {
+	sub := &Subscription{
+		bus:    b,
+		kinds:  make(map[EventKind]bool, len(kinds)),
+		events: make(chan Event, eventBuffer),
+	}
+	for _, kind := range kinds {
+		sub.kinds[kind] = true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Events returns the channel of events for the subscription
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close stops delivering events to the subscription
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	delete(s.bus.subscribers, s)
+}
+
+// Publish sends an event to every subscriber interested in its kind, setting
+// its time if it isn't set
+func (b *EventBus) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subscribers {
+		if len(sub.kinds) > 0 && !sub.kinds[e.Kind] {
+			continue
+		}
+		select {
+		case sub.events <- e:
+		default:
+			// Drop the event if the subscriber is behind
+		}
+	}
+}
+
+// publishShot publishes a player's shot with a weapon from a position on a
+// floor, as read under PlayersMutex when they fired
+func (gs *GameServer) publishShot(session *PlayerSession, weapon string, from game.Vector, floor int) {
+	gs.publish(Event{Kind: EventFired, SessionID: session.ID, Name: session.Name, Detail: weapon, Position: from, Floor: floor})
+}
+
+// publishPlayerEvent publishes an event about a player
+func (gs *GameServer) publishPlayerEvent(kind EventKind, session *PlayerSession, detail string) {
+	gs.publish(Event{Kind: kind, SessionID: session.ID, Name: session.Name, Detail: detail})
+}
+
+// publish timestamps and publishes an event, recording it in the chat log if
+// it's shown in chat
+func (gs *GameServer) publish(e Event) {
+	e.Time = time.Now()
+	if isChatKind(e.Kind) {
+		gs.Chat.add(e)
+	}
+	gs.Events.Publish(e)
+}
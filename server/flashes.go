@@ -0,0 +1,30 @@
+package server
+
+import "github.com/imjasonh/terminus/game"
+
+// spawnFlashes lights up where shots were fired and things exploded since the
+// last step, from the events published about them, with short-lived lights
+// everyone nearby sees flash off the walls
+func (gs *GameServer) spawnFlashes() {
+	gs.EntitiesMutex.Lock()
+	defer gs.EntitiesMutex.Unlock()
+
+	for {
+		select {
+		case e := <-gs.flashes.Events():
+			var flash *game.Entity
+			switch e.Kind {
+			case EventFired:
+				flash = game.NewMuzzleFlash(e.Position)
+			case EventExploded:
+				flash = game.NewExplosion(e.Position, e.Radius)
+			default:
+				continue
+			}
+			flash.Floor = e.Floor
+			gs.addEntity(flash)
+		default:
+			return
+		}
+	}
+}
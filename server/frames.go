@@ -0,0 +1,80 @@
+package server
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// FrameStats tracks how long a session's render loop takes to draw and send
+// each frame, for diagnosing slow sessions
+type FrameStats struct {
+	mu      sync.Mutex
+	count   int
+	last    time.Duration
+	average time.Duration // Exponential moving average
+	max     time.Duration
+}
+
+// frameAverageWeight is how much each new frame moves the average frame time
+const frameAverageWeight = 0.1
+
+// Record adds a frame that took d to draw and send
+func (f *FrameStats) Record(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.count == 0 {
+		f.average = d
+	} else {
+		f.average += time.Duration(frameAverageWeight * float64(d-f.average))
+	}
+	f.count++
+	f.last = d
+	f.max = max(f.max, d)
+}
+
+// FrameSummary is a session's frame timings, in milliseconds
+type FrameSummary struct {
+	Session   string  `json:"session"`
+	Name      string  `json:"name"`
+	Frames    int     `json:"frames"`
+	LastMs    float64 `json:"last_ms"`
+	AverageMs float64 `json:"average_ms"`
+	MaxMs     float64 `json:"max_ms"`
+}
+
+// Summary returns the frame timings so far
+func (f *FrameStats) Summary() FrameSummary {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return FrameSummary{
+		Frames:    f.count,
+		LastMs:    milliseconds(f.last),
+		AverageMs: milliseconds(f.average),
+		MaxMs:     milliseconds(f.max),
+	}
+}
+
+// milliseconds converts a duration to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// FrameTimes returns every connected player's frame timings, slowest first
+func (gs *GameServer) FrameTimes() []FrameSummary {
+	gs.PlayersMutex.RLock()
+	defer gs.PlayersMutex.RUnlock()
+
+	summaries := make([]FrameSummary, 0, len(gs.Players))
+	for _, session := range gs.Players {
+		summary := session.Frames.Summary()
+		summary.Session = session.ID[:min(8, len(session.ID))]
+		summary.Name = session.Name
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].AverageMs > summaries[j].AverageMs
+	})
+	return summaries
+}
@@ -0,0 +1,225 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/imjasonh/terminus/game"
+)
+
+// Ghost limits
+const (
+	replaySampleInterval = 0.1 // Seconds between the positions a recording keeps
+	MaxReplayLength      = 600 // Seconds a recording may run before it stops on its own
+	MaxGhosts            = 8   // Ghosts in the world at once
+	replayExt            = ".json"
+)
+
+// replayNames are the names replays may be saved under, which are also their
+// file names
+var replayNames = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,32}$`)
+
+// ghostRecording is a player's run being recorded, guarded by the server's
+// PlayersMutex
+type ghostRecording struct {
+	replay      game.Replay
+	sinceSample float64 // Seconds since the last frame
+	full        bool    // Whether it's reached MaxReplayLength
+}
+
+// StartRecording starts recording the player's run through the map, for a
+// ghost to retrace
+func (gs *GameServer) StartRecording(session *PlayerSession) error {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+	if session.recording != nil {
+		return errors.New("you're already recording; /ghost stop first")
+	}
+	p := session.Player
+	session.recording = &ghostRecording{replay: game.Replay{
+		Name:     session.Name,
+		Player:   session.Name,
+		Map:      gs.MapName,
+		Color:    p.Color,
+		Glyph:    p.Glyph,
+		Recorded: time.Now(),
+		Frames:   []game.ReplayFrame{{Position: p.Position}},
+	}}
+	session.Log.Info("Recording a ghost")
+	return nil
+}
+
+// StopRecording stops recording the player's run and returns it, also
+// keeping it as their latest replay for /ghost play
+func (gs *GameServer) StopRecording(session *PlayerSession) (*game.Replay, error) {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+	rec := session.recording
+	if rec == nil {
+		return nil, errors.New("you're not recording; /ghost record to start")
+	}
+	session.recording = nil
+	replay := rec.replay
+	if !rec.full {
+		replay.Frames = append(replay.Frames, game.ReplayFrame{T: replay.Duration() + rec.sinceSample, Position: session.Player.Position})
+	}
+	session.lastReplay = &replay
+	return &replay, nil
+}
+
+// LastReplay returns the player's latest recording, if they've made one
+func (gs *GameServer) LastReplay(session *PlayerSession) (*game.Replay, bool) {
+	gs.PlayersMutex.RLock()
+	defer gs.PlayersMutex.RUnlock()
+	return session.lastReplay, session.lastReplay != nil
+}
+
+// recordGhost adds the player's position to their recording, if they're
+// making one, every replaySampleInterval, until it reaches MaxReplayLength.
+// The simulation calls it for each player after moving them.
+func (gs *GameServer) recordGhost(session *PlayerSession, deltaTime float64) {
+	rec := session.recording
+	if rec == nil || rec.full {
+		return
+	}
+	rec.sinceSample += deltaTime
+	if rec.sinceSample < replaySampleInterval {
+		return
+	}
+	t := rec.replay.Duration() + rec.sinceSample
+	rec.sinceSample = 0
+	rec.replay.Frames = append(rec.replay.Frames, game.ReplayFrame{T: t, Position: session.Player.Position})
+	if t >= MaxReplayLength {
+		rec.full = true
+		session.Notify(session.T("Your recording reached its limit; /ghost stop to keep it"))
+	}
+}
+
+// PlayGhost adds a ghost retracing a replay from its start, looping if asked,
+// if the replay was recorded on the current map
+func (gs *GameServer) PlayGhost(replay *game.Replay, loop bool) error {
+	gs.PlayersMutex.RLock()
+	mapName := gs.MapName
+	gs.PlayersMutex.RUnlock()
+	if replay.Map != mapName {
+		return fmt.Errorf("%s was recorded on %s, not %s", replay.Name, replay.Map, mapName)
+	}
+	if len(replay.Frames) < 2 {
+		return fmt.Errorf("%s is empty", replay.Name)
+	}
+
+	gs.EntitiesMutex.Lock()
+	defer gs.EntitiesMutex.Unlock()
+	ghosts := 0
+	for _, e := range gs.Entities {
+		if e.Kind == game.KindGhost && !e.Removed {
+			ghosts++
+		}
+	}
+	if ghosts >= MaxGhosts {
+		return fmt.Errorf("there are already %d ghosts; /ghost clear first", MaxGhosts)
+	}
+	gs.addEntity(game.NewGhost(replay, loop))
+	return nil
+}
+
+// ClearGhosts removes every ghost from the world, returning how many there
+// were
+func (gs *GameServer) ClearGhosts() int {
+	gs.EntitiesMutex.Lock()
+	defer gs.EntitiesMutex.Unlock()
+	n := 0
+	for _, e := range gs.Entities {
+		if e.Kind == game.KindGhost && !e.Removed {
+			e.Removed = true
+			n++
+		}
+	}
+	return n
+}
+
+// ReplayStore keeps saved replays as JSON files in a directory
+type ReplayStore struct {
+	dir string
+}
+
+// NewReplayStore keeps replays in a directory, which is created when the
+// first one is saved
+func NewReplayStore(dir string) *ReplayStore {
+	return &ReplayStore{dir: dir}
+}
+
+// Save saves a replay under its name, replacing any saved before
+func (s *ReplayStore) Save(r *game.Replay) error {
+	if !replayNames.MatchString(r.Name) {
+		return fmt.Errorf("replay names are up to 32 letters, digits, dots, dashes, and underscores")
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create replay directory %s: %w", s.dir, err)
+	}
+	tmp, err := os.CreateTemp(s.dir, r.Name+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save replay %s: %w", r.Name, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save replay %s: %w", r.Name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save replay %s: %w", r.Name, err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(s.dir, r.Name+replayExt)); err != nil {
+		return fmt.Errorf("failed to save replay %s: %w", r.Name, err)
+	}
+	return nil
+}
+
+// Load reads a saved replay by name
+func (s *ReplayStore) Load(name string) (*game.Replay, error) {
+	if !replayNames.MatchString(name) {
+		return nil, fmt.Errorf("no replay %q", name)
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, name+replayExt))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no replay %q", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var r game.Replay
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("bad replay %s: %w", name, err)
+	}
+	return &r, nil
+}
+
+// List returns the names of the saved replays, in order
+func (s *ReplayStore) List() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), replayExt); ok && !e.IsDir() && replayNames.MatchString(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,50 @@
+package server
+
+import "github.com/imjasonh/terminus/game"
+
+// gridCellSize is the width of a spatial grid cell in map cells. Queries
+// usually cover a few cells around a point, like a projectile's reach or a
+// player's surroundings.
+const gridCellSize = 2.0
+
+// indexEntities rebuilds the spatial grid from the latest simulation step's
+// players, entities, and projectiles
+func (gs *GameServer) indexEntities() {
+	gs.gridMutex.Lock()
+	defer gs.gridMutex.Unlock()
+
+	if gs.grid == nil || gs.gridMap != gs.Map {
+		gs.grid = game.NewSpatialGrid(gs.Map.Width, gs.Map.Height, gridCellSize)
+		gs.gridMap = gs.Map
+	}
+	gs.grid.Clear()
+
+	gs.PlayersMutex.RLock()
+	for _, session := range gs.Players {
+		gs.grid.Insert(session.Player.Entity())
+	}
+	gs.PlayersMutex.RUnlock()
+
+	gs.EntitiesMutex.RLock()
+	for _, e := range gs.Entities {
+		gs.grid.Insert(e)
+	}
+	gs.EntitiesMutex.RUnlock()
+
+	for _, p := range gs.ProjectileManager.Snapshot() {
+		gs.grid.Insert(p.Entity())
+	}
+}
+
+// EntitiesNear returns the players, entities, and projectiles within radius
+// of pos as of the latest simulation step. It only looks at grid cells around
+// pos, so its cost depends on how crowded the area is rather than the world.
+func (gs *GameServer) EntitiesNear(pos game.Vector, radius float64) []*game.Entity {
+	gs.gridMutex.RLock()
+	defer gs.gridMutex.RUnlock()
+
+	if gs.grid == nil {
+		return nil
+	}
+	return gs.grid.Query(nil, pos, radius)
+}
@@ -0,0 +1,66 @@
+package server
+
+import "github.com/imjasonh/terminus/game"
+
+// ApplyEffect gives the session's player a status effect, like a powerup or
+// a spell, stacked with those they have by the effect kind's rules
+func (gs *GameServer) ApplyEffect(session *PlayerSession, e game.StatusEffect) {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+	session.Player.ApplyEffect(e)
+	session.Log.Debugf("Status effect %s from %s for %.0fs", game.GetEffectType(e.Kind).Name, e.Source, e.Remaining)
+}
+
+// HealPlayer restores a player's health to full
+func (gs *GameServer) HealPlayer(session *PlayerSession) {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+	session.Player.Heal(game.PlayerMaxHealth)
+}
+
+// RestoreStamina refills a player's stamina
+func (gs *GameServer) RestoreStamina(session *PlayerSession) {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+	session.Player.RestoreStamina()
+}
+
+// damagePlayer takes health from a player, killing them if it runs out, and
+// returns whether it did. attackerID is the session ID of the player who did
+// it, if any. Callers hold PlayersMutex for writing.
+func (gs *GameServer) damagePlayer(victim *PlayerSession, amount float64, attackerID string) bool {
+	victim.Player.Health -= amount
+	if victim.Player.Health > 0 {
+		return false
+	}
+
+	killer := ""
+	if attacker, ok := gs.Players[attackerID]; ok && attacker != victim {
+		killer = attacker.Name
+	}
+	gs.killPlayer(victim, killer)
+	return true
+}
+
+// killPlayer respawns a player who ran out of health, near their party's
+// leader if they're in a party, and tells everyone who killed them. Callers
+// hold PlayersMutex for writing.
+func (gs *GameServer) killPlayer(victim *PlayerSession, killer string) {
+	fell := victim.Player.Position
+	x, y := gs.findPlayerSpawnPoint()
+	if victim.Party != nil && victim.Party.Leader() != victim {
+		leader := victim.Party.Leader().Player.Position
+		x, y = gs.findSpawnPointNear(leader.X, leader.Y)
+	}
+	victim.Player.Respawn(x, y)
+
+	if killer == "" {
+		victim.Log.Info("Died")
+		victim.Notify(victim.T("You died"))
+	} else {
+		victim.Log.Infof("Killed by %s", killer)
+		victim.Notify(victim.T("You were killed by %s", killer))
+	}
+	gs.scoreKill(victim.Name, killer)
+	gs.publish(Event{Kind: EventKilled, SessionID: victim.ID, Name: victim.Name, Target: killer, Position: fell})
+}
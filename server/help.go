@@ -0,0 +1,159 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/imjasonh/terminus/game"
+)
+
+// Rulebook describes how the game is played on the server right now, for the
+// in-game help: scoring, dying, teams, parties, and any custom rules, from
+// the server's settings and the player's place in it
+func (gs *GameServer) Rulebook(session *PlayerSession) []string {
+	lines := []string{
+		"Free-for-all: every player you take down scores you a kill. A match lasts until the map changes or everyone leaves",
+		"When you die you respawn at full health with full magazines and a fresh torch, keeping your inventory but losing any powerups",
+	}
+	if gs.Leaderboard != nil {
+		var titles []string
+		for _, record := range matchRecords {
+			titles = append(titles, record.title)
+		}
+		lines = append(lines, "Server records are kept for the "+strings.Join(titles, " and ")+"; /top shows them")
+	}
+
+	gs.PlayersMutex.RLock()
+	teams := map[string]int{}
+	var teamNames []string
+	for _, other := range gs.Players {
+		if other.Team == "" {
+			continue
+		}
+		if teams[other.Team] == 0 {
+			teamNames = append(teamNames, other.Team)
+		}
+		teams[other.Team]++
+	}
+	team, party := session.Team, session.Party
+	var leader string
+	if party != nil {
+		leader = party.Leader().Name
+	}
+	gs.PlayersMutex.RUnlock()
+
+	switch {
+	case team != "":
+		lines = append(lines, fmt.Sprintf("You're on team %s (%d playing); your team's mines don't hurt you, and /team chats with them", team, teams[team]))
+	case len(teamNames) > 0:
+		lines = append(lines, "Teams: "+strings.Join(teamNames, ", ")+"; /jointeam to join one, and teammates' mines won't hurt you")
+	default:
+		lines = append(lines, "/jointeam to play on a team: teammates' mines don't hurt each other")
+	}
+	if party != nil {
+		lines = append(lines, fmt.Sprintf("You're in %s's party: members respawn near the leader", leader))
+	} else {
+		lines = append(lines, "/invite players to a party to respawn near its leader and see where they are")
+	}
+	if gs.SessionCap > 0 {
+		lines = append(lines, fmt.Sprintf("While others wait to play, anyone on for over %s may be rotated out", gs.SessionCap))
+	}
+	if gs.Practicing() {
+		npcs := "moving"
+		if gs.NPCsFrozen() {
+			npcs = "frozen"
+		}
+		lines = append(lines, fmt.Sprintf("Practice: the game runs at %gx with NPCs %s (/speed, /freeze), and nothing here counts toward your stats", gs.TimeScale(), npcs))
+	}
+
+	custom := 0
+	for _, rules := range gs.Rules {
+		if described, ok := rules.(DescribedRules); ok && described.Describe() != "" {
+			lines = append(lines, described.Describe())
+		} else {
+			custom++
+		}
+	}
+	if custom > 0 {
+		lines = append(lines, "The server also runs custom rules that may change any of this")
+	}
+	return lines
+}
+
+// Objectives describes the current map for the in-game help: its own
+// description, and what's in it to find, open, and go through
+func (gs *GameServer) Objectives() []string {
+	gs.PlayersMutex.RLock()
+	m, name := gs.Map, gs.MapName
+	gs.PlayersMutex.RUnlock()
+
+	title := "Map: " + name
+	if name == "" {
+		title = "Map: the built-in maze"
+	}
+	lines := []string{fmt.Sprintf("%s, %dx%d", title, m.Width, m.Height)}
+	if m.Comment != "" {
+		lines = append(lines, strings.Split(m.Comment, "\n")...)
+	}
+
+	if npcs := gs.CountEntities(game.KindNPC); npcs > 0 {
+		lines = append(lines, fmt.Sprintf("%d NPCs wander the map as targets", npcs))
+	}
+	if len(m.Pickups) > 0 {
+		counts := map[string]int{}
+		var nouns []string
+		for _, spawn := range m.Pickups {
+			noun := game.GetPickupType(spawn.Kind).Noun
+			if counts[noun] == 0 {
+				nouns = append(nouns, noun)
+			}
+			counts[noun]++
+		}
+		for i, noun := range nouns {
+			if counts[noun] > 1 {
+				nouns[i] = fmt.Sprintf("%s x%d", noun, counts[noun])
+			}
+		}
+		lines = append(lines, "Pickups, which come back a while after they're taken: "+strings.Join(nouns, ", "))
+	}
+
+	doors, cracked := 0, 0
+	for _, floor := range append([]*game.Map{m}, m.Floors...) {
+		for _, row := range floor.Grid {
+			for _, cell := range row {
+				switch cell {
+				case game.LockedDoor:
+					doors++
+				case game.BreakableWall:
+					cracked++
+				}
+			}
+		}
+	}
+	if doors > 0 {
+		key := game.GetItem(game.ItemKey)
+		lines = append(lines, fmt.Sprintf("%d locked doors: find a key, face a door, and press F%d to open it", doors, 4+key.Slot))
+	}
+	if cracked > 0 {
+		lines = append(lines, fmt.Sprintf("%d cracked walls: shoot or blast them to break through", cracked))
+	}
+	if len(m.Floors) > 0 {
+		lines = append(lines, fmt.Sprintf("%d floors: step onto stairs or a ladder to climb or descend; the status line shows which floor you're on", len(m.Floors)+1))
+	}
+	if len(m.Movers) > 0 {
+		lines = append(lines, fmt.Sprintf("%d moving walls slide open and shut: don't get caught in one as it closes", len(m.Movers)))
+	}
+	if len(m.Outdoors) > 0 {
+		lines = append(lines, "Parts of the map are outdoors, under open sky, where it's brighter, even at night")
+	}
+	for _, portal := range m.Portals {
+		lines = append(lines, fmt.Sprintf("A portal at (%.0f, %.0f) leads to %s", portal.Position.X, portal.Position.Y, portal.Shard))
+	}
+	if m.Darkness > 0 {
+		lines = append(lines, fmt.Sprintf("It's dark: you can see about %.0f cells without light, so light your torch or stay near lamps", m.Darkness))
+	}
+	if gs.dayNight() {
+		lines = append(lines, fmt.Sprintf("Day turns to night and back every %s", gs.DayLength))
+	}
+	return lines
+}
@@ -0,0 +1,111 @@
+package server
+
+import "github.com/imjasonh/terminus/game"
+
+// hitRadius is how close a projectile must come to a player or NPC to hit it
+const hitRadius = 0.4
+
+// resolveHits stops projectiles that reach an NPC or a player other than the
+// one who fired them, counting the hits for their shooters and damaging what
+// they hit. Projectiles that explode, including those the projectile manager
+// detonated at walls or when their fuses ran out, damage everything within
+// their blast radius instead; those that bounce only explode on their fuses.
+// Damaging players changes them, so it holds PlayersMutex for writing.
+func (gs *GameServer) resolveHits(detonated []game.Projectile) {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+	gs.EntitiesMutex.Lock()
+	defer gs.EntitiesMutex.Unlock()
+
+	gs.ProjectileManager.Collide(func(p *game.Projectile) bool {
+		if p.BlastRadius > 0 {
+			if !p.Bounces && gs.targetWithin(p, hitRadius) {
+				detonated = append(detonated, *p)
+				return true
+			}
+			return false
+		}
+
+		hit := false
+		for _, e := range gs.Entities {
+			if e.Kind == game.KindNPC && !e.Removed && e.Floor == p.Floor && e.Position.Sub(p.Position).Length() <= hitRadius {
+				if e.Health != nil {
+					e.Health.Current -= p.Damage
+				}
+				hit = true
+				break
+			}
+		}
+		if !hit {
+			for id, session := range gs.Players {
+				if id != p.Owner && session.Player.Floor == p.Floor && session.Player.Position.Sub(p.Position).Length() <= hitRadius {
+					gs.damagePlayer(session, p.Damage, p.Owner)
+					hit = true
+					break
+				}
+			}
+		}
+		if hit {
+			if shooter, ok := gs.Players[p.Owner]; ok {
+				shooter.recordHit(p.Volley)
+			}
+		}
+		return hit
+	})
+
+	for _, p := range detonated {
+		gs.explode(&p)
+	}
+}
+
+// targetWithin reports whether an NPC or a player other than the one who
+// fired the projectile is within a distance of it. Callers hold PlayersMutex
+// and EntitiesMutex.
+func (gs *GameServer) targetWithin(p *game.Projectile, distance float64) bool {
+	for _, e := range gs.Entities {
+		if e.Kind == game.KindNPC && !e.Removed && e.Floor == p.Floor && e.Position.Sub(p.Position).Length() <= distance {
+			return true
+		}
+	}
+	for id, session := range gs.Players {
+		if id != p.Owner && session.Player.Floor == p.Floor && session.Player.Position.Sub(p.Position).Length() <= distance {
+			return true
+		}
+	}
+	return false
+}
+
+// explode damages every NPC and every player within the projectile's blast
+// radius, sparing its thrower unless it HurtsOwner, and knocks those players
+// back, harder the closer they were. It counts a hit for the thrower if the
+// blast reached anyone else, damages the breakable walls it reaches, and
+// publishes it so it lights up its surroundings. Callers hold
+// PlayersMutex and EntitiesMutex.
+func (gs *GameServer) explode(p *game.Projectile) {
+	if gs.targetWithin(p, p.BlastRadius) {
+		if thrower, ok := gs.Players[p.Owner]; ok {
+			thrower.recordHit(p.Volley)
+		}
+	}
+	for _, e := range gs.Entities {
+		if e.Kind == game.KindNPC && !e.Removed && e.Health != nil && e.Floor == p.Floor && e.Position.Sub(p.Position).Length() <= p.BlastRadius {
+			e.Health.Current -= p.Damage
+		}
+	}
+	for id, session := range gs.Players {
+		offset := session.Player.Position.Sub(p.Position)
+		if (id == p.Owner && !p.HurtsOwner) || session.Player.Floor != p.Floor || offset.Length() > p.BlastRadius {
+			continue
+		}
+		if p.Knockback > 0 {
+			away := offset.Normalize()
+			if offset.Length() == 0 {
+				away = p.Direction
+			}
+			session.Player.Knockback(away.Scale(p.Knockback * (1 - offset.Length()/p.BlastRadius)))
+		}
+		gs.damagePlayer(session, p.Damage, p.Owner)
+	}
+	gs.blastWalls(p)
+	gs.publish(Event{Kind: EventExploded, SessionID: p.Owner, Position: p.Position, Floor: p.Floor, Radius: p.BlastRadius})
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/imjasonh/terminus/game"
+)
+
+// doorReach is how far in front of a player a locked door can be to unlock it
+const doorReach = 1.5
+
+// GiveItem adds up to n of an item to the session's inventory, like when they
+// pick one up or an admin gives them one, and returns how many fit
+func (gs *GameServer) GiveItem(session *PlayerSession, t game.ItemType, n int) int {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+	return session.Player.Inventory.Add(t, n)
+}
+
+// UseItem uses one of an item from the session's inventory: a key unlocks
+// the door in front of the player, a potion restores their stamina, a grenade
+// is thrown the way they face, and a mine is placed where they stand. It
+// returns what happened, or an error if the item couldn't be used, which
+// doesn't use it up.
+func (gs *GameServer) UseItem(session *PlayerSession, t game.ItemType) (string, error) {
+	if !session.Allow(ActionItem) {
+		return "", errors.New("you're using items too quickly")
+	}
+	item := game.GetItem(t)
+
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+
+	player := session.Player
+	if player.Inventory.Count(t) == 0 {
+		return "", fmt.Errorf("you have no %ss", strings.ToLower(item.Name))
+	}
+
+	var result string
+	where := player.Position
+	switch t {
+	case game.ItemKey:
+		x, y, ok := gs.doorAhead(player)
+		if !ok {
+			return "", errors.New("there's no locked door in front of you")
+		}
+		gs.Map = gs.Map.WithFloorCell(player.Floor, x, y, 0)
+		where = game.Vector{X: float64(x) + 0.5, Y: float64(y) + 0.5}
+		result = "You unlock the door"
+	case game.ItemPotion:
+		player.RestoreStamina()
+		result = "You drink a potion and feel refreshed"
+	case game.ItemGrenade:
+		grenade := game.NewGrenade(player.Position, player.Direction)
+		grenade.Owner = session.ID
+		grenade.Floor = player.Floor
+		grenade.Damage *= player.DamageMultiplier()
+		if !gs.ProjectileManager.AddProjectiles(MaxProjectiles, grenade) {
+			return "", errors.New("too much is flying already")
+		}
+		session.recordShot(item.Name)
+		result = "You throw a grenade"
+	case game.ItemMine:
+		if err := gs.placeMine(session); err != nil {
+			return "", err
+		}
+		session.recordShot(item.Name)
+		result = "You place a mine"
+	}
+	player.Inventory.Take(t)
+	session.Log.Debugf("Used a %s at (%.1f, %.1f)", item.Name, player.Position.X, player.Position.Y)
+	gs.publish(Event{Kind: EventUsedItem, SessionID: session.ID, Name: session.Name, Detail: item.Name, Position: where})
+	return result, nil
+}
+
+// doorAhead finds a locked door within doorReach in front of the player.
+// Callers hold PlayersMutex.
+func (gs *GameServer) doorAhead(player *game.Player) (x, y int, ok bool) {
+	floor := gs.Map.Floor(player.Floor)
+	for d := 0.5; d <= doorReach; d += 0.5 {
+		pos := player.Position.Add(player.Direction.Normalize().Scale(d))
+		x, y = int(pos.X), int(pos.Y)
+		if floor.GetWallType(x, y) == game.LockedDoor {
+			return x, y, true
+		}
+		if floor.IsWall(x, y) {
+			break // Another wall is in the way
+		}
+	}
+	return 0, 0, false
+}
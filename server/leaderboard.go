@@ -0,0 +1,202 @@
+package server
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Leaderboard buckets
+var (
+	matchesBucket = []byte("matches") // Sequence number to MatchResult
+	playersBucket = []byte("players") // Key fingerprint to LeaderboardEntry
+	recordsBucket = []byte("records") // Record name to Record
+)
+
+// MatchResult is one player's result from a session. Until the game has
+// rounds, each session counts as a match.
+type MatchResult struct {
+	Fingerprint string    `json:"fingerprint"`
+	Name        string    `json:"name"`
+	Map         string    `json:"map"`
+	Started     time.Time `json:"started"`
+	Ended       time.Time `json:"ended"`
+	ShotsFired  int       `json:"shots_fired"`
+	Hits        int       `json:"hits"`
+}
+
+// LeaderboardEntry is a player's lifetime totals
+type LeaderboardEntry struct {
+	Name        string    `json:"name"`
+	Matches     int       `json:"matches"`
+	PlaySeconds int64     `json:"play_seconds"`
+	ShotsFired  int       `json:"shots_fired"`
+	Hits        int       `json:"hits"`
+	LastPlayed  time.Time `json:"last_played"`
+}
+
+// LeaderboardStats are the stats the leaderboard can be ranked by
+var LeaderboardStats = map[string]func(LeaderboardEntry) int64{
+	"time":    func(e LeaderboardEntry) int64 { return e.PlaySeconds },
+	"matches": func(e LeaderboardEntry) int64 { return int64(e.Matches) },
+	"shots":   func(e LeaderboardEntry) int64 { return int64(e.ShotsFired) },
+	"hits":    func(e LeaderboardEntry) int64 { return int64(e.Hits) },
+}
+
+// Record is the best score anyone has had in a match, like most kills
+type Record struct {
+	Name  string    `json:"name"`
+	Value int       `json:"value"`
+	Set   time.Time `json:"set"`
+}
+
+// DefaultLeaderboardStat is the stat used when none is given
+const DefaultLeaderboardStat = "time"
+
+// Leaderboard records match results and lifetime totals in an embedded bbolt database
+type Leaderboard struct {
+	db *bolt.DB
+}
+
+// OpenLeaderboard opens or creates the leaderboard database
+func OpenLeaderboard(path string) (*Leaderboard, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open leaderboard %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{matchesBucket, playersBucket, recordsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize leaderboard %s: %w", path, err)
+	}
+	return &Leaderboard{db: db}, nil
+}
+
+// Close closes the database
+func (l *Leaderboard) Close() error {
+	return l.db.Close()
+}
+
+// Record stores a match result and adds it to the player's totals
+func (l *Leaderboard) Record(r MatchResult) error {
+	return l.db.Update(func(tx *bolt.Tx) error {
+		matches := tx.Bucket(matchesBucket)
+		seq, err := matches.NextSequence()
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, seq)
+		if err := matches.Put(key, data); err != nil {
+			return err
+		}
+
+		players := tx.Bucket(playersBucket)
+		var entry LeaderboardEntry
+		if existing := players.Get([]byte(r.Fingerprint)); existing != nil {
+			if err := json.Unmarshal(existing, &entry); err != nil {
+				return err
+			}
+		}
+		entry.Name = r.Name
+		entry.Matches++
+		entry.PlaySeconds += int64(r.Ended.Sub(r.Started).Seconds())
+		entry.ShotsFired += r.ShotsFired
+		entry.Hits += r.Hits
+		entry.LastPlayed = r.Ended
+		data, err = json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		return players.Put([]byte(r.Fingerprint), data)
+	})
+}
+
+// SetRecord saves a score as the named record if it beats the current one,
+// returning whether it did
+func (l *Leaderboard) SetRecord(name string, r Record) (bool, error) {
+	broken := false
+	err := l.db.Update(func(tx *bolt.Tx) error {
+		records := tx.Bucket(recordsBucket)
+		if existing := records.Get([]byte(name)); existing != nil {
+			var current Record
+			if err := json.Unmarshal(existing, &current); err != nil {
+				return err
+			}
+			if r.Value <= current.Value {
+				return nil
+			}
+		}
+		data, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		broken = true
+		return records.Put([]byte(name), data)
+	})
+	return broken, err
+}
+
+// Top returns the n players with the highest value of a stat from LeaderboardStats
+func (l *Leaderboard) Top(stat string, n int) ([]LeaderboardEntry, error) {
+	value, ok := LeaderboardStats[stat]
+	if !ok {
+		return nil, fmt.Errorf("unknown stat %q", stat)
+	}
+
+	var entries []LeaderboardEntry
+	err := l.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(playersBucket).ForEach(func(_, data []byte) error {
+			var entry LeaderboardEntry
+			if err := json.Unmarshal(data, &entry); err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return value(entries[i]) > value(entries[j]) })
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries, nil
+}
+
+// recordMatch adds a departing player's session to the leaderboard, if there is one
+func (gs *GameServer) recordMatch(session *PlayerSession) {
+	if gs.Leaderboard == nil || session.KeyFingerprint == "" {
+		return // Keyless players can't be told apart between sessions
+	}
+	stats := session.SessionStats()
+	err := gs.Leaderboard.Record(MatchResult{
+		Fingerprint: session.KeyFingerprint,
+		Name:        session.Name,
+		Map:         gs.MapName,
+		Started:     session.ConnectedAt,
+		Ended:       stats.LastSeen,
+		ShotsFired:  stats.ShotsFired,
+		Hits:        stats.Hits,
+	})
+	if err != nil {
+		session.Log.Warnf("Failed to record match: %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gliderlabs/ssh"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd socket activation
+const listenFDsStart = 3
+
+// Listen returns listeners passed in by systemd socket activation if there are
+// any, and otherwise listens on each of the comma-separated TCP addresses
+func Listen(addrs string) ([]net.Listener, error) {
+	listeners, err := SystemdListeners()
+	if err != nil || len(listeners) > 0 {
+		return listeners, err
+	}
+
+	for _, addr := range strings.Split(addrs, ",") {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			closeAll(listeners)
+			return nil, err
+		}
+		listeners = append(listeners, l)
+	}
+	if len(listeners) == 0 {
+		return nil, fmt.Errorf("no addresses to listen on")
+	}
+	return listeners, nil
+}
+
+// SystemdListeners returns the sockets passed in by systemd socket activation
+// (see sd_listen_fds(3)), or none if the process wasn't socket activated
+func SystemdListeners() ([]net.Listener, error) {
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	// Don't pass the sockets on to any child processes
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	var listeners []net.Listener
+	for i := range count {
+		fd := listenFDsStart + i
+		name := "LISTEN_FD_" + strconv.Itoa(fd)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		f := os.NewFile(uintptr(fd), name)
+		l, err := net.FileListener(f)
+		f.Close() // FileListener dups the descriptor, close-on-exec
+		if err != nil {
+			closeAll(listeners)
+			return nil, fmt.Errorf("socket %s from systemd isn't a listener: %w", name, err)
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// Serve runs the SSH server on every listener, returning when any of them fails
+func Serve(srv *ssh.Server, listeners []net.Listener) error {
+	errCh := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func() {
+			errCh <- fmt.Errorf("%s: %w", l.Addr(), srv.Serve(l))
+		}()
+	}
+	err := <-errCh
+	srv.Close()
+	return err
+}
+
+// closeAll closes listeners after a failure to open the rest
+func closeAll(listeners []net.Listener) {
+	for _, l := range listeners {
+		l.Close()
+	}
+}
@@ -0,0 +1,231 @@
+package server
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+)
+
+// Match is a stretch of play on one map. One starts when a player joins an
+// empty server, and ends when the last player leaves or the map changes,
+// which starts another for the players still on.
+type Match struct {
+	Map     string        `json:"map"`
+	Started time.Time     `json:"started"`
+	Ended   time.Time     `json:"ended"`
+	Players []MatchPlayer `json:"players"` // Everyone who played, most kills (their score) first
+}
+
+// MatchPlayer is a player's score and weapon stats in a match
+type MatchPlayer struct {
+	Name        string         `json:"name"`
+	Kills       int            `json:"kills"`
+	Deaths      int            `json:"deaths"`
+	BestStreak  int            `json:"best_streak"` // Most kills in a row without dying
+	ShotsFired  int            `json:"shots_fired"`
+	Hits        int            `json:"hits"`
+	WeaponShots map[string]int `json:"weapon_shots,omitempty"` // Shots fired by weapon name
+	Bot         bool           `json:"bot,omitempty"`          // Played by the server's AI
+
+	streak int
+}
+
+// Accuracy returns the fraction of the player's shots in the match that hit something
+func (p MatchPlayer) Accuracy() float64 {
+	if p.ShotsFired == 0 {
+		return 0
+	}
+	return float64(p.Hits) / float64(p.ShotsFired)
+}
+
+// matchRecord is a server record a player can set in a match
+type matchRecord struct {
+	name   string // Its key in the leaderboard
+	title  string // What it's for, like "most kills in a match"
+	format string // Describes a score that sets it, like "12 kills in a match"
+	value  func(MatchPlayer) int
+}
+
+// matchRecords are the records checked when a match ends
+var matchRecords = []matchRecord{
+	{"kills", "most kills in a match", "%d kills in a match", func(p MatchPlayer) int { return p.Kills }},
+	{"streak", "longest kill streak", "a %d-kill streak", func(p MatchPlayer) int { return p.BestStreak }},
+}
+
+// match is the match being played, while anyone's on the server
+type match struct {
+	mapName string
+	started time.Time
+	scores  map[string]*MatchPlayer // By player name
+
+	// Each player's session stats when they joined the match, so what they
+	// did in it can be counted when they leave it
+	joined map[*PlayerSession]Stats
+}
+
+// startMatch starts a match on the current map. Callers hold PlayersMutex.
+func (gs *GameServer) startMatch() {
+	gs.match = &match{
+		mapName: gs.MapName,
+		started: time.Now(),
+		scores:  make(map[string]*MatchPlayer),
+		joined:  make(map[*PlayerSession]Stats),
+	}
+	for _, session := range gs.Players {
+		gs.match.join(session)
+	}
+	gs.publish(Event{Kind: EventMatchStarted, Detail: gs.MapName})
+}
+
+// endMatch ends the match being played, if there is one, and returns its
+// results. Callers hold PlayersMutex, and check its records with
+// recordMatchResults once they've released it.
+func (gs *GameServer) endMatch() *Match {
+	if gs.match == nil {
+		return nil
+	}
+	for session := range gs.match.joined {
+		gs.match.leave(session)
+	}
+	m := &Match{Map: gs.match.mapName, Started: gs.match.started, Ended: time.Now()}
+	for _, p := range gs.match.scores {
+		m.Players = append(m.Players, *p)
+	}
+	gs.match = nil
+	rank(m.Players)
+	gs.publish(Event{Kind: EventMatchEnded, Detail: m.Map, Match: m})
+	return m
+}
+
+// Scores returns the scores so far in the match being played, most kills
+// first, or nil if there isn't one. Shots and hits are only counted as players
+// leave the match, so only kills, deaths, and streaks are up to date.
+func (gs *GameServer) Scores() []MatchPlayer {
+	gs.PlayersMutex.RLock()
+	defer gs.PlayersMutex.RUnlock()
+	if gs.match == nil {
+		return nil
+	}
+	scores := make([]MatchPlayer, 0, len(gs.match.scores))
+	for _, p := range gs.match.scores {
+		p := *p
+		p.WeaponShots = maps.Clone(p.WeaponShots)
+		scores = append(scores, p)
+	}
+	rank(scores)
+	return scores
+}
+
+// rank sorts players by score: most kills, then fewest deaths
+func rank(players []MatchPlayer) {
+	sort.Slice(players, func(i, j int) bool {
+		a, b := players[i], players[j]
+		if a.Kills != b.Kills {
+			return a.Kills > b.Kills
+		}
+		if a.Deaths != b.Deaths {
+			return a.Deaths < b.Deaths
+		}
+		return a.Name < b.Name
+	})
+}
+
+// score returns a player's score in the match, adding them if they're new to it
+func (m *match) score(name string) *MatchPlayer {
+	p, ok := m.scores[name]
+	if !ok {
+		p = &MatchPlayer{Name: name}
+		m.scores[name] = p
+	}
+	return p
+}
+
+// join adds a player to the match
+func (m *match) join(session *PlayerSession) {
+	m.score(session.Name).Bot = session.Bot
+	m.joined[session] = session.SessionStats()
+}
+
+// leave adds what a player did in the match to their score, as they leave it
+func (m *match) leave(session *PlayerSession) {
+	before, ok := m.joined[session]
+	if !ok {
+		return
+	}
+	delete(m.joined, session)
+	now := session.SessionStats()
+	p := m.score(session.Name)
+	p.ShotsFired += now.ShotsFired - before.ShotsFired
+	p.Hits += now.Hits - before.Hits
+	for weapon, n := range now.WeaponShots {
+		if n -= before.WeaponShots[weapon]; n > 0 {
+			if p.WeaponShots == nil {
+				p.WeaponShots = make(map[string]int)
+			}
+			p.WeaponShots[weapon] += n
+		}
+	}
+}
+
+// scoreKill counts a death, and a kill for the killer if there was one.
+// Callers hold PlayersMutex.
+func (gs *GameServer) scoreKill(victim, killer string) {
+	if gs.match == nil {
+		return
+	}
+	v := gs.match.score(victim)
+	v.Deaths++
+	v.streak = 0
+	if killer == "" || killer == victim {
+		return
+	}
+	k := gs.match.score(killer)
+	k.Kills++
+	k.streak++
+	k.BestStreak = max(k.BestStreak, k.streak)
+}
+
+// renameInMatch carries a renamed player's score over to their new name.
+// Callers hold PlayersMutex.
+func (gs *GameServer) renameInMatch(oldName, newName string) {
+	if gs.match == nil {
+		return
+	}
+	if p, ok := gs.match.scores[oldName]; ok {
+		delete(gs.match.scores, oldName)
+		p.Name = newName
+		gs.match.scores[newName] = p
+	}
+}
+
+// recordMatchResults checks whether anyone in a finished match set a server
+// record, saving and announcing any they did. Bots can't set records. It does
+// nothing for a nil match, or without a leaderboard to keep records in.
+func (gs *GameServer) recordMatchResults(m *Match) {
+	if m == nil || gs.Leaderboard == nil {
+		return
+	}
+	for _, record := range matchRecords {
+		best := MatchPlayer{}
+		for _, p := range m.Players {
+			if !p.Bot && record.value(p) > record.value(best) {
+				best = p
+			}
+		}
+		value := record.value(best)
+		if value == 0 {
+			continue
+		}
+		broken, err := gs.Leaderboard.SetRecord(record.name, Record{Name: best.Name, Value: value, Set: m.Ended})
+		if err != nil {
+			clog.Warnf("Failed to check the %s record: %v", record.name, err)
+			continue
+		}
+		if broken {
+			gs.publish(Event{Kind: EventRecordBroken, Name: best.Name, Detail: fmt.Sprintf(record.format, value), Target: record.name})
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package server
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// durationBuckets are histogram bucket boundaries, in milliseconds, for work
+// done many times a second, like simulation steps and frames
+var durationBuckets = metric.WithExplicitBucketBoundaries(0.1, 0.25, 0.5, 1, 2.5, 5, 10, 25, 50, 100, 250)
+
+// stepDuration measures how long simulation steps take, including
+// publishing their snapshot. The global meter provider sends it nowhere
+// unless the server exports telemetry.
+var stepDuration, _ = otel.Meter("github.com/imjasonh/terminus/server").Float64Histogram("terminus.tick.duration",
+	metric.WithDescription("Time to run a simulation step and publish its snapshot"),
+	metric.WithUnit("ms"),
+	durationBuckets)
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/imjasonh/terminus/game"
+)
+
+// placeMine puts a mine where the session's player stands, unless there's
+// one in that cell already or they have game.MaxMines placed. Callers hold
+// PlayersMutex.
+func (gs *GameServer) placeMine(session *PlayerSession) error {
+	gs.EntitiesMutex.Lock()
+	defer gs.EntitiesMutex.Unlock()
+
+	mine := game.NewMine(session.Player.Position, session.ID)
+	mine.Floor = session.Player.Floor
+	placed := 0
+	for _, e := range gs.Entities {
+		if e.Mine == nil || e.Removed {
+			continue
+		}
+		if e.Position == mine.Position && e.Floor == mine.Floor {
+			return errors.New("there's already a mine here")
+		}
+		if e.Mine.Owner == session.ID {
+			placed++
+		}
+	}
+	if placed >= game.MaxMines {
+		return fmt.Errorf("you already have %d mines placed", placed)
+	}
+	gs.addEntity(mine)
+	return nil
+}
+
+// triggerMines explodes each armed mine that an enemy of the player who
+// placed it has come near. Blasts damage players, so it holds PlayersMutex
+// for writing.
+func (gs *GameServer) triggerMines() {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+	gs.EntitiesMutex.Lock()
+	defer gs.EntitiesMutex.Unlock()
+
+	for _, e := range gs.Entities {
+		if e.Mine == nil || e.Removed || !e.Mine.Armed() || !gs.enemyWithin(e.Mine.Owner, e.Floor, e.Position, game.MineRange) {
+			continue
+		}
+		e.Removed = true
+		blast := e.Mine.Blast(e)
+		gs.explode(&blast)
+	}
+}
+
+// enemyWithin reports whether an NPC, or a player who isn't the given one or
+// on their team, is within a distance of a position on a floor. Callers hold
+// PlayersMutex and EntitiesMutex.
+func (gs *GameServer) enemyWithin(sessionID string, floor int, pos game.Vector, distance float64) bool {
+	for _, e := range gs.Entities {
+		if e.Kind == game.KindNPC && !e.Removed && e.Floor == floor && e.Position.Sub(pos).Length() <= distance {
+			return true
+		}
+	}
+	owner := gs.Players[sessionID]
+	for id, session := range gs.Players {
+		if id == sessionID || (owner != nil && owner.Team != "" && session.Team == owner.Team) {
+			continue
+		}
+		if session.Player.Floor == floor && session.Player.Position.Sub(pos).Length() <= distance {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/imjasonh/terminus/game"
+)
+
+// moveWalls slides the map's moving walls toward open or shut, swapping in a
+// copy of the map when any moved, then crushes whoever a closing wall caught
+func (gs *GameServer) moveWalls(deltaTime float64) {
+	if len(gs.Map.Movers) == 0 {
+		return
+	}
+	worldTime := gs.WorldTime()
+
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+	var movers []game.Mover
+	for i, mover := range gs.Map.Movers {
+		mover.Cycle(worldTime)
+		if !mover.Step(deltaTime) {
+			continue
+		}
+		if movers == nil {
+			movers = slices.Clone(gs.Map.Movers)
+		}
+		movers[i] = mover
+	}
+	if movers != nil {
+		gs.Map = gs.Map.WithMovers(movers)
+	}
+	gs.crush(deltaTime)
+}
+
+// crush hurts players standing where a moving wall is closing, and kills
+// those still there once it's too far shut to get out of. Moving walls are
+// on the ground floor. Callers hold PlayersMutex.
+func (gs *GameServer) crush(deltaTime float64) {
+	for _, session := range gs.Players {
+		pos := session.Player.Position
+		i, ok := gs.Map.MoverAt(int(pos.X), int(pos.Y))
+		if !ok || session.Player.Floor != 0 {
+			continue
+		}
+		mover := gs.Map.Movers[i]
+		switch {
+		case mover.Opening || mover.Slide == 1:
+			continue
+		case !mover.Passable():
+			session.Log.Info("Crushed by a moving wall")
+			gs.killPlayer(session, "")
+		default:
+			gs.damagePlayer(session, game.CrushDamage*deltaTime, "")
+		}
+	}
+}
+
+// TriggerMovers opens or shuts the moving walls a map gives a trigger name,
+// like a script opening a gate. It's an error if there are none.
+func (gs *GameServer) TriggerMovers(name string, open bool) error {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+
+	movers := slices.Clone(gs.Map.Movers)
+	found := false
+	for i := range movers {
+		if movers[i].Trigger == name {
+			movers[i].Opening = open
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("no moving walls are triggered by %q", name)
+	}
+	gs.Map = gs.Map.WithMovers(movers)
+	return nil
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+)
+
+// MaxPartySize is the most players a party may have
+const MaxPartySize = 4
+
+// partySpawnRadius is how far from their leader party members spawn, in map cells
+const partySpawnRadius = 3
+
+// Party is a group of players who spawn near each other, see each other on
+// the HUD, and are always on the same team. Its fields are guarded by the
+// server's PlayersMutex.
+type Party struct {
+	Members []*PlayerSession // In the order they joined; the first leads
+}
+
+// Leader returns the player who leads the party
+func (p *Party) Leader() *PlayerSession {
+	return p.Members[0]
+}
+
+// InviteToParty invites another player to join the session's party, or to
+// start one with the session if it isn't in one. The invite replaces any
+// the other player had.
+func (gs *GameServer) InviteToParty(session *PlayerSession, nameOrID string) error {
+	target, ok := gs.FindPlayer(nameOrID)
+	if !ok {
+		return fmt.Errorf("no player named %s", nameOrID)
+	}
+	if target == session {
+		return errors.New("you can't invite yourself")
+	}
+
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+
+	if session.Party != nil && target.Party == session.Party {
+		return fmt.Errorf("%s is already in your party", target.Name)
+	}
+	if session.Party != nil && len(session.Party.Members) >= MaxPartySize {
+		return fmt.Errorf("parties are limited to %d players", MaxPartySize)
+	}
+	target.partyInvite = session
+	session.Log.Infof("Invited %s to their party", target.Name)
+	target.Notify(target.T("%s invited you to their party; type /accept to join", session.Name))
+	return nil
+}
+
+// AcceptPartyInvite joins the party of the player who last invited the
+// session, leaving any party it was in, and moves it near the party's leader.
+// It returns the leader's name.
+func (gs *GameServer) AcceptPartyInvite(session *PlayerSession) (string, error) {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+
+	inviter := session.partyInvite
+	session.partyInvite = nil
+	if inviter == nil || !inviter.Connected {
+		return "", errors.New("you don't have a party invite")
+	}
+	party := inviter.Party
+	if party == nil {
+		party = &Party{Members: []*PlayerSession{inviter}}
+		inviter.Party = party
+	}
+	if party == session.Party {
+		return "", errors.New("you're already in that party")
+	}
+	if len(party.Members) >= MaxPartySize {
+		return "", fmt.Errorf("that party is full (%d players)", MaxPartySize)
+	}
+
+	gs.leaveParty(session)
+	party.Members = append(party.Members, session)
+	session.Party = party
+	session.Team = party.Leader().Team
+	session.Log.Infof("Joined %s's party", party.Leader().Name)
+	for _, member := range party.Members {
+		if member != session {
+			member.Notify(member.T("%s joined your party", session.Name))
+		}
+	}
+
+	leader := party.Leader().Player.Position
+	session.Player.Position.X, session.Player.Position.Y = gs.findSpawnPointNear(leader.X, leader.Y)
+	return party.Leader().Name, nil
+}
+
+// LeaveParty takes the session out of its party, if it's in one. A party left
+// with one player ends.
+func (gs *GameServer) LeaveParty(session *PlayerSession) error {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+
+	if session.Party == nil {
+		return errors.New("you're not in a party")
+	}
+	gs.leaveParty(session)
+	return nil
+}
+
+// leaveParty takes the session out of its party, if any. Callers hold PlayersMutex.
+func (gs *GameServer) leaveParty(session *PlayerSession) {
+	party := session.Party
+	if party == nil {
+		return
+	}
+	session.Party = nil
+	party.Members = slices.DeleteFunc(party.Members, func(m *PlayerSession) bool { return m == session })
+	session.Log.Info("Left their party")
+	for _, member := range party.Members {
+		member.Notify(member.T("%s left your party", session.Name))
+	}
+	if len(party.Members) == 1 {
+		party.Members[0].Party = nil
+	}
+}
+
+// PartyMembers returns the other players in the session's party, in the order
+// they joined, or nil if it isn't in one
+func (gs *GameServer) PartyMembers(session *PlayerSession) []*PlayerSession {
+	gs.PlayersMutex.RLock()
+	defer gs.PlayersMutex.RUnlock()
+
+	if session.Party == nil {
+		return nil
+	}
+	var members []*PlayerSession
+	for _, member := range session.Party.Members {
+		if member != session {
+			members = append(members, member)
+		}
+	}
+	return members
+}
+
+// spawnParties moves party members near their leaders, e.g. after everyone
+// respawns. Callers hold PlayersMutex.
+func (gs *GameServer) spawnParties() {
+	for _, session := range gs.Players {
+		if session.Party == nil || session.Party.Leader() == session {
+			continue
+		}
+		leader := session.Party.Leader().Player.Position
+		session.Player.Position.X, session.Player.Position.Y = gs.findSpawnPointNear(leader.X, leader.Y)
+	}
+}
+
+// findSpawnPointNear finds a random empty location within partySpawnRadius
+// cells of a point, or anywhere if there are none
+func (gs *GameServer) findSpawnPointNear(x, y float64) (float64, float64) {
+	var nearby [][2]int
+	cx, cy := int(x), int(y)
+	for dy := -partySpawnRadius; dy <= partySpawnRadius; dy++ {
+		for dx := -partySpawnRadius; dx <= partySpawnRadius; dx++ {
+			if (dx == 0 && dy == 0) || math.Hypot(float64(dx), float64(dy)) > partySpawnRadius {
+				continue
+			}
+			if !gs.Map.IsWall(cx+dx, cy+dy) {
+				nearby = append(nearby, [2]int{cx + dx, cy + dy})
+			}
+		}
+	}
+	if len(nearby) == 0 {
+		return gs.findPlayerSpawnPoint()
+	}
+
+	// Add some randomness within the cell, as for random spawns
+	chosen := nearby[rand.Intn(len(nearby))]
+	return float64(chosen[0]) + 0.2 + rand.Float64()*0.6, float64(chosen[1]) + 0.2 + rand.Float64()*0.6
+}
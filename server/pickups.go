@@ -0,0 +1,57 @@
+package server
+
+import "github.com/imjasonh/terminus/game"
+
+// pickupRadius is how close a player must come to a pickup to take it
+const pickupRadius = 0.5
+
+// spawnPickups places the map's pickups in the world
+func (gs *GameServer) spawnPickups() {
+	gs.EntitiesMutex.Lock()
+	defer gs.EntitiesMutex.Unlock()
+
+	for _, spawn := range gs.Map.Pickups {
+		gs.addEntity(game.NewPickup(spawn))
+	}
+}
+
+// spawnLights places the map's animated lights in the world, each animating
+// a little out of step with the others. Its steady lights are already baked
+// into its Lightmap.
+func (gs *GameServer) spawnLights() {
+	gs.EntitiesMutex.Lock()
+	defer gs.EntitiesMutex.Unlock()
+
+	for i, spawn := range gs.Map.Lights {
+		if spawn.Animation != game.LightSteady {
+			gs.addEntity(game.NewLamp(spawn, float64(i)*0.37))
+		}
+	}
+}
+
+// collectPickups gives each pickup that's there to take to the first player
+// touching it who has a use for it, then starts its respawn timer. Pickups
+// change players, so it holds PlayersMutex for writing.
+func (gs *GameServer) collectPickups() {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+	gs.EntitiesMutex.Lock()
+	defer gs.EntitiesMutex.Unlock()
+
+	for _, e := range gs.Entities {
+		if e.Pickup == nil || !e.Pickup.Ready() {
+			continue
+		}
+		t := game.GetPickupType(e.Pickup.Kind)
+		for _, session := range gs.Players {
+			if session.Player.Floor != e.Floor || session.Player.Position.Sub(e.Position).Length() > pickupRadius || !t.Apply(session.Player) {
+				continue
+			}
+			e.Pickup.Take()
+			session.Log.Debugf("Picked up %s at (%.1f, %.1f)", t.Noun, e.Position.X, e.Position.Y)
+			session.Notify(session.T("You picked up %s", session.T(t.Noun)))
+			gs.publishPlayerEvent(EventPickedUp, session, t.Noun)
+			break
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package server
+
+import "github.com/imjasonh/terminus/game"
+
+// Transfer is where a player is going when they leave through a portal to a
+// shard of the world on another server
+type Transfer struct {
+	Shard   string `json:"shard"`
+	Address string `json:"address"`  // Where the shard's server accepts SSH connections, like host:2222
+	HostKey string `json:"host_key"` // That server's SSH host key, in authorized_keys format
+	Token   string `json:"token"`    // Signs the player in there, once
+}
+
+// Traveler is what a player takes with them through a portal: who they are,
+// and what they're carrying
+type Traveler struct {
+	Profile   Profile         `json:"profile"`
+	Health    float64         `json:"health"`
+	Weapon    game.WeaponType `json:"weapon"`
+	Ammo      []int           `json:"ammo"` // By game.WeaponType
+	Inventory game.Inventory  `json:"inventory"`
+	TorchFuel float64         `json:"torch_fuel"`
+}
+
+// spawnPortals places the map's portals in the world, so players can see them
+func (gs *GameServer) spawnPortals() {
+	gs.EntitiesMutex.Lock()
+	defer gs.EntitiesMutex.Unlock()
+
+	for _, portal := range gs.Map.Portals {
+		gs.addEntity(game.NewPortal(portal))
+	}
+}
+
+// checkPortal publishes EventPortal when a player steps into a portal's cell.
+// The simulation calls it for each player after moving them.
+func (gs *GameServer) checkPortal(session *PlayerSession) {
+	pos := session.Player.Position
+	portal, ok := gs.Map.PortalAt(int(pos.X), int(pos.Y))
+	ok = ok && session.Player.Floor == 0 // Portals are on the ground floor
+	if ok && !session.inPortal {
+		gs.publish(Event{Kind: EventPortal, SessionID: session.ID, Name: session.Name, Detail: portal.Shard, Position: portal.Position})
+	}
+	session.inPortal = ok
+}
+
+// Transfer sends the player through a portal to another server: it ends their
+// session here like a kick, telling them where they're going, and whatever
+// runs their connection carries it over. Only the first kick or transfer
+// takes effect.
+func (ps *PlayerSession) Transfer(t Transfer) {
+	ps.kickOnce.Do(func() {
+		ps.Log.Infof("Left through the portal to %s", t.Shard)
+		ps.transfer = &t
+		ps.kickMsg = "Stepping through the portal to " + t.Shard + "..."
+		close(ps.kicked)
+	})
+}
+
+// Transferred returns where the player left for, if they've left through a
+// portal
+func (ps *PlayerSession) Transferred() (Transfer, bool) {
+	select {
+	case <-ps.kicked:
+	default:
+		return Transfer{}, false
+	}
+	if ps.transfer == nil {
+		return Transfer{}, false
+	}
+	return *ps.transfer, true
+}
+
+// Traveler returns what a player would take through a portal
+func (gs *GameServer) Traveler(session *PlayerSession) Traveler {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+	p := session.Player
+	return Traveler{
+		Profile:   profileOf(session),
+		Health:    p.Health,
+		Weapon:    p.Weapon,
+		Ammo:      append([]int(nil), p.Ammo[:]...),
+		Inventory: p.Inventory,
+		TorchFuel: p.TorchFuel,
+	}
+}
+
+// Arrive gives a player who came through a portal from another server what
+// they brought, and puts them where the portal leads: at a position, if it's
+// open, or else at one of the map's spawns
+func (gs *GameServer) Arrive(session *PlayerSession, t Traveler, at *game.Vector) {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+	gs.applyProfile(session, t.Profile)
+	session.UpdateLogger()
+
+	p := session.Player
+	if t.Health > 0 {
+		p.Health = min(t.Health, game.PlayerMaxHealth)
+	}
+	copy(p.Ammo[:], t.Ammo)
+	if t.Weapon >= 0 && int(t.Weapon) < len(p.Ammo) {
+		p.Weapon = t.Weapon
+	}
+	p.Inventory = t.Inventory
+	p.TorchFuel = t.TorchFuel
+	if at != nil && !gs.Map.IsWall(int(at.X), int(at.Y)) {
+		p.Position = *at
+	}
+
+	// Arriving in a portal doesn't send the player straight back through it
+	_, session.inPortal = gs.Map.PortalAt(int(p.Position.X), int(p.Position.Y))
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+
+	"github.com/imjasonh/terminus/game"
+)
+
+// Practice speeds, as multiples of normal
+const (
+	MinTimeScale = 0.25
+	MaxTimeScale = 2
+)
+
+// practice is what a practice instance lets its player change
+type practice struct {
+	timeScale atomic.Uint64 // math.Float64bits of how fast the simulation runs
+	frozen    bool          // Whether NPCs hold still, guarded by the server's EntitiesMutex
+}
+
+// NewPracticeServer creates a private copy of a server's world for one player
+// to learn its map and try out weapons in: the same map, with its own NPCs
+// and pickups, where nobody else plays and the player can slow the
+// simulation down, speed it up, and freeze NPCs. It shares the server's
+// profiles and replays, but not its rules, events, or leaderboard, and
+// nothing done in it counts toward the player's stats.
+func NewPracticeServer(gs *GameServer) *GameServer {
+	gs.PlayersMutex.RLock()
+	worldMap, mapName := gs.Map, gs.MapName
+	gs.PlayersMutex.RUnlock()
+
+	p := NewGameServer(worldMap, 1)
+	p.MapName = mapName
+	p.MapLoader = gs.MapLoader
+	p.Profiles = gs.Profiles
+	p.Replays = gs.Replays
+	p.Bans = gs.Bans
+	p.DayLength = gs.DayLength
+	p.practice = &practice{}
+	p.practice.timeScale.Store(math.Float64bits(1))
+	return p
+}
+
+// Practicing reports whether the server is a practice instance
+func (gs *GameServer) Practicing() bool {
+	return gs.practice != nil
+}
+
+// TimeScale returns how fast the simulation runs, as a multiple of normal
+func (gs *GameServer) TimeScale() float64 {
+	if gs.practice == nil {
+		return 1
+	}
+	return math.Float64frombits(gs.practice.timeScale.Load())
+}
+
+// SetTimeScale changes how fast a practice instance's simulation runs, from
+// MinTimeScale to MaxTimeScale times normal
+func (gs *GameServer) SetTimeScale(scale float64) error {
+	if gs.practice == nil {
+		return fmt.Errorf("the speed can only be changed in practice")
+	}
+	if !(scale >= MinTimeScale && scale <= MaxTimeScale) {
+		return fmt.Errorf("speeds are from %gx to %gx", MinTimeScale, float64(MaxTimeScale))
+	}
+	gs.practice.timeScale.Store(math.Float64bits(scale))
+	return nil
+}
+
+// NPCsFrozen reports whether a practice instance's NPCs are holding still
+func (gs *GameServer) NPCsFrozen() bool {
+	if gs.practice == nil {
+		return false
+	}
+	gs.EntitiesMutex.RLock()
+	defer gs.EntitiesMutex.RUnlock()
+	return gs.practice.frozen
+}
+
+// FreezeNPCs stops a practice instance's NPCs moving, or starts them again,
+// including any that spawn later
+func (gs *GameServer) FreezeNPCs(frozen bool) error {
+	if gs.practice == nil {
+		return fmt.Errorf("NPCs can only be frozen in practice")
+	}
+	gs.EntitiesMutex.Lock()
+	defer gs.EntitiesMutex.Unlock()
+	gs.practice.frozen = frozen
+	for _, e := range gs.Entities {
+		if e.Kind == game.KindNPC {
+			e.Frozen = frozen
+		}
+	}
+	return nil
+}
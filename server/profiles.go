@@ -0,0 +1,174 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/input"
+	"github.com/imjasonh/terminus/screen"
+)
+
+// Profile is what's remembered about a returning player, keyed by their SSH key fingerprint
+type Profile struct {
+	Name              string            `json:"name,omitempty"`
+	Color             string            `json:"color,omitempty"` // A name from game.PlayerColors
+	Glyph             string            `json:"glyph,omitempty"` // A name from game.PlayerGlyphs
+	Keymap            string            `json:"keymap,omitempty"`
+	TurnStrafeSwapped bool              `json:"turn_strafe_swapped,omitempty"`
+	FOV               float64           `json:"fov,omitempty"`
+	AccessMode        AccessMode        `json:"access_mode,omitempty"`
+	ColorLimit        screen.ColorDepth `json:"color_limit,omitempty"`
+	ReducedMotion     bool              `json:"reduced_motion,omitempty"`
+	Theme             string            `json:"theme,omitempty"`
+	Language          string            `json:"language,omitempty"`
+	LargeHUD          bool              `json:"large_hud,omitempty"`
+	FrameRate         int               `json:"frame_rate,omitempty"`
+	Bell              bool              `json:"bell,omitempty"`
+	Stats             Stats             `json:"stats"`
+}
+
+// ProfileStore holds player profiles, saved to a JSON file if it has a path
+type ProfileStore struct {
+	path string
+
+	mu       sync.Mutex
+	profiles map[string]Profile
+}
+
+// NewProfileStore creates an empty profile store that's only kept in memory
+func NewProfileStore() *ProfileStore {
+	return &ProfileStore{profiles: make(map[string]Profile)}
+}
+
+// LoadProfileStore reads profiles from a JSON file. A missing file is an empty store.
+func LoadProfileStore(path string) (*ProfileStore, error) {
+	ps := &ProfileStore{path: path, profiles: make(map[string]Profile)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ps, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &ps.profiles); err != nil {
+		return nil, fmt.Errorf("failed to parse profiles %s: %w", path, err)
+	}
+	return ps, nil
+}
+
+// Get returns the profile for a key fingerprint
+func (ps *ProfileStore) Get(fingerprint string) (Profile, bool) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	p, ok := ps.profiles[fingerprint]
+	return p, ok
+}
+
+// Put stores the profile for a key fingerprint and saves the store
+func (ps *ProfileStore) Put(fingerprint string, p Profile) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	ps.profiles[fingerprint] = p
+	return ps.save()
+}
+
+// save writes the store to its file, replacing the old one atomically. Callers hold mu.
+func (ps *ProfileStore) save() error {
+	if ps.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(ps.profiles, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(ps.path), filepath.Base(ps.path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save profiles: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save profiles: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save profiles: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), ps.path); err != nil {
+		return fmt.Errorf("failed to save profiles: %w", err)
+	}
+	return nil
+}
+
+// applyProfile restores a returning player's name and settings. Callers hold PlayersMutex.
+func (gs *GameServer) applyProfile(session *PlayerSession, p Profile) {
+	if p.Name != "" && !gs.nameTaken(session, p.Name) {
+		session.Name = p.Name
+	}
+	if c, ok := game.PlayerColors[p.Color]; ok {
+		session.Player.Color = c
+		session.Color = p.Color
+	}
+	if g, ok := game.PlayerGlyphs[p.Glyph]; ok {
+		session.Player.Glyph = g
+		session.Glyph = p.Glyph
+	}
+	if keymap, ok := input.Preset(p.Keymap); ok {
+		if p.TurnStrafeSwapped {
+			keymap.SwapTurnStrafe()
+		}
+		session.Keymap = keymap
+	}
+	if p.FOV > 0 {
+		session.Player.SetFOV(p.FOV)
+	}
+	session.AccessMode = p.AccessMode
+	session.ColorLimit = p.ColorLimit
+	session.ReducedMotion = p.ReducedMotion
+	session.Theme = p.Theme
+	session.Language = p.Language
+	session.LargeHUD = p.LargeHUD
+	session.FrameRate = p.FrameRate
+	session.Bell = p.Bell
+	session.stats = p.Stats
+}
+
+// saveProfile records a departing player's name, settings, and stats
+func (gs *GameServer) saveProfile(session *PlayerSession) {
+	if session.KeyFingerprint == "" {
+		return // Nothing to key the profile by
+	}
+	profile := profileOf(session)
+	if gs.practice != nil {
+		profile.Stats = session.stats // Practice doesn't count
+	}
+	if err := gs.Profiles.Put(session.KeyFingerprint, profile); err != nil {
+		session.Log.Warnf("Failed to save profile: %v", err)
+	}
+}
+
+// profileOf returns what's remembered about a player: their name, settings,
+// and stats
+func profileOf(session *PlayerSession) Profile {
+	return Profile{
+		Name:              session.Name,
+		Color:             session.Color,
+		Glyph:             session.Glyph,
+		Keymap:            session.Keymap.Name,
+		TurnStrafeSwapped: session.Keymap.TurnStrafeSwapped,
+		FOV:               session.Player.FOV(),
+		AccessMode:        session.AccessMode,
+		ColorLimit:        session.ColorLimit,
+		ReducedMotion:     session.ReducedMotion,
+		Theme:             session.Theme,
+		Language:          session.Language,
+		LargeHUD:          session.LargeHUD,
+		FrameRate:         session.FrameRate,
+		Bell:              session.Bell,
+		Stats:             session.Stats(),
+	}
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// ErrServerFull is returned by AddPlayer when there's no free slot for the session
+var ErrServerFull = errors.New("server full")
+
+// MaxQueue caps how many connections may wait for a slot
+const MaxQueue = 50
+
+// Enqueue adds a session to the back of the line for a player slot
+func (gs *GameServer) Enqueue(sessionID string) error {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+
+	if len(gs.queue) >= MaxQueue {
+		return errors.New("server full and the queue is too long, try again later")
+	}
+	gs.queue = append(gs.queue, sessionID)
+	return nil
+}
+
+// Dequeue removes a session from the line, if it's waiting
+func (gs *GameServer) Dequeue(sessionID string) {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+	gs.dequeue(sessionID)
+}
+
+// dequeue removes a session from the line. Callers hold PlayersMutex.
+func (gs *GameServer) dequeue(sessionID string) {
+	if i := slices.Index(gs.queue, sessionID); i >= 0 {
+		gs.queue = slices.Delete(gs.queue, i, i+1)
+	}
+}
+
+// QueuePosition returns a session's place in line, starting at 1, or 0 if it isn't waiting
+func (gs *GameServer) QueuePosition(sessionID string) int {
+	gs.PlayersMutex.RLock()
+	defer gs.PlayersMutex.RUnlock()
+	return slices.Index(gs.queue, sessionID) + 1
+}
+
+// QueueLength returns how many sessions are waiting for a slot
+func (gs *GameServer) QueueLength() int {
+	gs.PlayersMutex.RLock()
+	defer gs.PlayersMutex.RUnlock()
+	return len(gs.queue)
+}
+
+// rotateSessions kicks the longest-connected player past the session cap
+// when someone is waiting for a slot. Admins are never rotated out.
+func (gs *GameServer) rotateSessions(now time.Time) {
+	gs.PlayersMutex.RLock()
+	defer gs.PlayersMutex.RUnlock()
+	if gs.SessionCap <= 0 || len(gs.queue) == 0 || len(gs.Players) < gs.MaxPlayers {
+		return
+	}
+
+	var longest *PlayerSession
+	for _, session := range gs.Players {
+		if session.IsAdmin() || now.Sub(session.ConnectedAt) < gs.SessionCap {
+			continue
+		}
+		if longest == nil || session.ConnectedAt.Before(longest.ConnectedAt) {
+			longest = session
+		}
+	}
+	if longest != nil {
+		longest.Kick("Session time limit reached while others are waiting. Thanks for playing!")
+	}
+}
@@ -0,0 +1,50 @@
+package server
+
+import "github.com/imjasonh/terminus/game"
+
+// fireRailgun fires the session player's charged railgun: a single ray to the
+// first wall in front of them that damages every NPC and other player along
+// it, leaving a beam everyone sees for a moment. It damages other players, so
+// callers hold PlayersMutex for writing, as updatePlayers does.
+func (gs *GameServer) fireRailgun(session *PlayerSession) {
+	gs.EntitiesMutex.Lock()
+	defer gs.EntitiesMutex.Unlock()
+
+	player := session.Player
+	weapon := game.GetWeapon(player.Weapon)
+	from, direction := player.Position, player.Direction.Normalize()
+	length := game.CastRay(from, direction, gs.Map.Floor(player.Floor)).Distance
+	damage := game.RailgunDamage * player.DamageMultiplier()
+	beam := game.NewRailBeam(from, from.Add(direction.Scale(length)))
+	beam.Floor = player.Floor
+	gs.addEntity(beam)
+	player.Fired()
+	session.recordShot(weapon.Name)
+	gs.publishShot(session, weapon.Name, from, player.Floor)
+	session.Log.Debugf("Fired %s from (%.1f, %.1f)", weapon.Name, from.X, from.Y)
+
+	// onBeam reports whether a position is within hitRadius of the ray,
+	// between the player and the wall
+	onBeam := func(pos game.Vector) bool {
+		offset := pos.Sub(from)
+		along := offset.Dot(direction)
+		return along > 0 && along <= length && offset.Sub(direction.Scale(along)).Length() <= hitRadius
+	}
+
+	hit := false
+	for _, e := range gs.Entities {
+		if e.Kind == game.KindNPC && !e.Removed && e.Health != nil && e.Floor == player.Floor && onBeam(e.Position) {
+			e.Health.Current -= damage
+			hit = true
+		}
+	}
+	for id, other := range gs.Players {
+		if id != session.ID && other.Player.Floor == player.Floor && onBeam(other.Player.Position) {
+			gs.damagePlayer(other, damage, session.ID)
+			hit = true
+		}
+	}
+	if hit {
+		session.recordHit(beam.ID)
+	}
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// Action types that are rate limited per session
+const (
+	ActionToggle = "toggle" // Settings toggles like the accessibility mode
+	ActionEmote  = "emote"
+	ActionChat   = "chat"
+	ActionItem   = "item" // Using an inventory item
+)
+
+// RateLimit caps how often an action can happen
+type RateLimit struct {
+	PerSecond float64 // Sustained rate
+	Burst     int     // Actions allowed back-to-back before throttling
+}
+
+// DefaultRateLimits are the per-session limits for each action type
+var DefaultRateLimits = map[string]RateLimit{
+	ActionToggle: {PerSecond: 2, Burst: 1},
+	ActionEmote:  {PerSecond: 0.5, Burst: 2},
+	ActionChat:   {PerSecond: 1, Burst: 3},
+	ActionItem:   {PerSecond: 2, Burst: 2},
+}
+
+// rateLimiter is a token bucket for a single action type
+type rateLimiter struct {
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+}
+
+// allow reports whether the action may happen now, consuming a token if so
+func (r *rateLimiter) allow(now time.Time) bool {
+	if !r.last.IsZero() {
+		r.tokens += now.Sub(r.last).Seconds() * r.limit.PerSecond
+		if r.tokens > float64(r.limit.Burst) {
+			r.tokens = float64(r.limit.Burst)
+		}
+	}
+	r.last = now
+
+	if r.tokens < 1 {
+		return false
+	}
+	r.tokens--
+	return true
+}
+
+// sessionLimiters holds a session's rate limiters, created on first use
+type sessionLimiters struct {
+	mu       sync.Mutex
+	limiters map[string]*rateLimiter
+}
+
+// Allow reports whether the session may perform the action now. Actions
+// without a configured limit are always allowed.
+func (ps *PlayerSession) Allow(action string) bool {
+	limit, ok := DefaultRateLimits[action]
+	if !ok {
+		return true
+	}
+
+	ps.limiters.mu.Lock()
+	defer ps.limiters.mu.Unlock()
+
+	if ps.limiters.limiters == nil {
+		ps.limiters.limiters = make(map[string]*rateLimiter)
+	}
+	limiter, ok := ps.limiters.limiters[action]
+	if !ok {
+		limiter = &rateLimiter{limit: limit, tokens: float64(limit.Burst)}
+		ps.limiters.limiters[action] = limiter
+	}
+	return limiter.allow(time.Now())
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		name  string
+		limit RateLimit
+		at    []time.Duration // When each action is tried, after start
+		want  []bool
+	}{{
+		name:  "burst then throttled",
+		limit: RateLimit{PerSecond: 1, Burst: 3},
+		at:    []time.Duration{0, 0, 0, 0},
+		want:  []bool{true, true, true, false},
+	}, {
+		name:  "refills at the sustained rate",
+		limit: RateLimit{PerSecond: 2, Burst: 1},
+		at:    []time.Duration{0, 100 * time.Millisecond, 500 * time.Millisecond, 600 * time.Millisecond},
+		want:  []bool{true, false, true, false},
+	}, {
+		name:  "refills no further than the burst",
+		limit: RateLimit{PerSecond: 1, Burst: 2},
+		at:    []time.Duration{0, 0, time.Hour, time.Hour, time.Hour},
+		want:  []bool{true, true, true, true, false},
+	}, {
+		name:  "partial tokens add up",
+		limit: RateLimit{PerSecond: 0.5, Burst: 1},
+		at:    []time.Duration{0, time.Second, 2 * time.Second},
+		want:  []bool{true, false, true},
+	}} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &rateLimiter{limit: tt.limit, tokens: float64(tt.limit.Burst)}
+			for i, at := range tt.at {
+				if got := r.allow(start.Add(at)); got != tt.want[i] {
+					t.Errorf("action %d at %s: allowed %t, want %t", i, at, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAllowUnlimitedAction(t *testing.T) {
+	var ps PlayerSession
+	for range 100 {
+		if !ps.Allow("unlimited") {
+			t.Fatal("an action without a limit was throttled")
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/imjasonh/terminus/game"
+)
+
+// Rules customize the game, like a script for a custom mode. The simulation
+// steps them after everything else in each step, holding no locks, so they
+// may call any GameServer method.
+type Rules interface {
+	Step(deltaTime float64)
+}
+
+// DescribedRules are Rules that explain themselves to players, like how to
+// win a custom mode, in the in-game help
+type DescribedRules interface {
+	Rules
+	Describe() string // Empty if there's nothing to tell
+}
+
+// SpawnEntity adds an entity to the world, like an NPC or a pickup
+func (gs *GameServer) SpawnEntity(e *game.Entity) {
+	gs.EntitiesMutex.Lock()
+	defer gs.EntitiesMutex.Unlock()
+	gs.addEntity(e)
+}
+
+// SetCell changes a cell of the map, like building a wall or opening a door.
+// It won't build a wall where a player is standing.
+func (gs *GameServer) SetCell(x, y, value int) error {
+	if value < 0 || value > game.BreakableWall {
+		return fmt.Errorf("cells are 0 for open floor or 1-%d for walls", game.BreakableWall)
+	}
+
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+	m := gs.Map
+	if x <= 0 || x >= m.Width-1 || y <= 0 || y >= m.Height-1 {
+		return fmt.Errorf("(%d, %d) isn't inside the map's outer walls", x, y)
+	}
+	if _, ok := m.MoverAt(x, y); ok {
+		return fmt.Errorf("(%d, %d) is a moving wall", x, y)
+	}
+	if value != 0 {
+		for _, session := range gs.Players {
+			if pos := session.Player.Position; int(pos.X) == x && int(pos.Y) == y {
+				return fmt.Errorf("%s is standing at (%d, %d)", session.Name, x, y)
+			}
+		}
+	}
+	gs.Map = m.WithCell(x, y, value)
+	return nil
+}
+
+// Cell returns a cell of the current map: 0 for open floor, or a wall type,
+// which is 1 outside the map
+func (gs *GameServer) Cell(x, y int) int {
+	gs.PlayersMutex.RLock()
+	defer gs.PlayersMutex.RUnlock()
+	return gs.Map.GetWallType(x, y)
+}
+
+// SteerEntity sets which way a moving entity, like an NPC, heads and how
+// fast, taking it over from its wandering for good
+func (gs *GameServer) SteerEntity(id uint64, direction game.Vector, speed float64) error {
+	if direction.Length() == 0 || speed < 0 {
+		return fmt.Errorf("entities need a direction and a speed of at least 0")
+	}
+
+	gs.EntitiesMutex.Lock()
+	defer gs.EntitiesMutex.Unlock()
+	for _, e := range gs.Entities {
+		if e.ID != id || e.Removed {
+			continue
+		}
+		if e.Velocity == nil {
+			return fmt.Errorf("entity %d doesn't move", id)
+		}
+		e.Velocity.Direction = direction.Normalize()
+		e.Velocity.Speed = speed
+		e.Wander = nil
+		return nil
+	}
+	return fmt.Errorf("no entity %d", id)
+}
@@ -1,31 +1,172 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/chainguard-dev/clog"
 
 	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/input"
+	"github.com/imjasonh/terminus/screen"
 )
 
 // GameServer holds the shared state for all connected players
 type GameServer struct {
 	Map               *game.Map
+	MapName           string
 	ProjectileManager *game.ProjectileManager
 	Players           map[string]*PlayerSession
 	PlayersMutex      sync.RWMutex
-	NPCs              []*game.NPC
-	NPCsMutex         sync.RWMutex
+	Entities          []*game.Entity // NPCs and other world objects
+	EntitiesMutex     sync.RWMutex
 	MaxPlayers        int
+	MaxSpectators     int // Spectators watching without a player slot, not counting admins
+	Bans              *BanList
+	Profiles          *ProfileStore
+	Leaderboard       *Leaderboard // Optional; nil if results aren't recorded
+	Events            *EventBus    // Gameplay events, for anything that wants to react to them
+	Chat              *ChatLog     // Recent chat, for players who join later
+	ChatFilter        ChatFilter   // Optional; checks chat messages before they're sent, e.g. for profanity
+	StartedAt         time.Time
+	SessionCap        time.Duration // Play time after which a player may be rotated out for someone waiting; 0 for no limit
+	DayLength         time.Duration // How long a day and night last on maps with a day/night cycle; 0 for always day
+	Rules             []Rules       // Custom game rules, like scripts and plugins, run in order after each step
+	MapLoader         MapLoader     // Optional; loads maps for /map, like from remote storage, instead of reading files
+	Replays           *ReplayStore  // Optional; where /ghost saves and finds replays
+	WorldFile         string        // Optional; where the map is saved when players change it for good, like by crumbling walls
+
+	queue      []string            // Session IDs waiting for a slot, in arrival order
+	spectators map[string]struct{} // Session IDs watching without a player slot
+	match      *match              // The match being played, if anyone's on
+
+	lastEntityID uint64 // Numbers entities so snapshots can track them, guarded by EntitiesMutex
+
+	clock   worldClock    // The time of day
+	flashes *Subscription // Shots and explosions, which light up their surroundings
+
+	gridMutex sync.RWMutex
+	grid      *game.SpatialGrid // Everything in the world by location, rebuilt each step
+	gridMap   *game.Map         // The map the grid was sized for
+
+	snapshotMutex sync.RWMutex
+	snapshot      *Snapshot     // Renderable state as of the latest step
+	nextSnapshot  chan struct{} // Closed when the next snapshot is published
+	steps         uint64
+
+	practice *practice // What the player may change, if this is a practice instance
+
+	wallDamage map[[3]int]float64 // Damage each breakable wall cell has taken, by x, y, and floor, guarded by PlayersMutex
+	wallHits   []game.WallHit     // Damage explosions did to walls this step, touched only by the simulation
 }
 
+// DefaultMaxSpectators is how many non-admin spectators may watch at once by default
+const DefaultMaxSpectators = 20
+
+// MaxProjectiles caps the number of live projectiles across all players
+const MaxProjectiles = 200
+
+// maxNameLength is the longest display name, in bytes
+const maxNameLength = 16
+
 // PlayerSession represents a connected player's session
 type PlayerSession struct {
-	ID          string
-	Player      *game.Player
-	Connected   bool
-	ConnectedAt time.Time
+	ID             string
+	Name           string
+	KeyFingerprint string // SHA256 fingerprint of the SSH public key used to connect, if any
+	RemoteIP       string
+	Role           Role
+	Player         *game.Player
+	Connected      bool
+	ConnectedAt    time.Time
+	AccessMode     AccessMode
+	ColorLimit     screen.ColorDepth // Fewest colors the player asked to be sent, to save bandwidth; TrueColor for no limit
+	ReducedMotion  bool              // Whether the player asked for calmer lights and effects, being sensitive to motion
+	Theme          string            // Name of the renderer theme the player chose, if any, like "contrast"
+	Language       string            // Tag of the language the player reads, like "es"; empty for English
+	LargeHUD       bool              // Whether the player asked for health and ammo in big digits, for small or distant screens
+	FrameRate      int               // Frames per second the player asked to be drawn at, from FrameRates; 0 for the server's rate
+	Bell           bool              // Whether the player asked for the terminal bell on taking damage, killing someone, and pickups
+	Keymap         input.Keymap
+	Holds          *input.HoldTracker // Keys held down, applied by each simulation step
+	Color          string             // Name of the player's color in game.PlayerColors, if chosen
+	Glyph          string             // Name of the player's glyph in game.PlayerGlyphs, if chosen
+	Team           string             // The team the player chose, if any, guarded by the server's PlayersMutex
+	Party          *Party             // The party the player is in, if any, guarded by the server's PlayersMutex
+	Bot            bool               // Played by the server's own AI, which gives up its slot to people
+	CanTravel      bool               // Whether the player's connection can follow them through a portal to another server
+	Log            *clog.Logger       // Tags log lines with this session
+	Frames         FrameStats         // How long the session's frames take to draw and send
+	limiters       sessionLimiters
+	stats          Stats          // Lifetime stats from previous sessions
+	counters       sessionStats   // Stats for this session
+	partyInvite    *PlayerSession // Who last invited the player to a party, guarded by PlayersMutex
+
+	notices  chan string   // Messages for the player, like broadcasts
+	kicked   chan struct{} // Closed when the player is kicked
+	kickOnce sync.Once
+	kickMsg  string
+	transfer *Transfer // Where the player left for through a portal, if that's why they were kicked
+	inPortal bool      // Whether the player is standing in a portal, so stepping in is noticed once
+	onStairs bool      // Whether the player is standing on stairs, so they only take them once per step on
+
+	recording  *ghostRecording // The run being recorded for a ghost, if any, guarded by the server's PlayersMutex
+	lastReplay *game.Replay    // The player's latest recording, guarded by the server's PlayersMutex
+}
+
+// Connection is what's known about how a player connected, which their
+// session is given as it joins, before others can see it
+type Connection struct {
+	RemoteIP  string
+	Role      Role
+	CanTravel bool   // Whether the connection can follow the player through a portal to another server
+	Language  string // Tag of the language the terminal asked for, if any; a saved profile's language wins
+}
+
+// Role determines which commands a session may run
+type Role int
+
+const (
+	RolePlayer Role = iota
+	RoleAdmin
+)
+
+// IsAdmin reports whether the session has admin permissions
+func (ps *PlayerSession) IsAdmin() bool {
+	return ps.Role == RoleAdmin
+}
+
+// AccessMode controls whether a session gets textual scene descriptions
+type AccessMode int
+
+const (
+	AccessOff        AccessMode = iota // Graphics only
+	AccessSupplement                   // Graphics with scene descriptions in the HUD
+	AccessTextOnly                     // Periodic scene descriptions instead of graphics
+)
+
+// Next cycles to the following access mode
+func (m AccessMode) Next() AccessMode {
+	return (m + 1) % 3
+}
+
+// String returns a human-readable name for the access mode
+func (m AccessMode) String() string {
+	switch m {
+	case AccessSupplement:
+		return "descriptions"
+	case AccessTextOnly:
+		return "text only"
+	default:
+		return "off"
+	}
 }
 
 // NewGameServer creates a new game server instance
@@ -34,50 +175,237 @@ func NewGameServer(worldMap *game.Map, maxPlayers int) *GameServer {
 		Map:               worldMap,
 		ProjectileManager: game.NewProjectileManager(),
 		Players:           make(map[string]*PlayerSession),
-		NPCs:              make([]*game.NPC, 0),
+		Entities:          make([]*game.Entity, 0),
 		MaxPlayers:        maxPlayers,
+		MaxSpectators:     DefaultMaxSpectators,
+		Bans:              NewBanList(),
+		Profiles:          NewProfileStore(),
+		Events:            NewEventBus(),
+		Chat:              NewChatLog(),
+		StartedAt:         time.Now(),
+		DayLength:         DefaultDayLength,
+		spectators:        make(map[string]struct{}),
+		nextSnapshot:      make(chan struct{}),
 	}
+	gs.flashes = gs.Events.Subscribe(EventFired, EventExploded)
 
-	// Spawn NPCs based on map
+	// Spawn NPCs based on map, and the map's pickups, lights, portals, and stairs
 	gs.spawnNPCs()
+	gs.spawnPickups()
+	gs.spawnLights()
+	gs.spawnPortals()
+	gs.spawnStairs()
+	gs.snapshot = gs.Snapshot(time.Now())
 
 	return gs
 }
 
-// AddPlayer adds a new player to the server
-func (gs *GameServer) AddPlayer(sessionID string) (*PlayerSession, error) {
+// AddPlayer adds a new player to the server, restoring their profile if the
+// key fingerprint (which may be empty) has one. The player is named after the
+// name they logged in as, if any, unless their profile has a name. It returns
+// ErrServerFull if there's no free slot, or if others are queued ahead of the
+// session.
+func (gs *GameServer) AddPlayer(sessionID, fingerprint, username string, connection Connection) (*PlayerSession, error) {
+	return gs.addPlayer(sessionID, fingerprint, username, connection, false)
+}
+
+// AddBot adds a player for the server's own AI to play, named like a player
+// who logged in with the name. Bots have no key, so no profile, and don't
+// set server records.
+func (gs *GameServer) AddBot(sessionID, name string) (*PlayerSession, error) {
+	return gs.addPlayer(sessionID, "", name, Connection{}, true)
+}
+
+// addPlayer adds a player, or a bot, to the server
+func (gs *GameServer) addPlayer(sessionID, fingerprint, username string, connection Connection, bot bool) (*PlayerSession, error) {
 	gs.PlayersMutex.Lock()
 	defer gs.PlayersMutex.Unlock()
 
-	// Check player limit
+	// Check player limit; a free slot goes to the front of the queue first
 	if len(gs.Players) >= gs.MaxPlayers {
-		return nil, fmt.Errorf("server full: max %d players", gs.MaxPlayers)
+		return nil, fmt.Errorf("%w: max %d players", ErrServerFull, gs.MaxPlayers)
 	}
+	if len(gs.queue) > 0 && gs.queue[0] != sessionID {
+		return nil, fmt.Errorf("%w: %d waiting", ErrServerFull, len(gs.queue))
+	}
+	gs.dequeue(sessionID)
 
-	// Find random spawn point
-	spawnX, spawnY := gs.findRandomSpawnPoint()
+	// Start at one of the map's spawns, or anywhere open
+	spawnX, spawnY := gs.findPlayerSpawnPoint()
 
 	// Create new player
 	player := game.NewPlayer(spawnX, spawnY)
 	session := &PlayerSession{
-		ID:          sessionID,
-		Player:      player,
-		Connected:   true,
-		ConnectedAt: time.Now(),
+		ID:             sessionID,
+		Name:           sessionID[:min(8, len(sessionID))],
+		KeyFingerprint: fingerprint,
+		RemoteIP:       connection.RemoteIP,
+		Role:           connection.Role,
+		Player:         player,
+		Connected:      true,
+		ConnectedAt:    time.Now(),
+		Keymap:         input.DefaultKeymap(),
+		Holds:          input.NewHoldTracker(),
+		Bot:            bot,
+		CanTravel:      connection.CanTravel,
+		notices:        make(chan string, 16),
+		kicked:         make(chan struct{}),
+	}
+	if name := sanitizeName(username); name != "" {
+		session.Name = gs.uniqueName(session, name)
 	}
+	if profile, ok := gs.Profiles.Get(fingerprint); ok && fingerprint != "" {
+		gs.applyProfile(session, profile)
+	}
+	if session.Language == "" {
+		session.Language = connection.Language
+	}
+	session.UpdateLogger()
 
 	gs.Players[sessionID] = session
+	if gs.match == nil {
+		gs.startMatch()
+	} else {
+		gs.match.join(session)
+	}
+	gs.publishPlayerEvent(EventJoined, session, "")
 	return session, nil
 }
 
-// RemovePlayer removes a player from the server
+// RemovePlayer removes a player from the server, saving their profile
 func (gs *GameServer) RemovePlayer(sessionID string) {
+	var ended *Match
 	gs.PlayersMutex.Lock()
-	defer gs.PlayersMutex.Unlock()
-
-	if session, exists := gs.Players[sessionID]; exists {
+	session, exists := gs.Players[sessionID]
+	if exists {
 		session.Connected = false
 		delete(gs.Players, sessionID)
+		gs.leaveParty(session)
+		gs.publishPlayerEvent(EventLeft, session, "")
+		if gs.match != nil {
+			gs.match.leave(session)
+		}
+		if len(gs.Players) == 0 {
+			ended = gs.endMatch()
+		}
+	}
+	gs.PlayersMutex.Unlock()
+
+	if exists {
+		gs.saveProfile(session)
+		gs.recordMatch(session)
+		gs.recordMatchResults(ended)
+	}
+}
+
+// RenamePlayer changes a session's display name, which must be unique on the server
+func (gs *GameServer) RenamePlayer(session *PlayerSession, name string) error {
+	if name == "" || len(name) > maxNameLength {
+		return fmt.Errorf("names must be 1-%d characters", maxNameLength)
+	}
+
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+
+	if gs.nameTaken(session, name) {
+		return fmt.Errorf("the name %s is taken", name)
+	}
+	session.Log.Infof("Renamed from %s to %s", session.Name, name)
+	oldName := session.Name
+	session.Name = name
+	session.UpdateLogger()
+	gs.renameInMatch(oldName, name)
+	gs.publishPlayerEvent(EventRenamed, session, oldName)
+	return nil
+}
+
+// nameTaken reports whether another connected player uses the name. Callers hold PlayersMutex.
+func (gs *GameServer) nameTaken(session *PlayerSession, name string) bool {
+	for _, other := range gs.Players {
+		if other != session && strings.EqualFold(other.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeName turns a login name into a display name, keeping only letters,
+// digits, and "-_." and truncating it to fit. It returns "" if nothing's left.
+func sanitizeName(username string) string {
+	var b strings.Builder
+	for _, r := range username {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_.", r) {
+			continue
+		}
+		if b.Len()+utf8.RuneLen(r) > maxNameLength {
+			break
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// uniqueName returns name, or if another player has it, name with the lowest
+// number suffix that's free, like "jason2". Callers hold PlayersMutex.
+func (gs *GameServer) uniqueName(session *PlayerSession, name string) string {
+	candidate := name
+	for n := 2; gs.nameTaken(session, candidate); n++ {
+		suffix := strconv.Itoa(n)
+		base := name
+		for len(base)+len(suffix) > maxNameLength {
+			_, size := utf8.DecodeLastRuneInString(base)
+			base = base[:len(base)-size]
+		}
+		candidate = base + suffix
+	}
+	return candidate
+}
+
+// MapLoader loads a map by name, like a file path or URL
+type MapLoader func(ctx context.Context, name string) (*game.Map, error)
+
+// LoadMap loads a map by name with the server's MapLoader, or from a file
+// if it has none
+func (gs *GameServer) LoadMap(ctx context.Context, name string) (*game.Map, error) {
+	if gs.MapLoader != nil {
+		return gs.MapLoader(ctx, name)
+	}
+	return game.LoadMapFromFile(name)
+}
+
+// ChangeMap switches the server to a new map, clearing projectiles and
+// respawning all players and NPCs
+func (gs *GameServer) ChangeMap(name string, worldMap *game.Map) {
+	var ended *Match
+	defer func() { gs.recordMatchResults(ended) }() // Once the lock is released
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+
+	clog.Infof("Changing map to %s", name)
+	ended = gs.endMatch()
+	gs.Map = worldMap
+	gs.MapName = name
+	gs.ProjectileManager.Clear()
+	gs.wallDamage = nil
+
+	for _, session := range gs.Players {
+		session.Player.Position.X, session.Player.Position.Y = gs.findPlayerSpawnPoint()
+		session.Player.Floor = 0
+		session.recording = nil // Runs don't carry over to another map
+	}
+	gs.spawnParties()
+
+	gs.EntitiesMutex.Lock()
+	gs.Entities = nil
+	gs.EntitiesMutex.Unlock()
+	gs.spawnNPCs()
+	gs.spawnPickups()
+	gs.spawnLights()
+	gs.spawnPortals()
+	gs.spawnStairs()
+	gs.Events.Publish(Event{Kind: EventMapChanged, Detail: name})
+	if len(gs.Players) > 0 {
+		gs.startMatch()
 	}
 }
 
@@ -88,6 +416,21 @@ func (gs *GameServer) GetPlayerCount() int {
 	return len(gs.Players)
 }
 
+// CountPlayers returns how many of the connected players are people, and how
+// many are the server's bots
+func (gs *GameServer) CountPlayers() (people, bots int) {
+	gs.PlayersMutex.RLock()
+	defer gs.PlayersMutex.RUnlock()
+	for _, session := range gs.Players {
+		if session.Bot {
+			bots++
+		} else {
+			people++
+		}
+	}
+	return people, bots
+}
+
 // GetPlayerSession returns a player session by ID
 func (gs *GameServer) GetPlayerSession(sessionID string) (*PlayerSession, bool) {
 	gs.PlayersMutex.RLock()
@@ -96,6 +439,16 @@ func (gs *GameServer) GetPlayerSession(sessionID string) (*PlayerSession, bool)
 	return session, exists
 }
 
+// findPlayerSpawnPoint picks one of the map's player spawns, or a random empty
+// location if it has none
+func (gs *GameServer) findPlayerSpawnPoint() (float64, float64) {
+	if len(gs.Map.Spawns) == 0 {
+		return gs.findRandomSpawnPoint()
+	}
+	spawn := gs.Map.Spawns[rand.Intn(len(gs.Map.Spawns))]
+	return spawn.X, spawn.Y
+}
+
 // findRandomSpawnPoint finds a random empty location on the map
 func (gs *GameServer) findRandomSpawnPoint() (float64, float64) {
 	// Find all empty spaces (value 0)
@@ -124,13 +477,95 @@ func (gs *GameServer) findRandomSpawnPoint() (float64, float64) {
 	return spawnX, spawnY
 }
 
-// Update updates the shared game state (projectiles, NPCs, etc.)
+// Update advances the shared game state (players, projectiles, NPCs, etc.)
+// by one simulation step
 func (gs *GameServer) Update(deltaTime float64) {
-	// Update projectiles (thread-safe as it's called from main server loop)
-	gs.ProjectileManager.Update(deltaTime, gs.Map)
+	// Move players by their held keys, then give them what they walked over
+	gs.updatePlayers(deltaTime)
+	gs.collectPickups()
+	gs.triggerMines()
+
+	// Slide moving walls, crushing anyone caught in a closing one
+	gs.moveWalls(deltaTime)
+
+	// Update projectiles (the manager locks against players firing meanwhile),
+	// then stop those that hit something and explode any grenades
+	detonated, struck := gs.ProjectileManager.Update(deltaTime, gs.Map)
+	gs.resolveHits(detonated)
+	gs.damageWalls(struck)
+
+	// Light up where shots were fired and things exploded
+	gs.spawnFlashes()
+
+	// Update NPCs and other entities
+	gs.updateEntities(deltaTime)
+
+	// Move the time of day on
+	gs.advanceClock(deltaTime)
+
+	// Index everything by location for nearby queries
+	gs.indexEntities()
 
-	// Update NPCs
-	gs.updateNPCs(deltaTime)
+	// Make room for waiting players
+	gs.rotateSessions(time.Now())
+
+	// Apply any custom game rules
+	for _, rules := range gs.Rules {
+		rules.Step(deltaTime)
+	}
+}
+
+// FireProjectile fires the session player's current weapon, enforcing weapon
+// switch delays, the weapon's cooldown and ammo, and the global projectile
+// cap. It returns false if the shot was blocked.
+func (gs *GameServer) FireProjectile(session *PlayerSession) bool {
+	weapon, from, floor, ok := gs.fire(session)
+	if !ok {
+		return false
+	}
+	if weapon.ChargeTime > 0 {
+		session.Log.Debugf("Charging %s", weapon.Name)
+		return true // It fires when it's charged
+	}
+	session.recordShot(weapon.Name)
+	gs.publishShot(session, weapon.Name, from, floor)
+	session.Log.Debugf("Fired %s from (%.1f, %.1f)", weapon.Name, from.X, from.Y)
+	return true
+}
+
+// fire adds the projectiles for a shot of the session player's current weapon
+// if it's ready, spending its ammo and starting its cooldown, or starts
+// charging it if it's a charged weapon, and returns where the player fired
+// from. The simulation counts cooldowns, reloads, and charges down, and moves
+// players, so they're checked and read under PlayersMutex.
+func (gs *GameServer) fire(session *PlayerSession) (weapon *game.Weapon, from game.Vector, floor int, ok bool) {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+	return gs.shoot(session)
+}
+
+// shoot is fire for callers that hold PlayersMutex for writing
+func (gs *GameServer) shoot(session *PlayerSession) (weapon *game.Weapon, from game.Vector, floor int, ok bool) {
+	player := session.Player
+	if !player.CanFire() {
+		return nil, game.Vector{}, 0, false
+	}
+	weapon, from, floor = game.GetWeapon(player.Weapon), player.Position, player.Floor
+	if weapon.ChargeTime > 0 {
+		player.StartCharging()
+		return weapon, from, floor, true
+	}
+	volley := weapon.Fire(player.Position, player.Direction)
+	for _, p := range volley {
+		p.Owner = session.ID
+		p.Floor = player.Floor
+		p.Damage *= player.DamageMultiplier()
+	}
+	if !gs.ProjectileManager.AddProjectiles(MaxProjectiles, volley...) {
+		return nil, game.Vector{}, 0, false
+	}
+	player.Fired()
+	return weapon, from, floor, true
 }
 
 // GetOtherPlayers returns all players except the specified one
@@ -155,8 +590,8 @@ func (gs *GameServer) GetDebugInfo() string {
 
 	// Count active projectiles
 	activeProjectiles := 0
-	for _, p := range gs.ProjectileManager.Projectiles {
-		if p.Active && p.Type == game.Fireball {
+	for _, p := range gs.ProjectileManager.Snapshot() {
+		if p.Type == game.Fireball {
 			activeProjectiles++
 		}
 	}
@@ -167,8 +602,16 @@ func (gs *GameServer) GetDebugInfo() string {
 
 // spawnNPCs creates and places NPCs in the world
 func (gs *GameServer) spawnNPCs() {
-	gs.NPCsMutex.Lock()
-	defer gs.NPCsMutex.Unlock()
+	gs.EntitiesMutex.Lock()
+	defer gs.EntitiesMutex.Unlock()
+
+	// Maps may place their NPCs
+	if len(gs.Map.NPCs) > 0 {
+		for _, pos := range gs.Map.NPCs {
+			gs.addEntity(game.NewNPC(pos.X, pos.Y))
+		}
+		return
+	}
 
 	// Different NPC counts based on map size/type
 	npcCount := 3 // Default for maze
@@ -179,28 +622,49 @@ func (gs *GameServer) spawnNPCs() {
 	for i := 0; i < npcCount; i++ {
 		// Find random spawn point for NPC
 		spawnX, spawnY := gs.findRandomSpawnPoint()
-		npc := game.NewNPC(spawnX, spawnY, game.Wanderer)
-		gs.NPCs = append(gs.NPCs, npc)
+		gs.addEntity(game.NewNPC(spawnX, spawnY))
 	}
 }
 
-// updateNPCs updates all NPCs in the world
-func (gs *GameServer) updateNPCs(deltaTime float64) {
-	gs.NPCsMutex.RLock()
-	defer gs.NPCsMutex.RUnlock()
-
-	for _, npc := range gs.NPCs {
-		npc.Update(deltaTime, gs.Map)
+// addEntity numbers an entity and adds it to the world, frozen if it's an
+// NPC and NPCs are. Callers hold EntitiesMutex.
+func (gs *GameServer) addEntity(e *game.Entity) {
+	if e.Kind == game.KindNPC && gs.practice != nil {
+		e.Frozen = gs.practice.frozen
 	}
+	gs.lastEntityID++
+	e.ID = gs.lastEntityID
+	gs.Entities = append(gs.Entities, e)
 }
 
-// GetNPCs returns all NPCs for rendering (thread-safe)
-func (gs *GameServer) GetNPCs() []*game.NPC {
-	gs.NPCsMutex.RLock()
-	defer gs.NPCsMutex.RUnlock()
+// updateEntities runs the entity systems on the world's NPCs and objects
+func (gs *GameServer) updateEntities(deltaTime float64) {
+	gs.EntitiesMutex.Lock()
+	defer gs.EntitiesMutex.Unlock()
+	gs.Entities = game.UpdateEntities(gs.Entities, deltaTime, gs.Map)
+}
+
+// GetEntities returns the world's NPCs and objects for rendering (thread-safe)
+func (gs *GameServer) GetEntities() []*game.Entity {
+	gs.EntitiesMutex.RLock()
+	defer gs.EntitiesMutex.RUnlock()
 
 	// Return a copy to avoid race conditions during rendering
-	npcs := make([]*game.NPC, len(gs.NPCs))
-	copy(npcs, gs.NPCs)
-	return npcs
+	entities := make([]*game.Entity, len(gs.Entities))
+	copy(entities, gs.Entities)
+	return entities
+}
+
+// CountEntities returns how many entities of a kind are in the world
+func (gs *GameServer) CountEntities(kind game.EntityKind) int {
+	gs.EntitiesMutex.RLock()
+	defer gs.EntitiesMutex.RUnlock()
+
+	count := 0
+	for _, e := range gs.Entities {
+		if e.Kind == kind {
+			count++
+		}
+	}
+	return count
 }
@@ -0,0 +1,64 @@
+package server
+
+import "testing"
+
+func TestSanitizeName(t *testing.T) {
+	for _, tt := range []struct {
+		username, want string
+	}{
+		{"jason", "jason"},
+		{"Jason_H.1-2", "Jason_H.1-2"},
+		{"", ""},
+		{"bad name!", "badname"},
+		{"\x1b[31mred\x1b[0m", "31mred0m"},
+		{"<script>", "script"},
+		{"élan", "élan"},
+		{"名前", "名前"},
+		{"!!!", ""},
+		{"abcdefghijklmnopqrstuvwxyz", "abcdefghijklmnop"},
+		// Multi-byte letters count by their bytes, and aren't split
+		{"éééééééééé", "éééééééé"},
+		{"aéééééééé", "aééééééé"},
+	} {
+		if got := sanitizeName(tt.username); got != tt.want {
+			t.Errorf("sanitizeName(%q) = %q, want %q", tt.username, got, tt.want)
+		}
+	}
+}
+
+func TestUniqueName(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		taken []string
+		want  string
+	}{
+		{"jason", nil, "jason"},
+		{"jason", []string{"jason"}, "jason2"},
+		{"jason", []string{"JASON"}, "jason2"},
+		{"jason", []string{"jason", "jason2", "jason3"}, "jason4"},
+		{"jason", []string{"jason", "jason3"}, "jason2"},
+		{"jason", []string{"jason2"}, "jason"},
+		{"abcdefghijklmnop", []string{"abcdefghijklmnop"}, "abcdefghijklmno2"},
+		{"abcdefghijklmnop", []string{"abcdefghijklmnop", "abcdefghijklmno2", "abcdefghijklmno3", "abcdefghijklmno4", "abcdefghijklmno5", "abcdefghijklmno6", "abcdefghijklmno7", "abcdefghijklmno8", "abcdefghijklmno9"}, "abcdefghijklmn10"},
+		{"éééééééé", []string{"éééééééé"}, "ééééééé2"},
+	} {
+		gs := &GameServer{Players: map[string]*PlayerSession{}}
+		for i, name := range tt.taken {
+			id := string(rune('a' + i))
+			gs.Players[id] = &PlayerSession{ID: id, Name: name}
+		}
+		session := &PlayerSession{ID: "new"}
+		if got := gs.uniqueName(session, tt.name); got != tt.want {
+			t.Errorf("uniqueName(%q) with %q taken = %q, want %q", tt.name, tt.taken, got, tt.want)
+		}
+	}
+}
+
+func TestUniqueNameKeepsOwnName(t *testing.T) {
+	// A player renaming to their own name, in another case, doesn't clash with themselves
+	session := &PlayerSession{ID: "me", Name: "jason"}
+	gs := &GameServer{Players: map[string]*PlayerSession{"me": session}}
+	if got := gs.uniqueName(session, "Jason"); got != "Jason" {
+		t.Errorf("uniqueName = %q, want Jason", got)
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"github.com/chainguard-dev/clog"
+
+	"github.com/imjasonh/terminus/locale"
+)
+
+// UpdateLogger rebuilds Log to tag lines with the session's current ID, name,
+// remote address, and key fingerprint. Call it after any of them change,
+// under PlayersMutex once the session has joined, since the simulation logs
+// through it.
+func (ps *PlayerSession) UpdateLogger() {
+	ps.Log = clog.With(
+		"session", ps.ID[:min(8, len(ps.ID))],
+		"player", ps.Name,
+		"remote", ps.RemoteIP,
+		"key", ps.KeyFingerprint,
+	)
+}
+
+// T translates a message into the player's language, formatting it with its
+// arguments like fmt.Sprintf
+func (ps *PlayerSession) T(format string, args ...any) string {
+	return locale.T(ps.Language, format, args...)
+}
+
+// SetLanguage changes the language the session's player reads. The
+// simulation translates what it tells them, so it's changed under
+// PlayersMutex.
+func (gs *GameServer) SetLanguage(session *PlayerSession, tag string) {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+	session.Language = tag
+}
+
+// SetFrameRate changes the frame rate the session's player is drawn at, one
+// of FrameRates or 0 for the server's. Profiles save it from other
+// goroutines, so it's changed under PlayersMutex.
+func (gs *GameServer) SetFrameRate(session *PlayerSession, rate int) {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+	session.FrameRate = rate
+}
+
+// FrameRates are the frame rates players can choose to be drawn at, in frames
+// per second, lower than the server's for slow links and terminals like tmux.
+// The simulation steps at the server's tick rate whatever they choose.
+var FrameRates = []int{10, 15, 30}
+
+// NextFrameRate returns the frame rate after one in FrameRates, with 0 for the
+// server's rate before the first, wrapping around, for menus that cycle
+// through them
+func NextFrameRate(rate int) int {
+	for i, r := range FrameRates {
+		if r == rate {
+			if i+1 < len(FrameRates) {
+				return FrameRates[i+1]
+			}
+			return 0
+		}
+	}
+	return FrameRates[0]
+}
+
+// Notify queues a message for the player, dropping it if they have too many unread
+func (ps *PlayerSession) Notify(msg string) {
+	select {
+	case ps.notices <- msg:
+	default:
+	}
+}
+
+// Notices returns the channel of messages queued for the player
+func (ps *PlayerSession) Notices() <-chan string {
+	return ps.notices
+}
+
+// Kick disconnects the player with a reason shown to them. Only the first kick takes effect.
+func (ps *PlayerSession) Kick(reason string) {
+	ps.kickOnce.Do(func() {
+		ps.Log.Infof("Kicked: %s", reason)
+		ps.kickMsg = reason
+		close(ps.kicked)
+	})
+}
+
+// Kicked returns a channel that's closed when the player is kicked
+func (ps *PlayerSession) Kicked() <-chan struct{} {
+	return ps.kicked
+}
+
+// KickReason returns why the player was kicked, once Kicked is closed
+func (ps *PlayerSession) KickReason() string {
+	<-ps.kicked
+	return ps.kickMsg
+}
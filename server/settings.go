@@ -0,0 +1,118 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Setting is a server setting admins may change while it runs
+type Setting struct {
+	Name string
+	Help string
+	get  func(gs *GameServer) string
+	set  func(gs *GameServer, value string) error // Callers hold PlayersMutex
+}
+
+// Settings lists the settings admins may change at runtime, by name
+var Settings = []Setting{
+	{
+		Name: "max_players",
+		Help: "Player slots; lowering it doesn't disconnect anyone",
+		get:  func(gs *GameServer) string { return strconv.Itoa(gs.MaxPlayers) },
+		set: func(gs *GameServer, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return fmt.Errorf("max_players must be a number of at least 1")
+			}
+			gs.MaxPlayers = n
+			return nil
+		},
+	},
+	{
+		Name: "max_spectators",
+		Help: "Spectators watching at once, not counting admins",
+		get:  func(gs *GameServer) string { return strconv.Itoa(gs.MaxSpectators) },
+		set: func(gs *GameServer, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("max_spectators must be a number of at least 0")
+			}
+			gs.MaxSpectators = n
+			return nil
+		},
+	},
+	{
+		Name: "session_cap",
+		Help: "Play time before a player may be rotated out for someone waiting, e.g. 30m; 0 for no limit",
+		get:  func(gs *GameServer) string { return gs.SessionCap.String() },
+		set: func(gs *GameServer, value string) error {
+			d, err := time.ParseDuration(value)
+			if err != nil || d < 0 {
+				return fmt.Errorf("session_cap must be a duration like 30m, or 0")
+			}
+			gs.SessionCap = d
+			return nil
+		},
+	},
+	{
+		Name: "day_length",
+		Help: "How long a day and night last on maps with a day/night cycle, e.g. 20m; 0 for always day",
+		get:  func(gs *GameServer) string { return gs.DayLength.String() },
+		set: func(gs *GameServer, value string) error {
+			d, err := time.ParseDuration(value)
+			if err != nil || d < 0 {
+				return fmt.Errorf("day_length must be a duration like 20m, or 0")
+			}
+			gs.clock.mu.Lock()
+			defer gs.clock.mu.Unlock()
+			gs.DayLength = d
+			return nil
+		},
+	},
+}
+
+// findSetting returns the setting with a name
+func findSetting(name string) (*Setting, error) {
+	for i := range Settings {
+		if Settings[i].Name == name {
+			return &Settings[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown setting %q", name)
+}
+
+// SettingValues returns the current value of every setting, by name
+func (gs *GameServer) SettingValues() map[string]string {
+	gs.PlayersMutex.RLock()
+	defer gs.PlayersMutex.RUnlock()
+
+	values := make(map[string]string, len(Settings))
+	for _, s := range Settings {
+		values[s.Name] = s.get(gs)
+	}
+	return values
+}
+
+// ChangeSetting sets a setting from its text form, like "30m" or "12"
+func (gs *GameServer) ChangeSetting(name, value string) error {
+	s, err := findSetting(name)
+	if err != nil {
+		return err
+	}
+
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+	return s.set(gs, value)
+}
+
+// SettingNames returns the names of the settings, sorted
+func SettingNames() []string {
+	names := make([]string, 0, len(Settings))
+	for _, s := range Settings {
+		names = append(names, s.Name)
+	}
+	sort.Strings(names)
+	return names
+}
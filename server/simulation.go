@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/input"
+)
+
+// maxStepsPerTick caps how many simulation steps catch up at once after a
+// stall, so a slow server drops time rather than falling further behind
+const maxStepsPerTick = 5
+
+// Run advances the simulation in fixed steps of the given length until ctx is
+// done, using an accumulator so the world moves at the same rate however the
+// ticker drifts. Time accumulates at the server's TimeScale, so a slowed
+// practice instance takes the same steps less often. After each step it
+// publishes a snapshot for render loops, stamped with when the step was due.
+func (gs *GameServer) Run(ctx context.Context, step time.Duration) {
+	ticker := time.NewTicker(step)
+	defer ticker.Stop()
+
+	last := time.Now()
+	var accumulator time.Duration
+	for {
+		var now time.Time
+		select {
+		case <-ctx.Done():
+			return
+		case now = <-ticker.C:
+		}
+		scale := gs.TimeScale()
+		accumulator += time.Duration(float64(now.Sub(last)) * scale)
+		last = now
+		if accumulator > maxStepsPerTick*step {
+			accumulator = maxStepsPerTick * step
+		}
+
+		for accumulator >= step {
+			start := time.Now()
+			gs.Update(step.Seconds())
+			accumulator -= step
+			// This step happened, in simulation time, accumulator ago
+			gs.publishSnapshot(now.Add(-time.Duration(float64(accumulator) / scale)))
+			stepDuration.Record(context.Background(), float64(time.Since(start))/float64(time.Millisecond))
+		}
+	}
+}
+
+// updatePlayers moves every player by their held keys, advances their
+// stamina, torch fuel, and weapon timers, fires continuous weapons they're holding fire
+// with, and runs their status effects. It changes players that command
+// handlers and the API read under PlayersMutex, so it holds it for writing.
+func (gs *GameServer) updatePlayers(deltaTime float64) {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+
+	for _, session := range gs.Players {
+		player := session.Player
+		before := player.Position
+		applyHeldMovement(player, session.Holds, deltaTime, gs.Map.Floor(player.Floor))
+		session.recordDistance(player.Position.Sub(before).Length())
+		gs.checkPortal(session)
+		gs.checkStairs(session)
+		gs.recordGhost(session, deltaTime)
+		player.UpdateVelocity(deltaTime, gs.Map.Floor(player.Floor))
+		player.UpdateStamina(deltaTime)
+		player.UpdateTorch(deltaTime)
+		if player.UpdateWeapons(deltaTime) {
+			gs.fireRailgun(session)
+		}
+		if session.Holds.Strength(input.ActionFire) > 0 {
+			gs.fireHeld(session)
+		}
+		for _, tick := range player.UpdateEffects(deltaTime) {
+			if gs.damagePlayer(session, tick.Amount, tick.Owner) {
+				break // Respawned without their effects
+			}
+		}
+	}
+}
+
+// applyHeldMovement moves and turns the player for every held action, scaled by how strongly it's held
+func applyHeldMovement(player *game.Player, holds *input.HoldTracker, deltaTime float64, worldMap *game.Map) {
+	if holds.Strength(input.ActionSprint) > 0 {
+		player.Sprint()
+	}
+	if strength := holds.Strength(input.ActionMoveForward); strength > 0 {
+		player.MoveForward(deltaTime*strength, worldMap)
+	}
+	if strength := holds.Strength(input.ActionMoveBackward); strength > 0 {
+		player.MoveBackward(deltaTime*strength, worldMap)
+	}
+	if strength := holds.Strength(input.ActionStrafeLeft); strength > 0 {
+		player.StrafeLeft(deltaTime*strength, worldMap)
+	}
+	if strength := holds.Strength(input.ActionStrafeRight); strength > 0 {
+		player.StrafeRight(deltaTime*strength, worldMap)
+	}
+	// The player's rotation methods are named for the math convention; with the
+	// map's Y axis pointing down, RotateRight turns the view to the left
+	if strength := holds.Strength(input.ActionTurnLeft); strength > 0 {
+		player.RotateRight(deltaTime * strength)
+	}
+	if strength := holds.Strength(input.ActionTurnRight); strength > 0 {
+		player.RotateLeft(deltaTime * strength)
+	}
+	holds.Update(deltaTime)
+}
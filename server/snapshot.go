@@ -0,0 +1,238 @@
+package server
+
+import (
+	"sort"
+	"time"
+
+	"github.com/imjasonh/terminus/game"
+)
+
+// Snapshot is an immutable copy of what renderers draw, taken after each
+// simulation step. Render loops read the latest snapshot instead of the live
+// players, entities, and projectiles, which the simulation keeps changing,
+// and the map as of the step rather than GameServer.Map, which it replaces
+// as walls crumble and move.
+type Snapshot struct {
+	Step        uint64    // Simulation steps taken before this snapshot
+	Time        time.Time // When the step was due
+	Map         *game.Map // Copy-on-write, so it's never changed once it's in a snapshot
+	MapName     string
+	Players     []PlayerState // Ordered by name
+	Entities    []game.Entity // NPCs and other world objects
+	Projectiles []game.Projectile
+	Ambient     float64 // How lit the world is by the time of day, from 0 to 1
+	WorldTime   float64 // Seconds of simulation run, which animates lights
+}
+
+// PlayerState is a connected player as of a snapshot
+type PlayerState struct {
+	ID     string
+	Name   string
+	Player game.Player
+}
+
+// Snapshot copies the current renderable state of the game, as of the given time
+func (gs *GameServer) Snapshot(now time.Time) *Snapshot {
+	snap := &Snapshot{Time: now, Ambient: gs.Ambient(), WorldTime: gs.WorldTime()}
+
+	gs.PlayersMutex.RLock()
+	snap.Map, snap.MapName = gs.Map, gs.MapName
+	for _, session := range gs.Players {
+		if session.Connected {
+			snap.Players = append(snap.Players, PlayerState{ID: session.ID, Name: session.Name, Player: session.Player.Clone()})
+		}
+	}
+	gs.PlayersMutex.RUnlock()
+	sort.Slice(snap.Players, func(i, j int) bool {
+		if snap.Players[i].Name != snap.Players[j].Name {
+			return snap.Players[i].Name < snap.Players[j].Name
+		}
+		return snap.Players[i].ID < snap.Players[j].ID
+	})
+
+	gs.EntitiesMutex.RLock()
+	snap.Entities = make([]game.Entity, 0, len(gs.Entities))
+	for _, e := range gs.Entities {
+		snap.Entities = append(snap.Entities, e.Clone())
+	}
+	gs.EntitiesMutex.RUnlock()
+
+	snap.Projectiles = gs.ProjectileManager.Snapshot()
+	return snap
+}
+
+// publishSnapshot makes a snapshot of the step due at the given time the
+// latest one, waking every render loop waiting for it
+func (gs *GameServer) publishSnapshot(stepTime time.Time) {
+	snap := gs.Snapshot(stepTime)
+
+	gs.snapshotMutex.Lock()
+	defer gs.snapshotMutex.Unlock()
+	gs.steps++
+	snap.Step = gs.steps
+	gs.snapshot = snap
+	close(gs.nextSnapshot)
+	gs.nextSnapshot = make(chan struct{})
+}
+
+// LatestSnapshot returns the latest snapshot, and a channel that's closed
+// when the next one is published. Snapshots are shared by every render loop
+// and must not be modified.
+func (gs *GameServer) LatestSnapshot() (*Snapshot, <-chan struct{}) {
+	gs.snapshotMutex.RLock()
+	defer gs.snapshotMutex.RUnlock()
+	return gs.snapshot, gs.nextSnapshot
+}
+
+// Player returns a player's state in the snapshot by session ID
+func (s *Snapshot) Player(sessionID string) (*PlayerState, bool) {
+	for i := range s.Players {
+		if s.Players[i].ID == sessionID {
+			return &s.Players[i], true
+		}
+	}
+	return nil, false
+}
+
+// OtherPlayers returns every player in the snapshot except the specified one
+func (s *Snapshot) OtherPlayers(excludeSessionID string) []*game.Player {
+	var players []*game.Player
+	for i := range s.Players {
+		if s.Players[i].ID != excludeSessionID {
+			players = append(players, &s.Players[i].Player)
+		}
+	}
+	return players
+}
+
+// Lights returns the light cast by the snapshot's projectiles, entities like
+// explosions and map lights, and players' torches, animated as of the
+// snapshot's world time. With reduced motion, lights animate calmly and
+// brief flashes are dimmed.
+func (s *Snapshot) Lights(reducedMotion bool) []game.LightSource {
+	animate := game.LightSource.Animate
+	if reducedMotion {
+		animate = game.LightSource.AnimateCalmly
+	}
+	lights := make([]game.LightSource, 0, len(s.Projectiles))
+	for i := range s.Projectiles {
+		if light, ok := s.Projectiles[i].Light(); ok {
+			lights = append(lights, animate(light, s.WorldTime))
+		}
+	}
+	for i := range s.Entities {
+		if light, ok := s.Entities[i].LightSource(); ok {
+			if reducedMotion && s.Entities[i].Lifetime != nil {
+				light = light.Calm() // Muzzle flashes and explosions
+			}
+			lights = append(lights, animate(light, s.WorldTime))
+		}
+	}
+	for i := range s.Players {
+		if light, ok := s.Players[i].Player.TorchLight(); ok {
+			lights = append(lights, animate(light, s.WorldTime))
+		}
+	}
+	return lights
+}
+
+// VisibleEntities returns everything a view should draw: other players (all of
+// them if excludeSessionID is empty) who aren't invisible, the world's
+// entities except pickups waiting to respawn, and projectiles. Players with a
+// label, by session ID, have it drawn above them.
+func (s *Snapshot) VisibleEntities(excludeSessionID string, labels map[string]string) []*game.Entity {
+	var entities []*game.Entity
+	for i := range s.Players {
+		if s.Players[i].ID == excludeSessionID || s.Players[i].Player.IsInvisible() {
+			continue
+		}
+		e := s.Players[i].Player.Entity()
+		e.Sprite.Label = labels[s.Players[i].ID]
+		entities = append(entities, e)
+	}
+	for i := range s.Entities {
+		if s.Entities[i].Pickup != nil && !s.Entities[i].Pickup.Ready() {
+			continue
+		}
+		entities = append(entities, &s.Entities[i])
+	}
+	for i := range s.Projectiles {
+		if t := s.Projectiles[i].Type; t == game.Fireball || t == game.Grenade || t == game.Rocket || t == game.Flame {
+			entities = append(entities, s.Projectiles[i].Entity())
+		}
+	}
+	return entities
+}
+
+// Interpolate returns a snapshot with players, entities, and projectiles a
+// fraction alpha of the way from their places in an earlier snapshot to their
+// places in this one. Objects that weren't in the earlier snapshot, or that
+// jumped, are drawn where they are now.
+func (s *Snapshot) Interpolate(from *Snapshot, alpha float64) *Snapshot {
+	snap := *s
+
+	snap.Players = make([]PlayerState, len(s.Players))
+	for i, state := range s.Players {
+		if prev, ok := from.Player(state.ID); ok {
+			state.Player = *state.Player.Interpolated(&prev.Player, alpha)
+		}
+		snap.Players[i] = state
+	}
+
+	positions := make(map[uint64]game.Vector, len(from.Entities))
+	for _, e := range from.Entities {
+		if e.ID != 0 {
+			positions[e.ID] = e.Position
+		}
+	}
+	snap.Entities = make([]game.Entity, len(s.Entities))
+	for i, e := range s.Entities {
+		if prev, ok := positions[e.ID]; ok && game.Interpolates(prev, e.Position) {
+			e.Position = prev.Lerp(e.Position, alpha)
+		}
+		snap.Entities[i] = e
+	}
+
+	clear(positions)
+	for _, p := range from.Projectiles {
+		positions[p.ID] = p.Position
+	}
+	snap.Projectiles = make([]game.Projectile, len(s.Projectiles))
+	for i, p := range s.Projectiles {
+		if prev, ok := positions[p.ID]; ok && game.Interpolates(prev, p.Position) {
+			p.Position = prev.Lerp(p.Position, alpha)
+		}
+		snap.Projectiles[i] = p
+	}
+	return &snap
+}
+
+// SnapshotBuffer keeps a render loop's latest two snapshots, so it can draw
+// the world between them. Drawing runs one snapshot interval behind the
+// simulation, which keeps motion smooth however the two rates line up.
+type SnapshotBuffer struct {
+	previous, latest *Snapshot
+}
+
+// Push adds a newly published snapshot, dropping the oldest
+func (b *SnapshotBuffer) Push(snap *Snapshot) {
+	if b.latest != nil && b.latest.Step == snap.Step {
+		return
+	}
+	b.previous, b.latest = b.latest, snap
+}
+
+// At returns the world as it should be drawn at the given time: between the
+// buffered snapshots, as far along as now is past the latest one, relative to
+// the time between them
+func (b *SnapshotBuffer) At(now time.Time) *Snapshot {
+	if b.previous == nil {
+		return b.latest
+	}
+	interval := b.latest.Time.Sub(b.previous.Time)
+	if interval <= 0 {
+		return b.latest
+	}
+	alpha := float64(now.Sub(b.latest.Time)) / float64(interval)
+	return b.latest.Interpolate(b.previous, max(0, min(1, alpha)))
+}
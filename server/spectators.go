@@ -0,0 +1,33 @@
+package server
+
+import "errors"
+
+// ErrTooManySpectators is returned by AddSpectator when MaxSpectators are already watching
+var ErrTooManySpectators = errors.New("too many spectators, try again later")
+
+// AddSpectator counts a connection watching the match. Spectators don't take a
+// player slot; admins may watch even when MaxSpectators are already connected.
+func (gs *GameServer) AddSpectator(sessionID string, admin bool) error {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+
+	if !admin && len(gs.spectators) >= gs.MaxSpectators {
+		return ErrTooManySpectators
+	}
+	gs.spectators[sessionID] = struct{}{}
+	return nil
+}
+
+// RemoveSpectator stops counting a spectator
+func (gs *GameServer) RemoveSpectator(sessionID string) {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+	delete(gs.spectators, sessionID)
+}
+
+// SpectatorCount returns the number of connections watching the match
+func (gs *GameServer) SpectatorCount() int {
+	gs.PlayersMutex.RLock()
+	defer gs.PlayersMutex.RUnlock()
+	return len(gs.spectators)
+}
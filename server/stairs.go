@@ -0,0 +1,36 @@
+package server
+
+import "github.com/imjasonh/terminus/game"
+
+// spawnStairs places the map's stairs and ladders in the world, on both of
+// the floors each joins, so players can see them
+func (gs *GameServer) spawnStairs() {
+	gs.EntitiesMutex.Lock()
+	defer gs.EntitiesMutex.Unlock()
+
+	for _, s := range gs.Map.Stairs {
+		for _, e := range game.NewStairs(s) {
+			gs.addEntity(e)
+		}
+	}
+}
+
+// checkStairs takes a player who steps into a cell with stairs to the floor
+// they lead to. Arriving there on the stairs doesn't take them straight
+// back; they have to step off and on again. The simulation calls it for each
+// player after moving them.
+func (gs *GameServer) checkStairs(session *PlayerSession) {
+	player := session.Player
+	s, ok := gs.Map.StairsAt(player.Floor, int(player.Position.X), int(player.Position.Y))
+	if ok && !session.onStairs {
+		from := player.Floor
+		player.Floor = s.Other(from)
+		session.Log.Debugf("Took the stairs from floor %d to %d", from+1, player.Floor+1)
+		if player.Floor > from {
+			session.Notify(session.T("You climb up to floor %d", player.Floor+1))
+		} else {
+			session.Notify(session.T("You go down to floor %d", player.Floor+1))
+		}
+	}
+	session.onStairs = ok
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Stats are a player's totals, for a session or their lifetime
+type Stats struct {
+	FirstSeen   time.Time      `json:"first_seen"`
+	LastSeen    time.Time      `json:"last_seen"`
+	Sessions    int            `json:"sessions"`
+	PlaySeconds int64          `json:"play_seconds"`
+	ShotsFired  int            `json:"shots_fired"`
+	Hits        int            `json:"hits"`                   // Shots that hit something, counting a volley once
+	Distance    float64        `json:"distance"`               // Map units moved
+	WeaponShots map[string]int `json:"weapon_shots,omitempty"` // Shots fired by weapon name
+}
+
+// Accuracy returns the fraction of shots that hit something
+func (s Stats) Accuracy() float64 {
+	if s.ShotsFired == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.ShotsFired)
+}
+
+// FavoriteWeapon returns the name of the weapon fired most, or "" if none has been
+func (s Stats) FavoriteWeapon() string {
+	names := make([]string, 0, len(s.WeaponShots))
+	for name := range s.WeaponShots {
+		names = append(names, name)
+	}
+	sort.Strings(names) // Break ties by name
+	favorite := ""
+	for _, name := range names {
+		if favorite == "" || s.WeaponShots[name] > s.WeaponShots[favorite] {
+			favorite = name
+		}
+	}
+	return favorite
+}
+
+// Summary describes the stats on one line, e.g. for the console
+func (s Stats) Summary() string {
+	played := time.Duration(s.PlaySeconds) * time.Second
+	summary := fmt.Sprintf("%s played, %d shots, %d hits (%.0f%%), %.0fm travelled",
+		played, s.ShotsFired, s.Hits, s.Accuracy()*100, s.Distance)
+	if favorite := s.FavoriteWeapon(); favorite != "" {
+		summary += ", favorite weapon " + favorite
+	}
+	return summary
+}
+
+// Add returns the totals of both stats, keeping the earliest first and latest
+// last times seen
+func (s Stats) Add(other Stats) Stats {
+	sum := s
+	if sum.FirstSeen.IsZero() || (!other.FirstSeen.IsZero() && other.FirstSeen.Before(sum.FirstSeen)) {
+		sum.FirstSeen = other.FirstSeen
+	}
+	if other.LastSeen.After(sum.LastSeen) {
+		sum.LastSeen = other.LastSeen
+	}
+	sum.Sessions += other.Sessions
+	sum.PlaySeconds += other.PlaySeconds
+	sum.ShotsFired += other.ShotsFired
+	sum.Hits += other.Hits
+	sum.Distance += other.Distance
+	sum.WeaponShots = make(map[string]int, len(s.WeaponShots))
+	for name, n := range s.WeaponShots {
+		sum.WeaponShots[name] += n
+	}
+	for name, n := range other.WeaponShots {
+		sum.WeaponShots[name] += n
+	}
+	return sum
+}
+
+// recentVolleys is how many of a player's volleys that hit something are
+// remembered, so a volley's other projectiles don't count as more hits
+const recentVolleys = 8
+
+// sessionStats counts what a player does during a session. The player's
+// session and the simulation both update it.
+type sessionStats struct {
+	mu          sync.Mutex
+	shotsFired  int
+	hits        int
+	distance    float64
+	weaponShots map[string]int
+	hitVolleys  [recentVolleys]uint64 // The latest volleys that hit, as a ring
+	nextVolley  int                   // Where the next volley that hits goes in hitVolleys
+}
+
+// recordShot counts a shot fired with the named weapon
+func (ps *PlayerSession) recordShot(weapon string) {
+	ps.counters.mu.Lock()
+	defer ps.counters.mu.Unlock()
+	ps.counters.shotsFired++
+	if ps.counters.weaponShots == nil {
+		ps.counters.weaponShots = make(map[string]int)
+	}
+	ps.counters.weaponShots[weapon]++
+}
+
+// recordHit counts a hit by a projectile from a volley, unless another
+// projectile from the same volley already hit something
+func (ps *PlayerSession) recordHit(volley uint64) {
+	ps.counters.mu.Lock()
+	defer ps.counters.mu.Unlock()
+	if slices.Contains(ps.counters.hitVolleys[:], volley) {
+		return
+	}
+	ps.counters.hitVolleys[ps.counters.nextVolley] = volley
+	ps.counters.nextVolley = (ps.counters.nextVolley + 1) % recentVolleys
+	ps.counters.hits++
+}
+
+// recordDistance counts distance the player moved
+func (ps *PlayerSession) recordDistance(d float64) {
+	ps.counters.mu.Lock()
+	defer ps.counters.mu.Unlock()
+	ps.counters.distance += d
+}
+
+// SessionStats returns the player's stats for the current session only
+func (ps *PlayerSession) SessionStats() Stats {
+	ps.counters.mu.Lock()
+	defer ps.counters.mu.Unlock()
+	now := time.Now()
+	return Stats{
+		FirstSeen:   ps.ConnectedAt,
+		LastSeen:    now,
+		Sessions:    1,
+		PlaySeconds: int64(now.Sub(ps.ConnectedAt).Seconds()),
+		ShotsFired:  ps.counters.shotsFired,
+		Hits:        ps.counters.hits,
+		Distance:    ps.counters.distance,
+		WeaponShots: maps.Clone(ps.counters.weaponShots),
+	}
+}
+
+// Stats returns the player's lifetime stats, including the current session
+func (ps *PlayerSession) Stats() Stats {
+	return ps.stats.Add(ps.SessionStats())
+}
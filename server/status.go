@@ -0,0 +1,36 @@
+package server
+
+import (
+	"sort"
+	"time"
+)
+
+// Status is a public summary of the server, for server lists and status pages
+type Status struct {
+	Map           string   `json:"map"`
+	Players       int      `json:"players"`
+	MaxPlayers    int      `json:"max_players"`
+	PlayerNames   []string `json:"player_names"`
+	Queued        int      `json:"queued"`     // Connections waiting for a free slot
+	Spectators    int      `json:"spectators"` // Connections watching without a slot
+	UptimeSeconds int64    `json:"uptime_seconds"`
+	Mode          string   `json:"mode"` // Who may join, e.g. "open" or "allowlist"
+}
+
+// Status returns a snapshot of the server's public state
+func (gs *GameServer) Status() Status {
+	names := gs.PlayerNames()
+	sort.Strings(names)
+	if names == nil {
+		names = []string{}
+	}
+	return Status{
+		Map:           gs.MapName,
+		Players:       len(names),
+		MaxPlayers:    gs.MaxPlayers,
+		PlayerNames:   names,
+		Queued:        gs.QueueLength(),
+		Spectators:    gs.SpectatorCount(),
+		UptimeSeconds: int64(time.Since(gs.StartedAt).Seconds()),
+	}
+}
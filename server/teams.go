@@ -0,0 +1,57 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SetTeam puts the player on a team, named like players are, or takes them off
+// their team if the name is empty. The player's party, if any, moves with them.
+func (gs *GameServer) SetTeam(session *PlayerSession, team string) error {
+	if team != "" && sanitizeName(team) != team {
+		return fmt.Errorf("team names must be 1-%d letters, digits, or -_.", maxNameLength)
+	}
+
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+
+	// Match an existing team's capitalization so "Red" and "red" are one team
+	for _, other := range gs.Players {
+		if other.Team != "" && strings.EqualFold(other.Team, team) {
+			team = other.Team
+			break
+		}
+	}
+	if team == "" {
+		session.Log.Infof("Left team %s", session.Team)
+	} else {
+		session.Log.Infof("Joined team %s", team)
+	}
+	if session.Party != nil {
+		for _, member := range session.Party.Members {
+			member.Team = team
+		}
+	}
+	session.Team = team
+	return nil
+}
+
+// Teammates returns the session IDs of players on the session's team,
+// including the session itself, or nil if it isn't on a team
+func (gs *GameServer) Teammates(session *PlayerSession) []string {
+	gs.PlayersMutex.RLock()
+	defer gs.PlayersMutex.RUnlock()
+
+	if session.Team == "" {
+		return nil
+	}
+	var ids []string
+	for id, other := range gs.Players {
+		if other.Team == session.Team {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
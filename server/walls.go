@@ -0,0 +1,77 @@
+package server
+
+import (
+	"math"
+
+	"github.com/chainguard-dev/clog"
+
+	"github.com/imjasonh/terminus/game"
+)
+
+// blastWalls records the damage an explosion does to the breakable walls
+// within its blast radius, for damageWalls to apply after the step's hits.
+// Called by the simulation only.
+func (gs *GameServer) blastWalls(p *game.Projectile) {
+	reach := int(math.Ceil(p.BlastRadius))
+	cx, cy := int(p.Position.X), int(p.Position.Y)
+	floor := gs.Map.Floor(p.Floor)
+	for y := cy - reach; y <= cy+reach; y++ {
+		for x := cx - reach; x <= cx+reach; x++ {
+			center := game.Vector{X: float64(x) + 0.5, Y: float64(y) + 0.5}
+			if floor.GetWallType(x, y) == game.BreakableWall && center.Sub(p.Position).Length() <= p.BlastRadius+0.5 {
+				gs.wallHits = append(gs.wallHits, game.WallHit{X: x, Y: y, Floor: p.Floor, Damage: p.Damage})
+			}
+		}
+	}
+}
+
+// damageWalls applies damage done to walls this step, by projectiles that
+// stopped at them and by explosions, crumbling breakable walls that have
+// taken enough into open floor for everyone, with a burst of debris. If the
+// server saves its world, the changed map is saved.
+func (gs *GameServer) damageWalls(struck []game.WallHit) {
+	hits := append(gs.wallHits, struck...)
+	gs.wallHits = gs.wallHits[:0]
+	if len(hits) == 0 {
+		return
+	}
+
+	gs.PlayersMutex.Lock()
+	var crumbled []game.WallHit
+	for _, hit := range hits {
+		if gs.Map.Floor(hit.Floor).GetWallType(hit.X, hit.Y) != game.BreakableWall {
+			continue
+		}
+		cell := [3]int{hit.X, hit.Y, hit.Floor}
+		if gs.wallDamage == nil {
+			gs.wallDamage = make(map[[3]int]float64)
+		}
+		gs.wallDamage[cell] += hit.Damage
+		if gs.wallDamage[cell] < game.BreakableWallHealth {
+			continue
+		}
+		delete(gs.wallDamage, cell)
+		gs.Map = gs.Map.WithFloorCell(hit.Floor, hit.X, hit.Y, 0)
+		crumbled = append(crumbled, hit)
+	}
+	worldMap := gs.Map
+	gs.PlayersMutex.Unlock()
+	if len(crumbled) == 0 {
+		return
+	}
+
+	gs.EntitiesMutex.Lock()
+	for _, hit := range crumbled {
+		for _, e := range game.NewDebris(game.Vector{X: float64(hit.X) + 0.5, Y: float64(hit.Y) + 0.5}) {
+			e.Floor = hit.Floor
+			gs.addEntity(e)
+		}
+	}
+	gs.EntitiesMutex.Unlock()
+
+	if gs.WorldFile != "" {
+		if err := game.SaveMapToFile(worldMap, gs.WorldFile); err != nil {
+			clog.Warnf("Failed to save the world: %v", err)
+		}
+	}
+}
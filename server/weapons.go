@@ -0,0 +1,59 @@
+package server
+
+import "github.com/imjasonh/terminus/game"
+
+// SwitchWeapon starts switching the session player to another weapon,
+// cancelling any reload, and returns false if it's already selected. The
+// simulation counts weapon timers down, so they're changed under
+// PlayersMutex.
+func (gs *GameServer) SwitchWeapon(session *PlayerSession, t game.WeaponType) bool {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+	return session.Player.SwitchWeapon(t)
+}
+
+// fireHeld fires the session player's continuous weapon, like the
+// flamethrower, each step they hold fire, as often as its cooldown allows.
+// Bursts count toward their shots, but aren't announced as events like
+// single shots are. It spends ammo and adds projectiles, so callers hold
+// PlayersMutex for writing, as updatePlayers does.
+func (gs *GameServer) fireHeld(session *PlayerSession) {
+	if !game.GetWeapon(session.Player.Weapon).Continuous {
+		return
+	}
+	if weapon, _, _, ok := gs.shoot(session); ok {
+		session.recordShot(weapon.Name)
+	}
+}
+
+// ToggleTorch lights the session player's torch or puts it out, returning
+// what happened to tell them. The simulation burns its fuel, so it's toggled
+// under PlayersMutex.
+func (gs *GameServer) ToggleTorch(session *PlayerSession) string {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+	player := session.Player
+	wasLit := player.TorchLit
+	lit := player.ToggleTorch()
+	session.Log.Debugf("Torch lit: %v", lit)
+	switch {
+	case lit:
+		return "Torch lit"
+	case wasLit:
+		return "Torch out"
+	default:
+		return "Your torch is out of fuel"
+	}
+}
+
+// Reload starts reloading the session player's current weapon, returning
+// false if it's already full or reloading
+func (gs *GameServer) Reload(session *PlayerSession) bool {
+	gs.PlayersMutex.Lock()
+	defer gs.PlayersMutex.Unlock()
+	if !session.Player.Reload() {
+		return false
+	}
+	session.Log.Debugf("Reloading %s", game.GetWeapon(session.Player.Weapon).Name)
+	return true
+}
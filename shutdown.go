@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// shutdownTimeout is how long the server waits for sessions to end, saving
+// profiles and stats, before it closes the remaining connections
+const shutdownTimeout = 5 * time.Second
+
+// serverCtx is done once the server is asked to shut down, ending every session
+var serverCtx = context.Background()
+
+// liveSessions counts connections still being handled
+var liveSessions atomic.Int64
+
+// sessionContext returns a context for handling a connection, done when the
+// client disconnects or the server shuts down. The caller must cancel it once
+// the connection is handled, which stops the connection's goroutines.
+func sessionContext(s conn) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(s.Context())
+	stop := context.AfterFunc(serverCtx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// waitForSessions waits up to timeout for every connection to be handled, and
+// reports whether they all were
+func waitForSessions(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for liveSessions.Load() > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return true
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+
+	"github.com/imjasonh/terminus/engine"
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/input"
+	"github.com/imjasonh/terminus/locale"
+	"github.com/imjasonh/terminus/renderer"
+	"github.com/imjasonh/terminus/screen"
+	"github.com/imjasonh/terminus/server"
+)
+
+// spectatorUser is the login name that connects as a spectator, e.g. ssh spectate@host
+const spectatorUser = "spectate"
+
+// spectatorFlySpeed is how fast the free camera moves, in map cells per second
+const spectatorFlySpeed = 6.0
+
+// spectator is a camera that watches the match without a player slot, either
+// flying freely or following a player's point of view
+type spectator struct {
+	camera    *game.Player
+	following string // Session ID of the followed player, or empty for the free camera
+	holds     *input.HoldTracker
+}
+
+// newSpectator creates a spectator with a free camera at the first open spot
+// on the map as of a snapshot
+func newSpectator(snapshot *server.Snapshot) *spectator {
+	camera := game.NewPlayer(1.5, 1.5)
+	worldMap := snapshot.Map
+	for y := range worldMap.Height {
+		for x := range worldMap.Width {
+			if !worldMap.IsWall(x, y) {
+				camera.Position = game.Vector{X: float64(x) + 0.5, Y: float64(y) + 0.5}
+				return &spectator{camera: camera, holds: input.NewHoldTracker()}
+			}
+		}
+	}
+	return &spectator{camera: camera, holds: input.NewHoldTracker()}
+}
+
+// cycle follows the next (step 1) or previous (step -1) player by name,
+// starting from the first or last player when on the free camera
+func (sp *spectator) cycle(snapshot *server.Snapshot, step int) {
+	targets := snapshot.Players
+	if len(targets) == 0 {
+		sp.following = ""
+		return
+	}
+	current := -1
+	for i, t := range targets {
+		if t.ID == sp.following {
+			current = i
+		}
+	}
+	next := 0
+	switch {
+	case current >= 0:
+		next = (current + step + len(targets)) % len(targets)
+	case step < 0:
+		next = len(targets) - 1
+	}
+	sp.following = targets[next].ID
+}
+
+// target returns the followed player's state in the snapshot, switching to the
+// free camera (from the player's last view) if they've left
+func (sp *spectator) target(snapshot *server.Snapshot) (*server.PlayerState, bool) {
+	if sp.following == "" {
+		return nil, false
+	}
+	state, ok := snapshot.Player(sp.following)
+	if !ok {
+		sp.following = ""
+		return nil, false
+	}
+	return state, true
+}
+
+// view returns the camera to render from and the session whose player should be
+// hidden from the view, if following someone
+func (sp *spectator) view(snapshot *server.Snapshot) (*game.Player, string) {
+	state, ok := sp.target(snapshot)
+	if !ok {
+		return sp.camera, ""
+	}
+	// Copy the followed player's view, so leaving follow mode starts from there
+	p := &state.Player
+	sp.camera.Position, sp.camera.Direction, sp.camera.CameraPlane = p.Position, p.Direction, p.CameraPlane
+	sp.camera.Floor = p.Floor
+	sp.camera.Weapon = p.Weapon
+	return sp.camera, state.ID
+}
+
+// handleKey applies a spectator key, returning false if they asked to leave
+func (sp *spectator) handleKey(snapshot *server.Snapshot, key input.Key) bool {
+	keymap := input.DefaultKeymap()
+	switch key.Code {
+	case input.KeyUp:
+		sp.fly(input.ActionMoveForward)
+	case input.KeyDown:
+		sp.fly(input.ActionMoveBackward)
+	case input.KeyLeft:
+		sp.fly(input.ActionTurnLeft)
+	case input.KeyRight:
+		sp.fly(input.ActionTurnRight)
+	case input.KeyEscape:
+		return false
+	case input.KeyRune:
+		switch key.Rune {
+		case 3: // Ctrl+C
+			return false
+		case 'n', 'N':
+			sp.cycle(snapshot, 1)
+		case 'p', 'P':
+			sp.cycle(snapshot, -1)
+		case 'f', 'F':
+			sp.following = ""
+		default:
+			if action, ok := keymap.Lookup(key.Rune); ok && action.IsContinuous() && action != input.ActionSprint {
+				sp.fly(action)
+			}
+		}
+	}
+	return true
+}
+
+// fly holds a movement action, leaving follow mode from the current view
+func (sp *spectator) fly(action input.Action) {
+	sp.following = ""
+	sp.holds.Press(action)
+}
+
+// update moves the free camera by the held movement keys, passing through
+// walls, in the map as of a snapshot
+func (sp *spectator) update(snapshot *server.Snapshot, deltaTime float64) {
+	if sp.following != "" {
+		sp.holds.Update(deltaTime)
+		return
+	}
+	cam := sp.camera
+	step := spectatorFlySpeed * deltaTime
+	right := game.Vector{X: cam.Direction.Y, Y: -cam.Direction.X}
+	var offset game.Vector
+	offset = offset.Add(cam.Direction.Scale(step * sp.holds.Strength(input.ActionMoveForward)))
+	offset = offset.Sub(cam.Direction.Scale(step * sp.holds.Strength(input.ActionMoveBackward)))
+	offset = offset.Add(right.Scale(step * sp.holds.Strength(input.ActionStrafeRight)))
+	offset = offset.Sub(right.Scale(step * sp.holds.Strength(input.ActionStrafeLeft)))
+	cam.Fly(offset, snapshot.Map)
+	// As for players, RotateRight turns the view left since the map's Y axis points down
+	if strength := sp.holds.Strength(input.ActionTurnLeft); strength > 0 {
+		cam.RotateRight(deltaTime * strength)
+	}
+	if strength := sp.holds.Strength(input.ActionTurnRight); strength > 0 {
+		cam.RotateLeft(deltaTime * strength)
+	}
+	sp.holds.Update(deltaTime)
+}
+
+// processInput handles a spectator's pending keys, up to a cap per tick,
+// returning false if they asked to leave
+func (sp *spectator) processInput(snapshot *server.Snapshot, inputCh <-chan input.Key) bool {
+	for range engine.MaxKeysPerTick {
+		select {
+		case key := <-inputCh:
+			if !sp.handleKey(snapshot, key) {
+				return false
+			}
+		default:
+			return true
+		}
+	}
+	return true
+}
+
+// runSpectator shows the match to a connection without giving it a player
+// slot. A queued spectator, which has the connection it joins as, joins the
+// game once a slot opens, and the new player session is returned; otherwise
+// the session is nil when the spectator leaves. It also returns the latest
+// window size.
+func runSpectator(ctx context.Context, s conn, sessionID string, log *clog.Logger, inputCh <-chan input.Key, winCh <-chan winSize, window winSize, caps screen.Capabilities, queued *server.Connection) (*server.PlayerSession, winSize) {
+	width, height := window.Width, window.Height
+	if width <= 0 || height <= 0 {
+		width, height = 80, 24
+	}
+	gameScreen := screen.NewScreen(width, height)
+	gameScreen.SetCapabilities(caps)
+	gameRenderer := renderer.NewRenderer(width, height)
+	gameRenderer.Monochrome = caps.ColorDepth == screen.Monochrome
+
+	var snapshots server.SnapshotBuffer
+	latest, nextSnapshot := gameServer.LatestSnapshot()
+	snapshots.Push(latest)
+	sp := newSpectator(latest)
+	sp.cycle(latest, 1) // Start by following someone, if anyone's playing
+
+	emotes := gameServer.Events.Subscribe(server.EventEmote)
+	defer emotes.Close()
+	var bubbles server.EmoteBubbles
+
+	// Write from a separate goroutine, dropping stale frames if the connection is slow
+	out := engine.NewFrameWriter(connSink{s})
+	defer out.Close()
+
+	out.Print("\x1b[?25l\x1b[2J\x1b[H")
+
+	ticker := time.NewTicker(frameInterval())
+	defer ticker.Stop()
+	lastTime := time.Now()
+	lastJoinCheck := lastTime
+
+	for {
+		select {
+		case <-ticker.C:
+			currentTime := time.Now()
+			deltaTime := currentTime.Sub(lastTime).Seconds()
+			lastTime = currentTime
+
+			if queued != nil && currentTime.Sub(lastJoinCheck) >= queuePollInterval {
+				lastJoinCheck = currentTime
+				if playerSession, err := gameServer.AddPlayer(sessionID, s.KeyFingerprint(), s.User(), *queued); err == nil {
+					return playerSession, window
+				}
+			}
+
+			snapshot := snapshots.At(currentTime)
+			if !sp.processInput(snapshot, inputCh) {
+				out.Print("\x1b[0m\x1b[?25h\x1b[2J\x1b[H")
+				return nil, window
+			}
+			sp.update(snapshot, deltaTime)
+
+			camera, hidden := sp.view(snapshot)
+			otherPlayers := snapshot.OtherPlayers(hidden)
+			entities := snapshot.VisibleEntities(hidden, bubbles.Labels(snapshot, camera.Position, currentTime))
+
+			mode := "FREE CAMERA"
+			if state, ok := sp.target(snapshot); ok {
+				mode = "WATCHING " + state.Name
+			}
+			if len(snapshot.Map.Floors) > 0 {
+				mode += fmt.Sprintf(" (FLOOR %d)", camera.Floor+1)
+			}
+			gameScreen.SetStatus(fmt.Sprintf("%s | N/P: cycle players  F: free camera  ESC: leave", mode))
+			debugMsg := fmt.Sprintf("Spectating | Players: %d/%d | Spectators: %d",
+				len(snapshot.Players), gameServer.MaxPlayers, gameServer.SpectatorCount())
+			if queued != nil {
+				debugMsg += fmt.Sprintf(" | #%d in line", gameServer.QueuePosition(sessionID))
+			}
+			gameScreen.SetDebugMessage(debugMsg)
+
+			var markers []renderer.CompassMarker
+			for _, other := range otherPlayers {
+				if other.IsInvisible() || other.Floor != camera.Floor {
+					continue
+				}
+				markers = append(markers, renderer.CompassMarker{Position: other.Position, Glyph: other.Sprite().Glyph})
+			}
+			gameScreen.SetCompass(renderer.BuildCompass(gameScreen.Width, camera, markers))
+
+			gameRenderer.Ambient = snapshot.Ambient
+			gameRenderer.Render(camera, snapshot.Map.Floor(camera.Floor), gameScreen, snapshot.Lights(false), entities)
+			if err := out.WriteFrame(gameScreen.Frame()); err != nil {
+				log.Debugf("Failed to write frame, ending spectator session: %v", err)
+				return nil, window
+			}
+
+		case <-nextSnapshot:
+			latest, nextSnapshot = gameServer.LatestSnapshot()
+			snapshots.Push(latest)
+
+		case event := <-emotes.Events():
+			bubbles.Show(event)
+
+		case win := <-winCh:
+			if win.Width > 0 && win.Height > 0 {
+				window = win
+				gameScreen.Resize(win.Width, win.Height)
+				gameRenderer.Resize(win.Width, win.Height)
+			}
+
+		case <-ctx.Done():
+			return nil, window
+		}
+	}
+}
+
+// spectate runs a connection that asked to watch rather than play
+func spectate(ctx context.Context, s conn, sessionID string, log *clog.Logger, isAdmin bool, inputCh <-chan input.Key, winCh <-chan winSize, ptyReq ptyInfo) {
+	if err := gameServer.AddSpectator(sessionID, isAdmin); err != nil {
+		log.Info("Rejected spectator", "error", err)
+		fmt.Fprintf(s, "Connection rejected: %s\r\n", err.Error())
+		s.Close()
+		return
+	}
+	start := time.Now()
+	log.Info("Spectator connected", "admin", isAdmin)
+	defer func() {
+		gameServer.RemoveSpectator(sessionID)
+		log.Infof("Spectator disconnected after %s", time.Since(start).Round(time.Second))
+	}()
+
+	defer fmt.Fprint(s, "\x1b[?25h") // Show the cursor again on exit
+
+	lang := locale.FromEnviron(ptyReq.Environ)
+	caps, window, ok := negotiateTerminal(ctx, s, inputCh, winCh, ptyReq, &lang, nil)
+	if !ok {
+		fmt.Fprint(s, "\x1b[2J\x1b[H")
+		return
+	}
+	runSpectator(ctx, s, sessionID, log, inputCh, winCh, window, caps, nil)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+
+	"github.com/imjasonh/terminus/server"
+)
+
+// leaderboardSize is how many players /leaderboard returns
+const leaderboardSize = 20
+
+// serveStatus serves the server's status and leaderboard as JSON at /status
+// and /leaderboard until the listener fails
+func serveStatus(addr, mode string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		status := gameServer.Status()
+		status.Mode = mode
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*") // Let websites show the status
+		w.Header().Set("Cache-Control", "no-cache")
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			clog.Debugf("Failed to write status: %v", err)
+		}
+	})
+
+	mux.HandleFunc("GET /leaderboard", func(w http.ResponseWriter, r *http.Request) {
+		if gameServer.Leaderboard == nil {
+			http.Error(w, "this server doesn't keep a leaderboard", http.StatusNotFound)
+			return
+		}
+		stat := r.URL.Query().Get("stat")
+		if stat == "" {
+			stat = server.DefaultLeaderboardStat
+		}
+		entries, err := gameServer.Leaderboard.Top(stat, leaderboardSize)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if entries == nil {
+			entries = []server.LeaderboardEntry{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Cache-Control", "no-cache")
+		if err := json.NewEncoder(w).Encode(entries); err != nil {
+			clog.Debugf("Failed to write leaderboard: %v", err)
+		}
+	})
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      5 * time.Second,
+	}
+	clog.Infof("Status endpoint on http://%s/status", addr)
+	clog.Errorf("Status endpoint stopped: %v", srv.ListenAndServe())
+}
@@ -0,0 +1,57 @@
+package stream
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/google/uuid"
+)
+
+// mqttTimeout is how long connecting waits for the broker at first, and how
+// long a publish waits to be handed to it
+const mqttTimeout = 5 * time.Second
+
+// mqttBroker publishes to MQTT topics, like terminus/events/killed, at most
+// once (QoS 0)
+type mqttBroker struct {
+	client mqtt.Client
+}
+
+// dialMQTT connects to an MQTT broker, waiting briefly so the first events
+// aren't lost, and reconnecting whenever the connection is lost
+func dialMQTT(u *url.URL, name string) (broker, error) {
+	scheme := "tcp"
+	if u.Scheme == "mqtts" {
+		scheme = "ssl"
+	}
+	opts := mqtt.NewClientOptions().
+		AddBroker((&url.URL{Scheme: scheme, User: u.User, Host: u.Host}).String()).
+		SetClientID("terminus-" + uuid.New().String()[:8]).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetOnConnectHandler(func(mqtt.Client) { clog.Infof("Connected to %s", name) }).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) { clog.Warnf("Lost connection to %s: %v", name, err) })
+	client := mqtt.NewClient(opts)
+	if !client.Connect().WaitTimeout(mqttTimeout) {
+		clog.Warnf("Couldn't connect to %s yet; still trying", name)
+	}
+	return &mqttBroker{client: client}, nil
+}
+
+func (b *mqttBroker) publish(topic []string, data []byte, retain bool) error {
+	if !b.client.IsConnectionOpen() {
+		return mqtt.ErrNotConnected // Rather than queueing behind the reconnection
+	}
+	token := b.client.Publish(strings.Join(topic, "/"), 0, retain, data)
+	if !token.WaitTimeout(mqttTimeout) {
+		return mqtt.ErrNotConnected
+	}
+	return token.Error()
+}
+
+func (b *mqttBroker) close() {
+	b.client.Disconnect(250)
+}
@@ -0,0 +1,44 @@
+package stream
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/nats-io/nats.go"
+)
+
+// natsBroker publishes to NATS subjects, like terminus.events.killed
+type natsBroker struct {
+	conn *nats.Conn
+}
+
+// dialNATS connects to a NATS server, reconnecting whenever the connection
+// is lost
+func dialNATS(u *url.URL, name string) (broker, error) {
+	conn, err := nats.Connect(u.String(),
+		nats.Name("terminus"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.ConnectHandler(func(*nats.Conn) { clog.Infof("Connected to %s", name) }),
+		nats.ReconnectHandler(func(*nats.Conn) { clog.Infof("Reconnected to %s", name) }),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				clog.Warnf("Lost connection to %s: %v", name, err)
+			}
+		}))
+	if err != nil {
+		return nil, err
+	}
+	return &natsBroker{conn: conn}, nil
+}
+
+// publish sends a message to a subject. NATS keeps no messages, so retain is
+// ignored.
+func (b *natsBroker) publish(topic []string, data []byte, retain bool) error {
+	return b.conn.Publish(strings.Join(topic, "."), data)
+}
+
+func (b *natsBroker) close() {
+	b.conn.Drain()
+}
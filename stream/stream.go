@@ -0,0 +1,135 @@
+// Package stream publishes gameplay events to a NATS or MQTT broker as they
+// happen, so dashboards, bots, and stream overlays can react to kills, joins,
+// and scores without polling the server.
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+
+	"github.com/imjasonh/terminus/server"
+)
+
+// scoreKinds are the events after which the match's scores are published
+var scoreKinds = []server.EventKind{
+	server.EventJoined,
+	server.EventLeft,
+	server.EventRenamed,
+	server.EventKilled,
+	server.EventMatchStarted,
+	server.EventMatchEnded,
+}
+
+// Message describes an event, published as JSON to a topic named after its
+// kind, like terminus.events.killed on NATS or terminus/events/killed on MQTT
+type Message struct {
+	Event   string        `json:"event"` // The kind of event, like "killed"
+	Time    time.Time     `json:"time"`
+	Message string        `json:"message"` // Describes the event for people, like "alice joined"
+	Player  string        `json:"player,omitempty"`
+	Detail  string        `json:"detail,omitempty"`
+	Target  string        `json:"target,omitempty"`
+	X       float64       `json:"x,omitempty"` // Where it happened, for events like kills and explosions
+	Y       float64       `json:"y,omitempty"`
+	Match   *server.Match `json:"match,omitempty"` // The results, for match_ended
+}
+
+// Scores are the scores in the match being played, published to the scores
+// topic (terminus.scores or terminus/scores) whenever they may have changed.
+// On MQTT they're retained, so new subscribers get the latest right away.
+type Scores struct {
+	Time    time.Time            `json:"time"`
+	Players []server.MatchPlayer `json:"players"` // Most kills first; empty between matches
+}
+
+// broker is a connection to a message broker
+type broker interface {
+	// publish sends a message to a topic, made of parts joined by the
+	// broker's separator. A retained message is kept for later subscribers,
+	// where the broker supports it.
+	publish(topic []string, data []byte, retain bool) error
+	close()
+}
+
+// Stream publishes a server's events to a broker
+type Stream struct {
+	broker broker
+	prefix string
+	name   string // Names the broker in logs, without any password
+}
+
+// Dial connects to the broker at a URL, like nats://localhost:4222 or
+// mqtt://localhost:1883 (tls:// and mqtts:// for TLS), whose topics will all
+// start with prefix. Credentials can be given in the URL. If the broker can't
+// be reached yet, the stream keeps trying in the background, and events
+// published meanwhile may be lost.
+func Dial(rawURL, prefix string) (*Stream, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("%q isn't a broker URL, like nats://localhost:4222 or mqtt://localhost:1883", rawURL)
+	}
+	s := &Stream{prefix: prefix, name: u.Redacted()}
+	switch u.Scheme {
+	case "nats", "tls":
+		s.broker, err = dialNATS(u, s.name)
+	case "mqtt", "mqtts":
+		s.broker, err = dialMQTT(u, s.name)
+	default:
+		return nil, fmt.Errorf("unknown broker scheme %q: use nats, tls, mqtt, or mqtts", u.Scheme)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", s.name, err)
+	}
+	return s, nil
+}
+
+// Run publishes events from the bus until the process exits. Events meant
+// only for some players, like whispers, are never published.
+func (s *Stream) Run(gs *server.GameServer) {
+	sub := gs.Events.Subscribe(server.EventKinds()...)
+	defer sub.Close()
+	defer s.broker.close()
+
+	for e := range sub.Events() {
+		if len(e.Recipients) > 0 {
+			continue
+		}
+		s.send([]string{s.prefix, "events", e.Kind.String()}, Message{
+			Event:   e.Kind.String(),
+			Time:    e.Time,
+			Message: e.String(),
+			Player:  e.Name,
+			Detail:  e.Detail,
+			Target:  e.Target,
+			X:       e.Position.X,
+			Y:       e.Position.Y,
+			Match:   e.Match,
+		}, false)
+
+		if slices.Contains(scoreKinds, e.Kind) {
+			players := gs.Scores()
+			if players == nil {
+				players = []server.MatchPlayer{}
+			}
+			s.send([]string{s.prefix, "scores"}, Scores{Time: e.Time, Players: players}, true)
+		}
+	}
+}
+
+// send publishes a value as JSON, logging failures at debug level since the
+// broker may just be reconnecting
+func (s *Stream) send(topic []string, v any, retain bool) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		clog.Warnf("Failed to encode %v for %s: %v", topic, s.name, err)
+		return
+	}
+	if err := s.broker.publish(topic, data, retain); err != nil {
+		clog.Debugf("Failed to publish to %s: %v", s.name, err)
+	}
+}
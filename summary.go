@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/imjasonh/terminus/server"
+)
+
+// showMatchSummary prints the player's stats for the session they just ended,
+// where their terminal keeps it after they disconnect. Until the game has
+// rounds, each session counts as a match.
+func showMatchSummary(w io.Writer, playerSession *server.PlayerSession) {
+	stats := playerSession.SessionStats()
+	favorite := stats.FavoriteWeapon()
+	if favorite == "" {
+		favorite = "none"
+	}
+
+	fmt.Fprintf(w, "\x1b[0m\r\n  \x1b[1mMatch summary for %s\x1b[0m\r\n\r\n", playerSession.Name)
+	fmt.Fprintf(w, "  Played           %s\r\n", (time.Duration(stats.PlaySeconds) * time.Second).String())
+	fmt.Fprintf(w, "  Shots fired      %d\r\n", stats.ShotsFired)
+	fmt.Fprintf(w, "  Hits             %d (%.0f%% accuracy)\r\n", stats.Hits, stats.Accuracy()*100)
+	fmt.Fprintf(w, "  Distance         %.0fm\r\n", stats.Distance)
+	fmt.Fprintf(w, "  Favorite weapon  %s\r\n\r\n", favorite)
+}
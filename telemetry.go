@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.43.0"
+)
+
+// telemetryFlush is how long shutdown waits for the last spans and metrics
+// to be exported
+const telemetryFlush = 5 * time.Second
+
+// tracer traces each connection's lifecycle: a "session" span with children
+// for waiting in the queue, negotiating the terminal, the welcome screen, and
+// play. Until startTelemetry runs, it records nothing.
+var tracer = otel.Tracer("github.com/imjasonh/terminus")
+
+// startTelemetry exports traces and metrics over OTLP/gRPC, configured by the
+// standard OTEL_EXPORTER_OTLP_* environment variables, and reports the
+// player and connection counts. It returns a function that flushes and stops
+// the exporters.
+func startTelemetry(ctx context.Context) (func(), error) {
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName("terminus")))
+	if err != nil {
+		return nil, err
+	}
+	traces, err := otlptracegrpc.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := otlpmetricgrpc.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(traces), sdktrace.WithResource(res))
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metrics)), sdkmetric.WithResource(res))
+	otel.SetTracerProvider(tp)
+	otel.SetMeterProvider(mp)
+
+	meter := otel.Meter("github.com/imjasonh/terminus")
+	_, err = meter.Int64ObservableGauge("terminus.players",
+		metric.WithDescription("Connected players"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(int64(gameServer.GetPlayerCount()))
+			return nil
+		}))
+	if err != nil {
+		return nil, err
+	}
+	_, err = meter.Int64ObservableGauge("terminus.connections",
+		metric.WithDescription("Connections being handled, including spectators and queued players"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(liveSessions.Load())
+			return nil
+		}))
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), telemetryFlush)
+		defer cancel()
+		if err := errors.Join(tp.Shutdown(ctx), mp.Shutdown(ctx)); err != nil {
+			clog.Warnf("Failed to flush telemetry: %v", err)
+		}
+	}, nil
+}
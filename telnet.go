@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+
+	"github.com/imjasonh/terminus/auth"
+)
+
+// Telnet protocol bytes (RFC 854 and option RFCs)
+const (
+	telnetIAC  = 255
+	telnetDont = 254
+	telnetDo   = 253
+	telnetWont = 252
+	telnetWill = 251
+	telnetSB   = 250
+	telnetSE   = 240
+
+	telnetOptEcho  = 1
+	telnetOptSGA   = 3  // Suppress go-ahead, for character-at-a-time mode
+	telnetOptTType = 24 // Terminal type
+	telnetOptNAWS  = 31 // Negotiate about window size
+
+	telnetTTypeIs   = 0
+	telnetTTypeSend = 1
+)
+
+// telnetNegotiateTimeout is how long to wait for the client's window size and terminal type
+const telnetNegotiateTimeout = time.Second
+
+// serveTelnet accepts plain telnet connections until the listener fails
+func serveTelnet(addr string, authOpts auth.Options) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		clog.Errorf("Telnet listener failed: %v", err)
+		return
+	}
+	clog.Infof("Telnet listener on %s", addr)
+	for {
+		nc, err := l.Accept()
+		if err != nil {
+			clog.Errorf("Telnet listener stopped: %v", err)
+			return
+		}
+		go func() {
+			c := newTelnetConn(nc)
+			defer c.Close()
+			if authOpts.Mode() != "open" && !promptInviteCode(c, authOpts) {
+				return
+			}
+			handleConn(c)
+		}()
+	}
+}
+
+// promptInviteCode asks a telnet client for the invite code, without echoing it
+func promptInviteCode(c *telnetConn, authOpts auth.Options) bool {
+	if authOpts.InviteCode == "" {
+		io.WriteString(c, "This server only accepts allowlisted SSH keys.\r\n")
+		return false
+	}
+	io.WriteString(c, "This is a private Terminus server.\r\nInvite code: ")
+	// Read a byte at a time so nothing typed after Enter is lost
+	var code strings.Builder
+	b := make([]byte, 1)
+	for code.Len() < 256 {
+		if _, err := c.Read(b); err != nil {
+			return false
+		}
+		if b[0] == '\r' || b[0] == '\n' {
+			break
+		}
+		code.WriteByte(b[0])
+	}
+	io.WriteString(c, "\r\n")
+	if !authOpts.AdmitKeyless(c.RemoteAddr(), code.String()) {
+		io.WriteString(c, "Wrong invite code.\r\n")
+		return false
+	}
+	return true
+}
+
+// telnetConn adapts a telnet connection to a conn, handling option negotiation
+// so only the player's keys reach Read
+type telnetConn struct {
+	nc     net.Conn
+	ctx    context.Context
+	cancel context.CancelFunc
+	data   *io.PipeReader
+	winCh  chan winSize
+
+	mu         sync.Mutex
+	term       string
+	window     winSize
+	gotNAWS    bool
+	gotTType   bool
+	negotiated chan struct{} // Closed once the window size and terminal type are known
+	closeOnce  sync.Once
+}
+
+func newTelnetConn(nc net.Conn) *telnetConn {
+	ctx, cancel := context.WithCancel(context.Background())
+	pr, pw := io.Pipe()
+	c := &telnetConn{
+		nc:         nc,
+		ctx:        ctx,
+		cancel:     cancel,
+		data:       pr,
+		winCh:      make(chan winSize, 1),
+		window:     winSize{Width: 80, Height: 24},
+		negotiated: make(chan struct{}),
+	}
+
+	// Character mode: the server echoes (by not echoing), no go-aheads, and
+	// ask for the window size and terminal type
+	nc.Write([]byte{
+		telnetIAC, telnetWill, telnetOptEcho,
+		telnetIAC, telnetWill, telnetOptSGA,
+		telnetIAC, telnetDo, telnetOptSGA,
+		telnetIAC, telnetDo, telnetOptNAWS,
+		telnetIAC, telnetDo, telnetOptTType,
+	})
+
+	go func() {
+		err := c.parse(bufio.NewReader(nc), pw)
+		pw.CloseWithError(err)
+		cancel()
+	}()
+	return c
+}
+
+// parse strips telnet commands from the client's stream, writing the
+// remaining bytes to w
+func (c *telnetConn) parse(r *bufio.Reader, w io.Writer) error {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return io.EOF
+		}
+
+		switch {
+		case b == '\r':
+			// Enter arrives as CR LF or CR NUL; deliver just the CR
+			if next, err := r.Peek(1); err == nil && (next[0] == '\n' || next[0] == 0) {
+				r.ReadByte()
+			}
+		case b != telnetIAC:
+		default:
+			cmd, err := r.ReadByte()
+			if err != nil {
+				return io.EOF
+			}
+			switch cmd {
+			case telnetIAC:
+				// Escaped 0xFF data byte
+			case telnetWill, telnetWont, telnetDo, telnetDont:
+				opt, err := r.ReadByte()
+				if err != nil {
+					return io.EOF
+				}
+				if cmd == telnetWill && opt == telnetOptTType {
+					c.nc.Write([]byte{telnetIAC, telnetSB, telnetOptTType, telnetTTypeSend, telnetIAC, telnetSE})
+				}
+				if cmd == telnetWont && (opt == telnetOptTType || opt == telnetOptNAWS) {
+					c.negotiatedOption(opt)
+				}
+				continue
+			case telnetSB:
+				sub, err := readSubnegotiation(r)
+				if err != nil {
+					return io.EOF
+				}
+				c.subnegotiation(sub)
+				continue
+			default:
+				continue // Other commands, like NOP and go-ahead, carry no data
+			}
+		}
+
+		if _, err := w.Write([]byte{b}); err != nil {
+			return err
+		}
+	}
+}
+
+// readSubnegotiation reads the body of an IAC SB ... IAC SE sequence
+func readSubnegotiation(r *bufio.Reader) ([]byte, error) {
+	var sub []byte
+	for len(sub) < 256 {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if b == telnetIAC {
+			next, err := r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			if next == telnetSE {
+				return sub, nil
+			}
+			b = next // IAC IAC is a literal 0xFF
+		}
+		sub = append(sub, b)
+	}
+	return sub, nil
+}
+
+// subnegotiation handles window size and terminal type reports
+func (c *telnetConn) subnegotiation(sub []byte) {
+	if len(sub) == 0 {
+		return
+	}
+	switch {
+	case sub[0] == telnetOptNAWS && len(sub) >= 5:
+		win := winSize{Width: int(binary.BigEndian.Uint16(sub[1:3])), Height: int(binary.BigEndian.Uint16(sub[3:5]))}
+		if win.Width == 0 || win.Height == 0 {
+			return
+		}
+		win = clampWindow(win)
+		c.mu.Lock()
+		c.window = win
+		c.mu.Unlock()
+		// Keep only the latest size if the game hasn't caught up
+		select {
+		case <-c.winCh:
+		default:
+		}
+		c.winCh <- win
+		c.negotiatedOption(telnetOptNAWS)
+	case sub[0] == telnetOptTType && len(sub) >= 2 && sub[1] == telnetTTypeIs:
+		c.mu.Lock()
+		c.term = strings.ToLower(string(sub[2:]))
+		c.mu.Unlock()
+		c.negotiatedOption(telnetOptTType)
+	}
+}
+
+// negotiatedOption records that the client answered about an option
+func (c *telnetConn) negotiatedOption(opt byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch opt {
+	case telnetOptNAWS:
+		c.gotNAWS = true
+	case telnetOptTType:
+		c.gotTType = true
+	}
+	if c.gotNAWS && c.gotTType {
+		c.closeOnce.Do(func() { close(c.negotiated) })
+	}
+}
+
+func (c *telnetConn) Read(p []byte) (int, error) {
+	return c.data.Read(p)
+}
+
+// Write sends output, escaping any 0xFF bytes as telnet requires
+func (c *telnetConn) Write(p []byte) (int, error) {
+	out := p
+	if bytes.IndexByte(p, telnetIAC) >= 0 {
+		out = bytes.ReplaceAll(p, []byte{telnetIAC}, []byte{telnetIAC, telnetIAC})
+	}
+	if _, err := c.nc.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (c *telnetConn) Context() context.Context {
+	return c.ctx
+}
+
+func (c *telnetConn) RemoteAddr() net.Addr {
+	return c.nc.RemoteAddr()
+}
+
+// KeyFingerprint is always empty, since telnet has no keys
+func (c *telnetConn) KeyFingerprint() string {
+	return ""
+}
+
+// User is always empty, since telnet clients don't log in
+func (c *telnetConn) User() string {
+	return ""
+}
+
+// Pty waits briefly for the client to report its window size and terminal type
+func (c *telnetConn) Pty() (ptyInfo, <-chan winSize, bool) {
+	select {
+	case <-c.negotiated:
+	case <-time.After(telnetNegotiateTimeout):
+	case <-c.ctx.Done():
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Drop the initial size report so the game doesn't treat it as a resize
+	select {
+	case <-c.winCh:
+	default:
+	}
+	return ptyInfo{Term: c.term, Window: c.window}, c.winCh, true
+}
+
+func (c *telnetConn) Close() error {
+	c.cancel()
+	return c.nc.Close()
+}
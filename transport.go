@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+
+	"github.com/gliderlabs/ssh"
+
+	"github.com/imjasonh/terminus/auth"
+	"github.com/imjasonh/terminus/engine"
+	"github.com/imjasonh/terminus/input"
+)
+
+// winSize is a terminal size in character cells
+type winSize = engine.Size
+
+// maxWindowSize is the most columns or rows a client can report; larger sizes
+// are clamped, so no client makes the server draw enormous frames
+const maxWindowSize = 1000
+
+// clampWindow limits a reported terminal size to maxWindowSize
+func clampWindow(win winSize) winSize {
+	return winSize{Width: min(win.Width, maxWindowSize), Height: min(win.Height, maxWindowSize)}
+}
+
+// ptyInfo describes the client's terminal
+type ptyInfo struct {
+	Term    string
+	Window  winSize
+	Environ []string // KEY=VALUE entries, like the SSH session environment
+}
+
+// conn is a player's connection to the game, independent of transport. Reads
+// return the bytes the player types; writes send ANSI output to their terminal.
+type conn interface {
+	io.ReadWriter
+	Context() context.Context // Done when the client disconnects
+	RemoteAddr() net.Addr
+	KeyFingerprint() string // SHA256 fingerprint of the client's SSH key, or empty
+	User() string           // Name the client logged in as, e.g. spectatorUser, or empty
+	Pty() (ptyInfo, <-chan winSize, bool)
+	Close() error
+}
+
+// transportName names how a connection reached the server, like "ssh"
+func transportName(s conn) string {
+	switch s.(type) {
+	case sshConn:
+		return "ssh"
+	case *wsConn:
+		return "websocket"
+	case *telnetConn:
+		return "telnet"
+	default:
+		return "unknown"
+	}
+}
+
+// sshConn adapts an SSH session to a conn
+type sshConn struct {
+	ssh.Session
+}
+
+func (c sshConn) Context() context.Context {
+	return c.Session.Context()
+}
+
+func (c sshConn) KeyFingerprint() string {
+	return auth.Fingerprint(c.PublicKey())
+}
+
+func (c sshConn) Pty() (ptyInfo, <-chan winSize, bool) {
+	req, sshWinCh, ok := c.Session.Pty()
+	info := ptyInfo{
+		Term:    req.Term,
+		Window:  clampWindow(winSize{Width: req.Window.Width, Height: req.Window.Height}),
+		Environ: c.Environ(),
+	}
+
+	winCh := make(chan winSize)
+	go func() {
+		for {
+			select {
+			case win, ok := <-sshWinCh:
+				if !ok {
+					return
+				}
+				select {
+				case winCh <- clampWindow(winSize{Width: win.Width, Height: win.Height}):
+				case <-c.Context().Done():
+					return
+				}
+			case <-c.Context().Done():
+				return
+			}
+		}
+	}()
+	return info, winCh, ok
+}
+
+// connInput adapts a connection's decoded keys and resizes to an
+// engine.InputSource, done with the connection's session context
+type connInput struct {
+	ctx   context.Context
+	keys  <-chan input.Key
+	winCh <-chan winSize
+}
+
+func (i connInput) Keys() <-chan input.Key      { return i.keys }
+func (i connInput) Resizes() <-chan engine.Size { return i.winCh }
+func (i connInput) Done() <-chan struct{}       { return i.ctx.Done() }
+
+// connSink adapts a conn to an engine.FrameSink
+type connSink struct {
+	c conn
+}
+
+func (s connSink) WriteFrame(frame []byte) error {
+	_, err := s.c.Write(frame)
+	return err
+}
+
+// handleSSHSession handles incoming SSH connections
+func handleSSHSession(s ssh.Session) {
+	handleConn(sshConn{s})
+}
@@ -0,0 +1,166 @@
+package wasmplugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+
+	"github.com/imjasonh/terminus/game"
+)
+
+// Results of host functions that can fail
+const (
+	resultOK     int32 = 0
+	resultFailed int32 = -1
+)
+
+// Player is a connected player as plugins see it
+type Player struct {
+	Name   string  `json:"name"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Health float64 `json:"health"`
+}
+
+// Entity is an entity in the world as plugins see it
+type Entity struct {
+	ID     uint64  `json:"id"`
+	Kind   string  `json:"kind"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Health float64 `json:"health,omitempty"`
+}
+
+// hostModule defines the functions plugins import from "terminus"
+func (p *Plugin) hostModule() wazero.HostModuleBuilder {
+	return p.runtime.NewHostModuleBuilder("terminus").
+		NewFunctionBuilder().WithFunc(p.log).Export("log").
+		NewFunctionBuilder().WithFunc(p.broadcast).Export("broadcast").
+		NewFunctionBuilder().WithFunc(p.notify).Export("notify").
+		NewFunctionBuilder().WithFunc(p.players).Export("players").
+		NewFunctionBuilder().WithFunc(p.entities).Export("entities").
+		NewFunctionBuilder().WithFunc(p.spawn).Export("spawn").
+		NewFunctionBuilder().WithFunc(p.steer).Export("steer").
+		NewFunctionBuilder().WithFunc(p.cell).Export("cell").
+		NewFunctionBuilder().WithFunc(p.setCell).Export("set_cell")
+}
+
+func (p *Plugin) log(_ context.Context, m api.Module, ptr, size uint32) {
+	if msg, valid := readString(m, ptr, size); valid {
+		clog.Infof("Plugin %s: %s", p.path, msg)
+	}
+}
+
+func (p *Plugin) broadcast(_ context.Context, m api.Module, ptr, size uint32) {
+	if msg, valid := readString(m, ptr, size); valid {
+		p.gs.Broadcast(msg)
+	}
+}
+
+func (p *Plugin) notify(_ context.Context, m api.Module, namePtr, nameSize, msgPtr, msgSize uint32) int32 {
+	name, validName := readString(m, namePtr, nameSize)
+	msg, validMsg := readString(m, msgPtr, msgSize)
+	if !validName || !validMsg {
+		return resultFailed
+	}
+	session, found := p.gs.FindPlayer(name)
+	if !found {
+		return p.fail("notify", fmt.Errorf("no player named %s", name))
+	}
+	session.Notify(msg)
+	return resultOK
+}
+
+func (p *Plugin) players(_ context.Context, m api.Module, bufPtr, bufSize uint32) int32 {
+	snapshot, _ := p.gs.LatestSnapshot()
+	players := []Player{}
+	for _, ps := range snapshot.Players {
+		players = append(players, Player{Name: ps.Name, X: ps.Player.Position.X, Y: ps.Player.Position.Y, Health: ps.Player.Health})
+	}
+	return writeJSON(m, bufPtr, bufSize, players)
+}
+
+func (p *Plugin) entities(_ context.Context, m api.Module, kindPtr, kindSize, bufPtr, bufSize uint32) int32 {
+	kind, valid := readString(m, kindPtr, kindSize)
+	if !valid {
+		return resultFailed
+	}
+	snapshot, _ := p.gs.LatestSnapshot()
+	entities := []Entity{}
+	for _, e := range snapshot.Entities {
+		if string(e.Kind) != kind {
+			continue
+		}
+		entity := Entity{ID: e.ID, Kind: kind, X: e.Position.X, Y: e.Position.Y}
+		if e.Health != nil {
+			entity.Health = e.Health.Current
+		}
+		entities = append(entities, entity)
+	}
+	return writeJSON(m, bufPtr, bufSize, entities)
+}
+
+func (p *Plugin) spawn(_ context.Context, m api.Module, kindPtr, kindSize uint32, x, y float64) int32 {
+	kind, valid := readString(m, kindPtr, kindSize)
+	if !valid {
+		return resultFailed
+	}
+	if p.gs.Cell(int(x), int(y)) != 0 {
+		return p.fail("spawn", fmt.Errorf("(%.1f, %.1f) is inside a wall", x, y))
+	}
+	if kind == "npc" {
+		p.gs.SpawnEntity(game.NewNPC(x, y))
+		return resultOK
+	}
+	t, found := game.FindPickupType(kind)
+	if !found {
+		return p.fail("spawn", fmt.Errorf("can't spawn %q; spawn \"npc\" or a pickup", kind))
+	}
+	p.gs.SpawnEntity(game.NewPickup(game.PickupSpawn{Kind: t.Kind, Position: game.Vector{X: x, Y: y}}))
+	return resultOK
+}
+
+func (p *Plugin) steer(_ context.Context, id uint64, dx, dy, speed float64) int32 {
+	return p.fail("steer", p.gs.SteerEntity(id, game.Vector{X: dx, Y: dy}, speed))
+}
+
+func (p *Plugin) cell(_ context.Context, x, y int32) int32 {
+	return int32(p.gs.Cell(int(x), int(y)))
+}
+
+func (p *Plugin) setCell(_ context.Context, x, y, value int32) int32 {
+	return p.fail("set_cell", p.gs.SetCell(int(x), int(y), int(value)))
+}
+
+// fail logs why a host function failed, for plugin authors, and returns
+// what the function returns to the plugin
+func (p *Plugin) fail(fn string, err error) int32 {
+	if err == nil {
+		return resultOK
+	}
+	clog.Debugf("Plugin %s: %s failed: %v", p.path, fn, err)
+	return resultFailed
+}
+
+// readString copies a string out of the plugin's memory
+func readString(m api.Module, ptr, size uint32) (string, bool) {
+	buf, valid := m.Memory().Read(ptr, size)
+	return string(buf), valid
+}
+
+// writeJSON writes v as JSON into the plugin's buffer if it fits, returning
+// the JSON's length either way
+func writeJSON(m api.Module, bufPtr, bufSize uint32, v any) int32 {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return resultFailed
+	}
+	if len(data) <= int(bufSize) && !m.Memory().Write(bufPtr, data) {
+		return resultFailed
+	}
+	return int32(len(data))
+}
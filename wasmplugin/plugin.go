@@ -0,0 +1,179 @@
+// Package wasmplugin runs game extensions compiled to WebAssembly, a
+// sandboxed alternative to Lua scripts that can be written in any language
+// that targets WASI. A plugin is a WASI reactor module. It may export:
+//
+//	alloc(size i32) i32          memory for the host to pass it strings; required for on_event
+//	on_event(ptr i32, len i32)   a gameplay event as JSON (see Event)
+//	on_tick(dt f64)              after every simulation step of dt seconds
+//
+// and may import these functions from the "terminus" module, where strings
+// are a pointer and a length in the plugin's memory, and functions that can
+// fail return 0 on success and -1 on failure (see host.go):
+//
+//	log(msg)                                write to the server log
+//	broadcast(msg)                          send a message to every player
+//	notify(name, msg) i32                   show a message on one player's HUD
+//	players(buf, cap) i32                   the players as JSON (see Player)
+//	entities(kind, buf, cap) i32            the entities of a kind, like "enemy", as JSON (see Entity)
+//	spawn(kind, x f64, y f64) i32           add an NPC ("npc") or a pickup ("health", "speed", ...)
+//	steer(id i64, dx f64, dy f64, speed f64) i32   take over an NPC's movement
+//	cell(x i32, y i32) i32                  the map cell at a position: 0 for floor, or a wall type
+//	set_cell(x i32, y i32, value i32) i32   change a map cell, like building a wall
+//
+// players and entities write up to cap bytes to buf and return the JSON's
+// full length, so a plugin whose buffer is too small can retry with a
+// bigger one. Plugins get no files, network, or environment, and one that
+// takes longer than stepTimeout in a step is stopped.
+package wasmplugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+
+	"github.com/imjasonh/terminus/server"
+)
+
+// Limits that keep a plugin from stalling the simulation or using up memory
+const (
+	loadTimeout    = 5 * time.Second
+	stepTimeout    = 20 * time.Millisecond // For all the calls made in a step
+	maxMemoryPages = 1024                  // 64 MiB
+)
+
+// Event is a gameplay event as plugins receive it
+type Event struct {
+	Kind   string  `json:"kind"` // Like "joined" or "killed"; see server.EventKind
+	Name   string  `json:"name,omitempty"`
+	Detail string  `json:"detail,omitempty"`
+	Target string  `json:"target,omitempty"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+}
+
+// Plugin is a WebAssembly module that runs as one of a game server's Rules
+type Plugin struct {
+	path    string
+	gs      *server.GameServer
+	runtime wazero.Runtime
+	module  api.Module
+	events  *server.Subscription
+	stopped bool // Set once a call fails, after which the plugin isn't called
+}
+
+// Load compiles and starts a plugin, returning it ready to add to the
+// server's Rules
+func Load(gs *server.GameServer, path string) (*Plugin, error) {
+	wasm, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin %s: %w", path, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+	defer cancel()
+	p := &Plugin{
+		path: path,
+		gs:   gs,
+		runtime: wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+			WithCloseOnContextDone(true).
+			WithMemoryLimitPages(maxMemoryPages)),
+	}
+	if err := p.start(ctx, wasm); err != nil {
+		p.runtime.Close(context.Background())
+		return nil, fmt.Errorf("failed to start plugin %s: %w", path, err)
+	}
+	p.events = gs.Events.Subscribe()
+	return p, nil
+}
+
+// start instantiates the host functions and the plugin
+func (p *Plugin) start(ctx context.Context, wasm []byte) error {
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, p.runtime); err != nil {
+		return err
+	}
+	if _, err := p.hostModule().Instantiate(ctx); err != nil {
+		return err
+	}
+	compiled, err := p.runtime.CompileModule(ctx, wasm)
+	if err != nil {
+		return err
+	}
+	// Reactors export _initialize, which sets them up without running main
+	p.module, err = p.runtime.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().
+		WithName(p.path).
+		WithStartFunctions("_initialize"))
+	return err
+}
+
+// Close stops the plugin
+func (p *Plugin) Close() {
+	if p.events != nil {
+		p.events.Close()
+	}
+	p.runtime.Close(context.Background())
+}
+
+// Step passes the plugin what happened since the last step, then calls
+// on_tick. The simulation calls it after each step.
+func (p *Plugin) Step(deltaTime float64) {
+	ctx, cancel := context.WithTimeout(context.Background(), stepTimeout)
+	defer cancel()
+
+	for {
+		select {
+		case e := <-p.events.Events():
+			p.handle(ctx, e)
+		default:
+			p.call(ctx, "on_tick", api.EncodeF64(deltaTime))
+			return
+		}
+	}
+}
+
+// handle passes an event to the plugin's on_event as JSON
+func (p *Plugin) handle(ctx context.Context, e server.Event) {
+	if p.stopped || p.module.ExportedFunction("on_event") == nil {
+		return
+	}
+	data, err := json.Marshal(Event{Kind: e.Kind.String(), Name: e.Name, Detail: e.Detail, Target: e.Target, X: e.Position.X, Y: e.Position.Y})
+	if err != nil {
+		return
+	}
+	ptr, ok := p.alloc(ctx, len(data))
+	if !ok || !p.module.Memory().Write(ptr, data) {
+		return
+	}
+	p.call(ctx, "on_event", uint64(ptr), uint64(len(data)))
+}
+
+// alloc asks the plugin for size bytes of its memory
+func (p *Plugin) alloc(ctx context.Context, size int) (uint32, bool) {
+	results, ok := p.call(ctx, "alloc", uint64(size))
+	if !ok || len(results) != 1 {
+		return 0, false
+	}
+	return uint32(results[0]), true
+}
+
+// call calls a function the plugin exports, if it does, stopping the plugin
+// for good if it fails
+func (p *Plugin) call(ctx context.Context, name string, params ...uint64) ([]uint64, bool) {
+	fn := p.module.ExportedFunction(name)
+	if p.stopped || fn == nil {
+		return nil, false
+	}
+	results, err := fn.Call(ctx, params...)
+	if err != nil {
+		p.stopped = true
+		clog.Errorf("Plugin %s stopped: %s failed: %v", p.path, name, err)
+		return nil, false
+	}
+	return results, true
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+
+	"github.com/imjasonh/terminus/game"
+	"github.com/imjasonh/terminus/server"
+)
+
+// Web watcher limits, so a popular match can't slow the server down
+const (
+	watchInterval     = 100 * time.Millisecond // Least time between frames sent to each watcher
+	maxWatchers       = 100
+	watchWriteTimeout = 5 * time.Second
+)
+
+// watcherCount is how many browsers are watching at /events
+var watcherCount atomic.Int64
+
+// watchMap is the layout of the current map, sent when a watcher connects
+// and again whenever it changes
+type watchMap struct {
+	Name   string  `json:"name"`
+	Width  int     `json:"width"`
+	Height int     `json:"height"`
+	Grid   [][]int `json:"grid"` // Wall types by row, 0 for open floor
+}
+
+// watchDot is something drawn on the watcher's map
+type watchDot struct {
+	X     float64 `json:"x"`
+	Y     float64 `json:"y"`
+	Color string  `json:"color"`          // CSS hex color
+	Name  string  `json:"name,omitempty"` // Players only
+	DX    float64 `json:"dx,omitempty"`   // Which way a player faces
+	DY    float64 `json:"dy,omitempty"`
+}
+
+// watchFrame is where everything is as of a snapshot
+type watchFrame struct {
+	Players     []watchDot `json:"players"`
+	NPCs        []watchDot `json:"npcs"`
+	Projectiles []watchDot `json:"projectiles"`
+}
+
+// serveWatch serves a live top-down view of the match at / for browsers,
+// streamed as server-sent events from /events, until the listener fails
+func serveWatch(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, watchPage)
+	})
+	mux.HandleFunc("GET /events", streamWatch)
+
+	// No WriteTimeout: event streams stay open, with a deadline per write instead
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	clog.Infof("Web watcher on http://%s/", addr)
+	clog.Errorf("Web watcher stopped: %v", srv.ListenAndServe())
+}
+
+// streamWatch sends a frame per snapshot, at most one every watchInterval,
+// until the browser goes away, preceded by the snapshot's map whenever it
+// changes
+func streamWatch(w http.ResponseWriter, r *http.Request) {
+	if watcherCount.Add(1) > maxWatchers {
+		watcherCount.Add(-1)
+		http.Error(w, "too many watchers, try again later", http.StatusServiceUnavailable)
+		return
+	}
+	defer watcherCount.Add(-1)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	rc := http.NewResponseController(w)
+
+	var sentMap *game.Map
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		snapshot, next := gameServer.LatestSnapshot()
+		if snapshot != nil {
+			if snapshot.Map != sentMap {
+				if err := sendWatchEvent(rc, w, "map", newWatchMap(snapshot.MapName, snapshot.Map)); err != nil {
+					clog.Debugf("Web watcher %s left: %v", r.RemoteAddr, err)
+					return
+				}
+				sentMap = snapshot.Map
+			}
+			if err := sendWatchEvent(rc, w, "frame", newWatchFrame(snapshot)); err != nil {
+				clog.Debugf("Web watcher %s left: %v", r.RemoteAddr, err)
+				return
+			}
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-next:
+		}
+	}
+}
+
+// sendWatchEvent writes one server-sent event with a JSON payload and
+// flushes it to the browser
+func sendWatchEvent(rc *http.ResponseController, w http.ResponseWriter, event string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := rc.SetWriteDeadline(time.Now().Add(watchWriteTimeout)); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return err
+	}
+	return rc.Flush()
+}
+
+// newWatchMap describes a map's layout for watchers
+func newWatchMap(name string, m *game.Map) watchMap {
+	return watchMap{Name: name, Width: m.Width, Height: m.Height, Grid: m.Grid}
+}
+
+// newWatchFrame places a snapshot's players, NPCs, and projectiles for
+// watchers, leaving out invisible players
+func newWatchFrame(s *server.Snapshot) watchFrame {
+	frame := watchFrame{Players: []watchDot{}, NPCs: []watchDot{}, Projectiles: []watchDot{}}
+	for _, ps := range s.Players {
+		p := &ps.Player
+		if p.IsInvisible() {
+			continue
+		}
+		frame.Players = append(frame.Players, watchDot{
+			X: p.Position.X, Y: p.Position.Y, Color: cssColor(p.Sprite().Color),
+			Name: ps.Name, DX: p.Direction.X, DY: p.Direction.Y,
+		})
+	}
+	for _, e := range s.Entities {
+		if e.Kind == game.KindNPC && e.Sprite != nil {
+			frame.NPCs = append(frame.NPCs, watchDot{X: e.Position.X, Y: e.Position.Y, Color: cssColor(e.Sprite.Color)})
+		}
+	}
+	for i := range s.Projectiles {
+		e := s.Projectiles[i].Entity()
+		frame.Projectiles = append(frame.Projectiles, watchDot{X: e.Position.X, Y: e.Position.Y, Color: cssColor(e.Sprite.Color)})
+	}
+	return frame
+}
+
+// cssColor formats a color for the browser, like #00ff00
+func cssColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// watchPage draws the map on a canvas and redraws everything on it with
+// each frame from /events
+const watchPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Terminus</title>
+<style>
+  body { margin: 0; background: #000; color: #ccc; font: 14px monospace; text-align: center; }
+  h1 { font-size: 16px; font-weight: normal; margin: 12px; }
+  canvas { max-width: 95vw; max-height: 85vh; image-rendering: pixelated; }
+</style>
+</head>
+<body>
+<h1 id="title">Connecting...</h1>
+<canvas id="map"></canvas>
+<script>
+const cell = 24;
+const canvas = document.getElementById("map");
+const ctx = canvas.getContext("2d");
+const title = document.getElementById("title");
+let map = null;
+let frame = null;
+
+// The same colors as walls in the game
+const walls = {
+  1: "#b42020", 2: "#20b420", 3: "#2020b4", 4: "#b4b420", 5: "#b420b4",
+  6: "#20b4b4", 7: "#b46420", 8: "#6420b4", 9: "#784614",
+};
+
+function dot(d, radius) {
+  ctx.fillStyle = d.color;
+  ctx.beginPath();
+  ctx.arc(d.x * cell, d.y * cell, radius, 0, 2 * Math.PI);
+  ctx.fill();
+}
+
+function draw() {
+  if (!map) return;
+  ctx.fillStyle = "#111";
+  ctx.fillRect(0, 0, canvas.width, canvas.height);
+  for (let y = 0; y < map.height; y++) {
+    for (let x = 0; x < map.width; x++) {
+      const t = map.grid[y][x];
+      if (t) {
+        ctx.fillStyle = walls[t] || "#787878";
+        ctx.fillRect(x * cell, y * cell, cell, cell);
+      }
+    }
+  }
+  if (!frame) return;
+  frame.projectiles.forEach(p => dot(p, cell / 8));
+  frame.npcs.forEach(n => dot(n, cell / 4));
+  ctx.font = "12px monospace";
+  ctx.textAlign = "center";
+  frame.players.forEach(p => {
+    dot(p, cell / 3);
+    ctx.strokeStyle = p.color;
+    ctx.beginPath();
+    ctx.moveTo(p.x * cell, p.y * cell);
+    ctx.lineTo((p.x + p.dx * 0.6) * cell, (p.y + p.dy * 0.6) * cell);
+    ctx.stroke();
+    ctx.fillText(p.name, p.x * cell, p.y * cell - cell / 2);
+  });
+}
+
+const events = new EventSource("events");
+events.addEventListener("map", e => {
+  map = JSON.parse(e.data);
+  canvas.width = map.width * cell;
+  canvas.height = map.height * cell;
+  draw();
+});
+events.addEventListener("frame", e => {
+  frame = JSON.parse(e.data);
+  const names = frame.players.length;
+  title.textContent = "Terminus: " + map.name + " (" + names + (names == 1 ? " player)" : " players)");
+  draw();
+});
+events.onerror = () => { title.textContent = "Reconnecting..."; };
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,214 @@
+// Package webhook posts gameplay events as JSON to HTTP endpoints, like a
+// team chat's incoming webhook, so results show up there as they happen.
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"text/template"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+
+	"github.com/imjasonh/terminus/server"
+)
+
+// Delivery limits
+const (
+	queueSize    = 64 // Events waiting to be sent to a hook before more are dropped
+	maxAttempts  = 4
+	firstBackoff = time.Second // Doubled after each failed attempt
+	sendTimeout  = 10 * time.Second
+)
+
+// DefaultEvents are the events sent to hooks that don't list any
+var DefaultEvents = []string{"joined", "left", "match_started", "match_ended", "record_broken"}
+
+// Hook is an endpoint that events are posted to, as configured in a webhooks
+// file
+type Hook struct {
+	URL      string            `json:"url"`
+	Events   []string          `json:"events,omitempty"`   // Names of event kinds, like "match_ended"; DefaultEvents if empty
+	Template string            `json:"template,omitempty"` // text/template for the body, given a Payload; the Payload as JSON if empty
+	Headers  map[string]string `json:"headers,omitempty"`  // Like an Authorization header
+
+	host  string // Names the hook in logs without its path, which often holds a secret
+	kinds []server.EventKind
+	tmpl  *template.Template
+	queue chan Payload
+}
+
+// Payload describes an event for a hook
+type Payload struct {
+	Event   string        `json:"event"` // The kind of event, like "match_ended"
+	Time    time.Time     `json:"time"`
+	Message string        `json:"message"` // Describes the event for people, like "alice joined"
+	Player  string        `json:"player,omitempty"`
+	Detail  string        `json:"detail,omitempty"`
+	Target  string        `json:"target,omitempty"`
+	Match   *server.Match `json:"match,omitempty"` // The results, for match_ended
+}
+
+// templateFuncs are helpers for payload templates
+var templateFuncs = template.FuncMap{
+	// json encodes a value as JSON, like a quoted and escaped string
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// Load reads a webhooks file: a JSON list of hooks
+func Load(path string) ([]*Hook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhooks file %s: %w", path, err)
+	}
+	var hooks []*Hook
+	if err := json.Unmarshal(data, &hooks); err != nil {
+		return nil, fmt.Errorf("failed to parse webhooks file %s: %w", path, err)
+	}
+	for i, h := range hooks {
+		if err := h.init(); err != nil {
+			return nil, fmt.Errorf("%s: hook %d: %w", path, i+1, err)
+		}
+	}
+	return hooks, nil
+}
+
+// init checks a hook's configuration and prepares it to send events
+func (h *Hook) init() error {
+	u, err := url.Parse(h.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q isn't an http(s) URL", h.URL)
+	}
+	h.host = u.Host
+	names := h.Events
+	if len(names) == 0 {
+		names = DefaultEvents
+	}
+	for _, name := range names {
+		kind, ok := server.ParseEventKind(name)
+		if !ok {
+			return fmt.Errorf("unknown event %q", name)
+		}
+		h.kinds = append(h.kinds, kind)
+	}
+	if h.Template != "" {
+		tmpl, err := template.New(h.URL).Funcs(templateFuncs).Parse(h.Template)
+		if err != nil {
+			return fmt.Errorf("invalid template: %w", err)
+		}
+		h.tmpl = tmpl
+	}
+	h.queue = make(chan Payload, queueSize)
+	return nil
+}
+
+// Run posts events from the bus to the hooks that want them, until the
+// process exits. Events meant only for some players, like whispers, are
+// never sent.
+func Run(events *server.EventBus, hooks []*Hook) {
+	var kinds []server.EventKind
+	for _, h := range hooks {
+		kinds = append(kinds, h.kinds...)
+		go h.deliver()
+	}
+	sub := events.Subscribe(kinds...)
+	defer sub.Close()
+
+	for e := range sub.Events() {
+		if len(e.Recipients) > 0 {
+			continue
+		}
+		p := Payload{
+			Event:   e.Kind.String(),
+			Time:    e.Time,
+			Message: e.String(),
+			Player:  e.Name,
+			Detail:  e.Detail,
+			Target:  e.Target,
+			Match:   e.Match,
+		}
+		for _, h := range hooks {
+			if !slices.Contains(h.kinds, e.Kind) {
+				continue
+			}
+			select {
+			case h.queue <- p:
+			default:
+				clog.Warnf("Webhook %s is falling behind; dropped a %s event", h.host, p.Event)
+			}
+		}
+	}
+}
+
+// deliver sends the hook's queued events one at a time, in order
+func (h *Hook) deliver() {
+	client := &http.Client{Timeout: sendTimeout}
+	for p := range h.queue {
+		body, err := h.body(p)
+		if err != nil {
+			clog.Warnf("Webhook %s: failed to render a %s event: %v", h.host, p.Event, err)
+			continue
+		}
+		backoff := firstBackoff
+		for attempt := 1; ; attempt++ {
+			err := h.send(client, body)
+			if err == nil {
+				break
+			}
+			if attempt == maxAttempts {
+				clog.Warnf("Webhook %s: gave up on a %s event after %d attempts: %v", h.host, p.Event, attempt, err)
+				break
+			}
+			clog.Debugf("Webhook %s: attempt %d failed, retrying in %s: %v", h.host, attempt, backoff, err)
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+}
+
+// body renders an event's request body with the hook's template
+func (h *Hook) body(p Payload) ([]byte, error) {
+	if h.tmpl == nil {
+		return json.Marshal(p)
+	}
+	var b bytes.Buffer
+	if err := h.tmpl.Execute(&b, p); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// send posts a body to the hook once, returning an error if it should be
+// retried
+func (h *Hook) send(client *http.Client, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "terminus-webhook")
+	for name, value := range h.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	if resp.StatusCode >= 300 {
+		// The endpoint rejected the event itself, so sending it again won't help
+		clog.Warnf("Webhook %s rejected an event: %s", h.host, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chainguard-dev/clog"
+	"github.com/coder/websocket"
+
+	"github.com/imjasonh/terminus/auth"
+)
+
+// WebSocket defaults for browser terminals, which don't send an environment
+const (
+	wsDefaultTerm   = "xterm-256color"
+	wsDefaultWidth  = 80
+	wsDefaultHeight = 24
+)
+
+// wsControl is a control message sent by the browser as a text frame. Binary
+// frames carry the bytes the player types.
+type wsControl struct {
+	Type string `json:"type"` // "resize"
+	Cols int    `json:"cols"`
+	Rows int    `json:"rows"`
+}
+
+// serveWebSocket accepts browser connections at /ws until the listener fails.
+// Clients connect to /ws?cols=80&rows=24, with &code=... on invite-only
+// servers and &spectate to watch without playing, then exchange the same
+// bytes as an SSH terminal in binary frames.
+func serveWebSocket(addr string, authOpts auth.Options, originPatterns []string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /ws", func(w http.ResponseWriter, r *http.Request) {
+		remote := wsAddr(r.RemoteAddr)
+		query := r.URL.Query()
+		if !authOpts.AdmitKeyless(remote, query.Get("code")) {
+			http.Error(w, "this server requires an invite code", http.StatusForbidden)
+			return
+		}
+
+		ws, err := websocket.Accept(w, r, &websocket.AcceptOptions{OriginPatterns: originPatterns})
+		if err != nil {
+			clog.Infof("WebSocket upgrade from %s failed: %v", remote, err)
+			return
+		}
+		user := ""
+		if query.Has("spectate") {
+			user = spectatorUser
+		} else if query.Has("practice") {
+			user = practiceUser
+		}
+		c := newWSConn(ws, remote, user, ptyInfo{
+			Term:    queryOr(query.Get("term"), wsDefaultTerm),
+			Window:  winSize{Width: queryInt(query.Get("cols"), wsDefaultWidth), Height: queryInt(query.Get("rows"), wsDefaultHeight)},
+			Environ: []string{"COLORTERM=truecolor", "LANG=C.UTF-8"},
+		})
+		defer c.Close()
+		handleConn(c)
+	})
+
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	clog.Infof("WebSocket endpoint on ws://%s/ws", addr)
+	clog.Errorf("WebSocket endpoint stopped: %v", srv.ListenAndServe())
+}
+
+// wsConn adapts a WebSocket to a conn
+type wsConn struct {
+	ws     *websocket.Conn
+	ctx    context.Context
+	cancel context.CancelFunc
+	remote net.Addr
+	user   string
+	pty    ptyInfo
+	winCh  chan winSize
+	buf    []byte // Input received but not yet read
+}
+
+func newWSConn(ws *websocket.Conn, remote net.Addr, user string, pty ptyInfo) *wsConn {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &wsConn{
+		ws:     ws,
+		ctx:    ctx,
+		cancel: cancel,
+		remote: remote,
+		user:   user,
+		pty:    pty,
+		winCh:  make(chan winSize, 1),
+	}
+}
+
+// Read returns input from binary frames, handling control messages in between
+func (c *wsConn) Read(p []byte) (int, error) {
+	for len(c.buf) == 0 {
+		typ, data, err := c.ws.Read(c.ctx)
+		if err != nil {
+			c.cancel()
+			return 0, io.EOF
+		}
+		if typ == websocket.MessageBinary {
+			c.buf = data
+			continue
+		}
+
+		var msg wsControl
+		if json.Unmarshal(data, &msg) == nil && msg.Type == "resize" && msg.Cols > 0 && msg.Rows > 0 {
+			// Keep only the latest size if the game hasn't caught up
+			select {
+			case <-c.winCh:
+			default:
+			}
+			c.winCh <- clampWindow(winSize{Width: msg.Cols, Height: msg.Rows})
+		}
+	}
+	n := copy(p, c.buf)
+	c.buf = c.buf[n:]
+	return n, nil
+}
+
+// Write sends output as a binary frame
+func (c *wsConn) Write(p []byte) (int, error) {
+	if err := c.ws.Write(c.ctx, websocket.MessageBinary, p); err != nil {
+		c.cancel()
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (c *wsConn) Context() context.Context {
+	return c.ctx
+}
+
+func (c *wsConn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+// KeyFingerprint is always empty, since browsers don't have SSH keys
+func (c *wsConn) KeyFingerprint() string {
+	return ""
+}
+
+// User is spectatorUser when the client connected with ?spectate
+func (c *wsConn) User() string {
+	return c.user
+}
+
+func (c *wsConn) Pty() (ptyInfo, <-chan winSize, bool) {
+	return c.pty, c.winCh, true
+}
+
+func (c *wsConn) Close() error {
+	c.cancel()
+	return c.ws.Close(websocket.StatusNormalClosure, "")
+}
+
+// wsAddr is the remote address of a WebSocket client
+type wsAddr string
+
+func (a wsAddr) Network() string { return "tcp" }
+func (a wsAddr) String() string  { return string(a) }
+
+// queryInt parses a positive window size query parameter, up to
+// maxWindowSize, or returns def
+func queryInt(s string, def int) int {
+	if n, err := strconv.Atoi(s); err == nil && n > 0 && n <= maxWindowSize {
+		return n
+	}
+	return def
+}
+
+// queryOr returns s, or def if it's empty
+func queryOr(s, def string) string {
+	if strings.TrimSpace(s) == "" {
+		return def
+	}
+	return s
+}